@@ -7,7 +7,9 @@ import (
 	"os"
 
 	"github.com/SecDuckOps/agent/internal/adapters/audit"
+	"github.com/SecDuckOps/agent/internal/config"
 	"github.com/SecDuckOps/agent/internal/ports"
+	"github.com/SecDuckOps/shared/types"
 	"github.com/spf13/cobra"
 )
 
@@ -60,5 +62,122 @@ func NewLogCmd() *cobra.Command {
 	cmd.Flags().StringVarP(&sessionID, "session", "s", "", "Filter logs by session ID")
 	cmd.Flags().IntVarP(&limit, "limit", "l", 50, "Limit the number of log entries returned")
 
+	cmd.AddCommand(newLogVerifyCmd())
 	return cmd
 }
+
+func newLogVerifyCmd() *cobra.Command {
+	var (
+		profileName string
+		sessionID   string
+		logDir      string
+		signing     string
+		keyFile     string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "verify",
+		Short: "Check audit logs for tampering",
+		Long: "Walks the hash chain of one session log (--session) or of all of them, checks entry and checkpoint " +
+			"signatures when a signing key is configured, and reports the first broken link in each log.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			// Settings come from the profile's [audit] section unless given as flags
+			acfg := loadAuditConfig(profileName)
+			if logDir == "" {
+				logDir = acfg.LogDir
+			}
+			if signing == "" {
+				signing = acfg.Signing
+			}
+			if keyFile == "" && signing != "" {
+				path, err := acfg.KeyPath()
+				if err != nil {
+					return err
+				}
+				keyFile = path
+			}
+
+			logger, err := audit.New(logDir, "")
+			if err != nil {
+				return err
+			}
+			defer logger.Close()
+			if signing != "" {
+				signer, err := audit.LoadSigner(signing, keyFile, false)
+				if err != nil {
+					return err
+				}
+				logger.SetSigner(signer)
+			}
+
+			var results []audit.VerifyResult
+			if sessionID != "" {
+				result, err := logger.Verify(sessionID)
+				if err != nil {
+					return err
+				}
+				results = append(results, result)
+			} else if results, err = logger.VerifyAll(); err != nil {
+				return err
+			}
+			if len(results) == 0 {
+				fmt.Println("No audit logs found.")
+				return nil
+			}
+
+			failed := 0
+			for _, r := range results {
+				if !r.OK() {
+					failed++
+					if r.BrokenAt > 0 && r.File != "" {
+						fmt.Printf("✗ %s: entry %d (%s): %s\n", r.SessionID, r.BrokenAt, r.File, r.Reason)
+					} else if r.BrokenAt > 0 {
+						fmt.Printf("✗ %s: entry %d: %s\n", r.SessionID, r.BrokenAt, r.Reason)
+					} else {
+						fmt.Printf("✗ %s: %s\n", r.SessionID, r.Reason)
+					}
+					continue
+				}
+				status := fmt.Sprintf("%d entries", r.Entries)
+				if r.Pruned > 0 {
+					status += fmt.Sprintf(" after %d removed by retention", r.Pruned)
+				}
+				if r.Unchained > 0 {
+					status += fmt.Sprintf(", first %d written before chaining", r.Unchained)
+				}
+				if r.Signed {
+					status += ", signatures valid"
+				}
+				if r.Checkpoint != nil {
+					status += fmt.Sprintf(", checkpoint at %d", r.Checkpoint.Entries)
+				} else {
+					status += ", no checkpoint"
+				}
+				fmt.Printf("✓ %s: %s\n", r.SessionID, status)
+			}
+
+			if failed > 0 {
+				return types.Newf(types.ErrCodeInvalidInput, "%d of %d audit log(s) failed verification", failed, len(results))
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&profileName, "profile", "p", "default", "config profile to read audit settings from")
+	cmd.Flags().StringVarP(&sessionID, "session", "s", "", "verify only this session's log")
+	cmd.Flags().StringVar(&logDir, "dir", "", "audit log directory (default: audit.log_dir or ~/.duckops/audit)")
+	cmd.Flags().StringVar(&signing, "signing", "", "signature algorithm to check: hmac or ed25519 (default: audit.signing)")
+	cmd.Flags().StringVar(&keyFile, "key", "", "signing key file; an Ed25519 public key is enough (default: audit.key_file)")
+	return cmd
+}
+
+// loadAuditConfig returns the profile's [audit] section, or an empty one
+// (default directories) when there is none.
+func loadAuditConfig(profileName string) *config.AuditConfig {
+	if cfg, err := config.LoadTOML(); err == nil {
+		if profile, ok := cfg.GetProfile(profileName); ok && profile.Audit != nil {
+			return profile.Audit
+		}
+	}
+	return &config.AuditConfig{}
+}
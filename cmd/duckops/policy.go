@@ -0,0 +1,358 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	warden_adapter "github.com/SecDuckOps/agent/internal/adapters/warden"
+	"github.com/SecDuckOps/agent/internal/config"
+	"github.com/SecDuckOps/agent/internal/domain/security"
+	"github.com/SecDuckOps/shared/types"
+)
+
+// NewPolicyCmd builds the `duckops policy` command group.
+func NewPolicyCmd() *cobra.Command {
+	var profileName string
+
+	cmd := &cobra.Command{
+		Use:   "policy",
+		Short: "Inspect, validate and dry-run Warden policies",
+		Long:  "Work with the Cedar policy files configured in the profile's [warden] section.",
+	}
+	cmd.PersistentFlags().StringVarP(&profileName, "profile", "p", "default", "config profile to read warden settings from")
+
+	cmd.AddCommand(newPolicyListCmd(&profileName))
+	cmd.AddCommand(newPolicyValidateCmd(&profileName))
+	cmd.AddCommand(newPolicyTestCmd(&profileName))
+	return cmd
+}
+
+// loadWardenConfig returns the warden section of the selected profile.
+func loadWardenConfig(profileName string) (*config.WardenConfig, error) {
+	cfg, err := config.LoadTOML()
+	if err != nil {
+		return nil, types.Wrap(err, types.ErrCodeInternal, "failed to load config")
+	}
+	profile, ok := cfg.GetProfile(profileName)
+	if !ok {
+		return nil, types.Newf(types.ErrCodeNotFound, "profile %q not found in config.toml", profileName)
+	}
+	if profile.Warden == nil {
+		return &config.WardenConfig{DefaultDeny: true}, nil
+	}
+	return profile.Warden, nil
+}
+
+// loadPolicyWarden builds a standalone Warden loaded with the given policy files.
+func loadPolicyWarden(ctx context.Context, wcfg *config.WardenConfig, files []string) (*warden_adapter.Warden, error) {
+	policies, err := warden_adapter.ReadPolicyFiles(files)
+	if err != nil {
+		return nil, err
+	}
+	w := warden_adapter.New(wcfg.DefaultDeny, nil)
+	if err := w.LoadPolicies(ctx, policies); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+func newPolicyListCmd(profileName *string) *cobra.Command {
+	return &cobra.Command{
+		Use:   "list",
+		Short: "List the configured policies with their priority and state",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			wcfg, err := loadWardenConfig(*profileName)
+			if err != nil {
+				return err
+			}
+
+			policies, readErr := warden_adapter.ReadPolicyFiles(warden_adapter.ExpandPolicyFiles(wcfg.PolicyFiles, wcfg.PolicyDirs))
+			if len(policies) == 0 {
+				fmt.Println("No policies configured.")
+				return readErr
+			}
+
+			// Load into a Warden so the listing reflects evaluation order.
+			w := warden_adapter.New(wcfg.DefaultDeny, nil)
+			if err := w.LoadPolicies(cmd.Context(), policies); err != nil {
+				fmt.Fprintf(os.Stderr, "warning: %v\n", err)
+			} else {
+				policies = w.Policies()
+			}
+
+			tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(tw, "ID\tNAME\tPRIORITY\tENABLED\tSYNTAX")
+			for _, p := range policies {
+				syntax := "ok"
+				if issues := warden_adapter.ValidatePolicy(p.CedarBody); len(issues) > 0 {
+					syntax = fmt.Sprintf("%d issue(s)", len(issues))
+				}
+				fmt.Fprintf(tw, "%s\t%s\t%d\t%v\t%s\n", p.ID, p.Name, p.Priority, p.Enabled, syntax)
+			}
+			tw.Flush()
+
+			mode := "allow"
+			if wcfg.DefaultDeny {
+				mode = "deny"
+			}
+			fmt.Printf("\nUnmatched requests: default %s\n", mode)
+			return readErr
+		},
+	}
+}
+
+func newPolicyValidateCmd(profileName *string) *cobra.Command {
+	return &cobra.Command{
+		Use:   "validate [file...]",
+		Short: "Check policy files for syntax errors",
+		Long:  "Parses the given policy files (default: warden.policy_files and policy_dirs from config) and reports syntax errors with line numbers.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			files := args
+			if len(files) == 0 {
+				wcfg, err := loadWardenConfig(*profileName)
+				if err != nil {
+					return err
+				}
+				files = warden_adapter.ExpandPolicyFiles(wcfg.PolicyFiles, wcfg.PolicyDirs)
+			}
+			if len(files) == 0 {
+				fmt.Println("No policy files to validate.")
+				return nil
+			}
+
+			failed := 0
+			for _, path := range files {
+				content, err := os.ReadFile(path)
+				if err != nil {
+					fmt.Printf("✗ %s: %v\n", path, err)
+					failed++
+					continue
+				}
+				issues := warden_adapter.ValidatePolicy(string(content))
+				if len(issues) == 0 {
+					fmt.Printf("✓ %s\n", path)
+					continue
+				}
+				failed++
+				fmt.Printf("✗ %s\n", path)
+				for _, issue := range issues {
+					issue.File = path
+					fmt.Printf("    %s\n", issue)
+				}
+			}
+
+			if failed > 0 {
+				return types.Newf(types.ErrCodeInvalidInput, "%d of %d policy file(s) failed validation", failed, len(files))
+			}
+			return nil
+		},
+	}
+}
+
+func newPolicyTestCmd(profileName *string) *cobra.Command {
+	var (
+		policyFiles []string
+		asJSON      bool
+		sessionID   string
+
+		// execution request
+		command string
+		cmdArgs []string
+		cwd     string
+
+		// network request
+		reqURL     string
+		method     string
+		sourceTool string
+		headers    []string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "test [fixture...]",
+		Short: "Evaluate a synthetic request or fixture suite against the policies",
+		Long: `Builds an ExecutionRequest (--command) or NetworkRequest (--url) and prints the
+Warden's PolicyDecision, including the matched policy ID and the reasons.
+
+Without --command or --url, runs the given fixture files, or every
+<policy>` + warden_adapter.FixtureSuffix + ` found next to a policy file, and exits
+non-zero if any decision differs from the expected one.
+
+  duckops policy test --command git --arg push --arg --force
+  duckops policy test --url https://api.github.com/repos/org/repo --method GET --tool scan
+  duckops policy test policies/network.tests.toml`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if command != "" && reqURL != "" {
+				return types.New(types.ErrCodeInvalidInput, "specify only one of --command or --url")
+			}
+			if (command != "" || reqURL != "") && len(args) > 0 {
+				return types.New(types.ErrCodeInvalidInput, "fixture files cannot be combined with --command or --url")
+			}
+
+			wcfg, err := loadWardenConfig(*profileName)
+			if err != nil {
+				return err
+			}
+			files := policyFiles
+			if len(files) == 0 {
+				files = warden_adapter.ExpandPolicyFiles(wcfg.PolicyFiles, wcfg.PolicyDirs)
+			}
+
+			ctx := cmd.Context()
+			w, err := loadPolicyWarden(ctx, wcfg, files)
+			if err != nil {
+				return err
+			}
+
+			if command == "" && reqURL == "" {
+				return runPolicyFixtures(ctx, w, files, args, asJSON)
+			}
+
+			var request interface{}
+			var decision security.PolicyDecision
+			if command != "" {
+				if cwd == "" {
+					cwd, _ = os.Getwd()
+				}
+				req := security.ExecutionRequest{
+					Command:   command,
+					Args:      cmdArgs,
+					Context:   map[string]interface{}{"cwd": cwd},
+					SessionID: sessionID,
+					Timestamp: time.Now(),
+				}
+				request = req
+				decision, err = w.EvaluateExecution(ctx, req)
+			} else {
+				req := security.NetworkRequest{
+					Method:     strings.ToUpper(method),
+					URL:        reqURL,
+					Headers:    make(map[string]string),
+					SourceTool: sourceTool,
+					SessionID:  sessionID,
+					Timestamp:  time.Now(),
+				}
+				for _, h := range headers {
+					kv := strings.SplitN(h, ":", 2)
+					if len(kv) != 2 {
+						return types.Newf(types.ErrCodeInvalidInput, "invalid header %q, expected Key:Value", h)
+					}
+					req.Headers[strings.TrimSpace(kv[0])] = strings.TrimSpace(kv[1])
+				}
+				request = req
+				decision, err = w.Evaluate(ctx, req)
+			}
+			if err != nil {
+				return err
+			}
+
+			if asJSON {
+				out, _ := json.MarshalIndent(map[string]interface{}{
+					"request":  request,
+					"decision": decision,
+				}, "", "  ")
+				fmt.Println(string(out))
+				return nil
+			}
+
+			verdict := "✓ ALLOW"
+			if decision.RequiresApproval {
+				verdict = "⏸ REQUIRES APPROVAL"
+			} else if !decision.Allowed {
+				verdict = "✗ DENY"
+			}
+			fmt.Println(verdict)
+			policyID := decision.PolicyID
+			if policyID == "" {
+				policyID = "(none — default applied)"
+			}
+			fmt.Printf("  Policy:  %s\n", policyID)
+			if len(decision.Reasons) > 0 {
+				fmt.Println("  Reasons:")
+				for _, r := range decision.Reasons {
+					fmt.Printf("    • %s\n", r)
+				}
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringSliceVar(&policyFiles, "policy", nil, "policy file(s) to use instead of warden.policy_files and policy_dirs")
+	cmd.Flags().BoolVar(&asJSON, "json", false, "print the request and decision as JSON")
+	cmd.Flags().StringVar(&sessionID, "session", "", "session ID of the synthetic request")
+
+	cmd.Flags().StringVar(&command, "command", "", "command of a synthetic execution request")
+	cmd.Flags().StringArrayVar(&cmdArgs, "arg", nil, "argument of the execution request (repeatable)")
+	cmd.Flags().StringVar(&cwd, "cwd", "", "working directory of the execution request (default: current directory)")
+
+	cmd.Flags().StringVar(&reqURL, "url", "", "URL of a synthetic network request")
+	cmd.Flags().StringVar(&method, "method", "GET", "HTTP method of the network request")
+	cmd.Flags().StringVar(&sourceTool, "tool", "", "source tool of the network request")
+	cmd.Flags().StringArrayVar(&headers, "header", nil, "header of the network request as Key:Value (repeatable)")
+
+	return cmd
+}
+
+// runPolicyFixtures evaluates fixture files against the loaded Warden. When no
+// fixture paths are given, the fixture next to each policy file is used.
+func runPolicyFixtures(ctx context.Context, w *warden_adapter.Warden, policyFiles, fixturePaths []string, asJSON bool) error {
+	if len(fixturePaths) == 0 {
+		for _, path := range policyFiles {
+			fx := warden_adapter.FixturePathFor(path)
+			if _, err := os.Stat(fx); err == nil {
+				fixturePaths = append(fixturePaths, fx)
+			}
+		}
+	}
+	if len(fixturePaths) == 0 {
+		return types.Newf(types.ErrCodeInvalidInput, "no fixtures found; add <policy>%s files or pass --command/--url", warden_adapter.FixtureSuffix)
+	}
+
+	var results []warden_adapter.FixtureResult
+	for _, path := range fixturePaths {
+		fx, err := warden_adapter.LoadPolicyFixture(path)
+		if err != nil {
+			return err
+		}
+		res, err := warden_adapter.RunFixture(ctx, w, fx)
+		if err != nil {
+			return err
+		}
+		results = append(results, res...)
+	}
+
+	failed := 0
+	for _, r := range results {
+		if !r.Passed {
+			failed++
+		}
+	}
+
+	if asJSON {
+		out, _ := json.MarshalIndent(results, "", "  ")
+		fmt.Println(string(out))
+	} else {
+		for _, r := range results {
+			if r.Passed {
+				fmt.Printf("✓ %s: %s\n", r.Fixture, r.Case.Name)
+				continue
+			}
+			fmt.Printf("✗ %s: %s\n    %s\n", r.Fixture, r.Case.Name, r.Problem)
+			for _, reason := range r.Decision.Reasons {
+				fmt.Printf("      • %s\n", reason)
+			}
+		}
+		fmt.Printf("\n%d passed, %d failed\n", len(results)-failed, failed)
+	}
+
+	if failed > 0 {
+		return types.Newf(types.ErrCodeExecutionFailed, "%d of %d fixture case(s) failed", failed, len(results))
+	}
+	return nil
+}
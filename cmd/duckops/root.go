@@ -83,6 +83,8 @@ func init() {
 	rootCmd.AddCommand(configCmd)
 	rootCmd.AddCommand(versionCmd)
 	rootCmd.AddCommand(NewLogCmd())
+	rootCmd.AddCommand(NewPolicyCmd())
+	rootCmd.AddCommand(NewSessionCmd())
 }
 
 var versionCmd = &cobra.Command{
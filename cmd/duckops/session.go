@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/SecDuckOps/agent/internal/adapters/audit"
+	"github.com/spf13/cobra"
+)
+
+func NewSessionCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "session",
+		Short: "Manage past agent sessions",
+	}
+	cmd.AddCommand(newSessionRollbackCmd())
+	return cmd
+}
+
+func newSessionRollbackCmd() *cobra.Command {
+	var (
+		profileName string
+		files       []string
+		dryRun      bool
+		force       bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "rollback <session-id>",
+		Short: "Undo the file changes made in a session",
+		Long: "Restores every file the session wrote through file_edit or the terminal to its content before the " +
+			"session, and deletes the files the session created. Files changed since the session wrote them are " +
+			"left alone, and nothing is rolled back, unless --force is given.",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			acfg := loadAuditConfig(profileName)
+			logger, err := audit.New(acfg.LogDir, acfg.BackupDir)
+			if err != nil {
+				return err
+			}
+			defer logger.Close()
+
+			changes, err := logger.Rollback(context.Background(), args[0], audit.RollbackOptions{
+				Files:  files,
+				DryRun: dryRun,
+				Force:  force,
+			})
+
+			verb := map[string]string{
+				audit.RollbackRestore:   "restored",
+				audit.RollbackDelete:    "deleted",
+				audit.RollbackUnchanged: "unchanged",
+			}
+			if dryRun || err != nil {
+				verb[audit.RollbackRestore], verb[audit.RollbackDelete] = "restore", "delete"
+			}
+			changed, conflicts := 0, 0
+			for _, c := range changes {
+				if c.Action != audit.RollbackUnchanged {
+					changed++
+				}
+				if c.Conflict != "" {
+					conflicts++
+				}
+				line := fmt.Sprintf("  %-9s %s", verb[c.Action], c.Path)
+				if c.Conflict != "" {
+					line += " (" + c.Conflict + ")"
+				}
+				fmt.Println(line)
+			}
+			if err != nil {
+				if conflicts > 0 && !force {
+					fmt.Println("Re-run with --force to overwrite those changes.")
+				}
+				return err
+			}
+
+			if dryRun {
+				fmt.Printf("Dry run: %d of %d file(s) would be rolled back.\n", changed, len(changes))
+			} else {
+				fmt.Printf("Rolled back %d of %d file(s) from session %s.\n", changed, len(changes), args[0])
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&profileName, "profile", "p", "default", "config profile to read audit settings from")
+	cmd.Flags().StringSliceVar(&files, "file", nil, "roll back only this file (repeatable)")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "show what would change without changing anything")
+	cmd.Flags().BoolVar(&force, "force", false, "also overwrite files changed after the session wrote them")
+	return cmd
+}
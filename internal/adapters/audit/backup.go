@@ -0,0 +1,363 @@
+package audit
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/SecDuckOps/agent/internal/domain/security"
+	"github.com/SecDuckOps/shared/types"
+)
+
+// Rollback actions.
+const (
+	RollbackRestore   = "restore"   // write back the original content
+	RollbackDelete    = "delete"    // remove a file the session created
+	RollbackUnchanged = "unchanged" // already in its original state
+)
+
+// RollbackOptions selects what Rollback does.
+type RollbackOptions struct {
+	Files  []string // only these paths; every backed-up file when empty
+	DryRun bool     // report the changes without making them
+	Force  bool     // also overwrite files changed after the session wrote them
+}
+
+// RollbackChange is what Rollback does, or would do, to one file.
+type RollbackChange struct {
+	Path     string
+	Action   string // RollbackRestore, RollbackDelete or RollbackUnchanged
+	Conflict string // why the file no longer holds what the session wrote; "" if it does
+}
+
+// manifest is the manifest.json of a session's backup directory.
+type manifest struct {
+	SessionID string                   `json:"session_id"`
+	Files     []string                 `json:"files"`
+	CreatedAt time.Time                `json:"created_at"`
+	Originals map[string]*backupRecord `json:"originals,omitempty"`
+}
+
+// backupRecord is the original state of one file.
+type backupRecord struct {
+	Existed    bool      `json:"existed"`
+	File       string    `json:"file,omitempty"`    // backup file in the session's directory; see backupFileName
+	SHA256     string    `json:"sha256,omitempty"`  // of the original content
+	Written    string    `json:"written,omitempty"` // SHA-256 after the session's last write
+	CapturedAt time.Time `json:"captured_at"`
+}
+
+// BackupSession stores the original content of the files in snapshot, and
+// the absence of its Missing paths, under backups/{session_id}/. A path is
+// captured once per session, so later writes keep the state from before the
+// session began. Written hashes update the state Rollback expects to find.
+func (l *Logger) BackupSession(ctx context.Context, snapshot security.SessionSnapshot) error {
+	if snapshot.SessionID == "" {
+		snapshot.SessionID = SystemSession
+	}
+	dir := filepath.Join(l.backupDir, snapshot.SessionID)
+
+	l.backupMu.Lock()
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		l.backupMu.Unlock()
+		return types.Wrap(err, types.ErrCodeInternal, "cannot create backup directory")
+	}
+	m, err := l.readManifest(snapshot.SessionID)
+	if err != nil {
+		l.backupMu.Unlock()
+		return err
+	}
+	if m.CreatedAt.IsZero() {
+		m.CreatedAt = snapshot.CreatedAt
+	}
+
+	now := time.Now().UTC()
+	captured := make(map[string]*backupRecord)
+	for path, content := range snapshot.Files {
+		if _, ok := m.Originals[path]; ok {
+			continue
+		}
+		name := backupFileName(path)
+		if err := os.WriteFile(filepath.Join(dir, name), content, 0600); err != nil {
+			l.backupMu.Unlock()
+			return types.Wrapf(err, types.ErrCodeInternal, "failed to backup file %s", path)
+		}
+		captured[path] = &backupRecord{Existed: true, File: name, SHA256: hashContent(content), CapturedAt: now}
+	}
+	for _, path := range snapshot.Missing {
+		if _, ok := m.Originals[path]; !ok {
+			captured[path] = &backupRecord{CapturedAt: now}
+		}
+	}
+	for path, rec := range captured {
+		m.Originals[path] = rec
+	}
+	for path, hash := range snapshot.Written {
+		if rec, ok := m.Originals[path]; ok {
+			rec.Written = hash
+		}
+	}
+
+	files := make(map[string]bool)
+	for _, path := range append(m.Files, snapshot.FileList...) {
+		files[path] = true
+	}
+	for path := range m.Originals {
+		files[path] = true
+	}
+	m.Files = m.Files[:0]
+	for path := range files {
+		m.Files = append(m.Files, path)
+	}
+	sort.Strings(m.Files)
+
+	err = l.writeManifest(m)
+	l.backupMu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	for path, rec := range captured {
+		_ = l.Record(ctx, security.AuditEntry{
+			SessionID: snapshot.SessionID,
+			Action:    security.AuditFileBackup,
+			Actor:     "system",
+			Target:    path,
+			Details:   map[string]interface{}{"existed": rec.Existed, "sha256": rec.SHA256},
+			Timestamp: now,
+		})
+	}
+	return nil
+}
+
+// Rollback returns the files a session backed up to their original state:
+// it restores their content and deletes the files the session created. When
+// a file changed after the session wrote it, nothing is changed unless
+// opts.Force is set; the returned changes name the conflicts either way.
+func (l *Logger) Rollback(ctx context.Context, sessionID string, opts RollbackOptions) ([]RollbackChange, error) {
+	l.backupMu.Lock()
+	defer l.backupMu.Unlock()
+
+	m, err := l.readManifest(sessionID)
+	if err != nil {
+		return nil, err
+	}
+	if len(m.Originals) == 0 {
+		return nil, types.Newf(types.ErrCodeNotFound, "no backups found for session %s", sessionID)
+	}
+
+	paths := make([]string, 0, len(m.Originals))
+	if len(opts.Files) == 0 {
+		for path := range m.Originals {
+			paths = append(paths, path)
+		}
+	}
+	for _, file := range opts.Files {
+		path, err := filepath.Abs(file)
+		if err != nil {
+			return nil, types.Wrapf(err, types.ErrCodeInvalidInput, "invalid path %s", file)
+		}
+		if _, ok := m.Originals[path]; !ok {
+			return nil, types.Newf(types.ErrCodeNotFound, "session %s did not back up %s", sessionID, path)
+		}
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	changes := make([]RollbackChange, 0, len(paths))
+	conflicts := 0
+	for _, path := range paths {
+		change, err := planRollback(path, m.Originals[path])
+		if err != nil {
+			return nil, err
+		}
+		if change.Conflict != "" {
+			conflicts++
+		}
+		changes = append(changes, change)
+	}
+	if conflicts > 0 && !opts.Force {
+		return changes, types.Newf(types.ErrCodePermissionDenied,
+			"%d file(s) changed after session %s wrote them; nothing was rolled back", conflicts, sessionID)
+	}
+
+	// Every backup is checked before any file is changed
+	dir := filepath.Join(l.backupDir, sessionID)
+	contents := make(map[string][]byte)
+	for _, change := range changes {
+		if change.Action != RollbackRestore {
+			continue
+		}
+		content, err := readBackup(dir, change.Path, m.Originals[change.Path])
+		if err != nil {
+			return changes, err
+		}
+		contents[change.Path] = content
+	}
+	if opts.DryRun {
+		return changes, nil
+	}
+
+	for _, change := range changes {
+		if err := applyRollback(change, contents[change.Path]); err != nil {
+			return changes, err
+		}
+		if change.Action == RollbackUnchanged {
+			continue
+		}
+		details := map[string]interface{}{"action": change.Action}
+		if change.Conflict != "" {
+			details["forced"] = change.Conflict
+		}
+		_ = l.Record(ctx, security.AuditEntry{
+			SessionID: sessionID,
+			Action:    security.AuditFileRestore,
+			Actor:     "rollback",
+			Target:    change.Path,
+			Details:   details,
+			Timestamp: time.Now(),
+		})
+	}
+	return changes, nil
+}
+
+// planRollback compares a file with its backup record.
+func planRollback(path string, rec *backupRecord) (RollbackChange, error) {
+	change := RollbackChange{Path: path, Action: RollbackRestore}
+	if !rec.Existed {
+		change.Action = RollbackDelete
+	}
+
+	data, err := os.ReadFile(path)
+	exists := err == nil
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return change, types.Wrapf(err, types.ErrCodeInternal, "cannot read %s", path)
+	}
+	current := ""
+	if exists {
+		current = hashContent(data)
+	}
+
+	switch {
+	case rec.Existed && current == rec.SHA256, !rec.Existed && !exists:
+		change.Action = RollbackUnchanged
+	case rec.Written == "":
+		change.Conflict = "the session's write was not recorded"
+	case !exists:
+		change.Conflict = "deleted after the session wrote it"
+	case current != rec.Written:
+		change.Conflict = "modified after the session wrote it"
+	}
+	return change, nil
+}
+
+// readBackup reads the backup of path from dir. A backup whose content does
+// not match the recorded hash is rejected, so it is never written back.
+func readBackup(dir, path string, rec *backupRecord) ([]byte, error) {
+	name := rec.File
+	if name == "" {
+		name = flattenPath(path)
+	}
+	content, err := os.ReadFile(filepath.Join(dir, name))
+	if err != nil {
+		return nil, types.Wrapf(err, types.ErrCodeInternal, "cannot read backup of %s", path)
+	}
+	if hashContent(content) != rec.SHA256 {
+		return nil, types.Newf(types.ErrCodePermissionDenied,
+			"backup of %s does not match its recorded hash; nothing was rolled back", path)
+	}
+	return content, nil
+}
+
+// applyRollback carries out one change; content is the checked backup of a
+// file to restore.
+func applyRollback(change RollbackChange, content []byte) error {
+	switch change.Action {
+	case RollbackRestore:
+		mode := os.FileMode(0644)
+		if info, err := os.Stat(change.Path); err == nil {
+			mode = info.Mode().Perm()
+		}
+		if err := os.MkdirAll(filepath.Dir(change.Path), 0755); err != nil {
+			return types.Wrapf(err, types.ErrCodeInternal, "cannot restore %s", change.Path)
+		}
+		if err := os.WriteFile(change.Path, content, mode); err != nil {
+			return types.Wrapf(err, types.ErrCodeInternal, "cannot restore %s", change.Path)
+		}
+	case RollbackDelete:
+		if err := os.Remove(change.Path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return types.Wrapf(err, types.ErrCodeInternal, "cannot delete %s", change.Path)
+		}
+	}
+	return nil
+}
+
+// readManifest loads a session's manifest, or an empty one. Manifests
+// written before originals were tracked list backed-up files only, so their
+// records are rebuilt from the backup files. Those were named with
+// flattenPath, which maps different paths to the same name; such paths are
+// left without a record rather than guessed. Callers must hold l.backupMu.
+func (l *Logger) readManifest(sessionID string) (*manifest, error) {
+	m := &manifest{SessionID: sessionID}
+	dir := filepath.Join(l.backupDir, sessionID)
+	data, err := os.ReadFile(filepath.Join(dir, "manifest.json"))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, types.Wrapf(err, types.ErrCodeInternal, "cannot read backup manifest for session %s", sessionID)
+	}
+	if err == nil {
+		if err := json.Unmarshal(data, m); err != nil {
+			return nil, types.Wrapf(err, types.ErrCodeInternal, "invalid backup manifest for session %s", sessionID)
+		}
+	}
+	if m.Originals == nil {
+		m.Originals = make(map[string]*backupRecord)
+	}
+	flattened := make(map[string]int, len(m.Files))
+	for _, path := range m.Files {
+		flattened[flattenPath(path)]++
+	}
+	for _, path := range m.Files {
+		if _, ok := m.Originals[path]; ok || flattened[flattenPath(path)] > 1 {
+			continue
+		}
+		if content, err := os.ReadFile(filepath.Join(dir, flattenPath(path))); err == nil {
+			m.Originals[path] = &backupRecord{Existed: true, SHA256: hashContent(content), CapturedAt: m.CreatedAt}
+		}
+	}
+	return m, nil
+}
+
+// writeManifest atomically replaces a session's manifest. Callers must hold
+// l.backupMu.
+func (l *Logger) writeManifest(m *manifest) error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return types.Wrap(err, types.ErrCodeInternal, "failed to marshal backup manifest")
+	}
+	path := filepath.Join(l.backupDir, m.SessionID, "manifest.json")
+	if err := os.WriteFile(path+".tmp", data, 0600); err != nil {
+		return types.Wrap(err, types.ErrCodeInternal, "failed to write backup manifest")
+	}
+	if err := os.Rename(path+".tmp", path); err != nil {
+		return types.Wrap(err, types.ErrCodeInternal, "failed to write backup manifest")
+	}
+	return nil
+}
+
+// backupFileName names the backup of path by the hash of the path, so that
+// no two paths share a backup file. The manifest maps paths to names.
+func backupFileName(path string) string {
+	sum := sha256.Sum256([]byte(path))
+	return hex.EncodeToString(sum[:]) + ".bak"
+}
+
+func hashContent(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,134 @@
+package audit_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/SecDuckOps/agent/internal/adapters/audit"
+	"github.com/SecDuckOps/agent/internal/application/taskengine"
+	"github.com/SecDuckOps/agent/internal/ports"
+)
+
+func TestLogger_Rollback(t *testing.T) {
+	logDir, backupDir := tempDirs(t)
+	logger, err := audit.New(logDir, backupDir)
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+	defer logger.Close()
+
+	work := t.TempDir()
+	edited := filepath.Join(work, "main.go")
+	created := filepath.Join(work, "new.go")
+	if err := os.WriteFile(edited, []byte("original"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// The session writes each file twice; the first backup must win.
+	ctx := ports.WithSessionID(context.Background(), "rollback-session")
+	for _, content := range []string{"first", "second"} {
+		if err := taskengine.BackupFiles(ctx, logger, edited, created); err != nil {
+			t.Fatalf("BackupFiles failed: %v", err)
+		}
+		for _, path := range []string{edited, created} {
+			if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := taskengine.RecordWritten(ctx, logger, edited, created); err != nil {
+			t.Fatalf("RecordWritten failed: %v", err)
+		}
+	}
+
+	// A change after the session blocks the rollback unless forced.
+	if err := os.WriteFile(edited, []byte("user edit"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	changes, err := logger.Rollback(ctx, "rollback-session", audit.RollbackOptions{})
+	if err == nil {
+		t.Fatal("expected rollback to refuse a file changed after the session")
+	}
+	if len(changes) != 2 || changes[0].Path != edited || changes[0].Conflict == "" || changes[1].Conflict != "" {
+		t.Fatalf("unexpected changes: %+v", changes)
+	}
+	if data, _ := os.ReadFile(created); string(data) != "second" {
+		t.Fatal("a refused rollback must not change any file")
+	}
+
+	if _, err := logger.Rollback(ctx, "rollback-session", audit.RollbackOptions{DryRun: true, Force: true}); err != nil {
+		t.Fatalf("dry run failed: %v", err)
+	}
+	if data, _ := os.ReadFile(edited); string(data) != "user edit" {
+		t.Fatal("a dry run must not change any file")
+	}
+
+	changes, err = logger.Rollback(ctx, "rollback-session", audit.RollbackOptions{Force: true})
+	if err != nil {
+		t.Fatalf("Rollback failed: %v", err)
+	}
+	if changes[0].Action != audit.RollbackRestore || changes[1].Action != audit.RollbackDelete {
+		t.Errorf("unexpected changes: %+v", changes)
+	}
+	if data, _ := os.ReadFile(edited); string(data) != "original" {
+		t.Errorf("expected original content, got %q", data)
+	}
+	if _, err := os.Stat(created); !os.IsNotExist(err) {
+		t.Error("expected the file created by the session to be deleted")
+	}
+}
+
+func TestLogger_RollbackKeepsPathsApartAndChecksBackups(t *testing.T) {
+	logDir, backupDir := tempDirs(t)
+	logger, err := audit.New(logDir, backupDir)
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+	defer logger.Close()
+
+	// Both paths flatten to .../a_b_c
+	work := t.TempDir()
+	first := filepath.Join(work, "a", "b_c")
+	second := filepath.Join(work, "a_b", "c")
+	for _, path := range []string{first, second} {
+		os.MkdirAll(filepath.Dir(path), 0755)
+		if err := os.WriteFile(path, []byte("original "+path), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ctx := ports.WithSessionID(context.Background(), "collide")
+	if err := taskengine.BackupFiles(ctx, logger, first, second); err != nil {
+		t.Fatalf("BackupFiles failed: %v", err)
+	}
+	for _, path := range []string{first, second} {
+		os.WriteFile(path, []byte("changed"), 0644)
+	}
+	if err := taskengine.RecordWritten(ctx, logger, first, second); err != nil {
+		t.Fatalf("RecordWritten failed: %v", err)
+	}
+
+	if _, err := logger.Rollback(ctx, "collide", audit.RollbackOptions{}); err != nil {
+		t.Fatalf("Rollback failed: %v", err)
+	}
+	for _, path := range []string{first, second} {
+		if data, _ := os.ReadFile(path); string(data) != "original "+path {
+			t.Errorf("%s restored as %q", path, data)
+		}
+	}
+
+	// A backup that no longer matches its hash is not written back
+	os.WriteFile(first, []byte("changed"), 0644)
+	taskengine.RecordWritten(ctx, logger, first)
+	backups, _ := filepath.Glob(filepath.Join(backupDir, "collide", "*.bak"))
+	for _, backup := range backups {
+		os.WriteFile(backup, []byte("tampered"), 0600)
+	}
+	if _, err := logger.Rollback(ctx, "collide", audit.RollbackOptions{Files: []string{first}}); err == nil {
+		t.Fatal("expected a tampered backup to be refused")
+	}
+	if data, _ := os.ReadFile(first); string(data) != "changed" {
+		t.Errorf("expected the file to be left alone, got %q", data)
+	}
+}
@@ -0,0 +1,442 @@
+package audit
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/SecDuckOps/agent/internal/domain/security"
+	"github.com/SecDuckOps/shared/types"
+)
+
+// Checkpoint defaults; see Logger.SetCheckpointPolicy.
+const (
+	DefaultCheckpointEvery    = 100
+	DefaultCheckpointInterval = 5 * time.Minute
+)
+
+// maxLineSize bounds a single JSONL entry when reading a log back.
+const maxLineSize = 16 << 20
+
+// Checkpoint records how long a session log was at a point in time. It is
+// written next to the log as <session_id>.checkpoint, so removing entries
+// from the end of the log, which the hash chain alone cannot reveal, is
+// detected by Verify. It is written with a session's first entry, so every
+// chained log has one.
+type Checkpoint struct {
+	SessionID  string    `json:"session_id"`
+	Entries    int       `json:"entries"`
+	LastHash   string    `json:"last_hash"`
+	ChainStart int       `json:"chain_start,omitempty"` // see sessionIndex.ChainStart
+	Timestamp  time.Time `json:"timestamp"`
+	Algorithm  string    `json:"alg,omitempty"`
+	Signature  string    `json:"sig,omitempty"`
+}
+
+// VerifyResult describes the integrity of one session log.
+type VerifyResult struct {
+	SessionID  string
+	Entries    int         // entries read, across rotated segments and the active file
+	Pruned     int         // entries deleted by retention before the first one read
+	Unchained  int         // leading entries written before hash chaining, up to the recorded chain start
+	Signed     bool        // signatures were checked
+	Checkpoint *Checkpoint // latest checkpoint, if any
+	BrokenAt   int         // 1-based entry of the first broken link, counting pruned ones; 0 when intact
+	File       string      // file holding that entry
+	Reason     string      // why the link is broken
+}
+
+// OK reports whether the log is intact.
+func (r VerifyResult) OK() bool {
+	return r.Reason == ""
+}
+
+// chainHead is the end of a session's hash chain.
+type chainHead struct {
+	hash         string
+	entries      int // including rotated and pruned ones
+	pending      int // entries since the last checkpoint
+	checkpointed time.Time
+	size         int64     // of the active file
+	opened       time.Time // first entry of the active file; zero when empty
+}
+
+// entryHash returns the hash of entry over every field except Hash and
+// Signature, computed on canonical JSON so Verify can recompute it from the
+// written line.
+func entryHash(entry security.AuditEntry) (string, error) {
+	entry.Hash, entry.Signature = "", ""
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return "", err
+	}
+	var raw map[string]interface{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return "", err
+	}
+	return canonicalHash(raw)
+}
+
+// canonicalHash hashes a decoded entry without its hash and sig fields.
+// encoding/json sorts map keys, which makes the encoding canonical.
+func canonicalHash(raw map[string]interface{}) (string, error) {
+	content := make(map[string]interface{}, len(raw))
+	for k, v := range raw {
+		if k != "hash" && k != "sig" {
+			content[k] = v
+		}
+	}
+	data, err := json.Marshal(content)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// head returns the end of a session's chain, loading it and the session's
+// index on first use: pruned and rotated entries come from the index, the
+// rest from the active file. Callers must hold l.mu.
+func (l *Logger) head(sessionID string) (*chainHead, error) {
+	if head, ok := l.heads[sessionID]; ok {
+		return head, nil
+	}
+	index, err := l.readIndex()
+	if err != nil {
+		return nil, err
+	}
+	idx := index[sessionID]
+	if idx == nil {
+		idx = &sessionIndex{}
+	}
+
+	head := &chainHead{checkpointed: time.Now()}
+	if idx.Pruned != nil {
+		head.entries, head.hash = idx.Pruned.Entries, idx.Pruned.LastHash
+	}
+	for _, seg := range idx.Segments {
+		head.entries += seg.Entries
+		head.hash = seg.LastHash
+	}
+
+	active := l.activePath(sessionID)
+	var hash string
+	entries, unchained := 0, 0
+	err = readLogFile(active, func(line []byte) error {
+		var link struct {
+			Hash      string    `json:"hash"`
+			Timestamp time.Time `json:"timestamp"`
+		}
+		if json.Unmarshal(line, &link) == nil {
+			if link.Hash != "" {
+				hash = link.Hash
+			} else if hash == "" {
+				unchained++
+			}
+			if entries == 0 {
+				head.opened = link.Timestamp
+			}
+		}
+		entries++
+		head.size += int64(len(line)) + 1
+		return nil
+	})
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, types.Wrap(err, types.ErrCodeInternal, "cannot read audit file")
+	}
+
+	// An active file that ends where the last segment ends was already
+	// rotated, but not removed before a crash
+	if n := len(idx.Segments); n > 0 && entries == idx.Segments[n-1].Entries && hash == head.hash {
+		os.Remove(active)
+		entries, head.size, head.opened = 0, 0, time.Time{}
+	}
+	if entries > 0 {
+		head.entries += entries
+		if hash != "" {
+			head.hash = hash
+		}
+	}
+
+	// A session indexed for the first time may have a log written before
+	// hash chaining; record where the chain starts
+	if index[sessionID] == nil && head.hash == "" {
+		idx.ChainStart = unchained
+	}
+
+	l.heads[sessionID] = head
+	l.index[sessionID] = idx
+	return head, nil
+}
+
+// eachLine calls fn for each non-empty line of r.
+func eachLine(r io.Reader, fn func(line []byte) error) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64<<10), maxLineSize)
+	for scanner.Scan() {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		if err := fn(scanner.Bytes()); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+func (l *Logger) checkpointPath(sessionID string) string {
+	return filepath.Join(l.logDir, sessionID+".checkpoint")
+}
+
+// writeCheckpoint replaces the session's checkpoint file. Callers must hold l.mu.
+func (l *Logger) writeCheckpoint(sessionID string, head *chainHead) error {
+	cp := Checkpoint{
+		SessionID: sessionID,
+		Entries:   head.entries,
+		LastHash:  head.hash,
+		Timestamp: time.Now().UTC(),
+	}
+	if idx := l.index[sessionID]; idx != nil {
+		cp.ChainStart = idx.ChainStart
+	}
+	if l.signer != nil {
+		cp.Algorithm = l.signer.Algorithm()
+		sig, err := l.signer.Sign(checkpointPayload(cp))
+		if err != nil {
+			return types.Wrap(err, types.ErrCodeInternal, "failed to sign audit checkpoint")
+		}
+		cp.Signature = base64.StdEncoding.EncodeToString(sig)
+	}
+
+	data, err := json.MarshalIndent(cp, "", "  ")
+	if err != nil {
+		return types.Wrap(err, types.ErrCodeInternal, "failed to marshal audit checkpoint")
+	}
+	path := l.checkpointPath(sessionID)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return types.Wrap(err, types.ErrCodeInternal, "failed to write audit checkpoint")
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return types.Wrap(err, types.ErrCodeInternal, "failed to write audit checkpoint")
+	}
+
+	head.pending = 0
+	head.checkpointed = time.Now()
+	return nil
+}
+
+// checkpointPayload is what a checkpoint signature covers.
+func checkpointPayload(cp Checkpoint) []byte {
+	cp.Signature = ""
+	data, _ := json.Marshal(cp)
+	return data
+}
+
+// Verify checks the hash chain, signatures and checkpoint of a session log,
+// across its rotated segments, and reports the first broken link.
+// Signatures are checked only when a signer is set; it must then cover
+// every chained entry, the retention anchor and the checkpoint.
+//
+// Entries without a hash are accepted only up to the chain start recorded in
+// the checkpoint or index, and a chained log must have a checkpoint. A log
+// stripped of its hashes, or cut short together with its checkpoint, is
+// therefore reported as broken.
+func (l *Logger) Verify(sessionID string) (VerifyResult, error) {
+	result := VerifyResult{SessionID: sessionID, Signed: l.signer != nil}
+
+	index, err := l.snapshotIndex()
+	if err != nil {
+		return result, err
+	}
+	idx := index[sessionID]
+	files := l.sessionFiles(sessionID, idx, nil, nil)
+	if len(files) == 0 {
+		return result, types.Newf(types.ErrCodeNotFound, "no audit log for session %s", sessionID)
+	}
+
+	cp, err := l.readCheckpoint(sessionID)
+	invalidCheckpoint := errors.Is(err, errInvalidCheckpoint)
+	if err != nil && !invalidCheckpoint {
+		return result, err
+	}
+	chainStart := 0
+	if cp != nil {
+		chainStart = cp.ChainStart
+	} else if idx != nil {
+		chainStart = idx.ChainStart
+	}
+
+	var hashes []string // hash at each entry read, for the checkpoint
+	prev, anchorHash := "", ""
+	if idx != nil && idx.Pruned != nil {
+		anchor := idx.Pruned
+		result.Pruned, prev, anchorHash = anchor.Entries, anchor.LastHash, anchor.LastHash
+		if l.signer != nil {
+			sig, err := base64.StdEncoding.DecodeString(anchor.Signature)
+			if anchor.Signature == "" || err != nil || !l.signer.Verify(anchorPayload(sessionID, *anchor), sig) {
+				result.BrokenAt = anchor.Entries
+				result.Reason = "retention anchor signature is missing or invalid"
+				return result, nil
+			}
+		}
+	}
+
+	errBroken := errors.New("broken")
+	var file string
+	broken := func(reason string, args ...interface{}) error {
+		result.BrokenAt = result.Pruned + result.Entries
+		result.File = filepath.Base(file)
+		result.Reason = fmt.Sprintf(reason, args...)
+		return errBroken
+	}
+
+	for _, file = range files {
+		err = readLogFile(file, func(line []byte) error {
+			result.Entries++
+			var raw map[string]interface{}
+			if err := json.Unmarshal(line, &raw); err != nil {
+				return broken("entry is not valid JSON")
+			}
+
+			hash, _ := raw["hash"].(string)
+			if hash == "" {
+				if prev != "" {
+					return broken("entry has no hash")
+				}
+				if result.Entries > chainStart {
+					return broken("entry has no hash and was not written before hash chaining; the log was rewritten")
+				}
+				result.Unchained++
+				hashes = append(hashes, "")
+				return nil
+			}
+
+			if prevHash, _ := raw["prev_hash"].(string); prevHash != prev {
+				return broken("prev_hash does not match the previous entry; entries were removed, inserted or reordered")
+			}
+			computed, err := canonicalHash(raw)
+			if err != nil || computed != hash {
+				return broken("content does not match its hash; the entry was modified")
+			}
+			if l.signer != nil {
+				encoded, _ := raw["sig"].(string)
+				sig, err := base64.StdEncoding.DecodeString(encoded)
+				if encoded == "" || err != nil || !l.signer.Verify([]byte(hash), sig) {
+					return broken("entry signature is missing or invalid")
+				}
+			}
+
+			prev = hash
+			hashes = append(hashes, hash)
+			return nil
+		})
+		if errors.Is(err, errBroken) {
+			return result, nil
+		}
+		if errors.Is(err, os.ErrNotExist) {
+			result.File = filepath.Base(file)
+			result.BrokenAt = result.Pruned + result.Entries + 1
+			result.Reason = "log file is missing; entries were deleted"
+			return result, nil
+		}
+		if err != nil {
+			return result, types.Wrapf(err, types.ErrCodeInternal, "failed to read audit log for session %s", sessionID)
+		}
+	}
+
+	// Every session indexed by this logger got a checkpoint with its first
+	// entry; so did every chained log
+	switch {
+	case invalidCheckpoint:
+		result.Reason = errInvalidCheckpoint.Error()
+		return result, nil
+	case cp == nil:
+		if idx != nil || prev != "" {
+			result.Reason = "checkpoint is missing; entries may have been deleted from the end"
+		}
+		return result, nil
+	}
+	l.verifyCheckpoint(&result, cp, anchorHash, hashes)
+	return result, nil
+}
+
+// errInvalidCheckpoint stands for a checkpoint file that cannot be decoded.
+var errInvalidCheckpoint = errors.New("checkpoint is not valid JSON")
+
+// readCheckpoint reads a session's checkpoint. It returns nil when there is
+// none and errInvalidCheckpoint when the file cannot be decoded.
+func (l *Logger) readCheckpoint(sessionID string) (*Checkpoint, error) {
+	data, err := os.ReadFile(l.checkpointPath(sessionID))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, types.Wrapf(err, types.ErrCodeInternal, "failed to read checkpoint for session %s", sessionID)
+	}
+
+	var cp Checkpoint
+	if err := json.Unmarshal(data, &cp); err != nil {
+		return nil, errInvalidCheckpoint
+	}
+	return &cp, nil
+}
+
+// verifyCheckpoint compares the log with its latest checkpoint. hashes are
+// those of the entries read, which follow result.Pruned pruned ones ending
+// in anchorHash.
+func (l *Logger) verifyCheckpoint(result *VerifyResult, cp *Checkpoint, anchorHash string, hashes []string) {
+	result.Checkpoint = cp
+
+	if l.signer != nil {
+		sig, err := base64.StdEncoding.DecodeString(cp.Signature)
+		if cp.Signature == "" || err != nil || !l.signer.Verify(checkpointPayload(*cp), sig) {
+			result.Reason = "checkpoint signature is missing or invalid"
+			return
+		}
+	}
+
+	total := result.Pruned + len(hashes)
+	switch {
+	case cp.Entries > total:
+		result.BrokenAt = total + 1
+		result.Reason = fmt.Sprintf("log ends after %d entries but the checkpoint of %s records %d; entries were deleted",
+			total, cp.Timestamp.Format(time.RFC3339), cp.Entries)
+	case cp.Entries > result.Pruned && hashes[cp.Entries-result.Pruned-1] != cp.LastHash,
+		cp.Entries > 0 && cp.Entries == result.Pruned && cp.LastHash != anchorHash:
+		result.BrokenAt = cp.Entries
+		result.Reason = "entry does not match the checkpoint; the log was rewritten"
+	}
+	return
+}
+
+// VerifyAll verifies every session log in the log directory, oldest
+// session first.
+func (l *Logger) VerifyAll() ([]VerifyResult, error) {
+	index, err := l.snapshotIndex()
+	if err != nil {
+		return nil, err
+	}
+	sessions, err := l.listSessions(index)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]VerifyResult, 0, len(sessions))
+	for _, id := range sessions {
+		result, err := l.Verify(id)
+		if err != nil {
+			return results, err
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
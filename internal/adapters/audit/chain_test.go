@@ -0,0 +1,193 @@
+package audit_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/SecDuckOps/agent/internal/adapters/audit"
+	"github.com/SecDuckOps/agent/internal/domain/security"
+)
+
+// writeChain records n entries for session, signed with the HMAC key in
+// keyFile, and closes the logger so the checkpoint is written.
+func writeChain(t *testing.T, logDir, keyFile, session string, n int) {
+	t.Helper()
+	logger, err := audit.New(logDir, "")
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+	signer, err := audit.LoadSigner(audit.SigningHMAC, keyFile, true)
+	if err != nil {
+		t.Fatalf("LoadSigner() failed: %v", err)
+	}
+	logger.SetSigner(signer)
+	for i := 0; i < n; i++ {
+		err := logger.Record(context.Background(), security.AuditEntry{
+			SessionID: session,
+			Action:    security.AuditToolExecute,
+			Actor:     "echo",
+			Details:   map[string]interface{}{"i": i},
+			Timestamp: time.Now(),
+		})
+		if err != nil {
+			t.Fatalf("Record failed: %v", err)
+		}
+	}
+	if err := logger.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+}
+
+func verify(t *testing.T, logDir, keyFile, session string) audit.VerifyResult {
+	t.Helper()
+	logger, err := audit.New(logDir, "")
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+	defer logger.Close()
+	signer, err := audit.LoadSigner(audit.SigningHMAC, keyFile, false)
+	if err != nil {
+		t.Fatalf("LoadSigner() failed: %v", err)
+	}
+	logger.SetSigner(signer)
+	result, err := logger.Verify(session)
+	if err != nil {
+		t.Fatalf("Verify failed: %v", err)
+	}
+	return result
+}
+
+func TestLogger_VerifyDetectsTampering(t *testing.T) {
+	tests := []struct {
+		name     string
+		tamper   func(lines []string) []string
+		brokenAt int
+	}{
+		{"intact", func(lines []string) []string { return lines }, 0},
+		{"modified", func(lines []string) []string {
+			lines[1] = strings.Replace(lines[1], `"i":1`, `"i":9`, 1)
+			return lines
+		}, 2},
+		{"deleted", func(lines []string) []string {
+			return append(lines[:1], lines[2:]...)
+		}, 2},
+		{"truncated", func(lines []string) []string { return lines[:2] }, 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logDir, _ := tempDirs(t)
+			keyFile := filepath.Join(t.TempDir(), "audit.key")
+			writeChain(t, logDir, keyFile, "s1", 4)
+
+			path := filepath.Join(logDir, "s1.jsonl")
+			data, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			lines := tt.tamper(strings.Split(strings.TrimSpace(string(data)), "\n"))
+			if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0600); err != nil {
+				t.Fatal(err)
+			}
+
+			result := verify(t, logDir, keyFile, "s1")
+			if result.OK() != (tt.brokenAt == 0) || result.BrokenAt != tt.brokenAt {
+				t.Errorf("BrokenAt = %d (%s), want %d", result.BrokenAt, result.Reason, tt.brokenAt)
+			}
+			if tt.brokenAt == 0 && (result.Checkpoint == nil || result.Checkpoint.Entries != 4) {
+				t.Errorf("expected a checkpoint covering 4 entries, got %+v", result.Checkpoint)
+			}
+		})
+	}
+}
+
+func TestLogger_VerifyRejectsWrongKey(t *testing.T) {
+	logDir, _ := tempDirs(t)
+	keyFile := filepath.Join(t.TempDir(), "audit.key")
+	writeChain(t, logDir, keyFile, "s1", 2)
+
+	otherKey := filepath.Join(t.TempDir(), "other.key")
+	if err := os.WriteFile(otherKey, []byte("0123456789abcdef0123456789abcdef\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if result := verify(t, logDir, otherKey, "s1"); result.OK() {
+		t.Error("expected verification with the wrong key to fail")
+	}
+}
+
+func TestLogger_VerifyRequiresCheckpointAndChainStart(t *testing.T) {
+	tests := []struct {
+		name   string
+		tamper func(lines []string) []string
+	}{
+		{"stripped", func(lines []string) []string {
+			for i, line := range lines {
+				var raw map[string]interface{}
+				json.Unmarshal([]byte(line), &raw)
+				delete(raw, "hash")
+				delete(raw, "prev_hash")
+				delete(raw, "sig")
+				raw["target"] = "elsewhere"
+				data, _ := json.Marshal(raw)
+				lines[i] = string(data)
+			}
+			return lines
+		}},
+		{"truncated", func(lines []string) []string { return lines[:2] }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logDir, _ := tempDirs(t)
+			keyFile := filepath.Join(t.TempDir(), "audit.key")
+			writeChain(t, logDir, keyFile, "s1", 3)
+
+			path := filepath.Join(logDir, "s1.jsonl")
+			data, _ := os.ReadFile(path)
+			lines := tt.tamper(strings.Split(strings.TrimSpace(string(data)), "\n"))
+			os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0600)
+			os.Remove(filepath.Join(logDir, "s1.checkpoint"))
+
+			if result := verify(t, logDir, keyFile, "s1"); result.OK() {
+				t.Errorf("expected tampering to be detected, got %+v", result)
+			}
+		})
+	}
+}
+
+func TestLogger_VerifyAcceptsEntriesWrittenBeforeChaining(t *testing.T) {
+	logDir, _ := tempDirs(t)
+	keyFile := filepath.Join(t.TempDir(), "audit.key")
+	writeChain(t, logDir, keyFile, "other", 1) // creates the directory and key
+
+	// A log written before hash chaining, without an index or checkpoint
+	var legacy []string
+	for i := 0; i < 2; i++ {
+		data, _ := json.Marshal(security.AuditEntry{ID: fmt.Sprint(i), SessionID: "s1", Action: security.AuditToolExecute, Timestamp: time.Now()})
+		legacy = append(legacy, string(data))
+	}
+	path := filepath.Join(logDir, "s1.jsonl")
+	os.WriteFile(path, []byte(strings.Join(legacy, "\n")+"\n"), 0600)
+	if result := verify(t, logDir, keyFile, "s1"); result.OK() {
+		t.Error("expected a log without a recorded chain start to fail verification")
+	}
+
+	writeChain(t, logDir, keyFile, "s1", 2)
+	result := verify(t, logDir, keyFile, "s1")
+	if !result.OK() || result.Unchained != 2 || result.Entries != 4 {
+		t.Fatalf("expected 2 unchained entries before the chain, got %+v", result)
+	}
+
+	// Prepending another unhashed entry moves past the recorded chain start
+	data, _ := os.ReadFile(path)
+	os.WriteFile(path, append([]byte(legacy[0]+"\n"), data...), 0600)
+	if result := verify(t, logDir, keyFile, "s1"); result.OK() || result.BrokenAt != 3 {
+		t.Errorf("expected entry 3 to be reported, got %+v", result)
+	}
+}
@@ -0,0 +1,207 @@
+package audit
+
+import (
+	"context"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/SecDuckOps/agent/internal/domain/security"
+	"github.com/SecDuckOps/agent/internal/ports"
+	shared_ports "github.com/SecDuckOps/shared/ports"
+)
+
+// Forwarding defaults; see NewForwarder.
+const (
+	DefaultSinkBuffer     = 10000
+	DefaultSinkBatch      = 100
+	DefaultSinkFlushEvery = 2 * time.Second
+)
+
+const (
+	sinkSendTimeout  = 30 * time.Second
+	sinkMinBackoff   = time.Second
+	sinkMaxBackoff   = 5 * time.Minute
+	sinkCloseTimeout = 5 * time.Second
+)
+
+// Forwarder fans entries out to remote sinks. Each sink has its own buffer
+// and goroutine that sends batches and retries failures with exponential
+// backoff, so a slow or unreachable sink delays only itself. Enqueue never
+// blocks: when a sink's buffer is full the entry is dropped for that sink
+// and counted. The local log always has every entry.
+type Forwarder struct {
+	queues []*sinkQueue
+	logger shared_ports.Logger
+	batch  int
+	every  time.Duration
+
+	done   chan struct{}
+	closed atomic.Bool
+	wg     sync.WaitGroup
+}
+
+type sinkQueue struct {
+	sink     ports.AuditSinkPort
+	entries  chan security.AuditEntry
+	dropped  atomic.Int64
+	reported int64 // drops already logged; owned by the sink's goroutine
+}
+
+// NewForwarder starts forwarding to sinks with buffers of buffer entries
+// per sink; zero uses DefaultSinkBuffer. Failures are logged to logger.
+func NewForwarder(buffer int, logger shared_ports.Logger, sinks ...ports.AuditSinkPort) *Forwarder {
+	if buffer <= 0 {
+		buffer = DefaultSinkBuffer
+	}
+	f := &Forwarder{
+		logger: logger,
+		batch:  DefaultSinkBatch,
+		every:  DefaultSinkFlushEvery,
+		done:   make(chan struct{}),
+	}
+	for _, sink := range sinks {
+		q := &sinkQueue{sink: sink, entries: make(chan security.AuditEntry, buffer)}
+		f.queues = append(f.queues, q)
+		f.wg.Add(1)
+		go f.run(q)
+	}
+	return f
+}
+
+// Enqueue hands entry to every sink without blocking.
+func (f *Forwarder) Enqueue(entry security.AuditEntry) {
+	if f.closed.Load() {
+		return
+	}
+	for _, q := range f.queues {
+		select {
+		case q.entries <- entry:
+		default:
+			q.dropped.Add(1)
+		}
+	}
+}
+
+// Dropped returns how many entries each sink lost to a full buffer, by name.
+func (f *Forwarder) Dropped() map[string]int64 {
+	dropped := make(map[string]int64, len(f.queues))
+	for _, q := range f.queues {
+		dropped[q.sink.Name()] += q.dropped.Load()
+	}
+	return dropped
+}
+
+// Close stops accepting entries and gives each sink one last attempt at
+// what it has buffered, waiting at most a few seconds. Sinks that are
+// io.Closers are closed once they finish.
+func (f *Forwarder) Close() error {
+	if f.closed.Swap(true) {
+		return nil
+	}
+	close(f.done)
+
+	finished := make(chan struct{})
+	go func() {
+		f.wg.Wait()
+		close(finished)
+	}()
+	select {
+	case <-finished:
+	case <-time.After(sinkCloseTimeout):
+	}
+	return nil
+}
+
+// closeSink closes q's sink if it holds a connection.
+func closeSink(q *sinkQueue) {
+	if c, ok := q.sink.(io.Closer); ok {
+		c.Close()
+	}
+}
+
+// run batches a sink's entries until the forwarder is closed.
+func (f *Forwarder) run(q *sinkQueue) {
+	defer f.wg.Done()
+	defer closeSink(q)
+	ticker := time.NewTicker(f.every)
+	defer ticker.Stop()
+
+	batch := make([]security.AuditEntry, 0, f.batch)
+	for {
+		select {
+		case entry := <-q.entries:
+			batch = append(batch, entry)
+			if len(batch) < f.batch {
+				continue
+			}
+		case <-ticker.C:
+		case <-f.done:
+		drain:
+			for {
+				select {
+				case entry := <-q.entries:
+					batch = append(batch, entry)
+				default:
+					break drain
+				}
+			}
+			if len(batch) > 0 {
+				f.send(q, batch, true)
+			}
+			return
+		}
+		if len(batch) > 0 {
+			f.send(q, batch, false)
+			batch = batch[:0]
+		}
+	}
+}
+
+// send delivers batch, retrying with backoff until it succeeds or the
+// forwarder closes; with last set it tries once.
+func (f *Forwarder) send(q *sinkQueue, batch []security.AuditEntry, last bool) {
+	backoff := sinkMinBackoff
+	for {
+		ctx, cancel := context.WithTimeout(context.Background(), sinkSendTimeout)
+		err := q.sink.Send(ctx, batch)
+		cancel()
+		if err == nil {
+			f.reportDrops(q)
+			return
+		}
+		if f.logger != nil {
+			f.logger.ErrorErr(context.Background(), err, "Failed to forward audit entries",
+				shared_ports.Field{Key: "sink", Value: q.sink.Name()},
+				shared_ports.Field{Key: "entries", Value: len(batch)},
+				shared_ports.Field{Key: "retry_in", Value: backoff.String()},
+			)
+		}
+		if last {
+			return
+		}
+
+		select {
+		case <-time.After(backoff):
+		case <-f.done:
+			last = true
+		}
+		if backoff *= 2; backoff > sinkMaxBackoff {
+			backoff = sinkMaxBackoff
+		}
+	}
+}
+
+// reportDrops logs entries dropped since the last report.
+func (f *Forwarder) reportDrops(q *sinkQueue) {
+	dropped := q.dropped.Load()
+	if dropped == q.reported || f.logger == nil {
+		return
+	}
+	f.logger.Info(context.Background(), "Audit sink buffer was full, entries were not forwarded",
+		shared_ports.Field{Key: "sink", Value: q.sink.Name()},
+		shared_ports.Field{Key: "dropped", Value: dropped - q.reported},
+	)
+	q.reported = dropped
+}
@@ -0,0 +1,98 @@
+package audit
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/SecDuckOps/agent/internal/domain/security"
+	"github.com/SecDuckOps/shared/types"
+)
+
+// HTTPSinkConfig configures an HTTPSink.
+type HTTPSinkConfig struct {
+	URL     string            // https://; http:// only for loopback stand-ins
+	Headers map[string]string // e.g. an Authorization header
+	CACert  string            // PEM file to verify the server with, optional
+}
+
+// HTTPSink posts each batch as a JSON array of entries. Any 2xx response
+// is success.
+type HTTPSink struct {
+	url     string
+	headers map[string]string
+	client  *http.Client
+}
+
+// NewHTTPSink validates cfg and creates an HTTPSink.
+func NewHTTPSink(cfg HTTPSinkConfig) (*HTTPSink, error) {
+	u, err := url.Parse(cfg.URL)
+	if err != nil || u.Host == "" || (u.Scheme != "https" && u.Scheme != "http") {
+		return nil, types.Newf(types.ErrCodeInvalidInput, "invalid audit sink URL %q", cfg.URL)
+	}
+	if u.Scheme == "http" {
+		if ip := net.ParseIP(u.Hostname()); u.Hostname() != "localhost" && (ip == nil || !ip.IsLoopback()) {
+			return nil, types.Newf(types.ErrCodeInvalidInput, "audit sink URL %q must use https", cfg.URL)
+		}
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if cfg.CACert != "" {
+		pem, err := os.ReadFile(cfg.CACert)
+		if err != nil {
+			return nil, types.Wrapf(err, types.ErrCodeInternal, "failed to read audit sink CA certificate %s", cfg.CACert)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, types.Newf(types.ErrCodeInvalidInput, "no certificates found in %s", cfg.CACert)
+		}
+		transport.TLSClientConfig = &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}
+	}
+
+	return &HTTPSink{
+		url:     cfg.URL,
+		headers: cfg.Headers,
+		client:  &http.Client{Transport: transport, Timeout: 30 * time.Second},
+	}, nil
+}
+
+// Name identifies the sink.
+func (s *HTTPSink) Name() string {
+	return "http " + s.url
+}
+
+// Send posts entries.
+func (s *HTTPSink) Send(ctx context.Context, entries []security.AuditEntry) error {
+	body, err := json.Marshal(entries)
+	if err != nil {
+		return types.Wrap(err, types.ErrCodeInternal, "failed to marshal audit entries")
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return types.Wrap(err, types.ErrCodeInternal, "failed to build audit sink request")
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range s.headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return types.Wrapf(err, types.ErrCodeExecutionFailed, "audit sink %s unreachable", s.url)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<20))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return types.Newf(types.ErrCodeExecutionFailed, "audit sink %s returned %d", s.url, resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,149 @@
+package audit
+
+import (
+	"context"
+	"time"
+
+	"github.com/SecDuckOps/agent/internal/domain/security"
+	"github.com/SecDuckOps/agent/internal/ports"
+	"github.com/SecDuckOps/shared/llm/domain"
+	"github.com/google/uuid"
+)
+
+// LLMRegistry wraps an LLM registry so every generation of the providers it
+// hands out is recorded as an AuditLLMRequest / AuditLLMResponse pair. The
+// session comes from ports.SessionIDFromContext.
+type LLMRegistry struct {
+	domain.LLMRegistry
+	log ports.AuditLogPort
+}
+
+// NewLLMRegistry wraps registry, recording into log.
+func NewLLMRegistry(registry domain.LLMRegistry, log ports.AuditLogPort) *LLMRegistry {
+	return &LLMRegistry{LLMRegistry: registry, log: log}
+}
+
+// Get returns the named provider, audited, or nil.
+func (r *LLMRegistry) Get(name string) domain.LLM {
+	return r.wrap(r.LLMRegistry.Get(name))
+}
+
+// Default returns the default provider, audited, or nil.
+func (r *LLMRegistry) Default() domain.LLM {
+	return r.wrap(r.LLMRegistry.Default())
+}
+
+func (r *LLMRegistry) wrap(llm domain.LLM) domain.LLM {
+	if llm == nil {
+		return nil
+	}
+	audited := &auditedLLM{LLM: llm, log: r.log}
+	if tc, ok := llm.(ports.ToolCallingLLM); ok {
+		return &auditedToolLLM{auditedLLM: audited, tools: tc}
+	}
+	return audited
+}
+
+// auditedLLM records each Generate call before and after it runs.
+type auditedLLM struct {
+	domain.LLM
+	log ports.AuditLogPort
+}
+
+// Generate records the request, calls the provider and records the response
+// linked to the request. Only the newest message of the conversation is
+// recorded, since earlier ones were recorded by previous requests.
+func (l *auditedLLM) Generate(ctx context.Context, messages []domain.Message, tools []domain.ToolDefinition) (domain.GenerationResult, error) {
+	details := map[string]interface{}{}
+	if len(messages) > 0 {
+		last := messages[len(messages)-1]
+		details["role"] = last.Role
+		details["content"] = last.Content
+	}
+	requestID := l.recordRequest(ctx, len(messages), len(tools), details)
+
+	start := time.Now()
+	result, err := l.LLM.Generate(ctx, messages, tools)
+
+	details = map[string]interface{}{}
+	if err == nil {
+		details["content"] = result.Content
+		details["usage"] = result.Usage
+	}
+	l.recordResponse(ctx, requestID, start, err, details)
+	return result, err
+}
+
+// recordRequest records an AuditLLMRequest with details added and returns
+// its ID.
+func (l *auditedLLM) recordRequest(ctx context.Context, messageCount, toolCount int, details map[string]interface{}) string {
+	requestID := uuid.New().String()
+	details["provider"] = l.Name()
+	details["model"] = l.Model()
+	details["message_count"] = messageCount
+	details["tool_count"] = toolCount
+	_ = l.log.Record(ctx, security.AuditEntry{
+		ID:        requestID,
+		SessionID: ports.SessionIDFromContext(ctx),
+		Action:    security.AuditLLMRequest,
+		Actor:     "llm",
+		Target:    l.Name(),
+		Details:   details,
+		Timestamp: time.Now(),
+	})
+	return requestID
+}
+
+// recordResponse records the AuditLLMResponse to requestID: details, or
+// only the error if the call failed.
+func (l *auditedLLM) recordResponse(ctx context.Context, requestID string, start time.Time, err error, details map[string]interface{}) {
+	if err != nil {
+		details = map[string]interface{}{"error": err.Error()}
+	}
+	details["model"] = l.Model()
+	details["duration_ms"] = time.Since(start).Milliseconds()
+	_ = l.log.Record(ctx, security.AuditEntry{
+		SessionID: ports.SessionIDFromContext(ctx),
+		Action:    security.AuditLLMResponse,
+		Actor:     "llm",
+		Target:    l.Name(),
+		Details:   details,
+		Timestamp: time.Now(),
+		ParentID:  requestID,
+	})
+}
+
+// auditedToolLLM is an auditedLLM over a provider with native tool calling.
+type auditedToolLLM struct {
+	*auditedLLM
+	tools ports.ToolCallingLLM
+}
+
+// GenerateWithTools records the request and response like Generate; the
+// response includes the tool calls the model made.
+func (l *auditedToolLLM) GenerateWithTools(ctx context.Context, messages []ports.ChatMessage, tools []domain.ToolDefinition) (ports.ToolGeneration, error) {
+	details := map[string]interface{}{}
+	if len(messages) > 0 {
+		last := messages[len(messages)-1]
+		details["role"] = last.Role
+		details["content"] = last.Content
+		if last.ToolCallID != "" {
+			details["tool_call_id"] = last.ToolCallID
+		}
+	}
+	requestID := l.recordRequest(ctx, len(messages), len(tools), details)
+
+	start := time.Now()
+	result, err := l.tools.GenerateWithTools(ctx, messages, tools)
+
+	details = map[string]interface{}{}
+	if err == nil {
+		details["content"] = result.Content
+		details["usage"] = result.Usage
+		if len(result.ToolCalls) > 0 {
+			details["tool_calls"] = result.ToolCalls
+		}
+	}
+	l.recordResponse(ctx, requestID, start, err, details)
+	return result, err
+}
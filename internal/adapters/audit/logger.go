@@ -1,28 +1,49 @@
 package audit
 
 import (
-	"bufio"
 	"context"
+	"encoding/base64"
 	"encoding/json"
 	"os"
 	"path/filepath"
 	"sync"
 	"time"
 
+	"github.com/SecDuckOps/agent/internal/application/scrub"
 	"github.com/SecDuckOps/agent/internal/domain/security"
 	"github.com/SecDuckOps/agent/internal/ports"
 	"github.com/SecDuckOps/shared/types"
 	"github.com/google/uuid"
 )
 
+// SystemSession holds entries recorded outside of any session.
+const SystemSession = "system"
+
 // Logger implements ports.AuditLogPort using JSONL files.
 // One file per session: ~/.duckops/audit/{session_id}.jsonl
 // Backups stored in: ~/.duckops/audit/backups/{session_id}/
+//
+// Entries in a file form a hash chain, optionally signed, and a checkpoint
+// of the chain's end is kept next to it; see Verify. Files are rotated into
+// {session_id}.{seq}.jsonl.gz segments, listed in index.json; see
+// SetRotationPolicy.
 type Logger struct {
 	logDir    string
 	backupDir string
 	mu        sync.Mutex
-	writers   map[string]*os.File // session_id → open file handle
+	backupMu  sync.Mutex            // guards backup manifests
+	writers   map[string]*os.File   // session_id → open file handle
+	heads     map[string]*chainHead // session_id → end of its hash chain
+	index     map[string]*sessionIndex
+	redactor  ports.RedactorPort
+	signer    Signer
+	forwarder *Forwarder
+
+	checkpointEvery    int
+	checkpointInterval time.Duration
+	maxFileSize        int64
+	rotateAfter        time.Duration
+	retention          time.Duration
 }
 
 // New creates a new audit Logger.
@@ -46,14 +67,57 @@ func New(logDir, backupDir string) (*Logger, error) {
 	}
 
 	return &Logger{
-		logDir:    logDir,
-		backupDir: backupDir,
-		writers:   make(map[string]*os.File),
+		logDir:             logDir,
+		backupDir:          backupDir,
+		writers:            make(map[string]*os.File),
+		heads:              make(map[string]*chainHead),
+		index:              make(map[string]*sessionIndex),
+		checkpointEvery:    DefaultCheckpointEvery,
+		checkpointInterval: DefaultCheckpointInterval,
+		maxFileSize:        DefaultMaxFileSize,
+		rotateAfter:        DefaultRotateAfter,
 	}, nil
 }
 
+// SetSigner makes Record sign every entry hash and checkpoint, and Verify
+// require valid signatures. It must be called before the logger is shared.
+func (l *Logger) SetSigner(s Signer) {
+	l.signer = s
+}
+
+// SetCheckpointPolicy sets how often a session's checkpoint is rewritten:
+// after every entries entries or, on the next entry, once interval has
+// passed. Zero values keep the defaults. Close always writes a final one.
+func (l *Logger) SetCheckpointPolicy(entries int, interval time.Duration) {
+	if entries > 0 {
+		l.checkpointEvery = entries
+	}
+	if interval > 0 {
+		l.checkpointInterval = interval
+	}
+}
+
+// SetForwarder makes Record hand every written entry to f, which is closed
+// with the logger. It must be called before the logger is shared.
+func (l *Logger) SetForwarder(f *Forwarder) {
+	l.forwarder = f
+}
+
+// SetRedactor makes Record redact secrets from entry targets and details.
+// It must be called before the logger is shared.
+func (l *Logger) SetRedactor(r ports.RedactorPort) {
+	l.redactor = r
+}
+
 // Record writes an immutable audit entry to the session's JSONL file.
 func (l *Logger) Record(_ context.Context, entry security.AuditEntry) error {
+	if l.redactor != nil {
+		entry.Target = l.redactor.Redact(entry.Target)
+		if entry.Details != nil {
+			entry.Details = scrub.RedactValue(l.redactor, entry.Details).(map[string]interface{})
+		}
+	}
+
 	// Assign ID and timestamp if missing
 	if entry.ID == "" {
 		entry.ID = uuid.New().String()
@@ -61,94 +125,126 @@ func (l *Logger) Record(_ context.Context, entry security.AuditEntry) error {
 	if entry.Timestamp.IsZero() {
 		entry.Timestamp = time.Now()
 	}
-
-	data, err := json.Marshal(entry)
-	if err != nil {
-		return types.Wrap(err, types.ErrCodeInternal, "failed to marshal audit entry")
+	if entry.SessionID == "" {
+		entry.SessionID = SystemSession
 	}
 
 	l.mu.Lock()
 	defer l.mu.Unlock()
 
+	head, err := l.head(entry.SessionID)
+	if err != nil {
+		return err
+	}
+	if l.rotationDue(head) {
+		if err := l.rotate(entry.SessionID); err != nil {
+			return err
+		}
+	}
 	f, err := l.getOrCreateWriter(entry.SessionID)
 	if err != nil {
 		return err
 	}
 
+	// Link the entry to the end of the chain, then hash and sign it
+	entry.PrevHash = head.hash
+	entry.Hash, err = entryHash(entry)
+	if err != nil {
+		return types.Wrap(err, types.ErrCodeInternal, "failed to hash audit entry")
+	}
+	if l.signer != nil {
+		sig, err := l.signer.Sign([]byte(entry.Hash))
+		if err != nil {
+			return types.Wrap(err, types.ErrCodeInternal, "failed to sign audit entry")
+		}
+		entry.Signature = base64.StdEncoding.EncodeToString(sig)
+	}
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return types.Wrap(err, types.ErrCodeInternal, "failed to marshal audit entry")
+	}
+
 	// Append JSONL line
 	if _, err := f.Write(append(data, '\n')); err != nil {
 		return types.Wrap(err, types.ErrCodeInternal, "failed to write audit entry")
 	}
+	if err := f.Sync(); err != nil {
+		return types.Wrap(err, types.ErrCodeInternal, "failed to sync audit entry")
+	}
 
-	return f.Sync()
-}
-
-// Query returns audit entries matching the filter.
-func (l *Logger) Query(_ context.Context, filter ports.AuditFilter) ([]security.AuditEntry, error) {
-	var entries []security.AuditEntry
-
-	// If session ID specified, read only that file
-	if filter.SessionID != "" {
-		sessionEntries, err := l.readSessionFile(filter.SessionID)
-		if err != nil {
-			return nil, err
-		}
-		entries = filterEntries(sessionEntries, filter)
-		return entries, nil
+	if l.forwarder != nil {
+		l.forwarder.Enqueue(entry)
 	}
 
-	// Otherwise scan all session files
-	files, err := os.ReadDir(l.logDir)
-	if err != nil {
-		return nil, types.Wrap(err, types.ErrCodeInternal, "failed to read audit directory")
+	head.hash = entry.Hash
+	head.entries++
+	head.pending++
+	head.size += int64(len(data)) + 1
+	if head.opened.IsZero() {
+		head.opened = time.Now()
 	}
 
-	for _, f := range files {
-		if f.IsDir() || filepath.Ext(f.Name()) != ".jsonl" {
-			continue
-		}
-		sessionID := f.Name()[:len(f.Name())-len(".jsonl")]
-		sessionEntries, err := l.readSessionFile(sessionID)
-		if err != nil {
-			continue // skip unreadable files
+	// The index and checkpoint are saved for a new session, so Verify knows
+	// its chain start, and then periodically
+	idx := l.index[entry.SessionID]
+	isNew := idx.From.IsZero()
+	if isNew || entry.Timestamp.Before(idx.From) {
+		idx.From = entry.Timestamp
+	}
+	if entry.Timestamp.After(idx.To) {
+		idx.To = entry.Timestamp
+	}
+	if isNew || head.pending >= l.checkpointEvery || time.Since(head.checkpointed) >= l.checkpointInterval {
+		if err := l.writeCheckpoint(entry.SessionID, head); err != nil {
+			return err
 		}
-		entries = append(entries, filterEntries(sessionEntries, filter)...)
+		return l.saveIndex(entry.SessionID)
 	}
-
-	return entries, nil
+	return nil
 }
 
-// BackupSession creates a snapshot backup of all files modified in the session.
-func (l *Logger) BackupSession(_ context.Context, snapshot security.SessionSnapshot) error {
-	sessionBackupDir := filepath.Join(l.backupDir, snapshot.SessionID)
-	if err := os.MkdirAll(sessionBackupDir, 0700); err != nil {
-		return types.Wrap(err, types.ErrCodeInternal, "cannot create backup directory")
+// Query returns audit entries matching the filter. The index limits the
+// files read to those of the session, if given, that overlap From and To.
+func (l *Logger) Query(_ context.Context, filter ports.AuditFilter) ([]security.AuditEntry, error) {
+	index, err := l.snapshotIndex()
+	if err != nil {
+		return nil, err
 	}
 
-	for filePath, content := range snapshot.Files {
-		// Flatten the file path to a safe filename
-		safeName := flattenPath(filePath)
-		backupPath := filepath.Join(sessionBackupDir, safeName)
-
-		if err := os.WriteFile(backupPath, content, 0600); err != nil {
-			return types.Wrapf(err, types.ErrCodeInternal, "failed to backup file %s", filePath)
+	sessions := []string{filter.SessionID}
+	if filter.SessionID == "" {
+		if sessions, err = l.listSessions(index); err != nil {
+			return nil, err
 		}
 	}
 
-	// Write a manifest
-	manifest := struct {
-		SessionID string    `json:"session_id"`
-		Files     []string  `json:"files"`
-		CreatedAt time.Time `json:"created_at"`
-	}{
-		SessionID: snapshot.SessionID,
-		Files:     snapshot.FileList,
-		CreatedAt: snapshot.CreatedAt,
+	var entries []security.AuditEntry
+	for _, id := range sessions {
+		files := l.sessionFiles(id, index[id], filter.From, filter.To)
+		if len(files) == 0 && filter.SessionID != "" {
+			return nil, types.Newf(types.ErrCodeNotFound, "no audit log for session %s", id)
+		}
+		for _, file := range files {
+			remaining := filter
+			if filter.Limit > 0 {
+				remaining.Limit = filter.Limit - len(entries)
+			}
+			fileEntries, err := readEntries(file)
+			if err != nil {
+				if filter.SessionID != "" {
+					return nil, types.Wrap(err, types.ErrCodeInternal, "cannot open session file")
+				}
+				continue // skip unreadable files
+			}
+			entries = append(entries, filterEntries(fileEntries, remaining)...)
+			if filter.Limit > 0 && len(entries) >= filter.Limit {
+				return entries, nil
+			}
+		}
 	}
 
-	manifestData, _ := json.MarshalIndent(manifest, "", "  ")
-	manifestPath := filepath.Join(sessionBackupDir, "manifest.json")
-	return os.WriteFile(manifestPath, manifestData, 0600)
+	return entries, nil
 }
 
 // ReplaySession returns all audit entries for a session in chronological order.
@@ -162,24 +258,37 @@ func (l *Logger) Close() error {
 	defer l.mu.Unlock()
 
 	var lastErr error
+	for id, head := range l.heads {
+		if head.pending > 0 {
+			if err := l.writeCheckpoint(id, head); err != nil {
+				lastErr = err
+			}
+			if err := l.saveIndex(id); err != nil {
+				lastErr = err
+			}
+		}
+	}
 	for id, f := range l.writers {
 		if err := f.Close(); err != nil {
 			lastErr = err
 		}
 		delete(l.writers, id)
 	}
+	if l.forwarder != nil {
+		l.forwarder.Close()
+	}
 	return lastErr
 }
 
 // ──────────────── internal helpers ────────────────
 
+// getOrCreateWriter opens the active file of a session whose head is loaded.
 func (l *Logger) getOrCreateWriter(sessionID string) (*os.File, error) {
 	if f, ok := l.writers[sessionID]; ok {
 		return f, nil
 	}
 
-	path := filepath.Join(l.logDir, sessionID+".jsonl")
-	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	f, err := os.OpenFile(l.activePath(sessionID), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
 	if err != nil {
 		return nil, types.Wrap(err, types.ErrCodeInternal, "cannot open audit file")
 	}
@@ -188,25 +297,24 @@ func (l *Logger) getOrCreateWriter(sessionID string) (*os.File, error) {
 	return f, nil
 }
 
+// readSessionFile reads every entry of a session, across its segments.
 func (l *Logger) readSessionFile(sessionID string) ([]security.AuditEntry, error) {
-	path := filepath.Join(l.logDir, sessionID+".jsonl")
-	f, err := os.Open(path)
-	if err != nil {
-		return nil, types.Wrap(err, types.ErrCodeInternal, "cannot open session file")
-	}
-	defer f.Close()
+	return l.Query(context.Background(), ports.AuditFilter{SessionID: sessionID})
+}
 
+// readEntries reads the entries of one log file or segment.
+func readEntries(path string) ([]security.AuditEntry, error) {
 	var entries []security.AuditEntry
-	scanner := bufio.NewScanner(f)
-	for scanner.Scan() {
+	err := readLogFile(path, func(line []byte) error {
 		var entry security.AuditEntry
-		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
-			continue // skip malformed lines
+		if err := json.Unmarshal(line, &entry); err != nil {
+			return nil // skip malformed lines
 		}
 		entries = append(entries, entry)
-	}
+		return nil
+	})
 
-	return entries, scanner.Err()
+	return entries, err
 }
 
 func filterEntries(entries []security.AuditEntry, filter ports.AuditFilter) []security.AuditEntry {
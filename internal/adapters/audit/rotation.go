@@ -0,0 +1,452 @@
+package audit
+
+import (
+	"compress/gzip"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/SecDuckOps/shared/types"
+)
+
+// Rotation defaults; see Logger.SetRotationPolicy.
+const (
+	DefaultMaxFileSize = 10 << 20
+	DefaultRotateAfter = 24 * time.Hour
+)
+
+// indexFile lists, per session, the rotated segments and the time range of
+// the log, so Query can skip files outside the requested range.
+const indexFile = "index.json"
+
+// sessionIndex describes a session's log apart from the active
+// {session_id}.jsonl, whose entries all follow the last segment.
+type sessionIndex struct {
+	From     time.Time    `json:"from"` // first entry
+	To       time.Time    `json:"to"`   // last entry, as of the last save
+	NextSeq  int          `json:"next_seq"`
+	Segments []segment    `json:"segments,omitempty"` // oldest first
+	Pruned   *chainAnchor `json:"pruned,omitempty"`   // segments removed by retention
+
+	// ChainStart counts the entries written before hash chaining, found in
+	// the active file when the session was first indexed. Verify accepts
+	// entries without a hash only among these.
+	ChainStart int `json:"chain_start,omitempty"`
+}
+
+// segment is a rotated, gzip-compressed part of a session log.
+type segment struct {
+	File     string    `json:"file"`
+	Entries  int       `json:"entries"`
+	From     time.Time `json:"from"`
+	To       time.Time `json:"to"`
+	LastHash string    `json:"last_hash"`
+}
+
+// chainAnchor stands in for segments deleted by retention. The remaining
+// chain starts from LastHash, and its entries are numbered after Entries.
+type chainAnchor struct {
+	Entries   int       `json:"entries"`
+	LastHash  string    `json:"last_hash"`
+	To        time.Time `json:"to"`
+	Algorithm string    `json:"alg,omitempty"`
+	Signature string    `json:"sig,omitempty"`
+}
+
+// anchorPayload is what an anchor signature covers.
+func anchorPayload(sessionID string, a chainAnchor) []byte {
+	data, _ := json.Marshal(struct {
+		SessionID string `json:"session_id"`
+		Entries   int    `json:"entries"`
+		LastHash  string `json:"last_hash"`
+	}{sessionID, a.Entries, a.LastHash})
+	return data
+}
+
+// SetRotationPolicy sets when a session log is rotated into a compressed
+// segment: once it reaches maxSize bytes or its first entry is rotateAfter
+// old. Zero values keep the defaults. Segments and backups older than
+// retention are deleted by ApplyRetention; zero keeps them forever.
+func (l *Logger) SetRotationPolicy(maxSize int64, rotateAfter, retention time.Duration) {
+	if maxSize > 0 {
+		l.maxFileSize = maxSize
+	}
+	if rotateAfter > 0 {
+		l.rotateAfter = rotateAfter
+	}
+	l.retention = retention
+}
+
+func (l *Logger) activePath(sessionID string) string {
+	return filepath.Join(l.logDir, sessionID+".jsonl")
+}
+
+// readIndex loads the index from disk.
+func (l *Logger) readIndex() (map[string]*sessionIndex, error) {
+	index := make(map[string]*sessionIndex)
+	data, err := os.ReadFile(filepath.Join(l.logDir, indexFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return index, nil
+	}
+	if err != nil {
+		return nil, types.Wrap(err, types.ErrCodeInternal, "cannot read audit index")
+	}
+	if err := json.Unmarshal(data, &index); err != nil {
+		return nil, types.Wrap(err, types.ErrCodeInternal, "invalid audit index")
+	}
+	return index, nil
+}
+
+// saveIndex writes the in-memory index of the given sessions to disk,
+// keeping the other sessions as they are there, since another process may
+// be writing them. A session missing from memory is removed. Callers must
+// hold l.mu.
+func (l *Logger) saveIndex(sessionIDs ...string) error {
+	index, err := l.readIndex()
+	if err != nil {
+		return err
+	}
+	for _, id := range sessionIDs {
+		if idx, ok := l.index[id]; ok {
+			index[id] = idx
+		} else {
+			delete(index, id)
+		}
+	}
+
+	data, err := json.MarshalIndent(index, "", "  ")
+	if err != nil {
+		return types.Wrap(err, types.ErrCodeInternal, "failed to marshal audit index")
+	}
+	path := filepath.Join(l.logDir, indexFile)
+	if err := os.WriteFile(path+".tmp", data, 0600); err != nil {
+		return types.Wrap(err, types.ErrCodeInternal, "failed to write audit index")
+	}
+	if err := os.Rename(path+".tmp", path); err != nil {
+		return types.Wrap(err, types.ErrCodeInternal, "failed to write audit index")
+	}
+	return nil
+}
+
+// snapshotIndex returns a copy of the index: the disk version, with the
+// sessions this logger writes taken from memory.
+func (l *Logger) snapshotIndex() (map[string]*sessionIndex, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	index, err := l.readIndex()
+	if err != nil {
+		return nil, err
+	}
+	for id, idx := range l.index {
+		cp := *idx
+		cp.Segments = append([]segment(nil), idx.Segments...)
+		index[id] = &cp
+	}
+	return index, nil
+}
+
+// listSessions returns every session in index or with an active log file,
+// oldest first.
+func (l *Logger) listSessions(index map[string]*sessionIndex) ([]string, error) {
+	files, err := os.ReadDir(l.logDir)
+	if err != nil {
+		return nil, types.Wrap(err, types.ErrCodeInternal, "failed to read audit directory")
+	}
+	seen := make(map[string]bool, len(index))
+	var sessions []string
+	for id := range index {
+		seen[id] = true
+		sessions = append(sessions, id)
+	}
+	for _, f := range files {
+		id, ok := strings.CutSuffix(f.Name(), ".jsonl")
+		if ok && !f.IsDir() && !seen[id] {
+			sessions = append(sessions, id)
+		}
+	}
+
+	from := func(id string) time.Time {
+		if idx := index[id]; idx != nil {
+			return idx.From
+		}
+		return time.Time{}
+	}
+	sort.Slice(sessions, func(i, j int) bool {
+		if a, b := from(sessions[i]), from(sessions[j]); !a.Equal(b) {
+			return a.Before(b)
+		}
+		return sessions[i] < sessions[j]
+	})
+	return sessions, nil
+}
+
+// sessionFiles returns the files of a session log in order, keeping only
+// those that may hold entries between from and to (either may be nil).
+func (l *Logger) sessionFiles(sessionID string, idx *sessionIndex, from, to *time.Time) []string {
+	overlaps := func(start, end time.Time) bool {
+		return (from == nil || end.IsZero() || !end.Before(*from)) &&
+			(to == nil || start.IsZero() || !start.After(*to))
+	}
+
+	var files []string
+	var start, end time.Time
+	if idx != nil {
+		for _, seg := range idx.Segments {
+			if overlaps(seg.From, seg.To) {
+				files = append(files, filepath.Join(l.logDir, seg.File))
+			}
+		}
+		if n := len(idx.Segments); n > 0 {
+			start = idx.Segments[n-1].To
+		}
+		end = idx.To
+	}
+
+	active := l.activePath(sessionID)
+	info, err := os.Stat(active)
+	if err != nil {
+		return files
+	}
+	if idx == nil || info.ModTime().After(end) {
+		// The index is saved with checkpoints, so the file may be newer
+		end = info.ModTime()
+	}
+	if idx == nil || overlaps(start, end) {
+		files = append(files, active)
+	}
+	return files
+}
+
+// readLogFile calls fn for each line of a session log file or segment.
+func readLogFile(path string, fn func(line []byte) error) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var r io.Reader = f
+	if strings.HasSuffix(path, ".gz") {
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			return err
+		}
+		defer gz.Close()
+		r = gz
+	}
+	return eachLine(r, fn)
+}
+
+// rotationDue reports whether the active file of head should be rotated.
+func (l *Logger) rotationDue(head *chainHead) bool {
+	return head.size >= l.maxFileSize || (!head.opened.IsZero() && time.Since(head.opened) >= l.rotateAfter)
+}
+
+// rotate compresses the active file of a session into a new segment. The
+// chain continues in the next active file. Callers must hold l.mu and have
+// loaded the session's head.
+func (l *Logger) rotate(sessionID string) error {
+	if f, ok := l.writers[sessionID]; ok {
+		f.Close()
+		delete(l.writers, sessionID)
+	}
+	head, idx := l.heads[sessionID], l.index[sessionID]
+
+	seg := segment{File: fmt.Sprintf("%s.%06d.jsonl.gz", sessionID, idx.NextSeq+1), LastHash: head.hash}
+	active := l.activePath(sessionID)
+	if err := compressSegment(active, filepath.Join(l.logDir, seg.File), &seg); err != nil {
+		return types.Wrapf(err, types.ErrCodeInternal, "failed to rotate audit log for session %s", sessionID)
+	}
+	if seg.Entries == 0 {
+		os.Remove(filepath.Join(l.logDir, seg.File))
+		return nil
+	}
+
+	// The index is saved before the active file goes, so a crash in between
+	// leaves a duplicate that loadChainHead removes, never a gap
+	idx.NextSeq++
+	idx.Segments = append(idx.Segments, seg)
+	if err := l.saveIndex(sessionID); err != nil {
+		return err
+	}
+	if err := os.Remove(active); err != nil {
+		return types.Wrapf(err, types.ErrCodeInternal, "failed to rotate audit log for session %s", sessionID)
+	}
+	head.size, head.opened = 0, time.Time{}
+	return l.writeCheckpoint(sessionID, head)
+}
+
+// compressSegment gzips src into dst, recording the entry count and time
+// range in seg.
+func compressSegment(src, dst string, seg *segment) error {
+	tmp := dst + ".tmp"
+	out, err := os.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp)
+	defer out.Close()
+
+	gz := gzip.NewWriter(out)
+	err = readLogFile(src, func(line []byte) error {
+		var entry struct {
+			Timestamp time.Time `json:"timestamp"`
+		}
+		if json.Unmarshal(line, &entry) == nil {
+			if seg.From.IsZero() || entry.Timestamp.Before(seg.From) {
+				seg.From = entry.Timestamp
+			}
+			if entry.Timestamp.After(seg.To) {
+				seg.To = entry.Timestamp
+			}
+		}
+		seg.Entries++
+		if _, err := gz.Write(line); err != nil {
+			return err
+		}
+		_, err := gz.Write([]byte{'\n'})
+		return err
+	})
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	if err := gz.Close(); err != nil {
+		return err
+	}
+	if err := out.Sync(); err != nil {
+		return err
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp, dst)
+}
+
+// ApplyRetention rotates session logs that are due for rotation but idle,
+// and, with a retention period set, deletes segments and backups older than
+// it. Deleted segments are replaced by a signed anchor, so the rest of the
+// chain still verifies; a session with nothing left is removed entirely,
+// checkpoint included.
+func (l *Logger) ApplyRetention(now time.Time) error {
+	index, err := l.snapshotIndex()
+	if err != nil {
+		return err
+	}
+	sessions, err := l.listSessions(index)
+	if err != nil {
+		return err
+	}
+
+	l.mu.Lock()
+	var lastErr error
+	for _, id := range sessions {
+		if err := l.applySessionRetention(id, now); err != nil {
+			lastErr = err
+		}
+	}
+	l.mu.Unlock()
+
+	if l.retention > 0 {
+		if err := l.pruneBackups(now.Add(-l.retention)); err != nil {
+			lastErr = err
+		}
+	}
+	return lastErr
+}
+
+// applySessionRetention rotates and prunes one session. Callers must hold l.mu.
+func (l *Logger) applySessionRetention(sessionID string, now time.Time) error {
+	head, err := l.head(sessionID)
+	if err != nil {
+		return err
+	}
+	if l.rotationDue(head) {
+		if err := l.rotate(sessionID); err != nil {
+			return err
+		}
+	}
+	if l.retention <= 0 {
+		return nil
+	}
+
+	cutoff := now.Add(-l.retention)
+	idx := l.index[sessionID]
+	n := 0
+	for n < len(idx.Segments) && idx.Segments[n].To.Before(cutoff) {
+		n++
+	}
+	if n == 0 {
+		return nil
+	}
+
+	anchor := chainAnchor{}
+	if idx.Pruned != nil {
+		anchor.Entries = idx.Pruned.Entries
+	}
+	for _, seg := range idx.Segments[:n] {
+		anchor.Entries += seg.Entries
+		anchor.LastHash, anchor.To = seg.LastHash, seg.To
+	}
+	if l.signer != nil {
+		sig, err := l.signer.Sign(anchorPayload(sessionID, anchor))
+		if err != nil {
+			return types.Wrap(err, types.ErrCodeInternal, "failed to sign audit anchor")
+		}
+		anchor.Algorithm = l.signer.Algorithm()
+		anchor.Signature = base64.StdEncoding.EncodeToString(sig)
+	}
+	removed := idx.Segments[:n]
+	idx.Pruned = &anchor
+	idx.Segments = idx.Segments[n:]
+
+	_, open := l.writers[sessionID]
+	_, statErr := os.Stat(l.activePath(sessionID))
+	if len(idx.Segments) == 0 && !open && errors.Is(statErr, os.ErrNotExist) {
+		delete(l.index, sessionID)
+		delete(l.heads, sessionID)
+		os.Remove(l.checkpointPath(sessionID))
+	}
+	// Segments go only once the anchor replacing them is saved
+	if err := l.saveIndex(sessionID); err != nil {
+		return err
+	}
+	for _, seg := range removed {
+		if err := os.Remove(filepath.Join(l.logDir, seg.File)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return types.Wrapf(err, types.ErrCodeInternal, "failed to delete audit segment %s", seg.File)
+		}
+	}
+	return nil
+}
+
+// pruneBackups deletes session backups last written before cutoff.
+func (l *Logger) pruneBackups(cutoff time.Time) error {
+	l.backupMu.Lock()
+	defer l.backupMu.Unlock()
+
+	dirs, err := os.ReadDir(l.backupDir)
+	if err != nil {
+		return types.Wrap(err, types.ErrCodeInternal, "failed to read backup directory")
+	}
+	for _, d := range dirs {
+		if !d.IsDir() {
+			continue
+		}
+		dir := filepath.Join(l.backupDir, d.Name())
+		info, err := os.Stat(filepath.Join(dir, "manifest.json"))
+		if err != nil || info.ModTime().After(cutoff) {
+			continue
+		}
+		if err := os.RemoveAll(dir); err != nil {
+			return types.Wrapf(err, types.ErrCodeInternal, "failed to delete backups of session %s", d.Name())
+		}
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+package audit_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/SecDuckOps/agent/internal/adapters/audit"
+	"github.com/SecDuckOps/agent/internal/domain/security"
+	"github.com/SecDuckOps/agent/internal/ports"
+)
+
+func TestLogger_RotationAndRetention(t *testing.T) {
+	logDir, backupDir := tempDirs(t)
+	keyFile := filepath.Join(t.TempDir(), "audit.key")
+	signer, err := audit.LoadSigner(audit.SigningHMAC, keyFile, true)
+	if err != nil {
+		t.Fatalf("LoadSigner() failed: %v", err)
+	}
+	open := func() *audit.Logger {
+		logger, err := audit.New(logDir, backupDir)
+		if err != nil {
+			t.Fatalf("New() failed: %v", err)
+		}
+		logger.SetSigner(signer)
+		logger.SetRotationPolicy(1024, 0, 24*time.Hour)
+		return logger
+	}
+
+	// One entry an hour, rotated every few entries by size
+	ctx := context.Background()
+	base := time.Now().Add(-48 * time.Hour).UTC().Truncate(time.Second)
+	logger := open()
+	for i := 0; i < 20; i++ {
+		err := logger.Record(ctx, security.AuditEntry{
+			SessionID: "rotated",
+			Action:    security.AuditToolExecute,
+			Actor:     "echo",
+			Details:   map[string]interface{}{"i": i},
+			Timestamp: base.Add(time.Duration(i) * time.Hour),
+		})
+		if err != nil {
+			t.Fatalf("Record failed: %v", err)
+		}
+	}
+	logger.Close()
+
+	segments, _ := filepath.Glob(filepath.Join(logDir, "rotated.*.jsonl.gz"))
+	if len(segments) < 2 {
+		t.Fatalf("expected several rotated segments, got %v", segments)
+	}
+
+	logger = open()
+	defer logger.Close()
+	entries, err := logger.ReplaySession(ctx, "rotated")
+	if err != nil || len(entries) != 20 {
+		t.Fatalf("ReplaySession returned %d entries, err %v; want 20", len(entries), err)
+	}
+	if result, _ := logger.Verify("rotated"); !result.OK() || result.Entries != 20 {
+		t.Fatalf("expected an intact chain across segments, got %+v", result)
+	}
+
+	// Retention drops the segments of the first ten hours; the rest of the
+	// chain still verifies from the anchor
+	if err := logger.ApplyRetention(base.Add(34 * time.Hour)); err != nil {
+		t.Fatalf("ApplyRetention failed: %v", err)
+	}
+	result, err := logger.Verify("rotated")
+	if err != nil || !result.OK() || result.Pruned == 0 || result.Pruned+result.Entries != 20 {
+		t.Fatalf("expected an intact chain after retention, got %+v, err %v", result, err)
+	}
+
+	// Queries bounded in time only read the segments they need, so a
+	// missing old segment does not affect them
+	remaining, _ := filepath.Glob(filepath.Join(logDir, "rotated.*.jsonl.gz"))
+	if err := os.Remove(remaining[0]); err != nil {
+		t.Fatal(err)
+	}
+	from := base.Add(18 * time.Hour)
+	recent, err := logger.Query(ctx, ports.AuditFilter{SessionID: "rotated", From: &from})
+	if err != nil || len(recent) != 2 {
+		t.Fatalf("Query returned %d entries, err %v; want 2", len(recent), err)
+	}
+	if result, _ := logger.Verify("rotated"); result.OK() {
+		t.Error("expected verification to report the deleted segment")
+	}
+}
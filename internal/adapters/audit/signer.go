@@ -0,0 +1,163 @@
+package audit
+
+import (
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/SecDuckOps/shared/types"
+)
+
+// Signing algorithms for LoadSigner.
+const (
+	SigningHMAC    = "hmac"    // HMAC-SHA256 with a shared secret
+	SigningEd25519 = "ed25519" // Ed25519; verification only needs the public key
+)
+
+// minHMACKeyLength is the shortest HMAC key LoadSigner accepts, in bytes.
+const minHMACKeyLength = 16
+
+// Signer signs entry hashes and checkpoints.
+type Signer interface {
+	// Algorithm returns SigningHMAC or SigningEd25519.
+	Algorithm() string
+	// Sign returns the signature of msg.
+	Sign(msg []byte) ([]byte, error)
+	// Verify reports whether sig is a valid signature of msg.
+	Verify(msg, sig []byte) bool
+}
+
+// LoadSigner reads the key for algorithm from keyFile. With create, a
+// missing key file is generated with mode 0600. HMAC key files hold the key
+// as text (e.g. 64 hex digits); Ed25519 key files hold a PKCS#8 private key
+// or, for verification only, a PKIX public key, both PEM encoded.
+func LoadSigner(algorithm, keyFile string, create bool) (Signer, error) {
+	if algorithm != SigningHMAC && algorithm != SigningEd25519 {
+		return nil, types.Newf(types.ErrCodeInvalidInput, "unknown audit signing algorithm %q", algorithm)
+	}
+
+	data, err := os.ReadFile(keyFile)
+	if errors.Is(err, os.ErrNotExist) && create {
+		data, err = generateKey(algorithm, keyFile)
+	}
+	if err != nil {
+		return nil, types.Wrapf(err, types.ErrCodeInternal, "failed to read audit key %s", keyFile)
+	}
+
+	if algorithm == SigningHMAC {
+		key := []byte(strings.TrimSpace(string(data)))
+		if len(key) < minHMACKeyLength {
+			return nil, types.Newf(types.ErrCodeInvalidInput, "audit HMAC key in %s is shorter than %d bytes", keyFile, minHMACKeyLength)
+		}
+		return hmacSigner{key: key}, nil
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, types.Newf(types.ErrCodeInvalidInput, "no PEM block found in %s", keyFile)
+	}
+	switch block.Type {
+	case "PRIVATE KEY":
+		key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, types.Wrapf(err, types.ErrCodeInvalidInput, "invalid private key in %s", keyFile)
+		}
+		priv, ok := key.(ed25519.PrivateKey)
+		if !ok {
+			return nil, types.Newf(types.ErrCodeInvalidInput, "private key in %s is not an Ed25519 key", keyFile)
+		}
+		return ed25519Signer{priv: priv, pub: priv.Public().(ed25519.PublicKey)}, nil
+	case "PUBLIC KEY":
+		key, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, types.Wrapf(err, types.ErrCodeInvalidInput, "invalid public key in %s", keyFile)
+		}
+		pub, ok := key.(ed25519.PublicKey)
+		if !ok {
+			return nil, types.Newf(types.ErrCodeInvalidInput, "public key in %s is not an Ed25519 key", keyFile)
+		}
+		return ed25519Signer{pub: pub}, nil
+	default:
+		return nil, types.Newf(types.ErrCodeInvalidInput, "unexpected PEM block %q in %s", block.Type, keyFile)
+	}
+}
+
+// generateKey creates a new key file for algorithm and returns its content.
+func generateKey(algorithm, keyFile string) ([]byte, error) {
+	var data []byte
+	if algorithm == SigningHMAC {
+		key := make([]byte, 32)
+		if _, err := rand.Read(key); err != nil {
+			return nil, err
+		}
+		data = []byte(hex.EncodeToString(key) + "\n")
+	} else {
+		_, priv, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return nil, err
+		}
+		der, err := x509.MarshalPKCS8PrivateKey(priv)
+		if err != nil {
+			return nil, err
+		}
+		data = pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+	}
+
+	if err := os.MkdirAll(filepath.Dir(keyFile), 0700); err != nil {
+		return nil, err
+	}
+	// O_EXCL so two agents starting at once cannot overwrite each other's key
+	f, err := os.OpenFile(keyFile, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		if errors.Is(err, os.ErrExist) {
+			return os.ReadFile(keyFile)
+		}
+		return nil, err
+	}
+	defer f.Close()
+	if _, err := f.Write(data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+type hmacSigner struct{ key []byte }
+
+func (s hmacSigner) Algorithm() string { return SigningHMAC }
+
+func (s hmacSigner) Sign(msg []byte) ([]byte, error) {
+	mac := hmac.New(sha256.New, s.key)
+	mac.Write(msg)
+	return mac.Sum(nil), nil
+}
+
+func (s hmacSigner) Verify(msg, sig []byte) bool {
+	expected, _ := s.Sign(msg)
+	return hmac.Equal(expected, sig)
+}
+
+type ed25519Signer struct {
+	priv ed25519.PrivateKey // nil when only the public key is known
+	pub  ed25519.PublicKey
+}
+
+func (s ed25519Signer) Algorithm() string { return SigningEd25519 }
+
+func (s ed25519Signer) Sign(msg []byte) ([]byte, error) {
+	if s.priv == nil {
+		return nil, types.New(types.ErrCodeInvalidInput, "audit key holds only a public key and cannot sign")
+	}
+	return ed25519.Sign(s.priv, msg), nil
+}
+
+func (s ed25519Signer) Verify(msg, sig []byte) bool {
+	return ed25519.Verify(s.pub, msg, sig)
+}
@@ -0,0 +1,232 @@
+package audit_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/SecDuckOps/agent/internal/adapters/audit"
+	"github.com/SecDuckOps/agent/internal/domain/security"
+)
+
+// memorySink collects what it is sent.
+type memorySink struct {
+	mu      sync.Mutex
+	entries []security.AuditEntry
+}
+
+func (s *memorySink) Name() string { return "memory" }
+
+func (s *memorySink) Send(_ context.Context, entries []security.AuditEntry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries = append(s.entries, entries...)
+	return nil
+}
+
+func (s *memorySink) received() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.entries)
+}
+
+// stuckSink hangs in Send until released, then fails.
+type stuckSink struct {
+	entered chan struct{}
+	release chan struct{}
+	once    sync.Once
+}
+
+func (s *stuckSink) Name() string { return "stuck" }
+
+func (s *stuckSink) Send(ctx context.Context, _ []security.AuditEntry) error {
+	s.once.Do(func() { close(s.entered) })
+	select {
+	case <-s.release:
+	case <-ctx.Done():
+	}
+	return errors.New("sink is down")
+}
+
+func TestForwarder_StuckSinkDoesNotBlock(t *testing.T) {
+	logDir, backupDir := tempDirs(t)
+	logger, err := audit.New(logDir, backupDir)
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+	healthy := &memorySink{}
+	stuck := &stuckSink{entered: make(chan struct{}), release: make(chan struct{})}
+	buffer := audit.DefaultSinkBatch + 10
+	forwarder := audit.NewForwarder(buffer, nil, healthy, stuck)
+	logger.SetForwarder(forwarder)
+
+	ctx := context.Background()
+	record := func(n int) {
+		for i := 0; i < n; i++ {
+			if err := logger.Record(ctx, security.AuditEntry{SessionID: "s1", Action: security.AuditToolExecute, Actor: "echo"}); err != nil {
+				t.Fatalf("Record failed: %v", err)
+			}
+		}
+	}
+
+	// A full batch puts the stuck sink in Send; then its buffer fills up
+	record(audit.DefaultSinkBatch)
+	select {
+	case <-stuck.entered:
+	case <-time.After(5 * time.Second):
+		t.Fatal("stuck sink was never sent a batch")
+	}
+	start := time.Now()
+	record(buffer + 40)
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("Record took %s with a stuck sink", elapsed)
+	}
+	if dropped := forwarder.Dropped()["stuck"]; dropped != 40 {
+		t.Errorf("stuck sink dropped %d entries, want 40", dropped)
+	}
+
+	close(stuck.release)
+	if err := logger.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	total := audit.DefaultSinkBatch + buffer + 40
+	if got := healthy.received() + int(forwarder.Dropped()["memory"]); got != total || healthy.received() == 0 {
+		t.Errorf("healthy sink received %d entries and dropped the rest of %d, want all %d", healthy.received(), got, total)
+	}
+
+	// Everything is still in the local log
+	entries, err := logger.ReplaySession(ctx, "s1")
+	if err != nil || len(entries) != total {
+		t.Errorf("ReplaySession returned %d entries, err %v; want %d", len(entries), err, total)
+	}
+}
+
+func TestSyslogSink_SendsRFC5424(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen failed: %v", err)
+	}
+	defer ln.Close()
+
+	messages := make(chan string, 10)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+		for {
+			// Octet counting: "<length> <message>"
+			length, err := r.ReadString(' ')
+			if err != nil {
+				return
+			}
+			n, err := strconv.Atoi(strings.TrimSpace(length))
+			if err != nil {
+				return
+			}
+			msg := make([]byte, n)
+			if _, err := io.ReadFull(r, msg); err != nil {
+				return
+			}
+			messages <- string(msg)
+		}
+	}()
+
+	sink, err := audit.NewSyslogSink(audit.SyslogConfig{Address: ln.Addr().String(), Hostname: "agent-1"})
+	if err != nil {
+		t.Fatalf("NewSyslogSink failed: %v", err)
+	}
+	defer sink.Close()
+
+	entries := []security.AuditEntry{
+		{ID: "e1", SessionID: "s1", Action: security.AuditToolExecute, Actor: "terminal", Target: `echo "hi"`, Timestamp: time.Now()},
+		{ID: "e2", SessionID: "s1", Action: security.AuditPolicyDeny, Actor: "warden", Target: "evil.example.com", Timestamp: time.Now()},
+	}
+	if err := sink.Send(context.Background(), entries); err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+
+	for i, want := range []struct{ prefix, sd string }{
+		{"<109>1 ", `[duckops@32473 session="s1" actor="terminal" target="echo \"hi\""]`},
+		{"<108>1 ", `[duckops@32473 session="s1" actor="warden" target="evil.example.com"]`},
+	} {
+		var msg string
+		select {
+		case msg = <-messages:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("message %d not received", i)
+		}
+		if !strings.HasPrefix(msg, want.prefix) {
+			t.Errorf("message %d = %q, want prefix %q", i, msg, want.prefix)
+		}
+		if !strings.Contains(msg, " agent-1 duckops - ") || !strings.Contains(msg, want.sd) {
+			t.Errorf("message %d = %q, want host, app and %s", i, msg, want.sd)
+		}
+		var entry security.AuditEntry
+		if err := json.Unmarshal([]byte(msg[strings.Index(msg, "] ")+2:]), &entry); err != nil || entry.ID != entries[i].ID {
+			t.Errorf("message %d carries entry %q, err %v", i, entry.ID, err)
+		}
+	}
+}
+
+func TestHTTPSink_PostsBatches(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		received []security.AuditEntry
+		status   = http.StatusAccepted
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" || r.Header.Get("Content-Type") != "application/json" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		var batch []security.AuditEntry
+		if err := json.NewDecoder(r.Body).Decode(&batch); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		received = append(received, batch...)
+		w.WriteHeader(status)
+	}))
+	defer srv.Close()
+
+	sink, err := audit.NewHTTPSink(audit.HTTPSinkConfig{URL: srv.URL, Headers: map[string]string{"Authorization": "Bearer token"}})
+	if err != nil {
+		t.Fatalf("NewHTTPSink failed: %v", err)
+	}
+	batch := []security.AuditEntry{{ID: "e1"}, {ID: "e2"}}
+	if err := sink.Send(context.Background(), batch); err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+	if len(received) != 2 || received[1].ID != "e2" {
+		t.Errorf("server received %v", received)
+	}
+
+	mu.Lock()
+	status = http.StatusServiceUnavailable
+	mu.Unlock()
+	if err := sink.Send(context.Background(), batch); err == nil {
+		t.Error("expected an error for a 503 response")
+	}
+
+	if _, err := audit.NewHTTPSink(audit.HTTPSinkConfig{URL: "http://siem.example.com/ingest"}); err == nil {
+		t.Error("expected plain http to a remote host to be rejected")
+	}
+	if _, err := audit.NewHTTPSink(audit.HTTPSinkConfig{URL: "https://siem.example.com/ingest"}); err != nil {
+		t.Errorf("https URL rejected: %v", err)
+	}
+}
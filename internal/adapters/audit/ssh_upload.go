@@ -0,0 +1,258 @@
+package audit
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/user"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/SecDuckOps/shared/types"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// uploadedFile records which segments an SSHUploader has delivered.
+const uploadedFile = "uploaded.json"
+
+// SSHUploadConfig configures an SSHUploader.
+type SSHUploadConfig struct {
+	Host       string // [user@]host[:port]; the user defaults to the local one
+	RemoteDir  string // existing directory on the host
+	KeyFile    string // private key; default ~/.ssh/id_ed25519
+	KnownHosts string // default ~/.ssh/known_hosts; the host must be listed
+}
+
+// SSHUploader copies closed session files, the rotated segments, to a
+// backup host with the SCP protocol, followed by the index that lists
+// them. Each segment is uploaded once; segments deleted by retention before
+// they could be uploaded are lost to the backup.
+type SSHUploader struct {
+	log    *Logger
+	addr   string
+	dir    string
+	config *ssh.ClientConfig
+}
+
+// NewSSHUploader validates cfg and loads the key and known hosts.
+func NewSSHUploader(log *Logger, cfg SSHUploadConfig) (*SSHUploader, error) {
+	if cfg.Host == "" || cfg.RemoteDir == "" {
+		return nil, types.New(types.ErrCodeInvalidInput, "SSH backup needs a host and a remote directory")
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return nil, types.Wrap(err, types.ErrCodeInternal, "cannot determine home directory")
+	}
+	if cfg.KeyFile == "" {
+		cfg.KeyFile = filepath.Join(home, ".ssh", "id_ed25519")
+	}
+	if cfg.KnownHosts == "" {
+		cfg.KnownHosts = filepath.Join(home, ".ssh", "known_hosts")
+	}
+
+	name, addr, found := strings.Cut(cfg.Host, "@")
+	if !found {
+		addr, name = cfg.Host, ""
+		if u, err := user.Current(); err == nil {
+			name = u.Username
+		}
+	}
+	if _, _, err := net.SplitHostPort(addr); err != nil {
+		addr = net.JoinHostPort(addr, "22")
+	}
+
+	pem, err := os.ReadFile(cfg.KeyFile)
+	if err != nil {
+		return nil, types.Wrapf(err, types.ErrCodeInternal, "failed to read SSH key %s", cfg.KeyFile)
+	}
+	signer, err := ssh.ParsePrivateKey(pem)
+	if err != nil {
+		return nil, types.Wrapf(err, types.ErrCodeInvalidInput, "invalid SSH key in %s", cfg.KeyFile)
+	}
+	hostKeys, err := knownhosts.New(cfg.KnownHosts)
+	if err != nil {
+		return nil, types.Wrapf(err, types.ErrCodeInternal, "failed to read known hosts %s", cfg.KnownHosts)
+	}
+
+	return &SSHUploader{
+		log:  log,
+		addr: addr,
+		dir:  cfg.RemoteDir,
+		config: &ssh.ClientConfig{
+			User:            name,
+			Auth:            []ssh.AuthMethod{ssh.PublicKeys(signer)},
+			HostKeyCallback: hostKeys,
+			Timeout:         15 * time.Second,
+		},
+	}, nil
+}
+
+// Upload sends the segments not uploaded yet, then the index, and returns
+// how many segments it sent.
+func (u *SSHUploader) Upload(ctx context.Context) (int, error) {
+	index, err := u.log.snapshotIndex()
+	if err != nil {
+		return 0, err
+	}
+	uploaded, err := u.readUploaded()
+	if err != nil {
+		return 0, err
+	}
+
+	var pending []string
+	present := make(map[string]bool)
+	for _, idx := range index {
+		for _, seg := range idx.Segments {
+			present[seg.File] = true
+			if _, ok := uploaded[seg.File]; !ok {
+				pending = append(pending, seg.File)
+			}
+		}
+	}
+	if len(pending) == 0 {
+		return 0, nil
+	}
+	sort.Strings(pending)
+
+	client, err := u.dial(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer client.Close()
+
+	// The index goes last, so the backup never lists a segment it lacks
+	files := append(pending, indexFile)
+	if err := u.copy(client, files); err != nil {
+		return 0, err
+	}
+
+	now := time.Now().UTC()
+	for _, name := range pending {
+		uploaded[name] = now
+	}
+	for name := range uploaded {
+		if !present[name] {
+			delete(uploaded, name) // pruned since
+		}
+	}
+	return len(pending), u.writeUploaded(uploaded)
+}
+
+func (u *SSHUploader) dial(ctx context.Context) (*ssh.Client, error) {
+	conn, err := (&net.Dialer{Timeout: u.config.Timeout}).DialContext(ctx, "tcp", u.addr)
+	if err != nil {
+		return nil, types.Wrapf(err, types.ErrCodeExecutionFailed, "cannot connect to SSH backup host %s", u.addr)
+	}
+	c, chans, reqs, err := ssh.NewClientConn(conn, u.addr, u.config)
+	if err != nil {
+		conn.Close()
+		return nil, types.Wrapf(err, types.ErrCodeExecutionFailed, "SSH handshake with %s failed", u.addr)
+	}
+	return ssh.NewClient(c, chans, reqs), nil
+}
+
+// copy sends files from the log directory to the remote directory in one
+// "scp -t" session.
+func (u *SSHUploader) copy(client *ssh.Client, files []string) error {
+	session, err := client.NewSession()
+	if err != nil {
+		return types.Wrap(err, types.ErrCodeExecutionFailed, "failed to open SSH session")
+	}
+	defer session.Close()
+
+	stdin, err := session.StdinPipe()
+	if err != nil {
+		return types.Wrap(err, types.ErrCodeInternal, "failed to open SSH session input")
+	}
+	stdout, err := session.StdoutPipe()
+	if err != nil {
+		return types.Wrap(err, types.ErrCodeInternal, "failed to open SSH session output")
+	}
+	acks := bufio.NewReader(stdout)
+	if err := session.Start("scp -qt " + shellQuote(u.dir)); err != nil {
+		return types.Wrap(err, types.ErrCodeExecutionFailed, "failed to start scp on the backup host")
+	}
+
+	fail := func(err error) error {
+		return types.Wrapf(err, types.ErrCodeExecutionFailed, "scp upload to %s:%s failed", u.addr, u.dir)
+	}
+	if err := readSCPAck(acks); err != nil {
+		return fail(err)
+	}
+	for _, name := range files {
+		data, err := os.ReadFile(filepath.Join(u.log.logDir, name))
+		if err != nil {
+			return types.Wrapf(err, types.ErrCodeInternal, "failed to read %s", name)
+		}
+		if _, err := fmt.Fprintf(stdin, "C0600 %d %s\n", len(data), name); err != nil {
+			return fail(err)
+		}
+		if err := readSCPAck(acks); err != nil {
+			return fail(err)
+		}
+		if _, err := stdin.Write(append(data, 0)); err != nil {
+			return fail(err)
+		}
+		if err := readSCPAck(acks); err != nil {
+			return fail(err)
+		}
+	}
+	stdin.Close()
+	if err := session.Wait(); err != nil {
+		return fail(err)
+	}
+	return nil
+}
+
+// readSCPAck reads the remote scp's reply: 0 for success, or 1 or 2
+// followed by a message.
+func readSCPAck(r *bufio.Reader) error {
+	code, err := r.ReadByte()
+	if err != nil {
+		return err
+	}
+	if code == 0 {
+		return nil
+	}
+	msg, _ := r.ReadString('\n')
+	return errors.New(strings.TrimSpace(msg))
+}
+
+func (u *SSHUploader) readUploaded() (map[string]time.Time, error) {
+	uploaded := make(map[string]time.Time)
+	data, err := os.ReadFile(filepath.Join(u.log.logDir, uploadedFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return uploaded, nil
+	}
+	if err != nil {
+		return nil, types.Wrap(err, types.ErrCodeInternal, "cannot read upload state")
+	}
+	if err := json.Unmarshal(data, &uploaded); err != nil {
+		return nil, types.Wrap(err, types.ErrCodeInternal, "invalid upload state")
+	}
+	return uploaded, nil
+}
+
+func (u *SSHUploader) writeUploaded(uploaded map[string]time.Time) error {
+	data, err := json.MarshalIndent(uploaded, "", "  ")
+	if err != nil {
+		return types.Wrap(err, types.ErrCodeInternal, "failed to marshal upload state")
+	}
+	path := filepath.Join(u.log.logDir, uploadedFile)
+	if err := os.WriteFile(path+".tmp", data, 0600); err != nil {
+		return types.Wrap(err, types.ErrCodeInternal, "failed to write upload state")
+	}
+	return os.Rename(path+".tmp", path)
+}
+
+// shellQuote quotes s for a POSIX shell.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
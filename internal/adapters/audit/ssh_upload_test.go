@@ -0,0 +1,247 @@
+package audit_test
+
+import (
+	"bufio"
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/SecDuckOps/agent/internal/adapters/audit"
+	"github.com/SecDuckOps/agent/internal/domain/security"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// scpServer is an SSH server that accepts "scp -qt <dir>" for one user
+// and key and stores the files it receives by name.
+type scpServer struct {
+	addr string
+
+	mu    sync.Mutex
+	dirs  []string
+	files map[string][]byte
+}
+
+func startSCPServer(t *testing.T, clientKey ssh.PublicKey) (*scpServer, ssh.PublicKey) {
+	t.Helper()
+	_, hostPriv, _ := ed25519.GenerateKey(rand.Reader)
+	hostSigner, err := ssh.NewSignerFromKey(hostPriv)
+	if err != nil {
+		t.Fatalf("host key: %v", err)
+	}
+	config := &ssh.ServerConfig{
+		PublicKeyCallback: func(meta ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permissions, error) {
+			if meta.User() == "backup" && string(key.Marshal()) == string(clientKey.Marshal()) {
+				return nil, nil
+			}
+			return nil, fmt.Errorf("unknown key for %s", meta.User())
+		},
+	}
+	config.AddHostKey(hostSigner)
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen failed: %v", err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	srv := &scpServer{addr: ln.Addr().String(), files: make(map[string][]byte)}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go srv.serve(conn, config)
+		}
+	}()
+	return srv, hostSigner.PublicKey()
+}
+
+func (s *scpServer) serve(conn net.Conn, config *ssh.ServerConfig) {
+	_, chans, reqs, err := ssh.NewServerConn(conn, config)
+	if err != nil {
+		return
+	}
+	go ssh.DiscardRequests(reqs)
+	for newChan := range chans {
+		if newChan.ChannelType() != "session" {
+			newChan.Reject(ssh.UnknownChannelType, "sessions only")
+			continue
+		}
+		ch, requests, err := newChan.Accept()
+		if err != nil {
+			return
+		}
+		go func() {
+			defer ch.Close()
+			for req := range requests {
+				if req.Type != "exec" {
+					req.Reply(false, nil)
+					continue
+				}
+				var exec struct{ Command string }
+				ssh.Unmarshal(req.Payload, &exec)
+				dir, ok := strings.CutPrefix(exec.Command, "scp -qt ")
+				req.Reply(ok, nil)
+				if !ok {
+					return
+				}
+				status := s.receive(ch, strings.Trim(dir, "'"))
+				ch.SendRequest("exit-status", false, ssh.Marshal(struct{ Status uint32 }{status}))
+				return
+			}
+		}()
+	}
+}
+
+// receive speaks the sink side of the SCP protocol.
+func (s *scpServer) receive(ch ssh.Channel, dir string) uint32 {
+	s.mu.Lock()
+	s.dirs = append(s.dirs, dir)
+	s.mu.Unlock()
+
+	r := bufio.NewReader(ch)
+	ch.Write([]byte{0})
+	for {
+		header, err := r.ReadString('\n')
+		if err == io.EOF {
+			return 0
+		}
+		var mode string
+		var size int
+		var name string
+		if err != nil || !strings.HasPrefix(header, "C") {
+			return 1
+		}
+		if _, err := fmt.Sscanf(header, "C%s %d %s\n", &mode, &size, &name); err != nil {
+			return 1
+		}
+		ch.Write([]byte{0})
+		data := make([]byte, size+1)
+		if _, err := io.ReadFull(r, data); err != nil || data[size] != 0 {
+			return 1
+		}
+		s.mu.Lock()
+		s.files[name] = data[:size]
+		s.mu.Unlock()
+		ch.Write([]byte{0})
+	}
+}
+
+func (s *scpServer) received() map[string][]byte {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	files := make(map[string][]byte, len(s.files))
+	for name, data := range s.files {
+		files[name] = data
+	}
+	return files
+}
+
+func TestSSHUploader_UploadsSegments(t *testing.T) {
+	// Client key and a known_hosts file listing the server
+	clientPub, clientPriv, _ := ed25519.GenerateKey(rand.Reader)
+	block, err := ssh.MarshalPrivateKey(clientPriv, "")
+	if err != nil {
+		t.Fatalf("MarshalPrivateKey failed: %v", err)
+	}
+	sshDir := t.TempDir()
+	keyFile := filepath.Join(sshDir, "id_ed25519")
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(block), 0600); err != nil {
+		t.Fatal(err)
+	}
+	clientKey, _ := ssh.NewPublicKey(clientPub)
+	srv, hostKey := startSCPServer(t, clientKey)
+	knownHosts := filepath.Join(sshDir, "known_hosts")
+	line := knownhosts.Line([]string{knownhosts.Normalize(srv.addr)}, hostKey) + "\n"
+	if err := os.WriteFile(knownHosts, []byte(line), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	// A session rotated into several segments
+	logDir, backupDir := tempDirs(t)
+	logger, err := audit.New(logDir, backupDir)
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+	defer logger.Close()
+	logger.SetRotationPolicy(512, 0, 0)
+	ctx := context.Background()
+	for i := 0; i < 10; i++ {
+		err := logger.Record(ctx, security.AuditEntry{
+			SessionID: "s1",
+			Action:    security.AuditToolExecute,
+			Actor:     "echo",
+			Details:   map[string]interface{}{"i": i},
+		})
+		if err != nil {
+			t.Fatalf("Record failed: %v", err)
+		}
+	}
+	segments, _ := filepath.Glob(filepath.Join(logDir, "s1.*.jsonl.gz"))
+	if len(segments) < 2 {
+		t.Fatalf("expected several segments, got %v", segments)
+	}
+
+	uploader, err := audit.NewSSHUploader(logger, audit.SSHUploadConfig{
+		Host:       "backup@" + srv.addr,
+		RemoteDir:  "/srv/duckops audit",
+		KeyFile:    keyFile,
+		KnownHosts: knownHosts,
+	})
+	if err != nil {
+		t.Fatalf("NewSSHUploader failed: %v", err)
+	}
+	n, err := uploader.Upload(ctx)
+	if err != nil {
+		t.Fatalf("Upload failed: %v", err)
+	}
+	if n != len(segments) {
+		t.Errorf("Upload sent %d segments, want %d", n, len(segments))
+	}
+
+	files := srv.received()
+	for _, seg := range segments {
+		local, _ := os.ReadFile(seg)
+		if remote, ok := files[filepath.Base(seg)]; !ok || string(remote) != string(local) {
+			t.Errorf("segment %s was not uploaded intact", filepath.Base(seg))
+		}
+	}
+	if _, ok := files["index.json"]; !ok {
+		t.Error("index.json was not uploaded")
+	}
+	if srv.dirs[0] != "/srv/duckops audit" {
+		t.Errorf("remote directory = %q", srv.dirs[0])
+	}
+
+	// Nothing new, nothing sent
+	if n, err := uploader.Upload(ctx); err != nil || n != 0 {
+		t.Errorf("second Upload sent %d segments, err %v; want 0", n, err)
+	}
+
+	// An unknown host key is refused
+	other, _ := startSCPServer(t, clientKey)
+	uploader, err = audit.NewSSHUploader(logger, audit.SSHUploadConfig{
+		Host:       "backup@" + other.addr,
+		RemoteDir:  "/srv/audit",
+		KeyFile:    keyFile,
+		KnownHosts: knownHosts,
+	})
+	if err != nil {
+		t.Fatalf("NewSSHUploader failed: %v", err)
+	}
+	os.Remove(filepath.Join(logDir, "uploaded.json"))
+	if _, err := uploader.Upload(ctx); err == nil {
+		t.Error("expected upload to a host with an unknown key to fail")
+	}
+}
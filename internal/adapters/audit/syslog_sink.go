@@ -0,0 +1,195 @@
+package audit
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/SecDuckOps/agent/internal/domain/security"
+	"github.com/SecDuckOps/shared/types"
+)
+
+// Syslog defaults.
+const (
+	DefaultSyslogFacility = 13 // log audit
+	DefaultSyslogAppName  = "duckops"
+)
+
+// syslogSDID is the structured data ID of the entry fields; 32473 is the
+// private enterprise number reserved for documentation (RFC 5612).
+const syslogSDID = "duckops@32473"
+
+// SyslogConfig configures a SyslogSink.
+type SyslogConfig struct {
+	Address  string // host:port
+	TLS      bool   // RFC 5425; plain TCP otherwise
+	CACert   string // PEM file to verify the server with, optional
+	Facility int    // default DefaultSyslogFacility
+	AppName  string // default DefaultSyslogAppName
+	Hostname string // default os.Hostname()
+}
+
+// SyslogSink sends entries as RFC 5424 messages over TCP or TLS, framed by
+// octet counting (RFC 6587). Each message carries the session, actor and
+// target as structured data and the whole entry as JSON. The connection is
+// opened on first use and again after an error.
+type SyslogSink struct {
+	cfg       SyslogConfig
+	tlsConfig *tls.Config
+
+	mu   sync.Mutex
+	conn net.Conn
+}
+
+// NewSyslogSink validates cfg and creates a SyslogSink.
+func NewSyslogSink(cfg SyslogConfig) (*SyslogSink, error) {
+	if _, _, err := net.SplitHostPort(cfg.Address); err != nil {
+		return nil, types.Wrapf(err, types.ErrCodeInvalidInput, "invalid syslog address %q", cfg.Address)
+	}
+	if cfg.Facility == 0 {
+		cfg.Facility = DefaultSyslogFacility
+	}
+	if cfg.Facility < 0 || cfg.Facility > 23 {
+		return nil, types.Newf(types.ErrCodeInvalidInput, "syslog facility %d is not between 0 and 23", cfg.Facility)
+	}
+	if cfg.AppName == "" {
+		cfg.AppName = DefaultSyslogAppName
+	}
+	if cfg.Hostname == "" {
+		cfg.Hostname, _ = os.Hostname()
+	}
+
+	s := &SyslogSink{cfg: cfg}
+	if cfg.TLS {
+		host, _, _ := net.SplitHostPort(cfg.Address)
+		s.tlsConfig = &tls.Config{ServerName: host, MinVersion: tls.VersionTLS12}
+		if cfg.CACert != "" {
+			pem, err := os.ReadFile(cfg.CACert)
+			if err != nil {
+				return nil, types.Wrapf(err, types.ErrCodeInternal, "failed to read syslog CA certificate %s", cfg.CACert)
+			}
+			pool := x509.NewCertPool()
+			if !pool.AppendCertsFromPEM(pem) {
+				return nil, types.Newf(types.ErrCodeInvalidInput, "no certificates found in %s", cfg.CACert)
+			}
+			s.tlsConfig.RootCAs = pool
+		}
+	}
+	return s, nil
+}
+
+// Name identifies the sink.
+func (s *SyslogSink) Name() string {
+	return "syslog " + s.cfg.Address
+}
+
+// Send writes one message per entry.
+func (s *SyslogSink) Send(ctx context.Context, entries []security.AuditEntry) error {
+	var buf strings.Builder
+	for _, entry := range entries {
+		msg, err := s.format(entry)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(&buf, "%d %s", len(msg), msg)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.conn == nil {
+		conn, err := s.dial(ctx)
+		if err != nil {
+			return types.Wrapf(err, types.ErrCodeExecutionFailed, "cannot connect to syslog server %s", s.cfg.Address)
+		}
+		s.conn = conn
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		s.conn.SetWriteDeadline(deadline)
+	}
+	if _, err := s.conn.Write([]byte(buf.String())); err != nil {
+		s.conn.Close()
+		s.conn = nil
+		return types.Wrapf(err, types.ErrCodeExecutionFailed, "failed to write to syslog server %s", s.cfg.Address)
+	}
+	return nil
+}
+
+// Close closes the connection.
+func (s *SyslogSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.conn == nil {
+		return nil
+	}
+	err := s.conn.Close()
+	s.conn = nil
+	return err
+}
+
+func (s *SyslogSink) dial(ctx context.Context) (net.Conn, error) {
+	dialer := &net.Dialer{Timeout: 10 * time.Second}
+	if s.tlsConfig != nil {
+		return (&tls.Dialer{NetDialer: dialer, Config: s.tlsConfig}).DialContext(ctx, "tcp", s.cfg.Address)
+	}
+	return dialer.DialContext(ctx, "tcp", s.cfg.Address)
+}
+
+// format renders entry as an RFC 5424 message.
+func (s *SyslogSink) format(entry security.AuditEntry) (string, error) {
+	body, err := json.Marshal(entry)
+	if err != nil {
+		return "", types.Wrap(err, types.ErrCodeInternal, "failed to marshal audit entry")
+	}
+	return fmt.Sprintf("<%d>1 %s %s %s - %s [%s session=\"%s\" actor=\"%s\" target=\"%s\"] %s",
+		s.cfg.Facility*8+syslogSeverity(entry.Action),
+		entry.Timestamp.UTC().Format(time.RFC3339Nano),
+		syslogHeaderValue(s.cfg.Hostname, 255),
+		syslogHeaderValue(s.cfg.AppName, 48),
+		syslogHeaderValue(string(entry.Action), 32),
+		syslogSDID,
+		syslogParamValue(entry.SessionID),
+		syslogParamValue(entry.Actor),
+		syslogParamValue(entry.Target),
+		body,
+	), nil
+}
+
+// syslogSeverity is warning for denials and notice for everything else.
+func syslogSeverity(action security.AuditAction) int {
+	switch action {
+	case security.AuditPolicyDeny, security.AuditNetworkBlock:
+		return 4
+	default:
+		return 5
+	}
+}
+
+// syslogHeaderValue makes value a valid header field: printable ASCII
+// without spaces, at most max characters, or "-" when empty.
+func syslogHeaderValue(value string, max int) string {
+	clean := strings.Map(func(r rune) rune {
+		if r < 33 || r > 126 {
+			return -1
+		}
+		return r
+	}, value)
+	if len(clean) > max {
+		clean = clean[:max]
+	}
+	if clean == "" {
+		return "-"
+	}
+	return clean
+}
+
+// syslogParamValue escapes a structured data parameter value.
+func syslogParamValue(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, `]`, `\]`).Replace(value)
+}
@@ -5,12 +5,21 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"slices"
+	"syscall"
 
+	"github.com/SecDuckOps/agent/internal/adapters/audit"
 	"github.com/SecDuckOps/agent/internal/adapters/configsync"
+	es_adapter "github.com/SecDuckOps/agent/internal/adapters/elasticsearch"
 	"github.com/SecDuckOps/agent/internal/adapters/events"
 	"github.com/SecDuckOps/agent/internal/adapters/executor"
+	llm_adapter "github.com/SecDuckOps/agent/internal/adapters/llm"
+	"github.com/SecDuckOps/agent/internal/adapters/secrets"
 	"github.com/SecDuckOps/agent/internal/adapters/security"
+	"github.com/SecDuckOps/agent/internal/adapters/sessionstore"
+	pg_sessionstore "github.com/SecDuckOps/agent/internal/adapters/sessionstore/postgres"
 	agent_app "github.com/SecDuckOps/agent/internal/application"
 	sa "github.com/SecDuckOps/agent/internal/adapters/subagent"
 	"github.com/SecDuckOps/agent/internal/adapters/translator"
@@ -32,7 +41,6 @@ import (
 	"github.com/SecDuckOps/agent/internal/tools/implementations/terminal"
 	"github.com/SecDuckOps/agent/internal/tools/implementations/todo"
 	domain_skills "github.com/SecDuckOps/agent/internal/skills"
-	"github.com/google/uuid"
 
 	"time"
 
@@ -83,22 +91,39 @@ func FromTOML(parentCtx context.Context, tomlCfg *config.DuckOpsConfig) *App {
 	dir, _ := config.DuckOpsDir()
 	logPath := filepath.Join(dir, "duckops.log")
 
-	appLogger, err := logger.New("duckops-agent", "info", logPath)
+	baseLogger, err := logger.New("duckops-agent", "info", logPath)
 	if err != nil {
 		log.Fatalf("Failed to initialize logger: %v", err)
 	}
 
+	profile, ok := tomlCfg.GetProfile("default")
+	if !ok {
+		baseLogger.ErrorErr(ctx, fmt.Errorf("agent_config_failed"), "No 'default' profile found in config.toml")
+		log.Fatal("No 'default' profile found in config.toml")
+	}
+
+	// Initialize Secret Scanner (scrubs prompts before they reach any LLM)
+	// and the redactors built on it, one per enabled sink
+	var secretScanner ports.SecretScannerPort
+	var redactors map[string]ports.RedactorPort
+	if profile.Secrets != nil && (profile.Secrets.Enabled || (profile.Secrets.Redaction != nil && profile.Secrets.Redaction.Enabled)) {
+		scanner := newSecretScanner(ctx, profile.Secrets, baseLogger)
+		if profile.Secrets.Enabled {
+			secretScanner = scanner
+		}
+		redactors = newRedactors(ctx, profile.Secrets.Redaction, scanner, baseLogger)
+	}
+
+	var appLogger shared_ports.Logger = baseLogger
+	if r := redactors[secrets.SinkAppLog]; r != nil {
+		appLogger = secrets.NewRedactingLogger(baseLogger, r)
+	}
+
 	// Initialize the EventBus
 	eventBus := events.NewInMemoryEventBus(appLogger)
 
 	// Initialize App Session Manager (Phase 1 Enhancements)
 	appSessionManager := agent_app.NewSessionManagerService(appLogger, eventBus)
-	
-	profile, ok := tomlCfg.GetProfile("default")
-	if !ok {
-		appLogger.ErrorErr(ctx, fmt.Errorf("agent_config_failed"), "No 'default' profile found in config.toml")
-		log.Fatal("No 'default' profile found in config.toml")
-	}
 
 	// Create initial Agent workspace session
 	cwd, _ := os.Getwd()
@@ -110,6 +135,94 @@ func FromTOML(parentCtx context.Context, tomlCfg *config.DuckOpsConfig) *App {
 	// Capability Registry holds injected subagent profiles
 	capabilityRegistry := sa.NewCapabilityRegistry()
 
+	// Initialize Warden (Cedar policy evaluator)
+	useDefaultDeny := true
+	if profile.Warden != nil {
+		useDefaultDeny = profile.Warden.DefaultDeny
+	}
+	wardenInstance := warden_adapter.New(useDefaultDeny, appLogger)
+
+	// Cache decisions; scanners issue many near-identical requests
+	cacheSize, cacheTTL := 0, time.Duration(0)
+	if profile.Warden != nil {
+		cacheSize = profile.Warden.CacheSize
+		cacheTTL = time.Duration(profile.Warden.CacheTTLSeconds) * time.Second
+	}
+	if cacheSize >= 0 {
+		wardenInstance.SetCache(warden_adapter.NewPolicyCache(cacheSize, cacheTTL))
+	}
+
+	// Optional TLS interception so HTTPS policies can match full URLs
+	if profile.Warden != nil && profile.Warden.Intercept {
+		caDir := profile.Warden.InterceptCADir
+		if caDir == "" {
+			caDir = filepath.Join(dir, "warden")
+		}
+		ca, err := warden_adapter.LoadOrCreateCA(caDir)
+		if err == nil {
+			err = wardenInstance.EnableInterception(warden_adapter.InterceptConfig{CA: ca, Bypass: profile.Warden.InterceptBypass})
+		}
+		if err != nil {
+			appLogger.ErrorErr(ctx, err, "Failed to enable Warden TLS interception; HTTPS will be tunnelled")
+		} else {
+			appLogger.Info(ctx, "Warden TLS interception enabled; clients must trust the CA",
+				shared_ports.Field{Key: "ca_cert", Value: ca.CertPath()},
+			)
+		}
+	}
+
+	// Load policies
+	var policies []domain_security.NetworkPolicy
+	if profile.Warden != nil && len(profile.Warden.PolicyFiles)+len(profile.Warden.PolicyDirs) > 0 {
+		policies, err = warden_adapter.ReadPolicyFiles(warden_adapter.ExpandPolicyFiles(profile.Warden.PolicyFiles, profile.Warden.PolicyDirs))
+		if err != nil {
+			appLogger.ErrorErr(ctx, err, "Failed to read policy files")
+		}
+		for _, p := range policies {
+			for _, issue := range warden_adapter.ValidatePolicy(p.CedarBody) {
+				issue.File = p.Name
+				appLogger.Info(ctx, "Policy syntax issue (run `duckops policy validate`)", shared_ports.Field{Key: "issue", Value: issue.String()})
+			}
+		}
+	}
+
+	// A file that fails to parse is left out and default deny is forced on
+	// until it is fixed, so a typo cannot fail open
+	if err := wardenInstance.LoadValidPolicies(ctx, policies); err != nil {
+		appLogger.ErrorErr(ctx, err, "Invalid Warden policies skipped; default deny is forced on until they parse")
+	}
+
+	// Session audit log (optional)
+	var auditLog ports.AuditLogPort
+	if profile.Audit != nil && profile.Audit.Enabled {
+		al, err := audit.New(profile.Audit.LogDir, profile.Audit.BackupDir)
+		if err != nil {
+			appLogger.ErrorErr(ctx, err, "Failed to initialize audit log")
+		} else {
+			al.SetRedactor(redactors[secrets.SinkAudit])
+			configureAuditChain(ctx, al, profile.Audit, appLogger)
+			startAuditRetention(ctx, al, profile.Audit, appLogger)
+			startAuditSinks(ctx, al, profile.Audit, appLogger)
+			auditLog = al
+		}
+	}
+	wardenInstance.SetAuditLog(auditLog)
+
+	// Reload policies when the files change, without restarting the agent
+	var policyFiles, policyDirs []string
+	if profile.Warden != nil {
+		policyFiles, policyDirs = profile.Warden.PolicyFiles, profile.Warden.PolicyDirs
+	}
+	policyWatcher := warden_adapter.NewPolicyWatcher(wardenInstance, policyFiles, policyDirs, eventBus, auditLog, appLogger)
+	if len(policyFiles)+len(policyDirs) > 0 {
+		go policyWatcher.Run(ctx)
+	}
+
+	// Warden lifecycle: mTLS, egress proxy and certificate rotation
+	if profile.Warden != nil && profile.Warden.Enabled {
+		startWarden(ctx, wardenInstance, profile.Warden, appLogger)
+	}
+
 	// ---------------------------------------------------------
 	// Super Duck LOGIC
 	// ---------------------------------------------------------
@@ -123,7 +236,7 @@ func FromTOML(parentCtx context.Context, tomlCfg *config.DuckOpsConfig) *App {
 		} else {
 			appLogger.Info(ctx, "Successfully fetched remote config", shared_ports.Field{Key: "rules_count", Value: len(remoteCfg.Rules)})
 			capabilityRegistry.Sync(remoteCfg.Capabilities)
-			// TODO: Merge remoteCfg into local profile
+			applyRemoteRules(ctx, policyWatcher, remoteCfg.Rules, appLogger)
 		}
 
 		go func() {
@@ -139,8 +252,8 @@ func FromTOML(parentCtx context.Context, tomlCfg *config.DuckOpsConfig) *App {
 						appLogger.ErrorErr(ctx, err, "Periodic config sync failed")
 					} else {
 						capabilityRegistry.Sync(cfg.Capabilities)
-						// silent success, no need to spam logs unless changed
-						// TODO: Apply updates to running kernel dynamically
+						// silent success; the policy watcher logs only when rules changed
+						applyRemoteRules(ctx, policyWatcher, cfg.Rules, appLogger)
 					}
 				}
 			}
@@ -149,35 +262,8 @@ func FromTOML(parentCtx context.Context, tomlCfg *config.DuckOpsConfig) *App {
 	// ---------------------------------------------------------
 
 	llmRegistry := buildLLMRegistry(profile, appLogger)
-
-	// Initialize Warden (Cedar policy evaluator)
-	useDefaultDeny := true
-	if profile.Warden != nil {
-		useDefaultDeny = profile.Warden.DefaultDeny
-	}
-	wardenInstance := warden_adapter.New(useDefaultDeny, appLogger)
-
-	// Load policies
-	var policies []domain_security.NetworkPolicy
-	if profile.Warden != nil && len(profile.Warden.PolicyFiles) > 0 {
-		for _, path := range profile.Warden.PolicyFiles {
-			content, err := os.ReadFile(path)
-			if err != nil {
-				appLogger.ErrorErr(ctx, err, "Failed to read policy file", shared_ports.Field{Key: "path", Value: path})
-				continue
-			}
-			policies = append(policies, domain_security.NetworkPolicy{
-				ID:        uuid.New().String(),
-				Name:      filepath.Base(path),
-				CedarBody: string(content),
-				Enabled:   true,
-				Priority:  10,
-			})
-		}
-	}
-
-	if err := wardenInstance.LoadPolicies(ctx, policies); err != nil {
-		appLogger.ErrorErr(ctx, err, "Failed to load Warden policies")
+	if auditLog != nil {
+		llmRegistry = audit.NewLLMRegistry(llmRegistry, auditLog)
 	}
 
 	// Setup OS adapters for the kernel and dispatcher
@@ -191,9 +277,11 @@ func FromTOML(parentCtx context.Context, tomlCfg *config.DuckOpsConfig) *App {
 		ToolRegistry:   toolRegistry,
 		LLM:            llmRegistry,
 		Logger:         appLogger,
+		AuditLog:       auditLog,
 		Warden:         wardenInstance,
 		ShellExecution: osExecutor,
 		ShellLifecycle: osExecutor,
+		Secrets:        newSecretResolver(ctx, profile.Secrets, appLogger),
 	}
 	k := kernel.New(deps)
 	if k == nil {
@@ -203,16 +291,23 @@ func FromTOML(parentCtx context.Context, tomlCfg *config.DuckOpsConfig) *App {
 
 	// Tracker (implements ports.SessionManager)
 	bridge := &sa.KernelBridge{
-		ExecuteFn:    k.ExecuteCompat,
-		GetSchemasFn: k.GetToolSchemas,
-		LLMRegistry:  llmRegistry,
+		ExecuteFn:      k.ExecuteCompat,
+		ExecuteBatchFn: k.ExecuteBatchCompat,
+		GetSchemasFn:   k.GetToolSchemas,
+		LLMRegistry:    llmRegistry,
 	}
 
-	// Initialize Secret Scanner
-	var secretScanner ports.SecretScannerPort
-
-
 	tracker := sa.NewTracker(bridge, bridge, secretScanner, appLogger)
+	tracker.SetRedactor(redactors[secrets.SinkEvents])
+	tracker.SetAuditLog(auditLog)
+	sessionStore := newSessionStore(ctx, profile.Sessions, appLogger)
+	if sessionStore != nil {
+		tracker.SetStore(sessionStore)
+		if err := tracker.Restore(ctx); err != nil {
+			appLogger.ErrorErr(ctx, err, "Failed to restore subagent sessions")
+		}
+		startSessionRetention(ctx, tracker, profile.Sessions, appLogger)
+	}
 
 	// Initialize Docker Warden (Scanner Port)
 	var dockerWarden *warden_adapter.DockerWarden
@@ -267,7 +362,7 @@ func FromTOML(parentCtx context.Context, tomlCfg *config.DuckOpsConfig) *App {
 	}
 
 	// Register tools
-	skillRegistry := registerTools(ctx, toolRegistry, deps, tracker, bridge, capabilityRegistry, profile, appLogger, scannerSvc)
+	skillRegistry := registerTools(ctx, toolRegistry, deps, tracker, bridge, capabilityRegistry, profile, appLogger, scannerSvc, secretScanner, redactors)
 
 	provider := profile.Provider
 	if provider == "" {
@@ -287,7 +382,433 @@ func FromTOML(parentCtx context.Context, tomlCfg *config.DuckOpsConfig) *App {
 		Logger:        appLogger,
 		EventBus:      eventBus,
 		SkillRegistry: skillRegistry,
-		Shutdown:      cancel,
+		Shutdown: func() {
+			cancel()
+			stopCtx, stopCancel := context.WithTimeout(context.Background(), 5*time.Second)
+			if err := wardenInstance.StopProxy(stopCtx); err != nil {
+				appLogger.ErrorErr(stopCtx, err, "Warden proxy did not stop cleanly")
+			}
+			stopCancel()
+			if auditLog != nil {
+				auditLog.Close()
+			}
+			if sessionStore != nil {
+				sessionStore.Close()
+			}
+		},
+	}
+}
+
+// startWarden applies mTLS and starts the egress proxy. Certificate or listener
+// errors are fatal: running without the configured enforcement would be worse
+// than not starting. With mTLS, the certificates are re-read when their files
+// change or on SIGHUP.
+func startWarden(ctx context.Context, w *warden_adapter.Warden, cfg *config.WardenConfig, appLogger shared_ports.Logger) {
+	if cfg.CACert != "" || cfg.ClientCert != "" || cfg.ClientKey != "" {
+		err := w.ConfigureMTLS(ctx, domain_security.MTLSConfig{
+			CACert:     cfg.CACert,
+			ClientCert: cfg.ClientCert,
+			ClientKey:  cfg.ClientKey,
+		})
+		if err != nil {
+			appLogger.ErrorErr(ctx, err, "Failed to configure Warden mTLS")
+			log.Fatalf("Warden mTLS: %v", err)
+		}
+
+		certWatcher := warden_adapter.NewCertWatcher(w, appLogger)
+		go certWatcher.Run(ctx)
+
+		hup := make(chan os.Signal, 1)
+		signal.Notify(hup, syscall.SIGHUP)
+		go func() {
+			defer signal.Stop(hup)
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-hup:
+					certWatcher.Rotate(ctx, "sighup")
+				}
+			}
+		}()
+	}
+
+	if cfg.ProxyAddr != "" {
+		if err := w.StartProxy(ctx, cfg.ProxyAddr); err != nil {
+			appLogger.ErrorErr(ctx, err, "Failed to start Warden proxy")
+			log.Fatalf("Warden proxy on %s: %v", cfg.ProxyAddr, err)
+		}
+	}
+}
+
+// newSecretScanner builds the secret scanner. If the custom patterns cannot be
+// loaded, scrubbing continues with the built-in patterns rather than not at all.
+func newSecretScanner(ctx context.Context, cfg *config.SecretsConfig, appLogger shared_ports.Logger) *secrets.Scanner {
+	var custom []domain_security.SecretPattern
+	if cfg.CustomPatterns != "" {
+		patterns, err := secrets.LoadCustomPatterns(cfg.CustomPatterns)
+		if err != nil {
+			appLogger.ErrorErr(ctx, err, "Failed to load custom secret patterns, using built-in patterns only")
+		}
+		custom = patterns
+	}
+
+	scanner, err := secrets.New(custom)
+	if err != nil {
+		appLogger.ErrorErr(ctx, err, "Invalid custom secret pattern, using built-in patterns only")
+		scanner, _ = secrets.New(nil)
+	}
+
+	detection := secrets.Detection{Mode: cfg.Detection, Threshold: cfg.Threshold}
+	if cfg.Allowlist != "" {
+		allow, err := secrets.LoadAllowlist(cfg.Allowlist)
+		if err != nil {
+			appLogger.ErrorErr(ctx, err, "Failed to load secret allowlist, continuing without it")
+		}
+		detection.Allowlist = allow
+	}
+	if err := scanner.SetDetection(detection); err != nil {
+		appLogger.ErrorErr(ctx, err, "Invalid secret detection settings, using regex detection")
+		scanner.SetDetection(secrets.Detection{Allowlist: detection.Allowlist})
+	}
+	return scanner
+}
+
+// configureAuditChain applies the checkpoint policy and, when signing is
+// configured, the signing key. A key that cannot be loaded leaves entries
+// hash-chained but unsigned.
+func configureAuditChain(ctx context.Context, al *audit.Logger, cfg *config.AuditConfig, appLogger shared_ports.Logger) {
+	al.SetCheckpointPolicy(cfg.CheckpointEvery, time.Duration(cfg.CheckpointIntervalSeconds)*time.Second)
+	if cfg.Signing == "" {
+		return
+	}
+
+	keyFile, err := cfg.KeyPath()
+	if err == nil {
+		var signer audit.Signer
+		signer, err = audit.LoadSigner(cfg.Signing, keyFile, true)
+		if err == nil {
+			al.SetSigner(signer)
+			appLogger.Info(ctx, "Audit log signing enabled",
+				shared_ports.Field{Key: "algorithm", Value: cfg.Signing},
+				shared_ports.Field{Key: "key_file", Value: keyFile},
+			)
+			return
+		}
+	}
+	appLogger.ErrorErr(ctx, err, "Failed to load audit signing key, entries will be hash-chained but unsigned")
+}
+
+// auditRetentionInterval is how often idle audit logs are rotated and
+// expired data deleted.
+const auditRetentionInterval = time.Hour
+
+// startAuditRetention applies the rotation and retention policy and keeps
+// applying it in the background until ctx is done.
+func startAuditRetention(ctx context.Context, al *audit.Logger, cfg *config.AuditConfig, appLogger shared_ports.Logger) {
+	al.SetRotationPolicy(
+		int64(cfg.MaxFileSizeMB)<<20,
+		time.Duration(cfg.RotateAfterHours)*time.Hour,
+		time.Duration(cfg.RetentionDays)*24*time.Hour,
+	)
+	go func() {
+		ticker := time.NewTicker(auditRetentionInterval)
+		defer ticker.Stop()
+		for {
+			if err := al.ApplyRetention(time.Now()); err != nil {
+				appLogger.ErrorErr(ctx, err, "Failed to apply audit log retention")
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// startAuditSinks forwards entries to the remote sinks in cfg and starts the
+// periodic SSH backup. A sink that cannot be set up is logged and skipped.
+func startAuditSinks(ctx context.Context, al *audit.Logger, cfg *config.AuditConfig, appLogger shared_ports.Logger) {
+	var sinks []ports.AuditSinkPort
+	if c := cfg.Syslog; c != nil {
+		sink, err := audit.NewSyslogSink(audit.SyslogConfig{
+			Address:  c.Address,
+			TLS:      c.TLS,
+			CACert:   c.CACert,
+			Facility: c.Facility,
+			AppName:  c.AppName,
+		})
+		if err != nil {
+			appLogger.ErrorErr(ctx, err, "Failed to configure syslog audit sink")
+		} else {
+			sinks = append(sinks, sink)
+		}
+	}
+	if c := cfg.HTTP; c != nil {
+		headers := make(map[string]string, len(c.Headers)+1)
+		for k, v := range c.Headers {
+			headers[k] = v
+		}
+		if c.TokenEnv != "" {
+			if token := os.Getenv(c.TokenEnv); token != "" {
+				headers["Authorization"] = "Bearer " + token
+			} else {
+				appLogger.Info(ctx, "Audit sink token variable is not set", shared_ports.Field{Key: "token_env", Value: c.TokenEnv})
+			}
+		}
+		sink, err := audit.NewHTTPSink(audit.HTTPSinkConfig{URL: c.URL, Headers: headers, CACert: c.CACert})
+		if err != nil {
+			appLogger.ErrorErr(ctx, err, "Failed to configure HTTP audit sink")
+		} else {
+			sinks = append(sinks, sink)
+		}
+	}
+	if c := cfg.Elasticsearch; c != nil {
+		var password string
+		if c.PasswordEnv != "" {
+			password = os.Getenv(c.PasswordEnv)
+		}
+		sink, err := es_adapter.NewAuditSink(es_adapter.Config{
+			Addresses: c.Addresses,
+			Username:  c.Username,
+			Password:  password,
+			Index:     c.Index,
+		})
+		if err != nil {
+			appLogger.ErrorErr(ctx, err, "Failed to configure Elasticsearch audit sink")
+		} else {
+			sinks = append(sinks, sink)
+		}
+	}
+	if len(sinks) > 0 {
+		al.SetForwarder(audit.NewForwarder(cfg.SinkBufferSize, appLogger, sinks...))
+	}
+
+	if cfg.SSHBackupHost == "" {
+		return
+	}
+	uploader, err := audit.NewSSHUploader(al, audit.SSHUploadConfig{
+		Host:       cfg.SSHBackupHost,
+		RemoteDir:  cfg.SSHBackupPath,
+		KeyFile:    cfg.SSHKeyFile,
+		KnownHosts: cfg.SSHKnownHosts,
+	})
+	if err != nil {
+		appLogger.ErrorErr(ctx, err, "Failed to configure SSH audit backup")
+		return
+	}
+	interval := time.Duration(cfg.SSHBackupIntervalMinutes) * time.Minute
+	if interval <= 0 {
+		interval = defaultAuditUploadInterval
+	}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			if n, err := uploader.Upload(ctx); err != nil {
+				appLogger.ErrorErr(ctx, err, "Failed to upload audit logs to the backup host")
+			} else if n > 0 {
+				appLogger.Debug(ctx, "Uploaded audit logs to the backup host", shared_ports.Field{Key: "segments", Value: n})
+			}
+		}
+	}()
+}
+
+// sessionRetentionInterval is how often finished subagent sessions are
+// compacted and expired.
+const sessionRetentionInterval = time.Hour
+
+// defaultSessionCompactAfter is how long after a subagent session finishes
+// its stored conversation and events are compacted.
+const defaultSessionCompactAfter = 24 * time.Hour
+
+// startSessionRetention applies the retention policy for stored subagent
+// sessions now and every sessionRetentionInterval.
+func startSessionRetention(ctx context.Context, tracker *sa.Tracker, cfg *config.SessionsConfig, appLogger shared_ports.Logger) {
+	compactAfter := defaultSessionCompactAfter
+	var maxAge time.Duration
+	if cfg != nil {
+		if cfg.CompactAfterHours > 0 {
+			compactAfter = time.Duration(cfg.CompactAfterHours) * time.Hour
+		}
+		maxAge = time.Duration(cfg.RetentionDays) * 24 * time.Hour
+	}
+	tracker.SetRetentionPolicy(compactAfter, maxAge)
+
+	go func() {
+		ticker := time.NewTicker(sessionRetentionInterval)
+		defer ticker.Stop()
+		for {
+			if err := tracker.ApplyRetention(ctx, time.Now()); err != nil {
+				appLogger.ErrorErr(ctx, err, "Failed to apply subagent session retention")
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// newSessionStore opens the store subagent sessions are saved in, or returns
+// nil when they are kept in memory only. A store that cannot be opened is
+// logged and sessions are not saved.
+func newSessionStore(ctx context.Context, cfg *config.SessionsConfig, appLogger shared_ports.Logger) ports.SessionStore {
+	store := "file"
+	if cfg != nil && cfg.Store != "" {
+		store = cfg.Store
+	}
+
+	switch store {
+	case "memory":
+		return nil
+	case "postgres":
+		if cfg.Postgres == nil {
+			appLogger.ErrorErr(ctx, fmt.Errorf("missing [sessions.postgres]"), "Session store 'postgres' is not configured; sessions will not be saved")
+			return nil
+		}
+		c := cfg.Postgres
+		port := c.Port
+		if port == 0 {
+			port = 5432
+		}
+		var password string
+		if c.PasswordEnv != "" {
+			password = os.Getenv(c.PasswordEnv)
+		}
+		adapter, err := pg_sessionstore.NewAdapter(pg_sessionstore.Config{
+			Host:     c.Host,
+			Port:     port,
+			User:     c.User,
+			Password: password,
+			DBName:   c.DBName,
+			SSLMode:  c.SSLMode,
+		})
+		if err != nil {
+			appLogger.ErrorErr(ctx, err, "Failed to connect the session store; sessions will not be saved")
+			return nil
+		}
+		if err := adapter.Migrate(ctx); err != nil {
+			adapter.Close()
+			appLogger.ErrorErr(ctx, err, "Failed to migrate the session store; sessions will not be saved")
+			return nil
+		}
+		return adapter
+	case "file":
+		dir, err := cfg.DirPath()
+		if err == nil {
+			var fs *sessionstore.FileStore
+			if fs, err = sessionstore.NewFileStore(dir); err == nil {
+				return fs
+			}
+		}
+		appLogger.ErrorErr(ctx, err, "Failed to open the session store; sessions will not be saved")
+		return nil
+	default:
+		appLogger.ErrorErr(ctx, fmt.Errorf("unknown session store %q", store), "Sessions will not be saved")
+		return nil
+	}
+}
+
+// defaultAuditUploadInterval is how often closed audit log segments are
+// copied to the SSH backup host when no interval is configured.
+const defaultAuditUploadInterval = 15 * time.Minute
+
+// newRedactors returns a redactor for each sink redaction is enabled for,
+// keyed by sink name. Unknown sink names are logged and ignored.
+func newRedactors(ctx context.Context, cfg *config.RedactionConfig, scanner *secrets.Scanner, appLogger shared_ports.Logger) map[string]ports.RedactorPort {
+	if cfg == nil || !cfg.Enabled {
+		return nil
+	}
+
+	var key []byte
+	if cfg.KeyEnv != "" {
+		key = []byte(os.Getenv(cfg.KeyEnv))
+		if len(key) == 0 {
+			appLogger.Info(ctx, "Redaction key variable is not set, markers are unkeyed", shared_ports.Field{Key: "key_env", Value: cfg.KeyEnv})
+		}
+	}
+	redactor := secrets.NewRedactor(scanner, key)
+
+	sinks := cfg.Sinks
+	if len(sinks) == 0 {
+		sinks = secrets.Sinks
+	}
+	redactors := make(map[string]ports.RedactorPort, len(sinks))
+	for _, sink := range sinks {
+		if !slices.Contains(secrets.Sinks, sink) {
+			appLogger.Info(ctx, "Ignoring unknown redaction sink", shared_ports.Field{Key: "sink", Value: sink})
+			continue
+		}
+		redactors[sink] = redactor
+	}
+	appLogger.Info(ctx, "Secret redaction enabled", shared_ports.Field{Key: "sinks", Value: sinks})
+	return redactors
+}
+
+// newSecretResolver builds the resolver for secret:// references in tool
+// arguments. env and file references always work; cmd and vault references
+// need to be configured. A misconfigured backend is left out and logged.
+func newSecretResolver(ctx context.Context, cfg *config.SecretsConfig, appLogger shared_ports.Logger) *secrets.Resolver {
+	if cfg == nil {
+		cfg = &config.SecretsConfig{}
+	}
+
+	resolver := secrets.NewResolver()
+	resolver.Register("env", secrets.EnvProvider{})
+
+	roots := cfg.FileRoots
+	if len(roots) == 0 {
+		roots = []string{"~/.duckops/secrets"}
+	}
+	resolver.Register("file", secrets.NewFileProvider(roots))
+
+	if len(cfg.AllowedCommands) > 0 {
+		resolver.Register("cmd", secrets.NewCommandProvider(cfg.AllowedCommands, 0))
+	}
+
+	if v := cfg.Vault; v != nil && v.Address != "" {
+		tokenEnv := v.TokenEnv
+		if tokenEnv == "" {
+			tokenEnv = "VAULT_TOKEN"
+		}
+		vault, err := secrets.NewVaultProvider(secrets.VaultConfig{
+			Address:   v.Address,
+			Mount:     v.Mount,
+			Token:     os.Getenv(tokenEnv),
+			Namespace: v.Namespace,
+			CACert:    v.CACert,
+		})
+		if err != nil {
+			appLogger.ErrorErr(ctx, err, "Vault secret backend disabled")
+		} else {
+			resolver.Register("vault", vault)
+		}
+	}
+
+	appLogger.Info(ctx, "Secret reference backends configured", shared_ports.Field{Key: "backends", Value: resolver.Backends()})
+	return resolver
+}
+
+// applyRemoteRules loads the rules pushed by the API Gateway into the running
+// Warden, merged with the local policy files. Invalid rule sets are rejected as
+// a whole and the previously applied rules stay active; broken local files are
+// skipped and reported by the watcher.
+func applyRemoteRules(ctx context.Context, watcher *warden_adapter.PolicyWatcher, rules []ports.Rule, appLogger shared_ports.Logger) {
+	policies, err := warden_adapter.RemoteRulePolicies(rules)
+	if err != nil {
+		appLogger.ErrorErr(ctx, err, "Rejected remote policy rules, keeping previous rules")
+		return
+	}
+	if _, err := watcher.SetRemotePolicies(ctx, policies); err != nil {
+		appLogger.ErrorErr(ctx, err, "Failed to apply remote policy rules")
 	}
 }
 
@@ -325,29 +846,44 @@ func buildLLMRegistry(profile config.Profile, appLogger shared_ports.Logger) llm
 		}
 	}
 
-	return llmRegistry
+	// Native tool calling for the agent loop, on providers whose API has it
+	providers := make(map[string]llm_adapter.ProviderConfig, len(profile.Providers))
+	for name, prov := range profile.Providers {
+		providerType := prov.Type
+		if providerType == "" {
+			providerType = name
+		}
+		providers[name] = llm_adapter.ProviderConfig{
+			Type:            providerType,
+			APIKey:          prov.APIKey,
+			Model:           prov.Model,
+			BaseURL:         prov.BaseURL,
+			FunctionCalling: prov.UsesFunctionCalling(),
+		}
+	}
+	return llm_adapter.NewRegistry(llmRegistry, profile.Provider, providers)
 }
 
 // registerTools registers all agent tools with the kernel.
-func registerTools(ctx context.Context, toolRegistry ports.ToolRegistry, deps kernel.Dependencies, tracker *sa.Tracker, bridge *sa.KernelBridge, registry *sa.CapabilityRegistry, profile config.Profile, appLogger shared_ports.Logger, scannerSvc *aggregator.ScannerService) domain_skills.Registry {
+func registerTools(ctx context.Context, toolRegistry ports.ToolRegistry, deps kernel.Dependencies, tracker *sa.Tracker, bridge *sa.KernelBridge, registry *sa.CapabilityRegistry, profile config.Profile, appLogger shared_ports.Logger, scannerSvc *aggregator.ScannerService, secretScanner ports.SecretScannerPort, redactors map[string]ports.RedactorPort) domain_skills.Registry {
 	// Setup Hexagonal Task Engine Middleware Pipeline
 	osTranslator := translator.NewOSTranslatorAdapter("") // default to current OS
 	
 	// Create AI Reviewer for the Thinking phase
-	aiReviewer := security.NewAIReviewer(deps.LLM, profile.Provider, appLogger)
+	aiReviewer := security.NewAIReviewer(deps.LLM, profile.Provider, appLogger, secretScanner)
 	
-	taskWarden := security.NewTaskWardenAdapter(deps.Warden, osTranslator, appLogger)
+	taskWarden := security.NewTaskWardenAdapter(deps.Warden, osTranslator, deps.AuditLog, appLogger)
 
-	taskDispatcher := taskengine.NewDispatcher(osTranslator, taskWarden, deps.ShellExecution, aiReviewer, appLogger)
+	taskDispatcher := taskengine.NewDispatcher(osTranslator, taskWarden, deps.ShellExecution, aiReviewer, redactors[secrets.SinkTaskOutput], deps.AuditLog, appLogger)
 
 	// Security Gates
-	fsGate := filesystem.NewWardenGate(deps.Warden, appLogger)
+	fsGate := filesystem.NewWardenGate(deps.Warden, deps.AuditLog, appLogger)
 
 	tools := []struct {
 		name string
 		err  error
 	}{
-		{"chat", toolRegistry.RegisterTool(ctx, chat.NewChatTool(deps.LLM, bridge, bridge))},
+		{"chat", toolRegistry.RegisterTool(ctx, chat.NewChatTool(deps.LLM, bridge, bridge, secretScanner))},
 		{"scan", toolRegistry.RegisterTool(ctx, scan.NewScanTool(scannerSvc))},
 		{"subagent", toolRegistry.RegisterTool(ctx, subagent.NewSubagentTool(tracker))},
 		{"resume", toolRegistry.RegisterTool(ctx, subagent.NewResumeTool(tracker))},
@@ -355,8 +891,8 @@ func registerTools(ctx context.Context, toolRegistry ports.ToolRegistry, deps ke
 		{"terminal", toolRegistry.RegisterTool(ctx, terminal.NewTerminalTool(taskDispatcher))},
 		{"notes", toolRegistry.RegisterTool(ctx, notes.NewNotesTool())},
 		{"todo", toolRegistry.RegisterTool(ctx, todo.NewTodoTool())},
-		{"file_edit", toolRegistry.RegisterTool(ctx, file_ops.NewFileOpsTool(fsGate))},
-		{"generate_report", toolRegistry.RegisterTool(ctx, reporting.NewReportingTool(deps.LLM))},
+		{"file_edit", toolRegistry.RegisterTool(ctx, file_ops.NewFileOpsTool(fsGate, deps.AuditLog))},
+		{"generate_report", toolRegistry.RegisterTool(ctx, reporting.NewReportingTool(deps.LLM, secretScanner))},
 	}
 	skillRegistry, err := domain_skills.NewEmbeddedRegistry()
 	if err != nil {
@@ -368,10 +904,11 @@ func registerTools(ctx context.Context, toolRegistry ports.ToolRegistry, deps ke
 		}{"load_skill", toolRegistry.RegisterTool(ctx, skills.NewLoadSkillTool(skillRegistry))})
 	}
 
+	// A tool that fails to register, e.g. over a typo in its parameter tags,
+	// is left out instead of stopping the agent
 	for _, t := range tools {
 		if t.err != nil {
-			appLogger.ErrorErr(context.Background(), t.err, "Tool registration failed", shared_ports.Field{Key: "tool", Value: t.name})
-			log.Fatalf("%s tool registration failed: %v", t.name, t.err)
+			appLogger.ErrorErr(context.Background(), t.err, "Tool registration failed; continuing without it", shared_ports.Field{Key: "tool", Value: t.name})
 		}
 	}
 
@@ -0,0 +1,99 @@
+package elasticsearch
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+
+	"github.com/SecDuckOps/agent/internal/domain/security"
+	"github.com/SecDuckOps/shared/types"
+
+	"github.com/elastic/go-elasticsearch/v8"
+	"github.com/elastic/go-elasticsearch/v8/esapi"
+)
+
+// DefaultAuditIndex is the index audit entries go to when none is configured.
+const DefaultAuditIndex = "duckops-audit"
+
+// AuditSink implements ports.AuditSinkPort by bulk-indexing audit entries.
+// Documents are keyed by entry ID, so a batch sent twice is stored once.
+type AuditSink struct {
+	client *elasticsearch.Client
+	index  string
+}
+
+// NewAuditSink creates an AuditSink. Unlike NewAdapter it does not check
+// connectivity, so an unreachable cluster only delays delivery.
+func NewAuditSink(cfg Config) (*AuditSink, error) {
+	index := cfg.Index
+	if index == "" {
+		index = DefaultAuditIndex
+	}
+
+	client, err := elasticsearch.NewClient(elasticsearch.Config{
+		Addresses: cfg.Addresses,
+		Username:  cfg.Username,
+		Password:  cfg.Password,
+	})
+	if err != nil {
+		return nil, types.Wrap(err, types.ErrCodeInternal, "elasticsearch: failed to create client")
+	}
+
+	return &AuditSink{client: client, index: index}, nil
+}
+
+// Name identifies the sink.
+func (s *AuditSink) Name() string {
+	return "elasticsearch " + s.index
+}
+
+// Send indexes entries with one bulk request.
+func (s *AuditSink) Send(ctx context.Context, entries []security.AuditEntry) error {
+	var body bytes.Buffer
+	enc := json.NewEncoder(&body)
+	for _, entry := range entries {
+		action := map[string]interface{}{"index": map[string]string{"_index": s.index, "_id": entry.ID}}
+		if err := enc.Encode(action); err != nil {
+			return types.Wrap(err, types.ErrCodeInternal, "elasticsearch: marshal bulk action")
+		}
+		if err := enc.Encode(entry); err != nil {
+			return types.Wrap(err, types.ErrCodeInternal, "elasticsearch: marshal audit entry")
+		}
+	}
+
+	req := esapi.BulkRequest{Body: &body}
+	res, err := req.Do(ctx, s.client)
+	if err != nil {
+		return types.Wrap(err, types.ErrCodeExecutionFailed, "elasticsearch: bulk index audit entries")
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return types.Newf(types.ErrCodeExecutionFailed, "elasticsearch: bulk error: %s", res.String())
+	}
+
+	// A 200 response may still hold per-document failures
+	var result struct {
+		Errors bool `json:"errors"`
+		Items  []map[string]struct {
+			Status int `json:"status"`
+			Error  struct {
+				Type   string `json:"type"`
+				Reason string `json:"reason"`
+			} `json:"error"`
+		} `json:"items"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&result); err != nil {
+		return types.Wrap(err, types.ErrCodeInternal, "elasticsearch: decode bulk response")
+	}
+	if result.Errors {
+		for _, item := range result.Items {
+			for _, r := range item {
+				if r.Status > 299 {
+					return types.Newf(types.ErrCodeExecutionFailed, "elasticsearch: failed to index audit entry: %s: %s", r.Error.Type, r.Error.Reason)
+				}
+			}
+		}
+	}
+	return nil
+}
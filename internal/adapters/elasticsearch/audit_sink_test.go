@@ -0,0 +1,66 @@
+package elasticsearch_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/SecDuckOps/agent/internal/adapters/elasticsearch"
+	"github.com/SecDuckOps/agent/internal/domain/security"
+)
+
+func TestAuditSink_BulkIndexes(t *testing.T) {
+	var (
+		actions []map[string]map[string]string
+		reject  bool
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Elastic-Product", "Elasticsearch")
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path != "/_bulk" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		// Action and document lines alternate
+		scanner := bufio.NewScanner(r.Body)
+		for i := 0; scanner.Scan(); i++ {
+			if i%2 == 0 {
+				var action map[string]map[string]string
+				json.Unmarshal(scanner.Bytes(), &action)
+				actions = append(actions, action)
+			}
+		}
+		if reject {
+			w.Write([]byte(`{"errors":true,"items":[{"index":{"status":400,"error":{"type":"mapper_parsing_exception","reason":"bad field"}}}]}`))
+			return
+		}
+		w.Write([]byte(`{"errors":false,"items":[{"index":{"status":201}},{"index":{"status":201}}]}`))
+	}))
+	defer srv.Close()
+
+	sink, err := elasticsearch.NewAuditSink(elasticsearch.Config{Addresses: []string{srv.URL}})
+	if err != nil {
+		t.Fatalf("NewAuditSink failed: %v", err)
+	}
+	entries := []security.AuditEntry{
+		{ID: "e1", SessionID: "s1", Action: security.AuditToolExecute},
+		{ID: "e2", SessionID: "s1", Action: security.AuditPolicyDeny},
+	}
+	if err := sink.Send(context.Background(), entries); err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+	if len(actions) != 2 {
+		t.Fatalf("server received %d actions, want 2", len(actions))
+	}
+	if got := actions[1]["index"]; got["_index"] != elasticsearch.DefaultAuditIndex || got["_id"] != "e2" {
+		t.Errorf("second action = %v, want index %s and id e2", got, elasticsearch.DefaultAuditIndex)
+	}
+
+	reject = true
+	if err := sink.Send(context.Background(), entries[:1]); err == nil {
+		t.Error("expected a per-item bulk failure to be reported")
+	}
+}
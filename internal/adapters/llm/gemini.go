@@ -0,0 +1,220 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/SecDuckOps/agent/internal/ports"
+	"github.com/SecDuckOps/shared/llm/domain"
+	"github.com/SecDuckOps/shared/types"
+)
+
+// DefaultGeminiURL is the Gemini API the Gemini adapter calls by default.
+const DefaultGeminiURL = "https://generativelanguage.googleapis.com/v1beta"
+
+// Gemini adds native function calling through the generateContent API to a
+// Gemini provider; Generate still goes to the wrapped provider.
+type Gemini struct {
+	domain.LLM
+	client  *http.Client
+	baseURL string
+	apiKey  string
+	model   string
+}
+
+// NewGemini wraps inner. An empty baseURL uses DefaultGeminiURL and an empty
+// model inner's.
+func NewGemini(inner domain.LLM, baseURL, apiKey, model string) *Gemini {
+	if baseURL == "" {
+		baseURL = DefaultGeminiURL
+	}
+	if model == "" {
+		model = inner.Model()
+	}
+	return &Gemini{
+		LLM:     inner,
+		client:  &http.Client{Timeout: requestTimeout},
+		baseURL: strings.TrimRight(baseURL, "/"),
+		apiKey:  apiKey,
+		model:   strings.TrimPrefix(model, "models/"),
+	}
+}
+
+type geminiContent struct {
+	Role  string       `json:"role,omitempty"`
+	Parts []geminiPart `json:"parts"`
+}
+
+type geminiPart struct {
+	Text             string                  `json:"text,omitempty"`
+	FunctionCall     *geminiFunctionCall     `json:"functionCall,omitempty"`
+	FunctionResponse *geminiFunctionResponse `json:"functionResponse,omitempty"`
+}
+
+type geminiFunctionCall struct {
+	Name string                 `json:"name"`
+	Args map[string]interface{} `json:"args"`
+}
+
+type geminiFunctionResponse struct {
+	Name     string                 `json:"name"`
+	Response map[string]interface{} `json:"response"`
+}
+
+// GenerateWithTools sends messages with tools as function declarations and
+// returns the model's text and function calls. Gemini does not identify
+// calls, so they get IDs from their position.
+func (g *Gemini) GenerateWithTools(ctx context.Context, messages []ports.ChatMessage, tools []domain.ToolDefinition) (ports.ToolGeneration, error) {
+	var request struct {
+		SystemInstruction *geminiContent  `json:"systemInstruction,omitempty"`
+		Contents          []geminiContent `json:"contents"`
+		Tools             []struct {
+			FunctionDeclarations []domain.ToolDefinition `json:"functionDeclarations"`
+		} `json:"tools,omitempty"`
+	}
+
+	var system []string
+	for i, m := range messages {
+		var content geminiContent
+		switch m.Role {
+		case domain.RoleSystem:
+			// Only the leading system messages are instructions; later ones,
+			// such as history summaries, stay in place
+			if len(request.Contents) == 0 && i == len(system) {
+				system = append(system, m.Content)
+				continue
+			}
+			content = geminiContent{Role: "user", Parts: []geminiPart{{Text: m.Content}}}
+		case domain.RoleAssistant:
+			content.Role = "model"
+			if m.Content != "" {
+				content.Parts = append(content.Parts, geminiPart{Text: m.Content})
+			}
+			for _, tc := range m.ToolCalls {
+				content.Parts = append(content.Parts, geminiPart{FunctionCall: &geminiFunctionCall{Name: tc.Name, Args: tc.Args}})
+			}
+		case domain.RoleTool:
+			content = geminiContent{Role: "user", Parts: []geminiPart{{FunctionResponse: &geminiFunctionResponse{
+				Name:     m.ToolName,
+				Response: map[string]interface{}{"content": m.Content},
+			}}}}
+		default:
+			content = geminiContent{Role: "user", Parts: []geminiPart{{Text: m.Content}}}
+		}
+		if len(content.Parts) == 0 {
+			continue
+		}
+		// Consecutive messages of a role are one turn, so the responses to
+		// several calls travel together
+		if n := len(request.Contents); n > 0 && request.Contents[n-1].Role == content.Role {
+			request.Contents[n-1].Parts = append(request.Contents[n-1].Parts, content.Parts...)
+			continue
+		}
+		request.Contents = append(request.Contents, content)
+	}
+	if len(system) > 0 {
+		request.SystemInstruction = &geminiContent{Parts: []geminiPart{{Text: strings.Join(system, "\n\n")}}}
+	}
+	if len(tools) > 0 {
+		declarations := make([]domain.ToolDefinition, len(tools))
+		for i, t := range tools {
+			t.Parameters = geminiSchema(t.Parameters)
+			declarations[i] = t
+		}
+		request.Tools = append(request.Tools, struct {
+			FunctionDeclarations []domain.ToolDefinition `json:"functionDeclarations"`
+		}{declarations})
+	}
+
+	var response struct {
+		Candidates []struct {
+			Content      geminiContent `json:"content"`
+			FinishReason string        `json:"finishReason"`
+		} `json:"candidates"`
+		PromptFeedback struct {
+			BlockReason string `json:"blockReason"`
+		} `json:"promptFeedback"`
+		UsageMetadata struct {
+			PromptTokenCount     int `json:"promptTokenCount"`
+			CandidatesTokenCount int `json:"candidatesTokenCount"`
+			TotalTokenCount      int `json:"totalTokenCount"`
+		} `json:"usageMetadata"`
+	}
+	endpoint := fmt.Sprintf("%s/models/%s:generateContent", g.baseURL, url.PathEscape(g.model))
+	headers := map[string]string{"x-goog-api-key": g.apiKey}
+	if err := postJSON(ctx, g.client, endpoint, headers, request, &response); err != nil {
+		return ports.ToolGeneration{}, err
+	}
+	if response.PromptFeedback.BlockReason != "" {
+		return ports.ToolGeneration{}, types.Newf(types.ErrCodeExecutionFailed, "Gemini blocked the prompt: %s", response.PromptFeedback.BlockReason)
+	}
+	if len(response.Candidates) == 0 {
+		return ports.ToolGeneration{}, types.New(types.ErrCodeExecutionFailed, "LLM returned no candidates")
+	}
+
+	gen := ports.ToolGeneration{Usage: domain.TokenUsage{
+		PromptTokens:     response.UsageMetadata.PromptTokenCount,
+		CompletionTokens: response.UsageMetadata.CandidatesTokenCount,
+		TotalTokens:      response.UsageMetadata.TotalTokenCount,
+	}}
+	var text []string
+	for _, part := range response.Candidates[0].Content.Parts {
+		if part.FunctionCall != nil {
+			args := part.FunctionCall.Args
+			if args == nil {
+				args = map[string]interface{}{}
+			}
+			gen.ToolCalls = append(gen.ToolCalls, ports.ToolCall{
+				ID:   fmt.Sprintf("call_%d", len(gen.ToolCalls)),
+				Name: part.FunctionCall.Name,
+				Args: args,
+			})
+		} else if part.Text != "" {
+			text = append(text, part.Text)
+		}
+	}
+	gen.Content = strings.Join(text, "")
+	return gen, nil
+}
+
+// geminiSchemaKeys are the JSON Schema keywords Gemini accepts.
+var geminiSchemaKeys = map[string]bool{
+	"type": true, "description": true, "enum": true, "properties": true, "required": true,
+	"items": true, "minimum": true, "maximum": true, "minItems": true, "maxItems": true,
+}
+
+// geminiSchema strips a schema down to what Gemini accepts: no defaults,
+// lengths or map value schemas, and enums only on strings.
+func geminiSchema(schema map[string]interface{}) map[string]interface{} {
+	out := make(map[string]interface{}, len(schema))
+	for key, value := range schema {
+		if !geminiSchemaKeys[key] {
+			continue
+		}
+		switch key {
+		case "enum":
+			if schema["type"] != "string" {
+				continue
+			}
+		case "items":
+			if items, ok := value.(map[string]interface{}); ok {
+				value = geminiSchema(items)
+			}
+		case "properties":
+			if props, ok := value.(map[string]interface{}); ok {
+				cleaned := make(map[string]interface{}, len(props))
+				for name, prop := range props {
+					if p, ok := prop.(map[string]interface{}); ok {
+						cleaned[name] = geminiSchema(p)
+					}
+				}
+				value = cleaned
+			}
+		}
+		out[key] = value
+	}
+	return out
+}
@@ -0,0 +1,69 @@
+package llm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/SecDuckOps/shared/types"
+)
+
+// requestTimeout bounds one generation request.
+const requestTimeout = 5 * time.Minute
+
+// postJSON posts request as JSON to url and decodes the reply into response.
+// A non-2xx reply is an error carrying the provider's message.
+func postJSON(ctx context.Context, client *http.Client, url string, headers map[string]string, request, response interface{}) error {
+	body, err := json.Marshal(request)
+	if err != nil {
+		return types.Wrap(err, types.ErrCodeInternal, "failed to marshal LLM request")
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return types.Wrap(err, types.ErrCodeInternal, "failed to build LLM request")
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return types.Wrap(err, types.ErrCodeExecutionFailed, "LLM request failed")
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 16<<20))
+	if err != nil {
+		return types.Wrap(err, types.ErrCodeExecutionFailed, "failed to read LLM response")
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return types.Newf(types.ErrCodeExecutionFailed, "LLM returned %d: %s", resp.StatusCode, errorMessage(data))
+	}
+	if err := json.Unmarshal(data, response); err != nil {
+		return types.Wrap(err, types.ErrCodeExecutionFailed, "failed to decode LLM response")
+	}
+	return nil
+}
+
+// errorMessage pulls the message out of an error body; both OpenAI and
+// Gemini use {"error": {"message": ...}}.
+func errorMessage(data []byte) string {
+	var body struct {
+		Error struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if json.Unmarshal(data, &body) == nil && body.Error.Message != "" {
+		return body.Error.Message
+	}
+	msg := strings.TrimSpace(string(data))
+	if len(msg) > 200 {
+		msg = msg[:200] + "..."
+	}
+	return msg
+}
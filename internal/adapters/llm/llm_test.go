@@ -0,0 +1,194 @@
+package llm_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/SecDuckOps/agent/internal/adapters/llm"
+	"github.com/SecDuckOps/agent/internal/ports"
+	"github.com/SecDuckOps/shared/llm/domain"
+)
+
+type stubLLM struct{}
+
+func (stubLLM) Name() string  { return "stub" }
+func (stubLLM) Model() string { return "stub-model" }
+func (stubLLM) Generate(ctx context.Context, messages []domain.Message, tools []domain.ToolDefinition) (domain.GenerationResult, error) {
+	return domain.GenerationResult{Content: "plain"}, nil
+}
+
+var echoTool = domain.ToolDefinition{
+	Name:        "echo",
+	Description: "Echoes text",
+	Parameters: map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"text":  map[string]interface{}{"type": "string", "default": "hi", "maxLength": 10},
+			"level": map[string]interface{}{"type": "integer", "enum": []interface{}{1, 2}},
+		},
+		"required": []interface{}{"text"},
+	},
+}
+
+// conversation is a turn with a tool call answered by its result.
+var conversation = []ports.ChatMessage{
+	{Role: domain.RoleSystem, Content: "be brief"},
+	{Role: domain.RoleUser, Content: "echo hi"},
+	{Role: domain.RoleAssistant, ToolCalls: []ports.ToolCall{{ID: "call_1", Name: "echo", Args: map[string]interface{}{"text": "hi"}}}},
+	{Role: domain.RoleTool, ToolCallID: "call_1", ToolName: "echo", Content: "hi"},
+}
+
+func TestOpenAI_GenerateWithTools(t *testing.T) {
+	var request map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/chat/completions" || r.Header.Get("Authorization") != "Bearer key" {
+			t.Errorf("unexpected request %s with auth %q", r.URL.Path, r.Header.Get("Authorization"))
+		}
+		json.NewDecoder(r.Body).Decode(&request)
+		w.Write([]byte(`{
+			"choices": [{"message": {"content": null, "tool_calls": [
+				{"id": "call_2", "type": "function", "function": {"name": "echo", "arguments": "{\"text\": \"a\"}"}},
+				{"id": "call_3", "type": "function", "function": {"name": "echo", "arguments": "{not json"}}
+			]}}],
+			"usage": {"prompt_tokens": 7, "completion_tokens": 3, "total_tokens": 10}
+		}`))
+	}))
+	defer server.Close()
+
+	gen, err := llm.NewOpenAI(stubLLM{}, server.URL+"/v1", "key", "gpt-test").GenerateWithTools(context.Background(), conversation, []domain.ToolDefinition{echoTool})
+	if err != nil {
+		t.Fatalf("GenerateWithTools: %v", err)
+	}
+
+	if request["model"] != "gpt-test" {
+		t.Errorf("expected model gpt-test, got %v", request["model"])
+	}
+	messages := request["messages"].([]interface{})
+	assistant := messages[2].(map[string]interface{})
+	call := assistant["tool_calls"].([]interface{})[0].(map[string]interface{})
+	if assistant["content"] != nil || call["function"].(map[string]interface{})["arguments"] != `{"text":"hi"}` {
+		t.Errorf("unexpected assistant message %v", assistant)
+	}
+	if tool := messages[3].(map[string]interface{}); tool["role"] != "tool" || tool["tool_call_id"] != "call_1" {
+		t.Errorf("unexpected tool message %v", tool)
+	}
+	if fn := request["tools"].([]interface{})[0].(map[string]interface{})["function"].(map[string]interface{}); fn["name"] != "echo" {
+		t.Errorf("unexpected tools %v", request["tools"])
+	}
+
+	if len(gen.ToolCalls) != 2 || gen.ToolCalls[0].ID != "call_2" || gen.ToolCalls[0].Args["text"] != "a" {
+		t.Fatalf("unexpected tool calls %+v", gen.ToolCalls)
+	}
+	if gen.ToolCalls[0].Invalid != "" || gen.ToolCalls[1].Invalid == "" {
+		t.Errorf("expected only the second call to be invalid, got %+v", gen.ToolCalls)
+	}
+	if gen.Usage.TotalTokens != 10 {
+		t.Errorf("expected usage to be read, got %+v", gen.Usage)
+	}
+}
+
+func TestOpenAI_ReportsProviderError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"error": {"message": "tools are not supported by this model"}}`))
+	}))
+	defer server.Close()
+
+	_, err := llm.NewOpenAI(stubLLM{}, server.URL, "key", "").GenerateWithTools(context.Background(), conversation, nil)
+	if err == nil || !strings.Contains(err.Error(), "tools are not supported") {
+		t.Fatalf("expected the provider's message, got %v", err)
+	}
+}
+
+func TestGemini_GenerateWithTools(t *testing.T) {
+	var request struct {
+		SystemInstruction struct {
+			Parts []map[string]interface{} `json:"parts"`
+		} `json:"systemInstruction"`
+		Contents []struct {
+			Role  string                   `json:"role"`
+			Parts []map[string]interface{} `json:"parts"`
+		} `json:"contents"`
+		Tools []struct {
+			FunctionDeclarations []domain.ToolDefinition `json:"functionDeclarations"`
+		} `json:"tools"`
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/models/gemini-test:generateContent" || r.Header.Get("x-goog-api-key") != "key" {
+			t.Errorf("unexpected request %s", r.URL.Path)
+		}
+		json.NewDecoder(r.Body).Decode(&request)
+		w.Write([]byte(`{
+			"candidates": [{"content": {"role": "model", "parts": [
+				{"text": "Echoing twice."},
+				{"functionCall": {"name": "echo", "args": {"text": "a"}}},
+				{"functionCall": {"name": "echo", "args": {"text": "b"}}}
+			]}}],
+			"usageMetadata": {"promptTokenCount": 5, "candidatesTokenCount": 2, "totalTokenCount": 7}
+		}`))
+	}))
+	defer server.Close()
+
+	gen, err := llm.NewGemini(stubLLM{}, server.URL, "key", "models/gemini-test").GenerateWithTools(context.Background(), conversation, []domain.ToolDefinition{echoTool})
+	if err != nil {
+		t.Fatalf("GenerateWithTools: %v", err)
+	}
+
+	if request.SystemInstruction.Parts[0]["text"] != "be brief" {
+		t.Errorf("expected the system message as instruction, got %+v", request.SystemInstruction)
+	}
+	if len(request.Contents) != 3 || request.Contents[1].Role != "model" || request.Contents[2].Role != "user" {
+		t.Fatalf("unexpected contents %+v", request.Contents)
+	}
+	if _, ok := request.Contents[2].Parts[0]["functionResponse"]; !ok {
+		t.Errorf("expected the tool result as a function response, got %+v", request.Contents[2])
+	}
+	props := request.Tools[0].FunctionDeclarations[0].Parameters["properties"].(map[string]interface{})
+	text := props["text"].(map[string]interface{})
+	level := props["level"].(map[string]interface{})
+	if _, ok := text["default"]; ok {
+		t.Errorf("expected default to be stripped, got %v", text)
+	}
+	if _, ok := level["enum"]; ok {
+		t.Errorf("expected the integer enum to be stripped, got %v", level)
+	}
+
+	if gen.Content != "Echoing twice." || len(gen.ToolCalls) != 2 {
+		t.Fatalf("unexpected generation %+v", gen)
+	}
+	if gen.ToolCalls[0].ID == gen.ToolCalls[1].ID || gen.ToolCalls[1].Args["text"] != "b" {
+		t.Errorf("unexpected tool calls %+v", gen.ToolCalls)
+	}
+	if gen.Usage.TotalTokens != 7 {
+		t.Errorf("expected usage to be read, got %+v", gen.Usage)
+	}
+}
+
+func TestRegistry_WrapsProvidersWithToolCalling(t *testing.T) {
+	providers := map[string]llm.ProviderConfig{
+		"openai": {Type: "openai", APIKey: "key", FunctionCalling: true},
+		"legacy": {Type: "openai", APIKey: "key", FunctionCalling: false},
+		"other":  {Type: "anthropic", APIKey: "key", FunctionCalling: true},
+	}
+	registry := llm.NewRegistry(stubRegistry{}, "openai", providers)
+
+	if _, ok := registry.Default().(ports.ToolCallingLLM); !ok {
+		t.Error("expected the OpenAI provider to support tool calling")
+	}
+	for _, name := range []string{"legacy", "other"} {
+		if _, ok := registry.Get(name).(ports.ToolCallingLLM); ok {
+			t.Errorf("expected %s to keep the prompt protocol", name)
+		}
+	}
+}
+
+type stubRegistry struct{}
+
+func (stubRegistry) Get(name string) domain.LLM { return stubLLM{} }
+func (stubRegistry) Default() domain.LLM        { return stubLLM{} }
+func (stubRegistry) List() []string             { return []string{"stub"} }
+func (stubRegistry) Register(domain.LLM)        {}
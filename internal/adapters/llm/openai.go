@@ -0,0 +1,131 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/SecDuckOps/agent/internal/ports"
+	"github.com/SecDuckOps/shared/llm/domain"
+	"github.com/SecDuckOps/shared/types"
+)
+
+// OpenAI adds native tool calling through the Chat Completions API to a
+// provider; Generate still goes to the wrapped provider. It works with any
+// OpenAI-compatible endpoint, such as OpenRouter.
+type OpenAI struct {
+	domain.LLM
+	client  *http.Client
+	baseURL string
+	apiKey  string
+	model   string
+}
+
+// NewOpenAI wraps inner. An empty model uses inner's.
+func NewOpenAI(inner domain.LLM, baseURL, apiKey, model string) *OpenAI {
+	if model == "" {
+		model = inner.Model()
+	}
+	return &OpenAI{
+		LLM:     inner,
+		client:  &http.Client{Timeout: requestTimeout},
+		baseURL: strings.TrimRight(baseURL, "/"),
+		apiKey:  apiKey,
+		model:   model,
+	}
+}
+
+type openAIMessage struct {
+	Role       string           `json:"role"`
+	Content    *string          `json:"content"`
+	ToolCalls  []openAIToolCall `json:"tool_calls,omitempty"`
+	ToolCallID string           `json:"tool_call_id,omitempty"`
+}
+
+type openAIToolCall struct {
+	ID       string `json:"id"`
+	Type     string `json:"type"`
+	Function struct {
+		Name      string `json:"name"`
+		Arguments string `json:"arguments"`
+	} `json:"function"`
+}
+
+type openAITool struct {
+	Type     string                `json:"type"`
+	Function domain.ToolDefinition `json:"function"`
+}
+
+// GenerateWithTools sends messages with tools as functions and returns the
+// model's text and tool calls.
+func (o *OpenAI) GenerateWithTools(ctx context.Context, messages []ports.ChatMessage, tools []domain.ToolDefinition) (ports.ToolGeneration, error) {
+	request := struct {
+		Model    string          `json:"model"`
+		Messages []openAIMessage `json:"messages"`
+		Tools    []openAITool    `json:"tools,omitempty"`
+	}{Model: o.model}
+
+	for _, m := range messages {
+		content := m.Content
+		msg := openAIMessage{Role: string(m.Role), Content: &content, ToolCallID: m.ToolCallID}
+		for _, tc := range m.ToolCalls {
+			call := openAIToolCall{ID: tc.ID, Type: "function"}
+			call.Function.Name = tc.Name
+			args, _ := json.Marshal(tc.Args)
+			call.Function.Arguments = string(args)
+			msg.ToolCalls = append(msg.ToolCalls, call)
+		}
+		if len(msg.ToolCalls) > 0 && content == "" {
+			msg.Content = nil
+		}
+		request.Messages = append(request.Messages, msg)
+	}
+	for _, t := range tools {
+		request.Tools = append(request.Tools, openAITool{Type: "function", Function: t})
+	}
+
+	var response struct {
+		Choices []struct {
+			Message struct {
+				Content   *string          `json:"content"`
+				ToolCalls []openAIToolCall `json:"tool_calls"`
+			} `json:"message"`
+		} `json:"choices"`
+		Usage domain.TokenUsage `json:"usage"`
+	}
+	headers := map[string]string{"Authorization": "Bearer " + o.apiKey}
+	if err := postJSON(ctx, o.client, o.baseURL+"/chat/completions", headers, request, &response); err != nil {
+		return ports.ToolGeneration{}, err
+	}
+	if len(response.Choices) == 0 {
+		return ports.ToolGeneration{}, types.New(types.ErrCodeExecutionFailed, "LLM returned no choices")
+	}
+
+	msg := response.Choices[0].Message
+	gen := ports.ToolGeneration{Usage: response.Usage}
+	if msg.Content != nil {
+		gen.Content = *msg.Content
+	}
+	for _, call := range msg.ToolCalls {
+		tc := ports.ToolCall{ID: call.ID, Name: call.Function.Name}
+		if err := decodeArgs(call.Function.Arguments, &tc); err != nil {
+			tc.Invalid = err.Error()
+		}
+		gen.ToolCalls = append(gen.ToolCalls, tc)
+	}
+	return gen, nil
+}
+
+// decodeArgs parses the JSON object a model sent as a call's arguments.
+func decodeArgs(arguments string, tc *ports.ToolCall) error {
+	tc.Args = map[string]interface{}{}
+	if strings.TrimSpace(arguments) == "" {
+		return nil
+	}
+	if err := json.Unmarshal([]byte(arguments), &tc.Args); err != nil {
+		tc.Args = nil
+		return types.Wrap(err, types.ErrCodeInvalidInput, "arguments are not a JSON object")
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+package llm
+
+import (
+	"strings"
+
+	"github.com/SecDuckOps/shared/llm/domain"
+)
+
+// Base URLs of the OpenAI-compatible provider types.
+const (
+	DefaultOpenAIURL     = "https://api.openai.com/v1"
+	DefaultOpenRouterURL = "https://openrouter.ai/api/v1"
+)
+
+// ProviderConfig is what the registry needs to know about a configured
+// provider to call its tool calling API.
+type ProviderConfig struct {
+	Type            string // "openai", "openrouter", "custom", "gemini"
+	APIKey          string
+	Model           string
+	BaseURL         string
+	FunctionCalling bool // false keeps the provider on the prompt protocol
+}
+
+// Registry wraps an LLM registry so the providers it hands out implement
+// ports.ToolCallingLLM where their API supports it. Other providers are
+// returned as they are.
+type Registry struct {
+	domain.LLMRegistry
+	defaultName string
+	providers   map[string]ProviderConfig
+}
+
+// NewRegistry wraps registry. providers is keyed by provider name;
+// defaultName is the provider Default returns.
+func NewRegistry(registry domain.LLMRegistry, defaultName string, providers map[string]ProviderConfig) *Registry {
+	return &Registry{LLMRegistry: registry, defaultName: defaultName, providers: providers}
+}
+
+// Get returns the named provider, or nil.
+func (r *Registry) Get(name string) domain.LLM {
+	return r.wrap(name, r.LLMRegistry.Get(name))
+}
+
+// Default returns the default provider, or nil.
+func (r *Registry) Default() domain.LLM {
+	return r.wrap(r.defaultName, r.LLMRegistry.Default())
+}
+
+func (r *Registry) wrap(name string, llm domain.LLM) domain.LLM {
+	if llm == nil {
+		return nil
+	}
+	cfg, ok := r.providers[name]
+	if !ok {
+		cfg, ok = r.providers[llm.Name()]
+	}
+	if !ok || !cfg.FunctionCalling || cfg.APIKey == "" {
+		return llm
+	}
+
+	switch cfg.Type {
+	case "gemini":
+		return NewGemini(llm, cfg.BaseURL, cfg.APIKey, cfg.Model)
+	case "openai", "openrouter", "custom":
+		baseURL := cfg.BaseURL
+		if baseURL == "" {
+			switch cfg.Type {
+			case "openai":
+				baseURL = DefaultOpenAIURL
+			case "openrouter":
+				baseURL = DefaultOpenRouterURL
+			default:
+				return llm // a custom endpoint must be configured
+			}
+		}
+		model := cfg.Model
+		if cfg.Type == "openrouter" {
+			// Profiles name OpenRouter models with the provider prefix
+			model = strings.TrimPrefix(model, "openrouter/")
+		}
+		return NewOpenAI(llm, baseURL, cfg.APIKey, model)
+	}
+	return llm
+}
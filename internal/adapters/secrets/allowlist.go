@@ -0,0 +1,47 @@
+package secrets
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"strings"
+
+	"github.com/SecDuckOps/shared/types"
+)
+
+// Fingerprint returns the hex SHA-256 of a value, the form used in allowlists.
+// It is the same as `printf '%s' value | sha256sum`.
+func Fingerprint(value string) string {
+	sum := sha256.Sum256([]byte(value))
+	return hex.EncodeToString(sum[:])
+}
+
+// LoadAllowlist reads fingerprints of values that are known to be safe, one
+// per line. Blank lines and lines starting with # are ignored, and an
+// optional "sha256:" prefix is accepted.
+func LoadAllowlist(path string) (map[string]struct{}, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, types.Wrapf(err, types.ErrCodeInternal, "failed to read secret allowlist %s", path)
+	}
+	defer f.Close()
+
+	allow := make(map[string]struct{})
+	scanner := bufio.NewScanner(f)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fp := strings.ToLower(strings.TrimPrefix(line, "sha256:"))
+		if _, err := hex.DecodeString(fp); err != nil || len(fp) != sha256.Size*2 {
+			return nil, types.Newf(types.ErrCodeInvalidInput, "%s:%d: not a SHA-256 fingerprint", path, n)
+		}
+		allow[fp] = struct{}{}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, types.Wrapf(err, types.ErrCodeInternal, "failed to read secret allowlist %s", path)
+	}
+	return allow, nil
+}
@@ -0,0 +1,261 @@
+package secrets
+
+import (
+	"math"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/SecDuckOps/agent/internal/domain/security"
+	"github.com/SecDuckOps/shared/types"
+)
+
+// Detection modes for Detection.Mode.
+const (
+	DetectionRegex   = "regex"   // built-in and custom patterns only
+	DetectionEntropy = "entropy" // patterns plus context- and entropy-based rules
+)
+
+// DefaultThreshold is the minimum confidence a match needs to be reported.
+const DefaultThreshold = 0.7
+
+// Detection tunes what the scanner reports beyond its regex patterns.
+type Detection struct {
+	Mode      string              // DetectionRegex (default) or DetectionEntropy
+	Threshold float64             // minimum confidence, 0–1; 0 uses DefaultThreshold
+	Allowlist map[string]struct{} // fingerprints of known-safe values, see Fingerprint
+}
+
+// Assignments whose key names a secret. Each regex captures the key in group
+// 1 and the value, possibly quoted, in group 2.
+var assignmentRes = []*regexp.Regexp{
+	// KEY=value in env files, shell commands and query strings
+	regexp.MustCompile(`(?:^|[\s;,{(?&])(?:export\s+)?([A-Za-z_][A-Za-z0-9_.\-]*)[ \t]*=[ \t]*("[^"\n]*"|'[^'\n]*'|[^\s"'#;,&]+)`),
+	// "key": "value" in JSON
+	regexp.MustCompile(`"([^"\\\n]{1,64})"\s*:\s*("(?:[^"\\\n]|\\.)*")`),
+	// key: value in YAML and log lines
+	regexp.MustCompile(`(?m)^[ \t]*(?:- )?([A-Za-z_][A-Za-z0-9_.\-]*)[ \t]*:[ \t]+("[^"\n]*"|'[^'\n]*'|[^\s#"'][^\n#]*?)[ \t]*(?:#.*)?$`),
+}
+
+// tokenRe finds standalone candidates for the entropy rule.
+var tokenRe = regexp.MustCompile(`[A-Za-z0-9+/_\-]{20,}={0,2}`)
+
+// Key names are normalised (lower case, no separators) before matching.
+var (
+	secretKeyWords = []string{
+		"password", "passwd", "passphrase", "pwd", "secret", "token", "apikey",
+		"accesskey", "privatekey", "signingkey", "encryptionkey", "masterkey",
+		"sessionkey", "clientkey", "credential", "authorization", "bearer",
+	}
+	// Abbreviations too short to match anywhere in a key, e.g. DB_PASS.
+	secretKeySuffixes = []string{"pass", "pw"}
+	// Keys such as SECRET_NAME or token_ttl describe a secret but do not hold one.
+	nonSecretKeySuffixes = []string{
+		"name", "id", "ids", "file", "path", "dir", "type", "version", "ref",
+		"url", "uri", "length", "count", "enabled", "expiry", "expires", "ttl",
+		"header", "field", "env", "arn", "policy", "prompt", "hint",
+	}
+)
+
+// dummyValues are well-known example and placeholder values, compared in
+// lower case.
+var dummyValues = map[string]struct{}{
+	"changeme": {}, "change_me": {}, "changeit": {}, "password": {}, "secret": {},
+	"token": {}, "example": {}, "sample": {}, "dummy": {}, "test": {}, "testing": {},
+	"placeholder": {}, "redacted": {}, "none": {}, "null": {}, "nil": {}, "true": {},
+	"false": {}, "undefined": {}, "todo": {}, "fixme": {}, "notasecret": {},
+}
+
+// templateRe matches references to a value kept elsewhere: ${VAR}, $VAR,
+// {{ .Values.x }}, <your-token> and %(name)s.
+var templateRe = regexp.MustCompile(`^(?:\$\{[^}]*\}|\$[A-Za-z_][A-Za-z0-9_]*|\{\{.*\}\}|<[^<>]+>|%\([^)]*\)s)$`)
+
+// rulePrefixes name the placeholders for matches that have no pattern.
+var rulePrefixes = map[string]string{
+	security.RuleContext: "SECRET",
+	security.RuleEntropy: "HIGH_ENTROPY",
+}
+
+// classFactor weights how many character classes (lower, upper, digit,
+// other) a value mixes; random tokens tend to use three or four.
+var classFactor = [5]float64{0, 0.3, 0.6, 0.9, 1}
+
+// contextMatches finds values assigned to secret-looking keys.
+func contextMatches(text string) []security.SecretMatch {
+	var matches []security.SecretMatch
+	for _, re := range assignmentRes {
+		for _, loc := range re.FindAllStringSubmatchIndex(text, -1) {
+			key := text[loc[2]:loc[3]]
+			start, end := loc[4], loc[5]
+			if end-start >= 2 && (text[start] == '"' || text[start] == '\'') && text[end-1] == text[start] {
+				start, end = start+1, end-1
+			}
+			value := text[start:end]
+			if len(value) < 4 || !secretKey(key) || isPlaceholder(value) || dummyValue(value, true) {
+				continue
+			}
+			matches = append(matches, security.SecretMatch{
+				PatternName: "Secret Assignment (" + key + ")",
+				Rule:        security.RuleContext,
+				Confidence:  contextConfidence(value),
+				Value:       value,
+				StartIndex:  start,
+				EndIndex:    end,
+			})
+		}
+	}
+	return matches
+}
+
+// entropyMatches finds random-looking tokens regardless of context.
+func entropyMatches(text string) []security.SecretMatch {
+	var matches []security.SecretMatch
+	for _, loc := range tokenRe.FindAllStringIndex(text, -1) {
+		value := text[loc[0]:loc[1]]
+		if pathLike(value) || dummyValue(value, true) {
+			continue
+		}
+		matches = append(matches, security.SecretMatch{
+			PatternName: "High Entropy String",
+			Rule:        security.RuleEntropy,
+			Confidence:  entropyConfidence(value),
+			Value:       value,
+			StartIndex:  loc[0],
+			EndIndex:    loc[1],
+		})
+	}
+	return matches
+}
+
+// secretKey reports whether a key name suggests its value is a secret.
+func secretKey(key string) bool {
+	norm := strings.Map(func(r rune) rune {
+		if r == '_' || r == '-' || r == '.' {
+			return -1
+		}
+		return unicode.ToLower(r)
+	}, key)
+
+	for _, suffix := range nonSecretKeySuffixes {
+		if strings.HasSuffix(norm, suffix) {
+			return false
+		}
+	}
+	for _, word := range secretKeyWords {
+		if strings.Contains(norm, word) {
+			return true
+		}
+	}
+	for _, suffix := range secretKeySuffixes {
+		if strings.HasSuffix(norm, suffix) && norm != "bypass" {
+			return true
+		}
+	}
+	return false
+}
+
+// dummyValue reports whether value is an obvious placeholder. Specific
+// patterns (generic false) skip the substring checks, since real keys may
+// legitimately contain words such as "EXAMPLE".
+func dummyValue(value string, generic bool) bool {
+	lower := strings.ToLower(value)
+	if _, ok := dummyValues[lower]; ok {
+		return true
+	}
+	if templateRe.MatchString(value) || strings.Contains(lower, "xxxx") || strings.Contains(value, "****") {
+		return true
+	}
+	if sameRune(lower) {
+		return true
+	}
+	if generic {
+		for _, s := range []string{"example", "your_", "your-", "redacted", "placeholder", "changeme"} {
+			if strings.Contains(lower, s) {
+				return true
+			}
+		}
+		if pathLike(value) {
+			return true
+		}
+	}
+	return false
+}
+
+// sameRune reports whether s is one character repeated, e.g. "0000".
+func sameRune(s string) bool {
+	if len(s) < 3 {
+		return false
+	}
+	for i := 1; i < len(s); i++ {
+		if s[i] != s[0] {
+			return false
+		}
+	}
+	return true
+}
+
+// pathLike reports whether value looks like a file path or URL path rather
+// than an encoded secret.
+func pathLike(value string) bool {
+	if strings.HasPrefix(value, "/") || strings.HasPrefix(value, "~/") || strings.HasPrefix(value, "./") {
+		return true
+	}
+	return strings.Count(value, "/") >= 2 && !strings.HasSuffix(value, "=")
+}
+
+// contextConfidence scores a value assigned to a secret-looking key. The key
+// already makes a secret likely, so even short values score at least 0.6.
+func contextConfidence(value string) float64 {
+	return 0.6 + 0.4*math.Min(shannonEntropy(value)/4, 1)*classFactor[charClasses(value)]
+}
+
+// entropyConfidence scores a token with no context. Only values above 3 bits
+// of entropy per character that mix several character classes score high,
+// which keeps hex digests, identifiers and UUIDs below the default threshold.
+func entropyConfidence(value string) float64 {
+	h := math.Max(0, math.Min((shannonEntropy(value)-3)/1.5, 1))
+	return h * classFactor[charClasses(value)]
+}
+
+// charClasses counts the character classes present in s.
+func charClasses(s string) int {
+	var lower, upper, digit, other bool
+	for _, r := range s {
+		switch {
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsDigit(r):
+			digit = true
+		default:
+			other = true
+		}
+	}
+	n := 0
+	for _, b := range []bool{lower, upper, digit, other} {
+		if b {
+			n++
+		}
+	}
+	return n
+}
+
+// validateDetection applies defaults and rejects unknown modes and
+// out-of-range thresholds.
+func validateDetection(d Detection) (Detection, error) {
+	switch d.Mode {
+	case "":
+		d.Mode = DetectionRegex
+	case DetectionRegex, DetectionEntropy:
+	default:
+		return d, types.Newf(types.ErrCodeInvalidInput, "unknown secret detection mode %q", d.Mode)
+	}
+	if d.Threshold < 0 || d.Threshold > 1 {
+		return d, types.Newf(types.ErrCodeInvalidInput, "secret detection threshold must be between 0 and 1, got %v", d.Threshold)
+	}
+	if d.Threshold == 0 {
+		d.Threshold = DefaultThreshold
+	}
+	return d, nil
+}
@@ -0,0 +1,120 @@
+package secrets
+
+import (
+	"encoding/json"
+	"os"
+
+	"github.com/SecDuckOps/agent/internal/domain/security"
+	"github.com/SecDuckOps/shared/types"
+)
+
+// builtinPattern is a curated pattern with an optional entropy floor that
+// filters out obvious non-secrets such as `password = "changeme"`.
+type builtinPattern struct {
+	security.SecretPattern
+	minEntropy float64 // Shannon bits per character; 0 disables the check
+	confidence float64 // reported on matches; 0 means patternConfidence
+}
+
+// Confidence of regex matches. Generic patterns (those with an entropy floor)
+// set their own, lower confidence.
+const (
+	patternConfidence = 0.95
+	customConfidence  = 0.9
+)
+
+// Patterns with a capture group replace only that group, so the surrounding
+// key name or URL stays readable for the model.
+var builtinPatterns = []builtinPattern{
+	{SecretPattern: security.SecretPattern{
+		Name:    "Private Key",
+		Pattern: `-----BEGIN (?:[A-Z0-9]+ )*PRIVATE KEY(?: BLOCK)?-----[\s\S]*?-----END (?:[A-Z0-9]+ )*PRIVATE KEY(?: BLOCK)?-----`,
+		Prefix:  "PRIVATE_KEY",
+	}},
+	{SecretPattern: security.SecretPattern{
+		Name:    "AWS Access Key ID",
+		Pattern: `\b(?:AKIA|ASIA|ABIA|ACCA)[0-9A-Z]{16}\b`,
+		Prefix:  "AWS_ACCESS_KEY",
+	}},
+	{SecretPattern: security.SecretPattern{
+		Name:    "AWS Secret Access Key",
+		Pattern: `(?i)aws_?secret_?(?:access_?)?key["']?\s*[:=]\s*["']?([A-Za-z0-9/+]{40})\b`,
+		Prefix:  "AWS_SECRET_KEY",
+	}},
+	{SecretPattern: security.SecretPattern{
+		Name:    "GitHub Token",
+		Pattern: `\b(?:gh[pousr]_[A-Za-z0-9]{36,255}|github_pat_[A-Za-z0-9_]{80,255})\b`,
+		Prefix:  "GITHUB_TOKEN",
+	}},
+	{SecretPattern: security.SecretPattern{
+		Name:    "GitLab Token",
+		Pattern: `\bgl(?:pat|dt|rt|ptt|ft|soat|cbt)-[A-Za-z0-9_\-]{20,}`,
+		Prefix:  "GITLAB_TOKEN",
+	}},
+	{SecretPattern: security.SecretPattern{
+		Name:    "Slack Token",
+		Pattern: `\bxox[abposr]-[A-Za-z0-9\-]{10,}`,
+		Prefix:  "SLACK_TOKEN",
+	}},
+	{SecretPattern: security.SecretPattern{
+		Name:    "Slack Webhook",
+		Pattern: `https://hooks\.slack\.com/(?:services|workflows)/[A-Za-z0-9/_\-]{20,}`,
+		Prefix:  "SLACK_WEBHOOK",
+	}},
+	{SecretPattern: security.SecretPattern{
+		Name:    "LLM Provider API Key",
+		Pattern: `\bsk-(?:proj-|ant-(?:api|admin)\d{2}-|or-v1-)?[A-Za-z0-9_\-]{32,}`,
+		Prefix:  "LLM_API_KEY",
+	}},
+	{SecretPattern: security.SecretPattern{
+		Name:    "Google API Key",
+		Pattern: `\bAIza[0-9A-Za-z_\-]{35}\b`,
+		Prefix:  "GOOGLE_API_KEY",
+	}},
+	{SecretPattern: security.SecretPattern{
+		Name:    "Stripe Secret Key",
+		Pattern: `\b[rs]k_live_[0-9A-Za-z]{24,}\b`,
+		Prefix:  "STRIPE_KEY",
+	}},
+	{SecretPattern: security.SecretPattern{
+		Name:    "JSON Web Token",
+		Pattern: `\beyJ[A-Za-z0-9_\-]{8,}\.eyJ[A-Za-z0-9_\-]{8,}\.[A-Za-z0-9_\-]*`,
+		Prefix:  "JWT",
+	}},
+	{SecretPattern: security.SecretPattern{
+		Name:    "Database URL Password",
+		Pattern: `(?i)\b(?:postgres(?:ql)?|mysql|mariadb|mongodb(?:\+srv)?|rediss?|amqps?|mssql|sqlserver|clickhouse)://[^\s:/@]+:([^\s@/]+)@`,
+		Prefix:  "DB_PASSWORD",
+	}},
+	{SecretPattern: security.SecretPattern{
+		Name:    "Generic Secret Assignment",
+		Pattern: `(?i)\b[a-z0-9_.\-]*(?:api[_\-]?key|secret|token|passw(?:or)?d|pwd)[a-z0-9_.\-]*["']?\s*[:=]\s*["']?([^\s"'` + "`" + `,;]{8,})`,
+		Prefix:  "SECRET",
+	}, minEntropy: 3.0, confidence: 0.8},
+}
+
+// BuiltinPatterns returns the curated patterns the scanner always applies.
+func BuiltinPatterns() []security.SecretPattern {
+	out := make([]security.SecretPattern, len(builtinPatterns))
+	for i, p := range builtinPatterns {
+		out[i] = p.SecretPattern
+	}
+	return out
+}
+
+// LoadCustomPatterns reads extra patterns from a JSON file containing an array
+// of security.SecretPattern:
+//
+//	[{"name": "Acme API Key", "pattern": "acme_[a-z0-9]{32}", "prefix": "ACME_KEY"}]
+func LoadCustomPatterns(path string) ([]security.SecretPattern, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, types.Wrapf(err, types.ErrCodeInternal, "failed to read secret patterns %s", path)
+	}
+
+	var patterns []security.SecretPattern
+	if err := json.Unmarshal(data, &patterns); err != nil {
+		return nil, types.Wrapf(err, types.ErrCodeInvalidInput, "failed to parse secret patterns %s", path)
+	}
+	return patterns, nil
+}
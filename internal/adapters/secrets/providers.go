@@ -0,0 +1,181 @@
+package secrets
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/SecDuckOps/shared/types"
+)
+
+// maxSecretFileSize bounds what the file backend reads.
+const maxSecretFileSize = 64 << 10
+
+// DefaultCommandTimeout bounds how long a cmd reference may run.
+const DefaultCommandTimeout = 10 * time.Second
+
+var envNameRe = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// EnvProvider resolves secret://env/NAME from the agent's environment.
+type EnvProvider struct{}
+
+// Resolve returns the value of the environment variable name.
+func (EnvProvider) Resolve(_ context.Context, name string) (string, error) {
+	if !envNameRe.MatchString(name) {
+		return "", types.Newf(types.ErrCodeInvalidInput, "invalid environment variable name %q", name)
+	}
+	value, ok := os.LookupEnv(name)
+	if !ok {
+		return "", types.Newf(types.ErrCodeNotFound, "environment variable %s is not set", name)
+	}
+	return value, nil
+}
+
+// FileProvider resolves secret://file/<path> from files under its roots.
+// Paths may start with ~/; other paths are taken as absolute. Trailing
+// newlines are trimmed.
+type FileProvider struct {
+	roots []string
+}
+
+// NewFileProvider creates a FileProvider that only reads files under roots.
+// Roots that do not exist yet are kept, so they can be created later.
+func NewFileProvider(roots []string) *FileProvider {
+	p := &FileProvider{}
+	for _, root := range roots {
+		if abs, err := canonicalPath(root); err == nil {
+			p.roots = append(p.roots, abs)
+		}
+	}
+	return p
+}
+
+// Resolve reads the secret file at path.
+func (p *FileProvider) Resolve(_ context.Context, path string) (string, error) {
+	abs, err := canonicalPath(path)
+	if err != nil {
+		return "", types.Wrapf(err, types.ErrCodeNotFound, "secret file %s not found", path)
+	}
+	if !p.allowed(abs) {
+		return "", types.Newf(types.ErrCodePermissionDenied, "secret file %s is outside the allowed roots", path)
+	}
+
+	f, err := os.Open(abs)
+	if err != nil {
+		return "", types.Wrapf(err, types.ErrCodeNotFound, "failed to open secret file %s", path)
+	}
+	defer f.Close()
+
+	data, err := io.ReadAll(io.LimitReader(f, maxSecretFileSize+1))
+	if err != nil {
+		return "", types.Wrapf(err, types.ErrCodeInternal, "failed to read secret file %s", path)
+	}
+	if len(data) > maxSecretFileSize {
+		return "", types.Newf(types.ErrCodeInvalidInput, "secret file %s is larger than %d bytes", path, maxSecretFileSize)
+	}
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
+
+func (p *FileProvider) allowed(abs string) bool {
+	for _, root := range p.roots {
+		if rel, err := filepath.Rel(root, abs); err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
+
+// canonicalPath expands ~/, makes path absolute and resolves symlinks, so a
+// link inside a root cannot point outside it.
+func canonicalPath(path string) (string, error) {
+	if path == "~" || strings.HasPrefix(path, "~/") {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		path = filepath.Join(home, strings.TrimPrefix(path, "~"))
+	} else if !filepath.IsAbs(path) {
+		path = string(filepath.Separator) + path
+	}
+	path = filepath.Clean(path)
+
+	resolved, err := filepath.EvalSymlinks(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return path, nil
+	}
+	return resolved, err
+}
+
+// CommandProvider resolves secret://cmd/<command line> from the standard
+// output of a command, e.g. secret://cmd/pass show ci/token. The command is
+// run without a shell and must start with one of the allowed prefixes.
+type CommandProvider struct {
+	allowed [][]string
+	timeout time.Duration
+}
+
+// NewCommandProvider creates a CommandProvider for the allowed command
+// prefixes, such as "pass show" or "op read". A zero timeout uses
+// DefaultCommandTimeout.
+func NewCommandProvider(allowed []string, timeout time.Duration) *CommandProvider {
+	if timeout <= 0 {
+		timeout = DefaultCommandTimeout
+	}
+	p := &CommandProvider{timeout: timeout}
+	for _, prefix := range allowed {
+		if fields := strings.Fields(prefix); len(fields) > 0 {
+			p.allowed = append(p.allowed, fields)
+		}
+	}
+	return p
+}
+
+// Resolve runs the command line and returns its trimmed standard output.
+// Neither output stream is included in errors.
+func (p *CommandProvider) Resolve(ctx context.Context, cmdline string) (string, error) {
+	fields := strings.Fields(cmdline)
+	if len(fields) == 0 {
+		return "", types.New(types.ErrCodeInvalidInput, "empty secret command")
+	}
+	if !p.allowedCommand(fields) {
+		return "", types.Newf(types.ErrCodePermissionDenied, "secret command %q is not in allowed_commands", fields[0])
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, p.timeout)
+	defer cancel()
+
+	out, err := exec.CommandContext(ctx, fields[0], fields[1:]...).Output()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return "", types.Newf(types.ErrCodeExecutionFailed, "secret command %q exited with code %d", fields[0], exitErr.ExitCode())
+		}
+		return "", types.Wrapf(err, types.ErrCodeExecutionFailed, "secret command %q failed", fields[0])
+	}
+	return strings.TrimRight(string(out), "\r\n"), nil
+}
+
+func (p *CommandProvider) allowedCommand(fields []string) bool {
+	for _, prefix := range p.allowed {
+		if len(prefix) > len(fields) {
+			continue
+		}
+		match := true
+		for i := range prefix {
+			if prefix[i] != fields[i] {
+				match = false
+				break
+			}
+		}
+		if match {
+			return true
+		}
+	}
+	return false
+}
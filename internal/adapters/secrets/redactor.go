@@ -0,0 +1,139 @@
+package secrets
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+
+	"github.com/SecDuckOps/agent/internal/application/scrub"
+	"github.com/SecDuckOps/agent/internal/ports"
+	shared_ports "github.com/SecDuckOps/shared/ports"
+)
+
+// Sinks a Redactor can be enabled for.
+const (
+	SinkAudit      = "audit"       // AuditEntry targets and details
+	SinkEvents     = "events"      // subagent event messages and payloads
+	SinkTaskOutput = "task_output" // OS task stdout and stderr
+	SinkAppLog     = "app_log"     // the application log
+)
+
+// Sinks lists every sink, in the order they are documented.
+var Sinks = []string{SinkAudit, SinkEvents, SinkTaskOutput, SinkAppLog}
+
+// markerLength is the number of hex digits of the hash kept in a marker.
+const markerLength = 12
+
+// Redactor implements ports.RedactorPort with a Scanner. Each secret is
+// replaced by [REDACTED:<hash>], where the hash is derived from the value
+// alone, so the same secret gets the same marker in every sink and session.
+// Safe for concurrent use.
+type Redactor struct {
+	scanner *Scanner
+	key     []byte
+}
+
+// NewRedactor creates a Redactor that detects secrets with scanner. With a
+// key, markers are HMAC-SHA256 based, so they cannot be matched against
+// guessed values without it; otherwise they are a prefix of Fingerprint.
+func NewRedactor(scanner *Scanner, key []byte) *Redactor {
+	return &Redactor{scanner: scanner, key: key}
+}
+
+// Redact replaces every detected secret in text with its marker.
+func (r *Redactor) Redact(text string) string {
+	if text == "" {
+		return text
+	}
+	matches := r.scanner.Scan(text)
+	if len(matches) == 0 {
+		return text
+	}
+
+	var b strings.Builder
+	b.Grow(len(text))
+	last := 0
+	for _, m := range matches {
+		b.WriteString(text[last:m.StartIndex])
+		b.WriteString(r.Marker(m.Value))
+		last = m.EndIndex
+	}
+	b.WriteString(text[last:])
+	return b.String()
+}
+
+// Marker returns the marker that replaces value.
+func (r *Redactor) Marker(value string) string {
+	var sum string
+	if len(r.key) > 0 {
+		mac := hmac.New(sha256.New, r.key)
+		mac.Write([]byte(value))
+		sum = hex.EncodeToString(mac.Sum(nil))
+	} else {
+		sum = Fingerprint(value)
+	}
+	return "[REDACTED:" + sum[:markerLength] + "]"
+}
+
+// RedactingLogger redacts messages, fields and errors before passing them
+// to the wrapped logger.
+type RedactingLogger struct {
+	shared_ports.Logger
+	redactor ports.RedactorPort
+}
+
+// NewRedactingLogger wraps logger so secrets never reach the application log.
+func NewRedactingLogger(logger shared_ports.Logger, redactor ports.RedactorPort) *RedactingLogger {
+	return &RedactingLogger{Logger: logger, redactor: redactor}
+}
+
+// Debug logs a redacted debug message.
+func (l *RedactingLogger) Debug(ctx context.Context, msg string, fields ...shared_ports.Field) {
+	l.Logger.Debug(ctx, l.redactor.Redact(msg), l.fields(fields)...)
+}
+
+// Info logs a redacted informational message.
+func (l *RedactingLogger) Info(ctx context.Context, msg string, fields ...shared_ports.Field) {
+	l.Logger.Info(ctx, l.redactor.Redact(msg), l.fields(fields)...)
+}
+
+// ErrorErr logs a redacted error. The error is replaced by a plain one only
+// when its message contained a secret, so errors.Is keeps working otherwise.
+func (l *RedactingLogger) ErrorErr(ctx context.Context, err error, msg string, fields ...shared_ports.Field) {
+	l.Logger.ErrorErr(ctx, l.err(err), l.redactor.Redact(msg), l.fields(fields)...)
+}
+
+func (l *RedactingLogger) err(err error) error {
+	if err == nil {
+		return nil
+	}
+	if redacted := l.redactor.Redact(err.Error()); redacted != err.Error() {
+		return redactedError(redacted)
+	}
+	return err
+}
+
+func (l *RedactingLogger) value(v interface{}) interface{} {
+	if err, ok := v.(error); ok {
+		return l.err(err)
+	}
+	return scrub.RedactValue(l.redactor, v)
+}
+
+func (l *RedactingLogger) fields(fields []shared_ports.Field) []shared_ports.Field {
+	if len(fields) == 0 {
+		return fields
+	}
+	out := make([]shared_ports.Field, len(fields))
+	for i, f := range fields {
+		out[i] = shared_ports.Field{Key: f.Key, Value: l.value(f.Value)}
+	}
+	return out
+}
+
+// redactedError carries an error message with its secrets redacted.
+type redactedError string
+
+func (e redactedError) Error() string { return string(e) }
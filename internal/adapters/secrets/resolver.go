@@ -0,0 +1,67 @@
+package secrets
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/SecDuckOps/agent/internal/domain/security"
+	"github.com/SecDuckOps/shared/types"
+)
+
+// Resolver implements security.SecretProvider for secret://<backend>/<path>
+// references. Each backend is itself a SecretProvider and receives only the
+// path part of the reference. Safe for concurrent use.
+type Resolver struct {
+	mu       sync.RWMutex
+	backends map[string]security.SecretProvider
+}
+
+// NewResolver creates a Resolver with no backends.
+func NewResolver() *Resolver {
+	return &Resolver{backends: make(map[string]security.SecretProvider)}
+}
+
+// Register adds or replaces the backend for secret://<name>/ references.
+func (r *Resolver) Register(name string, backend security.SecretProvider) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.backends[name] = backend
+}
+
+// Backends returns the registered backend names, sorted.
+func (r *Resolver) Backends() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	names := make([]string, 0, len(r.backends))
+	for name := range r.backends {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Resolve returns the value a reference points to. Errors never contain the
+// value.
+func (r *Resolver) Resolve(ctx context.Context, ref string) (string, error) {
+	parsed, ok := security.ParseSecretRef(ref)
+	if !ok {
+		return "", types.Newf(types.ErrCodeInvalidInput, "malformed secret reference %q", ref)
+	}
+
+	r.mu.RLock()
+	backend := r.backends[parsed.Backend]
+	r.mu.RUnlock()
+	if backend == nil {
+		return "", types.Newf(types.ErrCodeNotFound, "no secret backend %q is configured", parsed.Backend)
+	}
+
+	value, err := backend.Resolve(ctx, parsed.Path)
+	if err != nil {
+		return "", err
+	}
+	if value == "" {
+		return "", types.Newf(types.ErrCodeNotFound, "secret reference %s resolved to an empty value", ref)
+	}
+	return value, nil
+}
@@ -0,0 +1,106 @@
+package secrets_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/SecDuckOps/agent/internal/adapters/secrets"
+)
+
+func TestResolver_EnvFileAndCommand(t *testing.T) {
+	ctx := context.Background()
+	root := t.TempDir()
+	os.WriteFile(filepath.Join(root, "db.pass"), []byte("s3cr3t-db\n"), 0600)
+	outside := filepath.Join(t.TempDir(), "other.pass")
+	os.WriteFile(outside, []byte("nope"), 0600)
+	t.Setenv("DUCKOPS_TEST_TOKEN", "env-value")
+
+	r := secrets.NewResolver()
+	r.Register("env", secrets.EnvProvider{})
+	r.Register("file", secrets.NewFileProvider([]string{root}))
+	r.Register("cmd", secrets.NewCommandProvider([]string{"echo"}, 0))
+
+	ok := map[string]string{
+		"secret://env/DUCKOPS_TEST_TOKEN":    "env-value",
+		"secret://file/" + root + "/db.pass": "s3cr3t-db",
+		"secret://cmd/echo cmd-value":        "cmd-value",
+	}
+	for ref, want := range ok {
+		if got, err := r.Resolve(ctx, ref); err != nil || got != want {
+			t.Errorf("Resolve(%q) = %q, %v; want %q", ref, got, err, want)
+		}
+	}
+
+	failing := []string{
+		"secret://env/DUCKOPS_TEST_UNSET",
+		"secret://file" + outside,
+		"secret://file/" + root + "/../" + filepath.Base(filepath.Dir(outside)) + "/other.pass",
+		"secret://cmd/cat /etc/passwd",
+		"secret://vault/ci/token",
+		"not a reference",
+	}
+	for _, ref := range failing {
+		if _, err := r.Resolve(ctx, ref); err == nil {
+			t.Errorf("expected Resolve(%q) to fail", ref)
+		}
+	}
+}
+
+func TestVaultProvider_KV2(t *testing.T) {
+	stub := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "root-token" {
+			w.WriteHeader(http.StatusForbidden)
+			json.NewEncoder(w).Encode(map[string]interface{}{"errors": []string{"permission denied"}})
+			return
+		}
+		switch r.URL.Path {
+		case "/v1/kv/data/ci/github":
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"data": map[string]interface{}{
+					"data":     map[string]interface{}{"token": "ghp-from-vault", "user": "ci"},
+					"metadata": map[string]interface{}{"version": 3},
+				},
+			})
+		case "/v1/kv/data/ci/single":
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"data": map[string]interface{}{"data": map[string]interface{}{"password": "only-one"}},
+			})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"errors":[]}`))
+		}
+	}))
+	defer stub.Close()
+
+	vault, err := secrets.NewVaultProvider(secrets.VaultConfig{Address: stub.URL, Mount: "kv", Token: "root-token"})
+	if err != nil {
+		t.Fatalf("NewVaultProvider failed: %v", err)
+	}
+	r := secrets.NewResolver()
+	r.Register("vault", vault)
+	ctx := context.Background()
+
+	if got, err := r.Resolve(ctx, "secret://vault/ci/github#token"); err != nil || got != "ghp-from-vault" {
+		t.Errorf("field lookup = %q, %v", got, err)
+	}
+	if got, err := r.Resolve(ctx, "secret://vault/ci/single"); err != nil || got != "only-one" {
+		t.Errorf("single-field lookup = %q, %v", got, err)
+	}
+	if _, err := r.Resolve(ctx, "secret://vault/ci/github"); err == nil || strings.Contains(err.Error(), "ghp-from-vault") {
+		t.Errorf("expected ambiguous field error without the value, got %v", err)
+	}
+	if _, err := r.Resolve(ctx, "secret://vault/ci/missing#token"); err == nil {
+		t.Error("expected missing secret to fail")
+	}
+
+	denied, _ := secrets.NewVaultProvider(secrets.VaultConfig{Address: stub.URL, Mount: "kv", Token: "wrong"})
+	if _, err := denied.Resolve(ctx, "ci/github#token"); err == nil {
+		t.Error("expected a wrong token to be denied")
+	}
+}
@@ -0,0 +1,260 @@
+package secrets
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/SecDuckOps/agent/internal/domain/security"
+	"github.com/SecDuckOps/shared/types"
+)
+
+// compiledPattern is a SecretPattern ready for matching.
+type compiledPattern struct {
+	security.SecretPattern
+	re         *regexp.Regexp
+	minEntropy float64
+	confidence float64
+}
+
+// generic reports whether the pattern matches on key names rather than on
+// the shape of the secret itself.
+func (p compiledPattern) generic() bool {
+	return p.minEntropy > 0
+}
+
+// sessionTable remembers which placeholder each secret got in a session, so
+// the same value is always replaced by the same placeholder.
+type sessionTable struct {
+	byValue  map[string]string // real value → placeholder
+	counters map[string]int    // prefix → last number handed out
+}
+
+// Scanner implements ports.SecretScannerPort with regular expressions.
+// Placeholders look like [[AWS_ACCESS_KEY_1]] and are stable per session.
+// Safe for concurrent use.
+type Scanner struct {
+	patterns  []compiledPattern
+	detection Detection
+
+	mu       sync.Mutex
+	sessions map[string]*sessionTable
+}
+
+// New creates a Scanner with the built-in patterns plus any extra ones.
+// An invalid extra pattern is reported by name.
+func New(extra []security.SecretPattern) (*Scanner, error) {
+	s := &Scanner{
+		detection: Detection{Mode: DetectionRegex, Threshold: DefaultThreshold},
+		sessions:  make(map[string]*sessionTable),
+	}
+
+	for _, p := range builtinPatterns {
+		confidence := p.confidence
+		if confidence == 0 {
+			confidence = patternConfidence
+		}
+		s.patterns = append(s.patterns, compiledPattern{
+			SecretPattern: p.SecretPattern,
+			re:            regexp.MustCompile(p.Pattern),
+			minEntropy:    p.minEntropy,
+			confidence:    confidence,
+		})
+	}
+
+	for i, p := range extra {
+		if p.Name == "" {
+			p.Name = fmt.Sprintf("custom pattern #%d", i+1)
+		}
+		if p.Prefix == "" {
+			p.Prefix = "SECRET"
+		}
+		p.Prefix = strings.ToUpper(p.Prefix)
+		re, err := regexp.Compile(p.Pattern)
+		if err != nil {
+			return nil, types.Wrapf(err, types.ErrCodeInvalidInput, "invalid secret pattern %q", p.Name)
+		}
+		s.patterns = append(s.patterns, compiledPattern{SecretPattern: p, re: re, confidence: customConfidence})
+	}
+
+	return s, nil
+}
+
+// SetDetection changes the detection mode, confidence threshold and
+// allowlist. It must be called before the scanner is shared.
+func (s *Scanner) SetDetection(d Detection) error {
+	d, err := validateDetection(d)
+	if err != nil {
+		return err
+	}
+	s.detection = d
+	return nil
+}
+
+// Scan returns all detected secrets at or above the confidence threshold,
+// ordered by position and without overlaps. Allowlisted values are skipped.
+// Placeholders are only assigned by Scrub.
+func (s *Scanner) Scan(text string) []security.SecretMatch {
+	var matches []security.SecretMatch
+	for _, p := range s.patterns {
+		for _, loc := range p.re.FindAllStringSubmatchIndex(text, -1) {
+			start, end := loc[0], loc[1]
+			// Replace only the first capture group when the pattern has one.
+			if len(loc) >= 4 && loc[2] >= 0 {
+				start, end = loc[2], loc[3]
+			}
+			value := text[start:end]
+			if value == "" || isPlaceholder(value) || dummyValue(value, p.generic()) {
+				continue
+			}
+			if p.minEntropy > 0 && shannonEntropy(value) < p.minEntropy {
+				continue
+			}
+			matches = append(matches, security.SecretMatch{
+				PatternName: p.Name,
+				Rule:        security.RulePattern,
+				Confidence:  p.confidence,
+				Value:       value,
+				StartIndex:  start,
+				EndIndex:    end,
+			})
+		}
+	}
+
+	if s.detection.Mode == DetectionEntropy {
+		matches = append(matches, contextMatches(text)...)
+		matches = append(matches, entropyMatches(text)...)
+	}
+
+	// Drop weak and allowlisted matches before resolving overlaps, so they
+	// cannot hide a stronger match.
+	confident := matches[:0]
+	for _, m := range matches {
+		if m.Confidence < s.detection.Threshold {
+			continue
+		}
+		if len(s.detection.Allowlist) > 0 {
+			if _, ok := s.detection.Allowlist[Fingerprint(m.Value)]; ok {
+				continue
+			}
+		}
+		confident = append(confident, m)
+	}
+	matches = confident
+
+	// Earlier matches win; at the same position the longer one does.
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].StartIndex != matches[j].StartIndex {
+			return matches[i].StartIndex < matches[j].StartIndex
+		}
+		return matches[i].EndIndex > matches[j].EndIndex
+	})
+	kept := matches[:0]
+	lastEnd := -1
+	for _, m := range matches {
+		if m.StartIndex < lastEnd {
+			continue
+		}
+		kept = append(kept, m)
+		lastEnd = m.EndIndex
+	}
+	return kept
+}
+
+// Scrub replaces every detected secret with its session placeholder. The
+// returned map holds the placeholders used in this text.
+func (s *Scanner) Scrub(sessionID string, text string) (string, security.PlaceholderMap) {
+	pm := security.PlaceholderMap{SessionID: sessionID, Mappings: make(map[string]string)}
+
+	matches := s.Scan(text)
+	if len(matches) == 0 {
+		return text, pm
+	}
+
+	prefixes := make(map[string]string, len(s.patterns))
+	for _, p := range s.patterns {
+		prefixes[p.Name] = p.Prefix
+	}
+
+	s.mu.Lock()
+	table := s.sessions[sessionID]
+	if table == nil {
+		table = &sessionTable{byValue: make(map[string]string), counters: make(map[string]int)}
+		s.sessions[sessionID] = table
+	}
+	for i, m := range matches {
+		placeholder, ok := table.byValue[m.Value]
+		if !ok {
+			prefix, ok := prefixes[m.PatternName]
+			if !ok {
+				prefix = rulePrefixes[m.Rule]
+			}
+			table.counters[prefix]++
+			placeholder = fmt.Sprintf("[[%s_%d]]", prefix, table.counters[prefix])
+			table.byValue[m.Value] = placeholder
+		}
+		matches[i].Placeholder = placeholder
+		pm.Mappings[placeholder] = m.Value
+	}
+	s.mu.Unlock()
+
+	var b strings.Builder
+	b.Grow(len(text))
+	last := 0
+	for _, m := range matches {
+		b.WriteString(text[last:m.StartIndex])
+		b.WriteString(m.Placeholder)
+		last = m.EndIndex
+	}
+	b.WriteString(text[last:])
+	return b.String(), pm
+}
+
+// Forget drops the placeholder table of a session. A later Scrub for the same
+// session starts numbering placeholders from 1 again.
+func (s *Scanner) Forget(sessionID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.sessions, sessionID)
+}
+
+// Restore replaces placeholders with the real values from pm.
+func (s *Scanner) Restore(text string, pm security.PlaceholderMap) string {
+	if len(pm.Mappings) == 0 || !strings.Contains(text, "[[") {
+		return text
+	}
+	pairs := make([]string, 0, 2*len(pm.Mappings))
+	for placeholder, value := range pm.Mappings {
+		pairs = append(pairs, placeholder, value)
+	}
+	return strings.NewReplacer(pairs...).Replace(text)
+}
+
+var placeholderRe = regexp.MustCompile(`^\[\[[A-Z0-9_]+_\d+\]\]$`)
+
+// isPlaceholder keeps already scrubbed text from being scrubbed again.
+func isPlaceholder(s string) bool {
+	return placeholderRe.MatchString(s)
+}
+
+// shannonEntropy returns the entropy of s in bits per character.
+func shannonEntropy(s string) float64 {
+	if s == "" {
+		return 0
+	}
+	counts := make(map[rune]int)
+	n := 0
+	for _, r := range s {
+		counts[r]++
+		n++
+	}
+	var h float64
+	for _, c := range counts {
+		p := float64(c) / float64(n)
+		h -= p * math.Log2(p)
+	}
+	return h
+}
@@ -0,0 +1,153 @@
+package secrets
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/SecDuckOps/shared/types"
+)
+
+// DefaultVaultMount is the KV v2 mount used when none is configured.
+const DefaultVaultMount = "secret"
+
+// VaultConfig configures a VaultProvider.
+type VaultConfig struct {
+	Address   string // e.g. https://vault.internal:8200
+	Mount     string // KV v2 mount; default DefaultVaultMount
+	Token     string
+	Namespace string // Vault Enterprise namespace, optional
+	CACert    string // PEM file to verify the server with, optional
+}
+
+// VaultProvider resolves secret://vault/<path>#<field> from a HashiCorp
+// Vault compatible KV v2 engine. The field may be omitted when the secret
+// has exactly one key.
+type VaultProvider struct {
+	address   *url.URL
+	mount     string
+	token     string
+	namespace string
+	client    *http.Client
+}
+
+// NewVaultProvider validates cfg and creates a VaultProvider.
+func NewVaultProvider(cfg VaultConfig) (*VaultProvider, error) {
+	addr, err := url.Parse(strings.TrimRight(cfg.Address, "/"))
+	if err != nil || addr.Scheme == "" || addr.Host == "" {
+		return nil, types.Newf(types.ErrCodeInvalidInput, "invalid Vault address %q", cfg.Address)
+	}
+	if cfg.Token == "" {
+		return nil, types.New(types.ErrCodeInvalidInput, "Vault token is empty")
+	}
+	mount := strings.Trim(cfg.Mount, "/")
+	if mount == "" {
+		mount = DefaultVaultMount
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if cfg.CACert != "" {
+		pem, err := os.ReadFile(cfg.CACert)
+		if err != nil {
+			return nil, types.Wrapf(err, types.ErrCodeInternal, "failed to read Vault CA certificate %s", cfg.CACert)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, types.Newf(types.ErrCodeInvalidInput, "no certificates found in %s", cfg.CACert)
+		}
+		transport.TLSClientConfig = &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}
+	}
+
+	return &VaultProvider{
+		address:   addr,
+		mount:     mount,
+		token:     cfg.Token,
+		namespace: cfg.Namespace,
+		client:    &http.Client{Transport: transport, Timeout: 10 * time.Second},
+	}, nil
+}
+
+// vaultKVResponse is the part of a KV v2 read response we use.
+type vaultKVResponse struct {
+	Data struct {
+		Data map[string]interface{} `json:"data"`
+	} `json:"data"`
+	Errors []string `json:"errors"`
+}
+
+// Resolve reads one field of a KV v2 secret.
+func (p *VaultProvider) Resolve(ctx context.Context, ref string) (string, error) {
+	path, field, _ := strings.Cut(ref, "#")
+	path = strings.Trim(path, "/")
+	if path == "" {
+		return "", types.New(types.ErrCodeInvalidInput, "Vault reference has no path")
+	}
+
+	endpoint := p.address.JoinPath("v1", p.mount, "data", path)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint.String(), nil)
+	if err != nil {
+		return "", types.Wrapf(err, types.ErrCodeInternal, "failed to build Vault request for %s", path)
+	}
+	req.Header.Set("X-Vault-Token", p.token)
+	req.Header.Set("X-Vault-Request", "true")
+	if p.namespace != "" {
+		req.Header.Set("X-Vault-Namespace", p.namespace)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return "", types.Wrapf(err, types.ErrCodeExecutionFailed, "Vault request for %s failed", path)
+	}
+	defer resp.Body.Close()
+
+	var body vaultKVResponse
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&body); err != nil && resp.StatusCode == http.StatusOK {
+		return "", types.Wrapf(err, types.ErrCodeInternal, "failed to decode Vault response for %s", path)
+	}
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return "", types.Newf(types.ErrCodeNotFound, "Vault secret %s not found", path)
+	case http.StatusForbidden, http.StatusUnauthorized:
+		return "", types.Newf(types.ErrCodePermissionDenied, "Vault denied access to %s", path)
+	default:
+		return "", types.Newf(types.ErrCodeExecutionFailed, "Vault returned %d for %s: %s", resp.StatusCode, path, strings.Join(body.Errors, "; "))
+	}
+
+	data := body.Data.Data
+	if field == "" {
+		if len(data) != 1 {
+			keys := make([]string, 0, len(data))
+			for k := range data {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+			return "", types.Newf(types.ErrCodeInvalidInput, "Vault secret %s has fields %v; add #<field> to the reference", path, keys)
+		}
+		for k := range data {
+			field = k
+		}
+	}
+
+	value, ok := data[field]
+	if !ok {
+		return "", types.Newf(types.ErrCodeNotFound, "Vault secret %s has no field %q", path, field)
+	}
+	if s, ok := value.(string); ok {
+		return s, nil
+	}
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return "", types.Wrapf(err, types.ErrCodeInternal, "failed to encode field %q of Vault secret %s", field, path)
+	}
+	return string(encoded), nil
+}
@@ -6,27 +6,48 @@ import (
 	"strings"
 	"time"
 
+	"github.com/SecDuckOps/agent/internal/application/scrub"
+	domain_security "github.com/SecDuckOps/agent/internal/domain/security"
+	agent_ports "github.com/SecDuckOps/agent/internal/ports"
 	"github.com/SecDuckOps/shared/llm/domain"
 	"github.com/SecDuckOps/shared/ports"
 )
 
+// reviewerSessionID scopes the placeholders used in reviewer prompts, so a
+// secret keeps its placeholder across Analyze and Reflect calls.
+const reviewerSessionID = "ai_reviewer"
+
 // AIReviewer implements ports.ThinkingPort.
 // It uses an LLM to generate a rationale or risk assessment for an OS command.
 type AIReviewer struct {
 	llmRegistry domain.LLMRegistry
 	provider    string
 	logger      ports.Logger
+	scanner     agent_ports.SecretScannerPort
 }
 
-// NewAIReviewer creates a new AI reasoning adapter.
-func NewAIReviewer(registry domain.LLMRegistry, provider string, logger ports.Logger) *AIReviewer {
+// NewAIReviewer creates a new AI reasoning adapter. Commands and their output
+// are scrubbed with scanner before being sent; a nil scanner disables that.
+func NewAIReviewer(registry domain.LLMRegistry, provider string, logger ports.Logger, scanner agent_ports.SecretScannerPort) *AIReviewer {
 	return &AIReviewer{
 		llmRegistry: registry,
 		provider:    provider,
 		logger:      logger,
+		scanner:     scanner,
 	}
 }
 
+// scrub masks secrets resolved for the current tool call back to their
+// secret:// references and replaces any other secrets with placeholders. The
+// rationale and presentation returned to the caller keep the placeholders.
+func (a *AIReviewer) scrub(ctx context.Context, prompt string) string {
+	prompt = scrub.MaskResolvedSecrets(ctx, prompt)
+	messages := []domain.Message{{Role: domain.RoleUser, Content: prompt}}
+	placeholders := domain_security.NewPlaceholderMap(reviewerSessionID)
+	scrub.Messages(a.scanner, &placeholders, messages)
+	return messages[0].Content
+}
+
 func (a *AIReviewer) Analyze(ctx context.Context, command string, args []string) (domain.GenerationResult, error) {
 	llm := a.llmRegistry.Get(a.provider)
 	if llm == nil {
@@ -45,6 +66,7 @@ Focus on:
 Command: %s %v
 
 Response format: Just the rationale text, no prefix like "Rationale:".`, command, args)
+	prompt = a.scrub(ctx, prompt)
 
 	var result domain.GenerationResult
 	var err error
@@ -135,6 +157,7 @@ Instructions:
 - If the output is an error, explain it simply.
 
 Response format: Just the beautified presentation text, no conversational prefix.`, command, args, content)
+	prompt = a.scrub(ctx, prompt)
 
 	var result domain.GenerationResult
 	var err error
@@ -4,11 +4,13 @@ import (
 	"context"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/SecDuckOps/agent/internal/domain"
 	"github.com/SecDuckOps/agent/internal/domain/security"
 	"github.com/SecDuckOps/agent/internal/ports"
 	shared_ports "github.com/SecDuckOps/shared/ports"
+	"github.com/google/uuid"
 )
 
 // TaskWardenAdapter implements the SecurityGatePort.
@@ -17,13 +19,14 @@ import (
 type TaskWardenAdapter struct {
 	warden     ports.WardenPort
 	normalizer ports.CommandNormalizer
+	auditLog   ports.AuditLogPort // records every decision, optional
 	logger     shared_ports.Logger
 
 	blocklist []string // substrings to block, e.g. shell operators
 }
 
 // NewTaskWardenAdapter creates a new security gate.
-func NewTaskWardenAdapter(w ports.WardenPort, n ports.CommandNormalizer, l shared_ports.Logger) ports.SecurityGatePort {
+func NewTaskWardenAdapter(w ports.WardenPort, n ports.CommandNormalizer, a ports.AuditLogPort, l shared_ports.Logger) ports.SecurityGatePort {
 	// Shell metacharacters that allow command chaining or subshells.
 	blocked := []string{
 		"&&", ";", "|", "||", ">", ">>", "<", "$(", "`", "!","{}",
@@ -32,6 +35,7 @@ func NewTaskWardenAdapter(w ports.WardenPort, n ports.CommandNormalizer, l share
 	return &TaskWardenAdapter{
 		warden:     w,
 		normalizer: n,
+		auditLog:   a,
 		logger:     l,
 		blocklist:  blocked,
 	}
@@ -43,7 +47,9 @@ func (g *TaskWardenAdapter) Evaluate(ctx context.Context, task domain.OSTask) er
 	for _, arg := range task.Args {
 		for _, blocked := range g.blocklist {
 			if strings.Contains(arg, blocked) {
-				return fmt.Errorf("argument contains blocked shell operator %q", blocked)
+				err := fmt.Errorf("argument contains blocked shell operator %q", blocked)
+				g.record(ctx, task, security.PolicyDecision{}, err)
+				return err
 			}
 		}
 	}
@@ -73,6 +79,12 @@ func (g *TaskWardenAdapter) Evaluate(ctx context.Context, task domain.OSTask) er
 			return fmt.Errorf("warden policy evaluation failed: %w", err)
 		}
 
+		if !decision.Allowed && decision.RequiresApproval {
+			err := g.requestApproval(ctx, task, decision)
+			g.record(ctx, task, decision, err)
+			return err
+		}
+
 		if !decision.Allowed {
 			reason := "blocked by zero-trust policy"
 			if len(decision.Reasons) > 0 {
@@ -85,9 +97,76 @@ func (g *TaskWardenAdapter) Evaluate(ctx context.Context, task domain.OSTask) er
 				shared_ports.Field{Key: "reason", Value: reason},
 			)
 
-			return fmt.Errorf("execution denied: %s (policy_id: %s)", reason, decision.PolicyID)
+			err := fmt.Errorf("execution denied: %s (policy_id: %s)", reason, decision.PolicyID)
+			g.record(ctx, task, decision, err)
+			return err
 		}
+		g.record(ctx, task, decision, nil)
+	}
+
+	return nil
+}
+
+// record audits a gate decision: AuditPolicyAllow when the command may run,
+// AuditPolicyDeny with the reason when it may not.
+func (g *TaskWardenAdapter) record(ctx context.Context, task domain.OSTask, decision security.PolicyDecision, denial error) {
+	if g.auditLog == nil {
+		return
+	}
+	action := security.AuditPolicyAllow
+	details := map[string]interface{}{
+		"args":              task.Args,
+		"cwd":               task.Cwd,
+		"policy_id":         decision.PolicyID,
+		"requires_approval": decision.RequiresApproval,
+	}
+	if denial != nil {
+		action = security.AuditPolicyDeny
+		details["reason"] = denial.Error()
+	}
+	err := g.auditLog.Record(ctx, security.AuditEntry{
+		SessionID: ports.SessionIDFromContext(ctx),
+		Action:    action,
+		Actor:     "warden",
+		Target:    task.OriginalCmd,
+		Details:   details,
+		Timestamp: time.Now(),
+	})
+	if err != nil && g.logger != nil {
+		g.logger.ErrorErr(ctx, err, "Failed to record Warden decision in audit log")
+	}
+}
+
+// requestApproval pauses for the approver attached to ctx when a policy allows
+// the command only after a human confirms it. Without an approver the command
+// is denied.
+func (g *TaskWardenAdapter) requestApproval(ctx context.Context, task domain.OSTask, decision security.PolicyDecision) error {
+	summary := strings.TrimSpace(task.OriginalCmd + " " + strings.Join(task.Args, " "))
+
+	approver := ports.ApproverFromContext(ctx)
+	if approver == nil {
+		return fmt.Errorf("execution of %q requires human approval, but no approver is available (policy_id: %s)", summary, decision.PolicyID)
 	}
 
+	if g.logger != nil {
+		g.logger.Info(ctx, "Execution awaiting approval",
+			shared_ports.Field{Key: "command", Value: summary},
+			shared_ports.Field{Key: "policy_id", Value: decision.PolicyID},
+		)
+	}
+
+	approved, err := approver.RequestApproval(ctx, security.ApprovalRequest{
+		ID:       uuid.New().String(),
+		Kind:     "execution",
+		Summary:  summary,
+		PolicyID: decision.PolicyID,
+		Reasons:  decision.Reasons,
+	})
+	if err != nil {
+		return fmt.Errorf("approval for %q failed: %w", summary, err)
+	}
+	if !approved {
+		return fmt.Errorf("execution of %q rejected by reviewer (policy_id: %s)", summary, decision.PolicyID)
+	}
 	return nil
 }
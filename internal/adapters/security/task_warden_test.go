@@ -0,0 +1,60 @@
+package security_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/SecDuckOps/agent/internal/adapters/security"
+	"github.com/SecDuckOps/agent/internal/adapters/warden"
+	"github.com/SecDuckOps/agent/internal/domain"
+	domain_security "github.com/SecDuckOps/agent/internal/domain/security"
+	"github.com/SecDuckOps/agent/internal/ports"
+)
+
+func newApprovalGate(t *testing.T) ports.SecurityGatePort {
+	t.Helper()
+	w := warden.New(false, nil)
+	err := w.LoadPolicies(context.Background(), []domain_security.NetworkPolicy{{
+		ID: "tf", Name: "tf.cedar", Enabled: true, Priority: 10,
+		CedarBody: `@approval("required")
+forbid(principal, action, resource == Command::"terraform") when { context.args.contains("apply") };`,
+	}})
+	if err != nil {
+		t.Fatalf("LoadPolicies failed: %v", err)
+	}
+	return security.NewTaskWardenAdapter(w, nil, nil, nil)
+}
+
+func TestTaskWarden_RequireApproval(t *testing.T) {
+	gate := newApprovalGate(t)
+	task := domain.OSTask{OriginalCmd: "terraform", Args: []string{"apply"}}
+
+	if err := gate.Evaluate(context.Background(), task); err == nil || !strings.Contains(err.Error(), "requires human approval") {
+		t.Errorf("expected denial without an approver, got %v", err)
+	}
+
+	var asked domain_security.ApprovalRequest
+	approve := func(answer bool) context.Context {
+		return ports.WithApprover(context.Background(), ports.ApprovalFunc(
+			func(_ context.Context, req domain_security.ApprovalRequest) (bool, error) {
+				asked = req
+				return answer, nil
+			}))
+	}
+
+	if err := gate.Evaluate(approve(true), task); err != nil {
+		t.Errorf("expected approved execution to pass, got %v", err)
+	}
+	if asked.PolicyID != "tf" || asked.Summary != "terraform apply" || asked.ID == "" {
+		t.Errorf("unexpected approval request: %+v", asked)
+	}
+
+	if err := gate.Evaluate(approve(false), task); err == nil || !strings.Contains(err.Error(), "rejected") {
+		t.Errorf("expected rejected execution to fail, got %v", err)
+	}
+
+	if err := gate.Evaluate(approve(false), domain.OSTask{OriginalCmd: "terraform", Args: []string{"plan"}}); err != nil {
+		t.Errorf("expected unrelated command to pass without approval, got %v", err)
+	}
+}
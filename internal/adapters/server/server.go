@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 
@@ -217,7 +218,17 @@ func (s *AgentServer) handleResumeSession(w http.ResponseWriter, r *http.Request
 	writeJSON(w, http.StatusOK, map[string]string{"status": "resumed"})
 }
 
+// handleStreamEvents streams a session's events over SSE. A reconnecting
+// client gets the events after its Last-Event-ID header (or ?since=) first;
+// events that are no longer available arrive as one "gap" event.
 func (s *AgentServer) handleStreamEvents(w http.ResponseWriter, r *http.Request, sessionID string) {
+	var since uint64
+	if lastID := r.Header.Get("Last-Event-ID"); lastID != "" {
+		since, _ = strconv.ParseUint(lastID, 10, 64)
+	} else if q := r.URL.Query().Get("since"); q != "" {
+		since, _ = strconv.ParseUint(q, 10, 64)
+	}
+
 	subID, events, err := s.sessions.StreamEvents(sessionID)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusNotFound)
@@ -225,8 +236,8 @@ func (s *AgentServer) handleStreamEvents(w http.ResponseWriter, r *http.Request,
 	}
 	defer s.sessions.UnsubscribeEvents(sessionID, subID)
 
-	// Replay buffered events first (catch-up)
-	replayed, _ := s.sessions.ReplayEvents(sessionID, 0)
+	// Replay missed events first (catch-up)
+	replayed, _ := s.sessions.ReplayEvents(sessionID, since)
 
 	w.Header().Set("Content-Type", "text/event-stream")
 	w.Header().Set("Cache-Control", "no-cache")
@@ -244,6 +255,7 @@ func (s *AgentServer) handleStreamEvents(w http.ResponseWriter, r *http.Request,
 		data, _ := json.Marshal(indexed.Event)
 		fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", indexed.SeqID, indexed.Event.Type, data)
 		flusher.Flush()
+		since = indexed.SeqID
 	}
 
 	ctx := r.Context()
@@ -262,6 +274,9 @@ func (s *AgentServer) handleStreamEvents(w http.ResponseWriter, r *http.Request,
 				flusher.Flush()
 				return
 			}
+			if indexed.SeqID <= since {
+				continue // already sent by the replay
+			}
 			data, _ := json.Marshal(indexed.Event)
 			fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", indexed.SeqID, indexed.Event.Type, data)
 			flusher.Flush()
@@ -0,0 +1,486 @@
+// Package sessionstore saves subagent sessions so they survive agent
+// restarts. FileStore is the default; the postgres subpackage stores them in
+// PostgreSQL.
+package sessionstore
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	sa "github.com/SecDuckOps/agent/internal/domain/subagent"
+	"github.com/SecDuckOps/agent/internal/ports"
+	"github.com/SecDuckOps/shared/types"
+)
+
+// File names within a session's directory. Events are kept in segments
+// named after the sequence ID of their first event.
+const (
+	sessionFile   = "session.json"
+	messagesFile  = "messages.json"
+	segmentPrefix = "events-"
+	segmentExt    = ".jsonl"
+	compactedExt  = ".jsonl.gz"
+	legacyEvents  = "events.jsonl" // single event log of earlier versions
+)
+
+// DefaultSegmentSize is the size at which an event segment is closed and
+// the next one started.
+const DefaultSegmentSize = 4 << 20
+
+// FileStore implements ports.SessionStore with one directory per session:
+//
+//	<dir>/<session-id>/session.json                 subagent state
+//	<dir>/<session-id>/messages.json                conversation
+//	<dir>/<session-id>/events-<first-seq-id>.jsonl  event log segments, one event per line
+//
+// State and conversation are replaced atomically; events are appended to the
+// newest segment. Compacting a session gzips its segments.
+type FileStore struct {
+	dir         string
+	segmentSize int64
+	writers     map[string]*segmentWriter // open event segments, by session ID
+	mu          sync.Mutex
+}
+
+// segmentWriter appends to a session's newest event segment.
+type segmentWriter struct {
+	f    *os.File
+	size int64
+}
+
+// segment is an event log segment of a session.
+type segment struct {
+	path  string
+	first uint64 // sequence ID of its first event
+	gzip  bool
+}
+
+var _ ports.SessionStore = (*FileStore)(nil)
+
+// NewFileStore creates the store in dir, creating the directory if needed.
+func NewFileStore(dir string) (*FileStore, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, types.Wrapf(err, types.ErrCodeInternal, "cannot create directory %s", dir)
+	}
+	return &FileStore{dir: dir, segmentSize: DefaultSegmentSize, writers: make(map[string]*segmentWriter)}, nil
+}
+
+// SetSegmentSize sets the size in bytes at which event segments are closed.
+// Zero or less restores DefaultSegmentSize.
+func (s *FileStore) SetSegmentSize(size int64) {
+	if size <= 0 {
+		size = DefaultSegmentSize
+	}
+	s.mu.Lock()
+	s.segmentSize = size
+	s.mu.Unlock()
+}
+
+// SaveSession writes the session's state. The event log of a session that
+// has finished is closed; it is reopened if more events arrive.
+func (s *FileStore) SaveSession(ctx context.Context, session sa.Subagent) error {
+	if err := s.writeJSON(session.SessionID, sessionFile, session); err != nil {
+		return err
+	}
+	if session.Status.IsTerminal() {
+		s.mu.Lock()
+		s.closeWriter(session.SessionID)
+		s.mu.Unlock()
+	}
+	return nil
+}
+
+// SaveMessages replaces the session's conversation.
+func (s *FileStore) SaveMessages(ctx context.Context, sessionID string, messages []ports.ChatMessage) error {
+	return s.writeJSON(sessionID, messagesFile, messages)
+}
+
+// AppendEvent adds evt to the session's newest event segment, starting a
+// new segment once it has reached the segment size. Events must be appended
+// in sequence order.
+func (s *FileStore) AppendEvent(ctx context.Context, sessionID string, evt ports.IndexedEvent) error {
+	line, err := json.Marshal(evt)
+	if err != nil {
+		return types.Wrap(err, types.ErrCodeInternal, "failed to encode session event")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	w, ok := s.writers[sessionID]
+	if ok && w.size >= s.segmentSize {
+		s.closeWriter(sessionID)
+		if w, err = s.createSegment(sessionID, evt.SeqID); err != nil {
+			return err
+		}
+	} else if !ok {
+		if w, err = s.openSegment(sessionID, evt.SeqID); err != nil {
+			return err
+		}
+	}
+
+	n, err := w.f.Write(append(line, '\n'))
+	w.size += int64(n)
+	if err != nil {
+		return types.Wrapf(err, types.ErrCodeInternal, "cannot write event log of session %s", sessionID)
+	}
+	return nil
+}
+
+// openSegment opens the session's newest segment for appending, or creates
+// one starting at firstSeqID if there is none or the newest is compacted.
+func (s *FileStore) openSegment(sessionID string, firstSeqID uint64) (*segmentWriter, error) {
+	dir, err := s.sessionDir(sessionID)
+	if err != nil {
+		return nil, err
+	}
+	segments, err := listSegments(dir)
+	if err != nil {
+		return nil, err
+	}
+	if len(segments) == 0 || segments[len(segments)-1].gzip {
+		return s.createSegment(sessionID, firstSeqID)
+	}
+
+	path := segments[len(segments)-1].path
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, types.Wrapf(err, types.ErrCodeInternal, "cannot open event log of session %s", sessionID)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, types.Wrapf(err, types.ErrCodeInternal, "cannot open event log of session %s", sessionID)
+	}
+	w := &segmentWriter{f: f, size: info.Size()}
+	s.writers[sessionID] = w
+	return w, nil
+}
+
+// createSegment starts a new segment whose first event is firstSeqID.
+func (s *FileStore) createSegment(sessionID string, firstSeqID uint64) (*segmentWriter, error) {
+	dir, err := s.sessionDir(sessionID)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, types.Wrapf(err, types.ErrCodeInternal, "cannot create directory %s", dir)
+	}
+	path := filepath.Join(dir, fmt.Sprintf("%s%020d%s", segmentPrefix, firstSeqID, segmentExt))
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, types.Wrapf(err, types.ErrCodeInternal, "cannot create event log of session %s", sessionID)
+	}
+	w := &segmentWriter{f: f}
+	s.writers[sessionID] = w
+	return w, nil
+}
+
+// closeWriter closes the session's open segment, if any. s.mu must be held.
+func (s *FileStore) closeWriter(sessionID string) {
+	if w, ok := s.writers[sessionID]; ok {
+		w.f.Close()
+		delete(s.writers, sessionID)
+	}
+}
+
+// LoadSessions reads the state of every session in the store. Directories
+// without a state file are skipped.
+func (s *FileStore) LoadSessions(ctx context.Context) ([]sa.Subagent, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, types.Wrapf(err, types.ErrCodeInternal, "cannot read directory %s", s.dir)
+	}
+
+	var sessions []sa.Subagent
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		var session sa.Subagent
+		found, err := s.readJSON(entry.Name(), sessionFile, &session)
+		if err != nil {
+			return nil, err
+		}
+		if found {
+			sessions = append(sessions, session)
+		}
+	}
+	return sessions, nil
+}
+
+// LoadMessages reads the session's conversation.
+func (s *FileStore) LoadMessages(ctx context.Context, sessionID string) ([]ports.ChatMessage, error) {
+	var messages []ports.ChatMessage
+	if _, err := s.readJSON(sessionID, messagesFile, &messages); err != nil {
+		return nil, err
+	}
+	return messages, nil
+}
+
+// LoadEvents reads the session's events after sinceSeqID. Segments that end
+// before sinceSeqID are not read. A last line cut short by a crash is
+// ignored.
+func (s *FileStore) LoadEvents(ctx context.Context, sessionID string, sinceSeqID uint64) ([]ports.IndexedEvent, error) {
+	dir, err := s.sessionDir(sessionID)
+	if err != nil {
+		return nil, err
+	}
+	segments, err := listSegments(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var events []ports.IndexedEvent
+	for i, seg := range segments {
+		if i+1 < len(segments) && segments[i+1].first <= sinceSeqID+1 {
+			continue // every event in seg is at or before sinceSeqID
+		}
+		if events, err = readSegment(seg, sinceSeqID, events); err != nil {
+			return nil, types.Wrapf(err, types.ErrCodeInternal, "cannot read event log of session %s", sessionID)
+		}
+	}
+
+	// A segment being compacted may be read twice
+	sort.SliceStable(events, func(i, j int) bool { return events[i].SeqID < events[j].SeqID })
+	events = slices.CompactFunc(events, func(a, b ports.IndexedEvent) bool { return a.SeqID == b.SeqID })
+	return events, nil
+}
+
+// CompactSession drops the session's conversation, which a finished session
+// no longer needs, and gzips its event segments.
+func (s *FileStore) CompactSession(ctx context.Context, sessionID string) error {
+	dir, err := s.sessionDir(sessionID)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closeWriter(sessionID)
+
+	if err := os.Remove(filepath.Join(dir, messagesFile)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return types.Wrapf(err, types.ErrCodeInternal, "cannot remove conversation of session %s", sessionID)
+	}
+	segments, err := listSegments(dir)
+	if err != nil {
+		return err
+	}
+	for _, seg := range segments {
+		if seg.gzip {
+			continue
+		}
+		if err := compressSegment(seg, dir); err != nil {
+			return types.Wrapf(err, types.ErrCodeInternal, "cannot compact event log of session %s", sessionID)
+		}
+	}
+	return nil
+}
+
+// DeleteSession removes the session's directory.
+func (s *FileStore) DeleteSession(ctx context.Context, sessionID string) error {
+	dir, err := s.sessionDir(sessionID)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closeWriter(sessionID)
+
+	if err := os.RemoveAll(dir); err != nil {
+		return types.Wrapf(err, types.ErrCodeInternal, "cannot delete session %s", sessionID)
+	}
+	return nil
+}
+
+// Close closes the open event segments.
+func (s *FileStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for id := range s.writers {
+		s.closeWriter(id)
+	}
+	return nil
+}
+
+// listSegments returns the event segments in dir, oldest first.
+func listSegments(dir string) ([]segment, error) {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, types.Wrapf(err, types.ErrCodeInternal, "cannot read directory %s", dir)
+	}
+
+	var segments []segment
+	for _, entry := range entries {
+		name := entry.Name()
+		if name == legacyEvents {
+			segments = append(segments, segment{path: filepath.Join(dir, name)})
+			continue
+		}
+		if !strings.HasPrefix(name, segmentPrefix) {
+			continue
+		}
+		seg := segment{path: filepath.Join(dir, name)}
+		number, ok := strings.CutSuffix(name[len(segmentPrefix):], compactedExt)
+		if ok {
+			seg.gzip = true
+		} else if number, ok = strings.CutSuffix(number, segmentExt); !ok {
+			continue
+		}
+		if seg.first, err = strconv.ParseUint(number, 10, 64); err != nil {
+			continue
+		}
+		segments = append(segments, seg)
+	}
+	sort.Slice(segments, func(i, j int) bool { return segments[i].first < segments[j].first })
+	return segments, nil
+}
+
+// readSegment appends the events of seg after sinceSeqID to events. A last
+// line that is not valid JSON was cut short and is ignored.
+func readSegment(seg segment, sinceSeqID uint64, events []ports.IndexedEvent) ([]ports.IndexedEvent, error) {
+	f, err := os.Open(seg.path)
+	if err != nil {
+		return events, err
+	}
+	defer f.Close()
+
+	var r io.Reader = f
+	if seg.gzip {
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			return events, err
+		}
+		defer gz.Close()
+		r = gz
+	}
+
+	reader := bufio.NewReader(r)
+	for {
+		line, readErr := reader.ReadBytes('\n')
+		if len(bytes.TrimSpace(line)) > 0 {
+			var evt ports.IndexedEvent
+			if err := json.Unmarshal(line, &evt); err != nil {
+				if readErr == io.EOF {
+					return events, nil
+				}
+				return events, err
+			}
+			if evt.SeqID > sinceSeqID {
+				events = append(events, evt)
+			}
+		}
+		if readErr == io.EOF {
+			return events, nil
+		}
+		if readErr != nil {
+			return events, readErr
+		}
+	}
+}
+
+// compressSegment replaces seg with a gzipped copy in dir.
+func compressSegment(seg segment, dir string) error {
+	in, err := os.Open(seg.path)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	path := filepath.Join(dir, fmt.Sprintf("%s%020d%s", segmentPrefix, seg.first, compactedExt))
+	out, err := os.OpenFile(path+".tmp", os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	gz := gzip.NewWriter(out)
+	if _, err := io.Copy(gz, in); err != nil {
+		out.Close()
+		return err
+	}
+	if err := gz.Close(); err != nil {
+		out.Close()
+		return err
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(path+".tmp", path); err != nil {
+		return err
+	}
+	return os.Remove(seg.path)
+}
+
+// sessionDir returns the directory of a session, rejecting IDs that would
+// point outside the store.
+func (s *FileStore) sessionDir(sessionID string) (string, error) {
+	if sessionID == "" || sessionID == "." || sessionID == ".." || strings.ContainsAny(sessionID, `/\`) {
+		return "", types.Newf(types.ErrCodeInvalidInput, "invalid session ID %q", sessionID)
+	}
+	return filepath.Join(s.dir, sessionID), nil
+}
+
+// writeJSON atomically replaces a file in the session's directory with v.
+// Writes are serialized so concurrent saves cannot share a temporary file.
+func (s *FileStore) writeJSON(sessionID, name string, v interface{}) error {
+	dir, err := s.sessionDir(sessionID)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return types.Wrapf(err, types.ErrCodeInternal, "cannot create directory %s", dir)
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return types.Wrapf(err, types.ErrCodeInternal, "failed to encode %s of session %s", name, sessionID)
+	}
+
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path+".tmp", data, 0600); err != nil {
+		return types.Wrapf(err, types.ErrCodeInternal, "cannot write %s", path)
+	}
+	if err := os.Rename(path+".tmp", path); err != nil {
+		return types.Wrapf(err, types.ErrCodeInternal, "cannot replace %s", path)
+	}
+	return nil
+}
+
+// readJSON decodes a file in the session's directory into v. found is false
+// when the file does not exist.
+func (s *FileStore) readJSON(sessionID, name string, v interface{}) (found bool, err error) {
+	dir, err := s.sessionDir(sessionID)
+	if err != nil {
+		return false, err
+	}
+	path := filepath.Join(dir, name)
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, types.Wrapf(err, types.ErrCodeInternal, "cannot read %s", path)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return false, types.Wrapf(err, types.ErrCodeInternal, "corrupt %s", path)
+	}
+	return true, nil
+}
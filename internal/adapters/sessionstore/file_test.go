@@ -0,0 +1,175 @@
+package sessionstore_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/SecDuckOps/agent/internal/adapters/sessionstore"
+	sa "github.com/SecDuckOps/agent/internal/domain/subagent"
+	"github.com/SecDuckOps/agent/internal/ports"
+	"github.com/SecDuckOps/shared/llm/domain"
+)
+
+func TestFileStore_SavesAndLoadsSessions(t *testing.T) {
+	dir := t.TempDir()
+	store, err := sessionstore.NewFileStore(dir)
+	if err != nil {
+		t.Fatalf("NewFileStore: %v", err)
+	}
+	ctx := context.Background()
+
+	session := sa.Subagent{SessionID: "s-1", Status: sa.StatusRunning, Step: 2, Config: sa.SessionConfig{Instructions: "scan"}}
+	messages := []ports.ChatMessage{
+		{Role: domain.RoleUser, Content: "scan"},
+		{Role: domain.RoleAssistant, ToolCalls: []ports.ToolCall{{ID: "call_1", Name: "echo", Args: map[string]interface{}{"text": "hi"}}}},
+	}
+	if err := store.SaveSession(ctx, session); err != nil {
+		t.Fatalf("SaveSession: %v", err)
+	}
+	session.Status = sa.StatusPaused
+	if err := store.SaveSession(ctx, session); err != nil {
+		t.Fatalf("SaveSession: %v", err)
+	}
+	if err := store.SaveMessages(ctx, "s-1", messages); err != nil {
+		t.Fatalf("SaveMessages: %v", err)
+	}
+	for _, seq := range []uint64{1, 3, 2} {
+		if err := store.AppendEvent(ctx, "s-1", ports.IndexedEvent{SeqID: seq, Event: sa.SubagentEvent{Type: sa.EventLog}}); err != nil {
+			t.Fatalf("AppendEvent: %v", err)
+		}
+	}
+	store.Close()
+
+	// A fresh store sees everything the first one wrote
+	store, err = sessionstore.NewFileStore(dir)
+	if err != nil {
+		t.Fatalf("NewFileStore: %v", err)
+	}
+	defer store.Close()
+
+	sessions, err := store.LoadSessions(ctx)
+	if err != nil {
+		t.Fatalf("LoadSessions: %v", err)
+	}
+	if len(sessions) != 1 || sessions[0].Status != sa.StatusPaused || sessions[0].Step != 2 || sessions[0].Config.Instructions != "scan" {
+		t.Fatalf("unexpected sessions %+v", sessions)
+	}
+
+	loaded, err := store.LoadMessages(ctx, "s-1")
+	if err != nil {
+		t.Fatalf("LoadMessages: %v", err)
+	}
+	if len(loaded) != 2 || loaded[1].ToolCalls[0].ID != "call_1" || loaded[1].ToolCalls[0].Args["text"] != "hi" {
+		t.Fatalf("unexpected messages %+v", loaded)
+	}
+
+	events, err := store.LoadEvents(ctx, "s-1", 1)
+	if err != nil {
+		t.Fatalf("LoadEvents: %v", err)
+	}
+	if len(events) != 2 || events[0].SeqID != 2 || events[1].SeqID != 3 {
+		t.Fatalf("expected events 2 and 3 in order, got %+v", events)
+	}
+}
+
+func TestFileStore_IgnoresTruncatedLastEvent(t *testing.T) {
+	dir := t.TempDir()
+	store, err := sessionstore.NewFileStore(dir)
+	if err != nil {
+		t.Fatalf("NewFileStore: %v", err)
+	}
+	defer store.Close()
+	ctx := context.Background()
+
+	if err := store.AppendEvent(ctx, "s-1", ports.IndexedEvent{SeqID: 1, Event: sa.SubagentEvent{Type: sa.EventLog}}); err != nil {
+		t.Fatalf("AppendEvent: %v", err)
+	}
+	segments, _ := filepath.Glob(filepath.Join(dir, "s-1", "events-*.jsonl"))
+	if len(segments) != 1 {
+		t.Fatalf("expected one event segment, got %v", segments)
+	}
+	f, err := os.OpenFile(segments[0], os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString(`{"seq_id": 2, "event": {"ty`)
+	f.Close()
+
+	events, err := store.LoadEvents(ctx, "s-1", 0)
+	if err != nil {
+		t.Fatalf("LoadEvents: %v", err)
+	}
+	if len(events) != 1 || events[0].SeqID != 1 {
+		t.Fatalf("expected only the complete event, got %+v", events)
+	}
+}
+
+func TestFileStore_RejectsPathsOutsideStore(t *testing.T) {
+	store, err := sessionstore.NewFileStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewFileStore: %v", err)
+	}
+	defer store.Close()
+
+	if err := store.SaveMessages(context.Background(), "../escape", nil); err == nil {
+		t.Fatal("expected an invalid session ID to be rejected")
+	}
+}
+
+func TestFileStore_RotatesAndCompactsSegments(t *testing.T) {
+	dir := t.TempDir()
+	store, err := sessionstore.NewFileStore(dir)
+	if err != nil {
+		t.Fatalf("NewFileStore: %v", err)
+	}
+	defer store.Close()
+	store.SetSegmentSize(1) // one event per segment
+	ctx := context.Background()
+
+	for seq := uint64(1); seq <= 5; seq++ {
+		if err := store.AppendEvent(ctx, "s-1", ports.IndexedEvent{SeqID: seq, Event: sa.SubagentEvent{Type: sa.EventLog}}); err != nil {
+			t.Fatalf("AppendEvent: %v", err)
+		}
+	}
+	store.SaveMessages(ctx, "s-1", []ports.ChatMessage{{Role: domain.RoleUser, Content: "scan"}})
+
+	segments, _ := filepath.Glob(filepath.Join(dir, "s-1", "events-*.jsonl"))
+	if len(segments) != 5 {
+		t.Fatalf("expected 5 segments, got %v", segments)
+	}
+	events, err := store.LoadEvents(ctx, "s-1", 3)
+	if err != nil || len(events) != 2 || events[0].SeqID != 4 {
+		t.Fatalf("expected events 4 and 5, got %+v (%v)", events, err)
+	}
+
+	if err := store.CompactSession(ctx, "s-1"); err != nil {
+		t.Fatalf("CompactSession: %v", err)
+	}
+	if plain, _ := filepath.Glob(filepath.Join(dir, "s-1", "events-*.jsonl")); len(plain) != 0 {
+		t.Fatalf("expected every segment to be compressed, got %v", plain)
+	}
+	if messages, _ := store.LoadMessages(ctx, "s-1"); messages != nil {
+		t.Fatalf("expected the conversation to be dropped, got %+v", messages)
+	}
+	events, err = store.LoadEvents(ctx, "s-1", 0)
+	if err != nil || len(events) != 5 || events[4].SeqID != 5 {
+		t.Fatalf("expected compacted events to stay readable, got %+v (%v)", events, err)
+	}
+
+	// Events after compaction start a new segment
+	if err := store.AppendEvent(ctx, "s-1", ports.IndexedEvent{SeqID: 6, Event: sa.SubagentEvent{Type: sa.EventLog}}); err != nil {
+		t.Fatalf("AppendEvent: %v", err)
+	}
+	if events, _ = store.LoadEvents(ctx, "s-1", 5); len(events) != 1 || events[0].SeqID != 6 {
+		t.Fatalf("expected event 6, got %+v", events)
+	}
+
+	if err := store.DeleteSession(ctx, "s-1"); err != nil {
+		t.Fatalf("DeleteSession: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "s-1")); !os.IsNotExist(err) {
+		t.Fatalf("expected the session directory to be removed, got %v", err)
+	}
+}
@@ -0,0 +1,260 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	sa "github.com/SecDuckOps/agent/internal/domain/subagent"
+	"github.com/SecDuckOps/agent/internal/ports"
+	"github.com/SecDuckOps/shared/types"
+
+	_ "github.com/lib/pq" // PostgreSQL driver
+)
+
+// Adapter implements ports.SessionStore using PostgreSQL.
+// Stores subagent session state, conversations and event logs.
+type Adapter struct {
+	db *sql.DB
+}
+
+var _ ports.SessionStore = (*Adapter)(nil)
+
+// Config holds connection parameters for the PostgreSQL adapter.
+type Config struct {
+	Host     string
+	Port     int
+	User     string
+	Password string
+	DBName   string
+	SSLMode  string
+}
+
+// DSN builds a PostgreSQL connection string.
+func (c Config) DSN() string {
+	sslMode := c.SSLMode
+	if sslMode == "" {
+		sslMode = "disable"
+	}
+	return fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
+		c.Host, c.Port, c.User, c.Password, c.DBName, sslMode,
+	)
+}
+
+// NewAdapter creates a new PostgreSQL adapter and verifies the connection.
+func NewAdapter(cfg Config) (*Adapter, error) {
+	db, err := sql.Open("postgres", cfg.DSN())
+	if err != nil {
+		return nil, types.Wrap(err, types.ErrCodeInternal, "postgres: failed to open connection")
+	}
+
+	// Connection pool tuning
+	db.SetMaxOpenConns(10)
+	db.SetMaxIdleConns(5)
+	db.SetConnMaxLifetime(5 * time.Minute)
+
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, types.Wrap(err, types.ErrCodeInternal, "postgres: failed to ping")
+	}
+
+	return &Adapter{db: db}, nil
+}
+
+// Migrate creates the required tables if they don't exist.
+func (a *Adapter) Migrate(ctx context.Context) error {
+	queries := []string{
+		`CREATE TABLE IF NOT EXISTS subagent_sessions (
+			session_id  TEXT PRIMARY KEY,
+			status      TEXT NOT NULL,
+			state       JSONB NOT NULL,
+			updated_at  TIMESTAMPTZ DEFAULT NOW()
+		)`,
+		`CREATE TABLE IF NOT EXISTS subagent_messages (
+			session_id  TEXT PRIMARY KEY,
+			messages    JSONB NOT NULL,
+			updated_at  TIMESTAMPTZ DEFAULT NOW()
+		)`,
+		`CREATE TABLE IF NOT EXISTS subagent_events (
+			session_id  TEXT NOT NULL,
+			seq_id      BIGINT NOT NULL,
+			event       JSONB NOT NULL,
+			PRIMARY KEY (session_id, seq_id)
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_subagent_sessions_status ON subagent_sessions(status)`,
+	}
+
+	for _, q := range queries {
+		if _, err := a.db.ExecContext(ctx, q); err != nil {
+			return types.Wrap(err, types.ErrCodeInternal, "postgres: migration failed")
+		}
+	}
+	return nil
+}
+
+// SaveSession creates or replaces the session's state.
+func (a *Adapter) SaveSession(ctx context.Context, s sa.Subagent) error {
+	stateJSON, err := json.Marshal(s)
+	if err != nil {
+		return types.Wrap(err, types.ErrCodeInternal, "postgres: encode session")
+	}
+
+	_, err = a.db.ExecContext(ctx,
+		`INSERT INTO subagent_sessions (session_id, status, state, updated_at)
+		 VALUES ($1, $2, $3, NOW())
+		 ON CONFLICT (session_id) DO UPDATE SET
+		   status = EXCLUDED.status,
+		   state = EXCLUDED.state,
+		   updated_at = EXCLUDED.updated_at`,
+		s.SessionID, s.Status, stateJSON,
+	)
+	if err != nil {
+		return types.Wrapf(err, types.ErrCodeInternal, "postgres: save session %s", s.SessionID)
+	}
+	return nil
+}
+
+// SaveMessages replaces the session's conversation.
+func (a *Adapter) SaveMessages(ctx context.Context, sessionID string, messages []ports.ChatMessage) error {
+	messagesJSON, err := json.Marshal(messages)
+	if err != nil {
+		return types.Wrap(err, types.ErrCodeInternal, "postgres: encode messages")
+	}
+
+	_, err = a.db.ExecContext(ctx,
+		`INSERT INTO subagent_messages (session_id, messages, updated_at)
+		 VALUES ($1, $2, NOW())
+		 ON CONFLICT (session_id) DO UPDATE SET
+		   messages = EXCLUDED.messages,
+		   updated_at = EXCLUDED.updated_at`,
+		sessionID, messagesJSON,
+	)
+	if err != nil {
+		return types.Wrapf(err, types.ErrCodeInternal, "postgres: save messages of session %s", sessionID)
+	}
+	return nil
+}
+
+// AppendEvent adds an event to the session's log.
+func (a *Adapter) AppendEvent(ctx context.Context, sessionID string, evt ports.IndexedEvent) error {
+	eventJSON, err := json.Marshal(evt.Event)
+	if err != nil {
+		return types.Wrap(err, types.ErrCodeInternal, "postgres: encode event")
+	}
+
+	_, err = a.db.ExecContext(ctx,
+		`INSERT INTO subagent_events (session_id, seq_id, event)
+		 VALUES ($1, $2, $3)
+		 ON CONFLICT (session_id, seq_id) DO NOTHING`,
+		sessionID, int64(evt.SeqID), eventJSON,
+	)
+	if err != nil {
+		return types.Wrapf(err, types.ErrCodeInternal, "postgres: append event to session %s", sessionID)
+	}
+	return nil
+}
+
+// LoadSessions returns every stored session.
+func (a *Adapter) LoadSessions(ctx context.Context) ([]sa.Subagent, error) {
+	rows, err := a.db.QueryContext(ctx, `SELECT state FROM subagent_sessions ORDER BY updated_at`)
+	if err != nil {
+		return nil, types.Wrap(err, types.ErrCodeInternal, "postgres: query sessions")
+	}
+	defer rows.Close()
+
+	var sessions []sa.Subagent
+	for rows.Next() {
+		var stateJSON []byte
+		if err := rows.Scan(&stateJSON); err != nil {
+			return nil, types.Wrap(err, types.ErrCodeInternal, "postgres: scan session")
+		}
+		var s sa.Subagent
+		if err := json.Unmarshal(stateJSON, &s); err != nil {
+			return nil, types.Wrap(err, types.ErrCodeInternal, "postgres: decode session")
+		}
+		sessions = append(sessions, s)
+	}
+	return sessions, rows.Err()
+}
+
+// LoadMessages returns the session's conversation, or nil if none was saved.
+func (a *Adapter) LoadMessages(ctx context.Context, sessionID string) ([]ports.ChatMessage, error) {
+	var messagesJSON []byte
+	err := a.db.QueryRowContext(ctx,
+		`SELECT messages FROM subagent_messages WHERE session_id = $1`, sessionID,
+	).Scan(&messagesJSON)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, types.Wrapf(err, types.ErrCodeInternal, "postgres: load messages of session %s", sessionID)
+	}
+
+	var messages []ports.ChatMessage
+	if err := json.Unmarshal(messagesJSON, &messages); err != nil {
+		return nil, types.Wrap(err, types.ErrCodeInternal, "postgres: decode messages")
+	}
+	return messages, nil
+}
+
+// LoadEvents returns the session's events with SeqID > sinceSeqID, in order.
+func (a *Adapter) LoadEvents(ctx context.Context, sessionID string, sinceSeqID uint64) ([]ports.IndexedEvent, error) {
+	rows, err := a.db.QueryContext(ctx,
+		`SELECT seq_id, event FROM subagent_events
+		 WHERE session_id = $1 AND seq_id > $2
+		 ORDER BY seq_id`,
+		sessionID, int64(sinceSeqID),
+	)
+	if err != nil {
+		return nil, types.Wrapf(err, types.ErrCodeInternal, "postgres: query events of session %s", sessionID)
+	}
+	defer rows.Close()
+
+	var events []ports.IndexedEvent
+	for rows.Next() {
+		var seqID int64
+		var eventJSON []byte
+		if err := rows.Scan(&seqID, &eventJSON); err != nil {
+			return nil, types.Wrap(err, types.ErrCodeInternal, "postgres: scan event")
+		}
+		evt := ports.IndexedEvent{SeqID: uint64(seqID)}
+		if err := json.Unmarshal(eventJSON, &evt.Event); err != nil {
+			return nil, types.Wrap(err, types.ErrCodeInternal, "postgres: decode event")
+		}
+		events = append(events, evt)
+	}
+	return events, rows.Err()
+}
+
+// CompactSession drops the session's conversation, which a finished session
+// no longer needs. Events stay as they are; PostgreSQL compresses large
+// JSONB values itself.
+func (a *Adapter) CompactSession(ctx context.Context, sessionID string) error {
+	if _, err := a.db.ExecContext(ctx, `DELETE FROM subagent_messages WHERE session_id = $1`, sessionID); err != nil {
+		return types.Wrapf(err, types.ErrCodeInternal, "postgres: compact session %s", sessionID)
+	}
+	return nil
+}
+
+// DeleteSession removes the session's state, conversation and events.
+func (a *Adapter) DeleteSession(ctx context.Context, sessionID string) error {
+	tx, err := a.db.BeginTx(ctx, nil)
+	if err != nil {
+		return types.Wrap(err, types.ErrCodeInternal, "postgres: begin tx")
+	}
+	defer tx.Rollback()
+
+	for _, table := range []string{"subagent_events", "subagent_messages", "subagent_sessions"} {
+		if _, err := tx.ExecContext(ctx, `DELETE FROM `+table+` WHERE session_id = $1`, sessionID); err != nil {
+			return types.Wrapf(err, types.ErrCodeInternal, "postgres: delete session %s from %s", sessionID, table)
+		}
+	}
+	return tx.Commit()
+}
+
+// Close closes the database connection.
+func (a *Adapter) Close() error {
+	return a.db.Close()
+}
@@ -10,9 +10,10 @@ import (
 // KernelBridge wraps the Kernel to satisfy both ToolExecutor and LLMProvider
 // without the subagent package importing the kernel package directly.
 type KernelBridge struct {
-	ExecuteFn    func(ctx context.Context, task domain.Task) (domain.Result, error)
-	GetSchemasFn func(allowedTools []string) []domain.ToolSchema
-	LLMRegistry  shared_domain.LLMRegistry
+	ExecuteFn      func(ctx context.Context, task domain.Task) (domain.Result, error)
+	ExecuteBatchFn func(ctx context.Context, tasks []domain.Task) ([]domain.Result, error)
+	GetSchemasFn   func(allowedTools []string) []domain.ToolSchema
+	LLMRegistry    shared_domain.LLMRegistry
 }
 
 // Execute delegates to the kernel's Execute method.
@@ -20,6 +21,31 @@ func (b *KernelBridge) Execute(ctx context.Context, task domain.Task) (domain.Re
 	return b.ExecuteFn(ctx, task)
 }
 
+// ExecuteBatch delegates to the kernel's ExecuteBatch method. Without one,
+// the tasks run one after another.
+func (b *KernelBridge) ExecuteBatch(ctx context.Context, tasks []domain.Task) ([]domain.Result, error) {
+	if b.ExecuteBatchFn != nil {
+		return b.ExecuteBatchFn(ctx, tasks)
+	}
+	results := make([]domain.Result, len(tasks))
+	var firstErr error
+	for i, task := range tasks {
+		res, err := b.ExecuteFn(ctx, task)
+		if err != nil {
+			res.TaskID = task.ID
+			res.Success = false
+			if res.Error == "" {
+				res.Error = err.Error()
+			}
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+		results[i] = res
+	}
+	return results, firstErr
+}
+
 // GetToolSchemas delegates to the kernel's GetToolSchemas method.
 func (b *KernelBridge) GetToolSchemas(allowedTools []string) []domain.ToolSchema {
 	return b.GetSchemasFn(allowedTools)
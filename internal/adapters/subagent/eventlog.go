@@ -1,6 +1,9 @@
 package subagent
 
 import (
+	"cmp"
+	"fmt"
+	"slices"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -16,13 +19,23 @@ type IndexedEvent struct {
 	Event sa.SubagentEvent `json:"event"`
 }
 
+// EventBacking keeps a session's events beyond what the ring holds. EventLog
+// appends every event to it, in sequence order and outside its own lock, and
+// replays from it what the ring no longer has.
+type EventBacking interface {
+	Append(evt IndexedEvent)
+	Load(sinceSeqID uint64) ([]IndexedEvent, error)
+}
+
 // EventLog provides durable, ordered event storage for a single session.
 //   - Monotonic event IDs per session
-//   - Ring buffer for efficient replay
+//   - Ring buffer for efficient replay, spilling over to an optional backing
+//   - Gap markers for events a replay can no longer return
 //   - Fan-out to multiple SSE subscribers
 //   - Thread-safe
 type EventLog struct {
 	sessionID string
+	backing   EventBacking // optional
 
 	// Ring buffer
 	ring     []IndexedEvent
@@ -37,6 +50,12 @@ type EventLog struct {
 
 	mu     sync.RWMutex
 	closed bool
+
+	// Events waiting to be written to the backing, in sequence order. One
+	// appender at a time drains the queue; see flush.
+	queueMu  sync.Mutex
+	queue    []IndexedEvent
+	flushing bool
 }
 
 // NewEventLog creates a new EventLog for the given session.
@@ -60,6 +79,29 @@ func NewEventLogWithSize(sessionID string, ringSize int) *EventLog {
 	return el
 }
 
+// NewBackedEventLog creates an EventLog that keeps every event in backing.
+// Events already there are loaded, and sequence IDs continue after them.
+func NewBackedEventLog(sessionID string, backing EventBacking) (*EventLog, error) {
+	events, err := backing.Load(0)
+	if err != nil {
+		return nil, err
+	}
+	el := NewEventLog(sessionID)
+	el.backing = backing
+	if len(events) > el.ringSize {
+		events = events[len(events)-el.ringSize:]
+	}
+	for _, evt := range events {
+		el.ring[el.head] = evt
+		el.head = (el.head + 1) % el.ringSize
+		el.count++
+	}
+	if len(events) > 0 {
+		el.nextSeq.Store(events[len(events)-1].SeqID + 1)
+	}
+	return el, nil
+}
+
 // Append adds an event to the log, stamps it, and broadcasts to all subscribers.
 func (el *EventLog) Append(evt sa.SubagentEvent) IndexedEvent {
 	// Stamp metadata
@@ -68,10 +110,20 @@ func (el *EventLog) Append(evt sa.SubagentEvent) IndexedEvent {
 		evt.Timestamp = time.Now()
 	}
 
+	// Sequence IDs are taken and queued under the lock so the backing gets
+	// events in order; the write itself happens after the lock is released
+	el.mu.Lock()
 	seqID := el.nextSeq.Add(1) - 1
 	indexed := IndexedEvent{SeqID: seqID, Event: evt}
 
-	el.mu.Lock()
+	// Kept even after Close, like the session's final status change
+	if el.backing != nil {
+		el.queueMu.Lock()
+		el.queue = append(el.queue, indexed)
+		el.queueMu.Unlock()
+		defer el.flush()
+	}
+
 	if el.closed {
 		el.mu.Unlock()
 		return indexed
@@ -97,14 +149,82 @@ func (el *EventLog) Append(evt sa.SubagentEvent) IndexedEvent {
 	return indexed
 }
 
+// flush writes the queued events to the backing. If another appender is
+// already writing, it picks up the new events and flush returns at once, so
+// a slow backing holds up only that appender, never the log's lock. Events
+// leave the queue only once written, so Replay always finds them in one or
+// the other.
+func (el *EventLog) flush() {
+	el.queueMu.Lock()
+	if el.flushing {
+		el.queueMu.Unlock()
+		return
+	}
+	el.flushing = true
+	for len(el.queue) > 0 {
+		batch := el.queue
+		el.queueMu.Unlock()
+
+		for _, evt := range batch {
+			el.backing.Append(evt)
+		}
+
+		el.queueMu.Lock()
+		el.queue = el.queue[len(batch):]
+	}
+	el.queue = nil
+	el.flushing = false
+	el.queueMu.Unlock()
+}
+
+// queuedBefore returns the queued events with sinceSeqID < SeqID < beforeSeqID.
+func (el *EventLog) queuedBefore(sinceSeqID, beforeSeqID uint64) []IndexedEvent {
+	el.queueMu.Lock()
+	defer el.queueMu.Unlock()
+
+	var events []IndexedEvent
+	for _, evt := range el.queue {
+		if evt.SeqID > sinceSeqID && evt.SeqID < beforeSeqID {
+			events = append(events, evt)
+		}
+	}
+	return events
+}
+
 // Replay returns all events with SeqID > sinceSeqID.
-// Pass sinceSeqID=0 to get all buffered events.
+// Pass sinceSeqID=0 to get every event. Events older than the ring are read
+// from the backing; any that cannot be returned are replaced by one
+// EventGap marker per missing range, whose SeqID is the last one missing.
 func (el *EventLog) Replay(sinceSeqID uint64) []IndexedEvent {
+	result, oldest := el.replayRing(sinceSeqID)
+
+	if oldest > sinceSeqID+1 && el.backing != nil {
+		// Queued events are read first: an event written in between is then
+		// found in the backing, and duplicates are dropped below
+		queued := el.queuedBefore(sinceSeqID, oldest)
+		if older, err := el.backing.Load(sinceSeqID); err == nil {
+			n := 0
+			for n < len(older) && older[n].SeqID < oldest {
+				n++
+			}
+			older = append(older[:n:n], queued...)
+			slices.SortFunc(older, func(a, b IndexedEvent) int { return cmp.Compare(a.SeqID, b.SeqID) })
+			older = slices.CompactFunc(older, func(a, b IndexedEvent) bool { return a.SeqID == b.SeqID })
+			result = append(older, result...)
+		}
+	}
+
+	return el.markGaps(sinceSeqID, result)
+}
+
+// replayRing returns the buffered events with SeqID > sinceSeqID and the
+// sequence ID of the oldest event the ring still holds.
+func (el *EventLog) replayRing(sinceSeqID uint64) ([]IndexedEvent, uint64) {
 	el.mu.RLock()
 	defer el.mu.RUnlock()
 
 	if el.count == 0 {
-		return nil
+		return nil, el.nextSeq.Load()
 	}
 
 	result := make([]IndexedEvent, 0, el.count)
@@ -123,9 +243,50 @@ func (el *EventLog) Replay(sinceSeqID uint64) []IndexedEvent {
 		}
 	}
 
+	return result, el.ring[start].SeqID
+}
+
+// markGaps inserts a gap marker wherever events after sinceSeqID are
+// missing from events.
+func (el *EventLog) markGaps(sinceSeqID uint64, events []IndexedEvent) []IndexedEvent {
+	var result []IndexedEvent
+	next := sinceSeqID + 1
+	for i, evt := range events {
+		if evt.SeqID > next {
+			if result == nil {
+				result = append(make([]IndexedEvent, 0, len(events)+1), events[:i]...)
+			}
+			result = append(result, el.gap(next, evt.SeqID-1))
+		}
+		if result != nil {
+			result = append(result, evt)
+		}
+		next = evt.SeqID + 1
+	}
+	if result == nil {
+		return events
+	}
 	return result
 }
 
+// gap returns the marker for the missing events from..to.
+func (el *EventLog) gap(from, to uint64) IndexedEvent {
+	message := fmt.Sprintf("Event %d is no longer available", from)
+	if to > from {
+		message = fmt.Sprintf("Events %d-%d are no longer available", from, to)
+	}
+	return IndexedEvent{
+		SeqID: to,
+		Event: sa.SubagentEvent{
+			SessionID: el.sessionID,
+			Type:      sa.EventGap,
+			Message:   message,
+			Data:      map[string]interface{}{"from_seq_id": from, "to_seq_id": to},
+			Timestamp: time.Now(),
+		},
+	}
+}
+
 // Subscribe creates a new subscription channel that receives live events.
 // The returned channel is buffered (256). Call Unsubscribe with the returned ID
 // when done (e.g., when the SSE client disconnects).
@@ -157,7 +318,9 @@ func (el *EventLog) Unsubscribe(id uint64) {
 }
 
 // Close marks the log as closed and closes all subscriber channels.
-// After Close, Append is a no-op and Subscribe returns a closed channel.
+// After Close, Append still writes events to the backing, such as the
+// session's final status change, but no longer keeps them in the ring or
+// delivers them; Subscribe returns a closed channel.
 func (el *EventLog) Close() {
 	el.mu.Lock()
 	if el.closed {
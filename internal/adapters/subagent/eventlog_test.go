@@ -55,13 +55,76 @@ func TestEventLog_RingBufferOverflow(t *testing.T) {
 		t.Fatalf("expected len 4 (ring size), got %d", el.Len())
 	}
 
-	// Should only have events 3,4,5,6
+	// Should only have events 3,4,5,6, after a marker for the dropped 1,2
 	all := el.Replay(0)
-	if len(all) != 4 {
-		t.Fatalf("expected 4 events, got %d", len(all))
+	if len(all) != 5 {
+		t.Fatalf("expected a gap marker and 4 events, got %d", len(all))
 	}
-	if all[0].SeqID != 3 || all[3].SeqID != 6 {
-		t.Fatalf("expected first=3, last=6, got first=%d, last=%d", all[0].SeqID, all[3].SeqID)
+	if all[0].Event.Type != sa.EventGap || all[0].SeqID != 2 {
+		t.Fatalf("expected a gap marker up to 2, got %+v", all[0])
+	}
+	if all[1].SeqID != 3 || all[4].SeqID != 6 {
+		t.Fatalf("expected first=3, last=6, got first=%d, last=%d", all[1].SeqID, all[4].SeqID)
+	}
+}
+
+// memoryBacking is an EventBacking that can lose events.
+type memoryBacking struct {
+	mu     sync.Mutex
+	events []IndexedEvent
+}
+
+func (b *memoryBacking) Append(evt IndexedEvent) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.events = append(b.events, evt)
+}
+
+func (b *memoryBacking) Load(sinceSeqID uint64) ([]IndexedEvent, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	var result []IndexedEvent
+	for _, evt := range b.events {
+		if evt.SeqID > sinceSeqID {
+			result = append(result, evt)
+		}
+	}
+	return result, nil
+}
+
+func TestEventLog_ReplaysOlderEventsFromBacking(t *testing.T) {
+	backing := &memoryBacking{}
+	el, err := NewBackedEventLog("test-session", backing)
+	if err != nil {
+		t.Fatalf("NewBackedEventLog: %v", err)
+	}
+	el.ringSize = 4
+	el.ring = el.ring[:4]
+
+	for i := 0; i < 10; i++ {
+		el.Append(sa.SubagentEvent{Type: sa.EventLog, Message: "msg"})
+	}
+
+	since := el.Replay(2)
+	if len(since) != 8 || since[0].SeqID != 3 || since[7].SeqID != 10 {
+		t.Fatalf("expected events 3..10, got %d events", len(since))
+	}
+
+	// Events lost from the backing are reported as a gap
+	backing.events = append(backing.events[:2:2], backing.events[5:]...)
+	since = el.Replay(0)
+	if len(since) != 8 || since[2].Event.Type != sa.EventGap || since[2].SeqID != 5 || since[3].SeqID != 6 {
+		t.Fatalf("expected events 1,2, a gap for 3-5 and events 6..10, got %+v", since)
+	}
+
+	// A new log on the same backing continues the sequence
+	el.Close()
+	reopened, err := NewBackedEventLog("test-session", backing)
+	if err != nil {
+		t.Fatalf("NewBackedEventLog: %v", err)
+	}
+	if next := reopened.Append(sa.SubagentEvent{Type: sa.EventLog}); next.SeqID != 11 {
+		t.Fatalf("expected the sequence to continue at 11, got %d", next.SeqID)
 	}
 }
 
@@ -142,3 +205,66 @@ func TestEventLog_ConcurrentAppend(t *testing.T) {
 		t.Fatalf("expected 100 events, got lastSeqID=%d", el.LastSeqID())
 	}
 }
+
+// blockingBacking holds up Append until released.
+type blockingBacking struct {
+	memoryBacking
+	entered chan struct{}
+	release chan struct{}
+}
+
+func (b *blockingBacking) Append(evt IndexedEvent) {
+	if evt.SeqID == 1 {
+		close(b.entered)
+		<-b.release
+	}
+	b.memoryBacking.Append(evt)
+}
+
+func TestEventLog_SlowBackingDoesNotBlockAppend(t *testing.T) {
+	backing := &blockingBacking{entered: make(chan struct{}), release: make(chan struct{})}
+	el, err := NewBackedEventLog("test-session", backing)
+	if err != nil {
+		t.Fatalf("NewBackedEventLog: %v", err)
+	}
+	defer el.Close()
+	el.ringSize = 2
+	el.ring = el.ring[:2]
+
+	first := make(chan struct{})
+	go func() {
+		defer close(first)
+		el.Append(sa.SubagentEvent{Type: sa.EventLog, Message: "msg"})
+	}()
+	<-backing.entered
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 4; i++ {
+			el.Append(sa.SubagentEvent{Type: sa.EventLog, Message: "msg"})
+		}
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Append blocked on a slow backing")
+	}
+
+	// Events not yet written are replayed from the queue
+	if all := el.Replay(0); len(all) != 5 || all[0].SeqID != 1 || all[4].SeqID != 5 {
+		t.Fatalf("expected events 1..5 while the backing is blocked, got %+v", all)
+	}
+
+	close(backing.release)
+	<-first
+	events, _ := backing.Load(0)
+	if len(events) != 5 {
+		t.Fatalf("expected 5 events in the backing, got %d", len(events))
+	}
+	for i, evt := range events {
+		if evt.SeqID != uint64(i+1) {
+			t.Fatalf("expected the backing to get events in order, got %+v", events)
+		}
+	}
+}
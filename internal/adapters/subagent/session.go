@@ -5,8 +5,11 @@ import (
 	_ "embed"
 	"encoding/json"
 	"fmt"
+	"slices"
 	"strings"
+	"sync"
 
+	"github.com/SecDuckOps/agent/internal/application/scrub"
 	"github.com/SecDuckOps/agent/internal/domain"
 	"github.com/SecDuckOps/agent/internal/domain/security"
 	sa "github.com/SecDuckOps/agent/internal/domain/subagent"
@@ -23,10 +26,33 @@ type LLMProvider interface {
 }
 
 // agentResponse is the structured JSON format the LLM responds with.
+// Independent calls may come together in ToolCalls.
 type agentResponse struct {
-	Type     string         `json:"type"`
-	ToolCall *agentToolCall `json:"tool_call,omitempty"`
-	Answer   string         `json:"answer,omitempty"`
+	Type      string          `json:"type"`
+	ToolCall  *agentToolCall  `json:"tool_call,omitempty"`
+	ToolCalls []agentToolCall `json:"tool_calls,omitempty"`
+	Answer    string          `json:"answer,omitempty"`
+}
+
+// calls returns every tool call in the response, or an error when a
+// tool_call response has none or one without a name.
+func (r agentResponse) calls() ([]ports.ToolCall, error) {
+	var calls []ports.ToolCall
+	if r.ToolCall != nil {
+		calls = append(calls, ports.ToolCall{Name: r.ToolCall.Name, Args: r.ToolCall.Args})
+	}
+	for _, tc := range r.ToolCalls {
+		calls = append(calls, ports.ToolCall{Name: tc.Name, Args: tc.Args})
+	}
+	if len(calls) == 0 {
+		return nil, types.New(types.ErrCodeInvalidInput, "tool_call has no tool call")
+	}
+	for _, tc := range calls {
+		if tc.Name == "" {
+			return nil, types.New(types.ErrCodeInvalidInput, "tool_call has no tool name")
+		}
+	}
+	return calls, nil
 }
 
 type agentToolCall struct {
@@ -40,6 +66,14 @@ type SessionActor struct {
 	schemaProvider ports.ToolSchemaProvider
 	secretScanner  ports.SecretScannerPort
 	session        *SubagentSession
+
+	// placeholders accumulates every secret scrubbed from this conversation.
+	// It is only used to restore tool arguments right before execution.
+	placeholders security.PlaceholderMap
+
+	// approvalMu makes tools running in parallel raise policy approvals one
+	// at a time, since the session can only show one pause.
+	approvalMu sync.Mutex
 }
 
 func NewSessionActor(executor ports.ToolExecutor, schemaProvider ports.ToolSchemaProvider, secretScanner ports.SecretScannerPort, session *SubagentSession) *SessionActor {
@@ -48,6 +82,7 @@ func NewSessionActor(executor ports.ToolExecutor, schemaProvider ports.ToolSchem
 		schemaProvider: schemaProvider,
 		secretScanner:  secretScanner,
 		session:        session,
+		placeholders:   security.NewPlaceholderMap(session.Subagent.SessionID),
 	}
 }
 
@@ -87,8 +122,10 @@ func downgradeModel(model string) string {
 	return model
 }
 
-// buildSystemPrompt creates the system prompt with 4-tuple context.
-func (a *SessionActor) buildSystemPrompt() string {
+// buildSystemPrompt creates the system prompt with 4-tuple context. With
+// native tool calling the provider receives the tools with each request;
+// otherwise the prompt lists them and describes the JSON reply protocol.
+func (a *SessionActor) buildSystemPrompt(native bool) string {
 	config := a.session.Subagent.Config
 
 	// Build base prompt
@@ -145,6 +182,12 @@ If the user message is NOT a raw command, continue normal conversational reasoni
 		prompt.WriteString("\n")
 	}
 
+	if native {
+		prompt.WriteString(`
+Call the tools you have been given to do the work; independent calls may be made together in one turn. When the task is done, reply with your complete final answer as plain text, without calling a tool.`)
+		return prompt.String()
+	}
+
 	// Available tools
 	schemas := a.schemaProvider.GetToolSchemas(config.AllowedTools)
 	toolsJSON, _ := json.MarshalIndent(schemas, "", "  ")
@@ -158,6 +201,11 @@ You MUST respond in JSON with one of two formats:
 1. To call a tool:
 {"type": "tool_call", "tool_call": {"name": "tool_name", "args": {"key": "value"}}}
 
+   To call several independent tools at once, list them; they run in parallel:
+{"type": "tool_call", "tool_calls": [{"name": "tool_a", "args": {}}, {"name": "tool_b", "args": {}}]}
+
+IMPORTANT: When calling a tool, you MUST respond with ONLY the JSON object. Do NOT include any conversational text, explanations, or markdown blocks BEFORE or AFTER the JSON.
+
 2. To provide your final answer:
 {"type": "final_answer", "answer": "Your complete response here"}
 
@@ -174,7 +222,14 @@ func (a *SessionActor) logRole(role shared_domain.MessageRole, format string, ar
 	})
 }
 
-// Run executes the full agent loop with pause-on-approval support.
+// maxMalformedReplies is how many replies in a row may look like a broken
+// tool call in the JSON protocol before the session fails.
+const maxMalformedReplies = 3
+
+// Run executes the full agent loop with pause-on-approval support. Providers
+// with native tool calling (ports.ToolCallingLLM) receive the tool schemas
+// with every request and may call several tools per turn; other providers
+// fall back to the JSON protocol described in the system prompt.
 func (a *SessionActor) Run() error {
 	ctx := a.session.Ctx
 	config := a.session.Subagent.Config
@@ -199,6 +254,13 @@ func (a *SessionActor) Run() error {
 		return types.Newf(types.ErrCodeNotFound, "LLM provider not found: %s", provider)
 	}
 
+	// ===== Native tool calling =====
+	var tools []shared_domain.ToolDefinition
+	if _, ok := llm.(ports.ToolCallingLLM); ok {
+		tools = domain.ToolDefinitions(a.schemaProvider.GetToolSchemas(config.AllowedTools))
+	}
+	native := len(tools) > 0
+
 	// ===== Model downgrade for subagents =====
 	model := config.Model
 	if model != "" {
@@ -210,8 +272,8 @@ func (a *SessionActor) Run() error {
 	}
 
 	// ===== Build conversation =====
-	systemPrompt := a.buildSystemPrompt()
-	messages := []shared_domain.Message{
+	systemPrompt := a.buildSystemPrompt(native)
+	messages := []ports.ChatMessage{
 		{Role: shared_domain.RoleSystem, Content: systemPrompt},
 		{Role: shared_domain.RoleAssistant, Content: "Understood. I am DuckOps, an expert DevSecOps agent. I will follow your instructions precisely, avoid fluff, and prioritize action."},
 		{Role: shared_domain.RoleUser, Content: config.Instructions},
@@ -228,7 +290,7 @@ func (a *SessionActor) Run() error {
 
 	a.session.Emit(sa.SubagentEvent{
 		Type:    sa.EventLog,
-		Message: fmt.Sprintf("Starting agent loop — provider: %s, max_steps: %d, pause_on_approval: %v", provider, maxSteps, config.PauseOnApproval),
+		Message: fmt.Sprintf("Starting agent loop — provider: %s, max_steps: %d, pause_on_approval: %v, native_tools: %v", provider, maxSteps, config.PauseOnApproval, native),
 	})
 	a.logRole(shared_domain.RoleSystem, "Starting background loop (provider: %s, MaxSteps: %d)", provider, maxSteps)
 
@@ -236,8 +298,24 @@ func (a *SessionActor) Run() error {
 	var lastToolName string
 	var lastToolArgsHash string
 	var identicalCallCount int
+	var malformedCount int
+
+	// ===== Restarted session =====
+	start := 0
+	a.session.mu.Lock()
+	restored, restoredPaused := a.session.restored, a.session.restoredPaused
+	step := a.session.Subagent.Step
+	a.session.restored = nil
+	a.session.mu.Unlock()
+	if len(restored) > 0 {
+		var err error
+		messages, start, err = a.resume(ctx, restored, step, restoredPaused)
+		if err != nil {
+			return err
+		}
+	}
 
-	for i := 0; i < maxSteps; i++ {
+	for i := start; i < maxSteps; i++ {
 		select {
 		case <-ctx.Done():
 			return ctx.Err()
@@ -270,218 +348,485 @@ func (a *SessionActor) Run() error {
 			}
 		}
 
-		// ===== Scrub Secrets Before LLM =====
-		promptText := "No prompt generated yet"
-		if len(messages) > 0 {
-			promptText = messages[len(messages)-1].Content
-		}
-
-		var pm security.PlaceholderMap
-		if a.secretScanner != nil {
-			var scrubbed string
-			scrubbed, pm = a.secretScanner.Scrub(a.session.Subagent.SessionID, promptText)
-			if scrubbed != promptText {
-				messages[len(messages)-1].Content = scrubbed
-				// Less noisy logging internally
-			}
-		}
+		a.checkpoint(i, messages)
 
 		// ===== Call LLM =====
-		result, err := llm.Generate(ctx, messages, nil)
+		result, err := a.generate(ctx, llm, messages, tools)
 		if err != nil {
 			return types.Wrapf(err, types.ErrCodeInternal, "LLM call failed on step %d", i+1)
 		}
 
+		// The response keeps its placeholders: it goes back into the history,
+		// events and the final result, all of which the LLM may read again.
 		response := result.Content
 
-		// ===== Restore Secrets After LLM =====
-		if a.secretScanner != nil && len(pm.Mappings) > 0 {
-			response = a.secretScanner.Restore(response, pm)
-		}
-
 		// ===== Parse response =====
-		var parsed agentResponse
-		if err := json.Unmarshal([]byte(response), &parsed); err != nil {
-			// Non-JSON = final answer
-			a.session.mu.Lock()
-			a.session.Subagent.Result = response
-			a.session.mu.Unlock()
-			a.session.Emit(sa.SubagentEvent{Type: sa.EventResult, Message: response})
-			a.logRole(shared_domain.RoleAssistant, "Final Answer: %s", response)
-			return nil
-		}
-
-		// ===== Final answer =====
-		if parsed.Type == "final_answer" {
-			a.session.mu.Lock()
-			a.session.Subagent.Result = parsed.Answer
-			a.session.mu.Unlock()
-			a.session.Emit(sa.SubagentEvent{Type: sa.EventResult, Message: parsed.Answer})
-			
-			a.session.Emit(sa.SubagentEvent{
-				Type:    sa.EventLog,
-				Message: "Lifecycle: Finished → Task execution completed naturally",
-			})
-
-			a.logRole(shared_domain.RoleAssistant, "Final Answer: %s", parsed.Answer)
-			return nil
-		}
+		var calls []ports.ToolCall
+		if native {
+			// A reply without tool calls is the final answer
+			if len(result.ToolCalls) == 0 {
+				a.finish(response)
+				return nil
+			}
+			calls = result.ToolCalls
+		} else {
+			var parsed agentResponse
+			parseErr := json.Unmarshal([]byte(stripCodeFence(response)), &parsed)
+			if parseErr == nil && parsed.Type == "tool_call" {
+				calls, parseErr = parsed.calls()
+			}
 
-		// ===== Tool call =====
-		if parsed.Type == "tool_call" && parsed.ToolCall != nil {
-			tc := parsed.ToolCall
-			
-			// Compute simple hash for arguments
-			argsBytes, _ := json.Marshal(tc.Args)
-			currentArgsHash := string(argsBytes)
-
-			// Termination Guard: Exactly repeating the last tool call
-			if tc.Name == lastToolName && currentArgsHash == lastToolArgsHash {
-				identicalCallCount++
-				
-				// First offense: Warn the LLM strongly
-				if identicalCallCount == 1 {
+			// A broken tool call is sent back for correction rather than
+			// taken as the final answer
+			if parseErr != nil && looksLikeToolCall(response) {
+				malformedCount++
+				if malformedCount > maxMalformedReplies {
 					a.session.Emit(sa.SubagentEvent{
-						Type:    sa.EventLog,
-						Message: fmt.Sprintf("Guard triggered: Recursive loop detected. Subagent repeatedly calling '%s'. Forcing termination sequence.", tc.Name),
-					})
-					
-					messages = append(messages, shared_domain.Message{
-						Role: shared_domain.RoleAssistant, Content: response,
+						Type:    sa.EventError,
+						Message: fmt.Sprintf("Subagent sent %d malformed tool calls in a row. Terminating.", malformedCount),
 					})
-					messages = append(messages, shared_domain.Message{
-						Role:    shared_domain.RoleUser,
-						Content: "SYSTEM GUARDFENCE: You have executed the exact same tool with the exact same arguments consecutively. This indicates an infinite loop. DO NOT REPEAT THIS TOOL CALL. You MUST immediately generate a 'final_answer' summarizing your findings so far, or your process will be structurally terminated.",
-					})
-					continue
+					return types.Wrapf(parseErr, types.ErrCodeExecutionFailed, "agent sent %d malformed tool calls in a row", malformedCount)
 				}
-
-				// Second offense: Kill the agent completely
 				a.session.Emit(sa.SubagentEvent{
-					Type:    sa.EventError,
-					Message: fmt.Sprintf("Fatal Guardfence: Subagent stubbornly repeated '%s' consecutive times despite warnings. Terminating.", tc.Name),
+					Type:    sa.EventLog,
+					Message: fmt.Sprintf("Malformed tool call (%d/%d): %v. Asking for a corrected reply.", malformedCount, maxMalformedReplies, parseErr),
 				})
-				return types.Newf(types.ErrCodeExecutionFailed, "agent gracefully killed: infinite loop identified on tool '%s'", tc.Name)
+				messages = append(messages,
+					ports.ChatMessage{Role: shared_domain.RoleAssistant, Content: response},
+					ports.ChatMessage{
+						Role:    shared_domain.RoleUser,
+						Content: fmt.Sprintf("SYSTEM: Your last reply could not be parsed (%v). Reply again with ONLY one valid JSON object: either a tool_call or a final_answer.", parseErr),
+					},
+				)
+				continue
+			}
+			malformedCount = 0
+
+			switch {
+			case parseErr != nil:
+				// Non-JSON = final answer
+				a.finish(response)
+				return nil
+			case parsed.Type == "final_answer":
+				a.finish(parsed.Answer)
+				return nil
+			case parsed.Type == "tool_call":
+				// calls were read above
+			default:
+				// Unknown type = final answer
+				a.finish(response)
+				return nil
 			}
+		}
 
-			// Update loop memory
-			lastToolName = tc.Name
-			lastToolArgsHash = currentArgsHash
-			identicalCallCount = 0
+		assistant := ports.ChatMessage{Role: shared_domain.RoleAssistant, Content: response}
+		if native {
+			assistant.ToolCalls = calls
+		}
 
-			tcID := fmt.Sprintf("tc_%s_%d", a.session.Subagent.SessionID[:8], i)
+		// Compute simple hash for arguments
+		names, currentArgsHash := callSignature(calls)
 
-			a.session.Emit(sa.SubagentEvent{
-				Type:    sa.EventToolCall,
-				Message: fmt.Sprintf("Tool call: %s", tc.Name),
-				Data: map[string]interface{}{
-					"id":   tcID,
-					"tool": tc.Name,
-					"args": tc.Args,
-				},
-			})
-			a.logRole(shared_domain.RoleAssistant, "Calling tool: %s", tc.Name)
+		// Termination Guard: Exactly repeating the last tool calls
+		if names == lastToolName && currentArgsHash == lastToolArgsHash {
+			identicalCallCount++
 
-			// ===== Pause-on-approval (non-sandbox mode) =====
-			if config.PauseOnApproval {
-				approved, err := a.waitForApproval(ctx, tcID, tc)
-				if err != nil {
-					return err
-				}
+			// First offense: Warn the LLM strongly
+			if identicalCallCount == 1 {
+				a.session.Emit(sa.SubagentEvent{
+					Type:    sa.EventLog,
+					Message: fmt.Sprintf("Guard triggered: Recursive loop detected. Subagent repeatedly calling '%s'. Forcing termination sequence.", names),
+				})
 
-				if !approved {
-					// Tool rejected — inform LLM
-					cleanResponse := response
-					if a.secretScanner != nil {
-						cleanResponse, _ = a.secretScanner.Scrub(a.session.Subagent.SessionID, response)
+				messages = append(messages, assistant)
+				if native {
+					// Every call needs an answer before the conversation goes on
+					for _, tc := range calls {
+						messages = append(messages, ports.ChatMessage{
+							Role: shared_domain.RoleTool, ToolCallID: tc.ID, ToolName: tc.Name,
+							Content: "Not executed: this exact call was just made.",
+						})
 					}
-					messages = append(messages, shared_domain.Message{
-						Role: shared_domain.RoleAssistant, Content: cleanResponse,
-					})
-					messages = append(messages, shared_domain.Message{
-						Role:    shared_domain.RoleUser,
-						Content: fmt.Sprintf("Tool '%s' was REJECTED by the master agent. Try a different approach.", tc.Name),
-					})
-					
-					// Rejections reset loop memory so they can retry tools safely
-					lastToolName = ""
-					continue
 				}
+				messages = append(messages, ports.ChatMessage{
+					Role:    shared_domain.RoleUser,
+					Content: fmt.Sprintf("SYSTEM GUARDFENCE: You have executed the exact same tool with the exact same arguments consecutively. This indicates an infinite loop. DO NOT REPEAT THIS TOOL CALL. You MUST immediately %s summarizing your findings so far, or your process will be structurally terminated.", finalAnswerHint(native)),
+				})
+				continue
 			}
 
-			// ===== Execute tool via Kernel =====
-			task := domain.Task{
-				ID:        tcID,
-				SessionID: a.session.Subagent.SessionID,
-				Tool:      tc.Name,
-				Args:      tc.Args,
-			}
-
+			// Second offense: Kill the agent completely
 			a.session.Emit(sa.SubagentEvent{
-				Type:    sa.EventLog,
-				Message: fmt.Sprintf("Executing tool '%s' via sandbox boundary (timeout: configured per tool)", tc.Name),
+				Type:    sa.EventError,
+				Message: fmt.Sprintf("Fatal Guardfence: Subagent stubbornly repeated '%s' consecutive times despite warnings. Terminating.", names),
 			})
+			return types.Newf(types.ErrCodeExecutionFailed, "agent gracefully killed: infinite loop identified on tool '%s'", names)
+		}
+
+		// Update loop memory
+		lastToolName = names
+		lastToolArgsHash = currentArgsHash
+		identicalCallCount = 0
 
-			result, execErr := a.executor.Execute(ctx, task)
+		// ===== Tool calls =====
+		// Saved first, so a restart knows which calls were cut off
+		messages = append(messages, assistant)
+		a.checkpoint(i, messages)
 
-			messages = append(messages, shared_domain.Message{
-				Role: shared_domain.RoleAssistant, Content: response,
+		outputs, rejected, err := a.runToolCalls(ctx, i, calls)
+		if err != nil {
+			return err
+		}
+		if rejected {
+			// Rejections reset loop memory so they can retry tools safely
+			lastToolName = ""
+		}
+
+		messages = appendResults(messages, calls, outputs, native)
+	}
+
+	return types.Newf(types.ErrCodeInternal, "agent loop exceeded maximum steps (%d)", maxSteps)
+}
+
+// appendResults answers the tool calls of the last assistant message. Every
+// result goes back in one turn: a tool message per call with native tool
+// calling, otherwise one user message.
+func appendResults(messages []ports.ChatMessage, calls []ports.ToolCall, outputs []string, native bool) []ports.ChatMessage {
+	note := fmt.Sprintf("SYSTEM_NOTE: Evaluate the output. If the task is strictly fulfilled, %s.", finalAnswerHint(native))
+	if !native {
+		return append(messages, ports.ChatMessage{
+			Role:    shared_domain.RoleUser,
+			Content: strings.Join(outputs, "\n\n") + "\n\n" + note,
+		})
+	}
+	for n, tc := range calls {
+		output := outputs[n]
+		if n == len(calls)-1 {
+			output += "\n\n" + note
+		}
+		messages = append(messages, ports.ChatMessage{
+			Role: shared_domain.RoleTool, Content: output, ToolCallID: tc.ID, ToolName: tc.Name,
+		})
+	}
+	return messages
+}
+
+// checkpoint saves the conversation and the step the loop is at, so the
+// session can continue from there after a restart. Messages are scrubbed
+// first: secrets are never written to the store, and their placeholders are
+// not kept across restarts.
+func (a *SessionActor) checkpoint(step int, messages []ports.ChatMessage) {
+	a.session.mu.Lock()
+	a.session.Subagent.Step = step
+	a.session.mu.Unlock()
+	if a.session.store == nil {
+		return
+	}
+	scrub.ChatMessages(a.secretScanner, &a.placeholders, messages)
+	a.session.save()
+	a.session.saveMessages(messages)
+}
+
+// resume continues a restarted session from its saved conversation and
+// returns it with the step to run next. Tool calls of the saved step that
+// were never answered run again when the session was paused for their
+// approval; otherwise the restart cut them off, and the model is told so.
+func (a *SessionActor) resume(ctx context.Context, messages []ports.ChatMessage, step int, paused bool) ([]ports.ChatMessage, int, error) {
+	a.session.Emit(sa.SubagentEvent{
+		Type:    sa.EventLog,
+		Message: fmt.Sprintf("Continuing saved conversation (%d messages) at step %d", len(messages), step+1),
+	})
+
+	last := messages[len(messages)-1]
+	if last.Role != shared_domain.RoleAssistant {
+		return messages, step, nil
+	}
+	native := len(last.ToolCalls) > 0
+	calls := last.ToolCalls
+	if !native {
+		var parsed agentResponse
+		if json.Unmarshal([]byte(stripCodeFence(last.Content)), &parsed) != nil || parsed.Type != "tool_call" {
+			return messages, step, nil
+		}
+		var err error
+		if calls, err = parsed.calls(); err != nil {
+			return messages, step, nil
+		}
+	}
+
+	var outputs []string
+	if paused {
+		var err error
+		if outputs, _, err = a.runToolCalls(ctx, step, calls); err != nil {
+			return nil, 0, err
+		}
+	} else {
+		outputs = make([]string, len(calls))
+		for n, tc := range calls {
+			outputs[n] = fmt.Sprintf("Tool '%s' was interrupted by an agent restart and may not have finished. Check its effects before calling it again.", tc.Name)
+		}
+	}
+	return appendResults(messages, calls, outputs, native), step + 1, nil
+}
+
+// finish records answer as the session's result.
+func (a *SessionActor) finish(answer string) {
+	a.session.mu.Lock()
+	a.session.Subagent.Result = answer
+	a.session.mu.Unlock()
+	a.session.Emit(sa.SubagentEvent{Type: sa.EventResult, Message: answer})
+
+	a.session.Emit(sa.SubagentEvent{
+		Type:    sa.EventLog,
+		Message: "Lifecycle: Finished → Task execution completed naturally",
+	})
+	a.logRole(shared_domain.RoleAssistant, "Final Answer: %s", answer)
+}
+
+// toolCallID names the n-th tool call of a step.
+func (a *SessionActor) toolCallID(step, n int) string {
+	if n == 0 {
+		return fmt.Sprintf("tc_%s_%d", a.session.Subagent.SessionID[:8], step)
+	}
+	return fmt.Sprintf("tc_%s_%d_%d", a.session.Subagent.SessionID[:8], step, n)
+}
+
+// runToolCalls approves and executes the tool calls of one step and returns
+// what the model is told about each, in call order. With pause-on-approval
+// the session pauses once for all of them; the approved calls then run
+// concurrently. rejected reports that the master agent declined any call.
+func (a *SessionActor) runToolCalls(ctx context.Context, step int, calls []ports.ToolCall) (outputs []string, rejected bool, err error) {
+	config := a.session.Subagent.Config
+	outputs = make([]string, len(calls))
+	ids := make([]string, len(calls))
+
+	var pending []sa.PendingToolCall
+	for n, tc := range calls {
+		ids[n] = a.toolCallID(step, n)
+		a.session.Emit(sa.SubagentEvent{
+			Type:    sa.EventToolCall,
+			Message: fmt.Sprintf("Tool call: %s", tc.Name),
+			Data: map[string]interface{}{
+				"id":   ids[n],
+				"tool": tc.Name,
+				"args": tc.Args,
+			},
+		})
+		a.logRole(shared_domain.RoleAssistant, "Calling tool: %s", tc.Name)
+
+		// Arguments the provider could not decode never reach the kernel
+		if tc.Invalid != "" {
+			a.session.Emit(sa.SubagentEvent{
+				Type:    sa.EventError,
+				Message: fmt.Sprintf("Tool call '%s' has invalid arguments: %s", tc.Name, tc.Invalid),
 			})
+			outputs[n] = fmt.Sprintf("Tool '%s' was not called: %s. Fix the arguments and call it again.", tc.Name, tc.Invalid)
+			continue
+		}
+		pending = append(pending, sa.PendingToolCall{ID: ids[n], Name: tc.Name, Args: tc.Args})
+	}
 
-			if execErr != nil {
-				messages = append(messages, shared_domain.Message{
-					Role:    shared_domain.RoleUser,
-					Content: fmt.Sprintf("Tool '%s' failed: %v", tc.Name, execErr),
-				})
-				a.session.Emit(sa.SubagentEvent{
-					Type:    sa.EventError,
-					Message: fmt.Sprintf("Tool '%s' failed: %v", tc.Name, execErr),
-				})
-				a.logRole(shared_domain.RoleTool, "Tool error: %v", execErr)
-			} else {
-				resultJSON, _ := json.Marshal(result.Data)
-				messages = append(messages, shared_domain.Message{
-					Role:    shared_domain.RoleUser,
-					Content: fmt.Sprintf("Tool '%s' returned: %s\n\nSYSTEM_NOTE: Evaluate the output. If the task is strictly fulfilled, emit 'final_answer'.", tc.Name, string(resultJSON)),
-				})
-				a.logRole(shared_domain.RoleTool, "Tool output received (length: %d)", len(resultJSON))
+	// ===== Pause-on-approval (non-sandbox mode) =====
+	approved := pending
+	if config.PauseOnApproval && len(pending) > 0 {
+		decision, err := a.waitForApproval(ctx, pending)
+		if err != nil {
+			return nil, false, err
+		}
+		approved = nil
+		for _, p := range pending {
+			if decision.Approves(p.ID) {
+				approved = append(approved, p)
+				continue
 			}
+			// Tool rejected — inform LLM
+			outputs[slices.Index(ids, p.ID)] = fmt.Sprintf("Tool '%s' was REJECTED by the master agent. Try a different approach.", p.Name)
+			rejected = true
+		}
+	}
+	if len(approved) == 0 {
+		return outputs, rejected, nil
+	}
+
+	// ===== Execute tools via Kernel =====
+	// Real secret values are put back only for the executor.
+	tasks := make([]domain.Task, len(approved))
+	for n, p := range approved {
+		tasks[n] = domain.Task{
+			ID:        p.ID,
+			SessionID: a.session.Subagent.SessionID,
+			Tool:      p.Name,
+			Args:      scrub.RestoreArgs(a.secretScanner, a.placeholders, p.Args),
+		}
+	}
+
+	if len(tasks) == 1 {
+		a.session.Emit(sa.SubagentEvent{
+			Type:    sa.EventLog,
+			Message: fmt.Sprintf("Executing tool '%s' via sandbox boundary (timeout: configured per tool)", tasks[0].Tool),
+		})
+	} else {
+		a.session.Emit(sa.SubagentEvent{
+			Type:    sa.EventLog,
+			Message: fmt.Sprintf("Executing %d tools concurrently via sandbox boundary (timeout: configured per tool)", len(tasks)),
+		})
+	}
+
+	// Policy approvals raised while the tools run pause this session
+	results := a.execute(ports.WithApprover(ctx, a), tasks)
+
+	for n, result := range results {
+		name := tasks[n].Tool
+		output := &outputs[slices.Index(ids, tasks[n].ID)]
+		if result.Error != "" {
+			a.session.Emit(sa.SubagentEvent{
+				Type:    sa.EventError,
+				Message: fmt.Sprintf("Tool '%s' failed: %s", name, result.Error),
+			})
+			a.logRole(shared_domain.RoleTool, "Tool error: %s", result.Error)
+			*output = fmt.Sprintf("Tool '%s' failed: %s", name, result.Error)
 			continue
 		}
 
-		// Unknown type = final answer
-		a.session.mu.Lock()
-		a.session.Subagent.Result = response
-		a.session.mu.Unlock()
-		a.session.Emit(sa.SubagentEvent{Type: sa.EventResult, Message: response})
-		return nil
+		resultJSON, _ := json.Marshal(result.Data)
+		a.logRole(shared_domain.RoleTool, "Tool output received (length: %d)", len(resultJSON))
+		*output = fmt.Sprintf("Tool '%s' returned: %s", name, string(resultJSON))
 	}
+	return outputs, rejected, nil
+}
 
-	return types.Newf(types.ErrCodeInternal, "agent loop exceeded maximum steps (%d)", maxSteps)
+// execute runs tasks, concurrently when the executor supports batches. A
+// failed task's result carries its error.
+func (a *SessionActor) execute(ctx context.Context, tasks []domain.Task) []domain.Result {
+	if batch, ok := a.executor.(ports.BatchToolExecutor); ok && len(tasks) > 1 {
+		results, _ := batch.ExecuteBatch(ctx, tasks)
+		return results
+	}
+
+	results := make([]domain.Result, len(tasks))
+	for n, task := range tasks {
+		result, err := a.executor.Execute(ctx, task)
+		if err != nil {
+			result.Success = false
+			result.Error = err.Error()
+		}
+		results[n] = result
+	}
+	return results
+}
+
+// finalAnswerHint tells the model how to finish in the protocol in use.
+func finalAnswerHint(native bool) string {
+	if native {
+		return "reply with your final answer"
+	}
+	return "emit 'final_answer'"
 }
 
-// waitForApproval pauses the session and waits for the master agent's approval.
-// Returns true if approved, false if rejected.
-func (a *SessionActor) waitForApproval(ctx context.Context, tcID string, tc *agentToolCall) (bool, error) {
+// callSignature identifies a turn's tool calls for the loop guard.
+func callSignature(calls []ports.ToolCall) (names, args string) {
+	if len(calls) == 1 {
+		data, _ := json.Marshal(calls[0].Args)
+		return calls[0].Name, string(data)
+	}
+	nameList := make([]string, len(calls))
+	argList := make([]map[string]interface{}, len(calls))
+	for i, tc := range calls {
+		nameList[i] = tc.Name
+		argList[i] = tc.Args
+	}
+	data, _ := json.Marshal(argList)
+	return strings.Join(nameList, ", "), string(data)
+}
+
+// stripCodeFence returns the body of a reply wrapped in a Markdown code
+// block, which models often do with JSON.
+func stripCodeFence(text string) string {
+	trimmed := strings.TrimSpace(text)
+	if !strings.HasPrefix(trimmed, "```") || !strings.HasSuffix(trimmed, "```") || len(trimmed) < 6 {
+		return text
+	}
+	body := strings.TrimSuffix(trimmed[3:], "```")
+	if nl := strings.IndexByte(body, '\n'); nl >= 0 && !strings.ContainsAny(body[:nl], "{[") {
+		body = body[nl+1:] // language tag
+	}
+	return body
+}
+
+// looksLikeToolCall reports whether a reply that is not a valid agentResponse
+// was meant as JSON, as opposed to a plain-text final answer.
+func looksLikeToolCall(text string) bool {
+	trimmed := strings.TrimSpace(stripCodeFence(text))
+	return strings.HasPrefix(trimmed, "{") || strings.Contains(text, `"tool_call"`)
+}
+
+// waitForApproval pauses the session until the master agent decides on the
+// pending tool calls. Each call is approved or rejected by its ID.
+func (a *SessionActor) waitForApproval(ctx context.Context, pending []sa.PendingToolCall) (sa.ResumeDecision, error) {
+	names := make([]string, len(pending))
+	ids := make([]string, len(pending))
+	for n, p := range pending {
+		names[n] = p.Name
+		ids[n] = p.ID
+	}
+
+	message := fmt.Sprintf("Tool '%s' requires approval", names[0])
+	if len(pending) > 1 {
+		message = fmt.Sprintf("%d tool calls require approval: %s", len(pending), strings.Join(names, ", "))
+	}
 	pauseInfo := &sa.PauseInfo{
-		Reason:  sa.PauseToolApproval,
-		Message: fmt.Sprintf("Tool '%s' requires approval", tc.Name),
+		Reason:           sa.PauseToolApproval,
+		Message:          message,
+		PendingToolCalls: pending,
+	}
+
+	a.session.Emit(sa.SubagentEvent{
+		Type:    sa.EventLog,
+		Message: fmt.Sprintf("⏸ Paused — waiting for approval on tool '%s' (id: %s)", strings.Join(names, "', '"), strings.Join(ids, ", ")),
+	})
+
+	return a.awaitDecision(ctx, pauseInfo)
+}
+
+// RequestApproval implements ports.ApprovalPort. Security gates call it through
+// the execution context when a Warden policy allows a request only after a
+// human confirms it; the session pauses until the request ID is approved or
+// rejected via ResumeSession (TUI, HTTP /resume or the resume tool).
+func (a *SessionActor) RequestApproval(ctx context.Context, req security.ApprovalRequest) (bool, error) {
+	a.approvalMu.Lock()
+	defer a.approvalMu.Unlock()
+
+	req.SessionID = a.session.Subagent.SessionID
+	pauseInfo := &sa.PauseInfo{
+		Reason:  sa.PausePolicyApproval,
+		Message: fmt.Sprintf("Policy '%s' requires approval for %s: %s", req.PolicyID, req.Kind, req.Summary),
 		PendingToolCalls: []sa.PendingToolCall{
-			{ID: tcID, Name: tc.Name, Args: tc.Args},
+			{ID: req.ID, Name: req.Kind, Args: map[string]interface{}{"summary": req.Summary, "policy_id": req.PolicyID}},
 		},
+		Approval: &req,
 	}
 
-	a.session.SetPauseInfo(pauseInfo)
-
 	a.session.Emit(sa.SubagentEvent{
 		Type:    sa.EventLog,
-		Message: fmt.Sprintf("⏸ Paused — waiting for approval on tool '%s' (id: %s)", tc.Name, tcID),
+		Message: fmt.Sprintf("⏸ Paused — policy '%s' requires approval for %s (id: %s)", req.PolicyID, req.Summary, req.ID),
 	})
 
+	decision, err := a.awaitDecision(ctx, pauseInfo)
+	if err != nil {
+		return false, err
+	}
+	return decision.Approves(req.ID), nil
+}
+
+// awaitDecision pauses the session with the given info and blocks until a
+// resume decision arrives.
+func (a *SessionActor) awaitDecision(ctx context.Context, pauseInfo *sa.PauseInfo) (sa.ResumeDecision, error) {
+	a.session.SetPauseInfo(pauseInfo)
+
 	// Block until we receive a resume decision or context cancellation
 	select {
 	case <-ctx.Done():
-		return false, ctx.Err()
+		return sa.ResumeDecision{}, ctx.Err()
 
 	case decision := <-a.session.ResumeChan:
 		// Clear pause info
@@ -495,28 +840,30 @@ func (a *SessionActor) waitForApproval(ctx context.Context, tcID string, tc *age
 			Message: "Resumed by master agent",
 		})
 
-		// Determine if approved
-		if decision.ApproveAll {
-			return true, nil
-		}
-		if decision.RejectAll {
-			return false, nil
-		}
+		return decision, nil
+	}
+}
 
-		// Check specific approvals
-		for _, approvedID := range decision.Approve {
-			if approvedID == tcID {
-				return true, nil
-			}
-		}
+// generate scrubs every message before calling the LLM. Scrubbed content is
+// written back into messages, so raw secrets never re-enter the history.
+// tools are offered through native tool calling; without them the provider
+// gets plain messages.
+func (a *SessionActor) generate(ctx context.Context, llm shared_domain.LLM, messages []ports.ChatMessage, tools []shared_domain.ToolDefinition) (ports.ToolGeneration, error) {
+	scrub.ChatMessages(a.secretScanner, &a.placeholders, messages)
+	if toolLLM, ok := llm.(ports.ToolCallingLLM); ok && len(tools) > 0 {
+		return toolLLM.GenerateWithTools(ctx, messages, tools)
+	}
 
-		// If not explicitly approved, it's rejected
-		return false, nil
+	plain := make([]shared_domain.Message, len(messages))
+	for i, m := range messages {
+		plain[i] = shared_domain.Message{Role: m.Role, Content: m.Content}
 	}
+	result, err := llm.Generate(ctx, plain, nil)
+	return ports.ToolGeneration{Content: result.Content, Usage: result.Usage}, err
 }
 
 // compressHistory reduces message count by summarizing old context while preserving recent state.
-func (a *SessionActor) compressHistory(ctx context.Context, llm shared_domain.LLM, messages []shared_domain.Message, reserve int) ([]shared_domain.Message, error) {
+func (a *SessionActor) compressHistory(ctx context.Context, llm shared_domain.LLM, messages []ports.ChatMessage, reserve int) ([]ports.ChatMessage, error) {
 	if len(messages) <= reserve+3 {
 		return messages, nil
 	}
@@ -524,18 +871,29 @@ func (a *SessionActor) compressHistory(ctx context.Context, llm shared_domain.LL
 	// 1. Keep the core setup (System, Ack, Instruction)
 	head := messages[0:3]
 
-	// 2. Extract context to summarize (the "middle")
+	// 2. Extract context to summarize (the "middle"). Tool results stay
+	// with the call they answer.
 	midIdx := len(messages) - reserve
+	for midIdx > 3 && messages[midIdx].Role == shared_domain.RoleTool {
+		midIdx--
+	}
 	middle := messages[3:midIdx]
 	tail := messages[midIdx:]
+	if len(middle) == 0 {
+		return messages, nil
+	}
 
 	// 3. Build summary prompt
 	var summaryText strings.Builder
 	for _, m := range middle {
 		summaryText.WriteString(fmt.Sprintf("%s: %s\n", strings.ToUpper(string(m.Role)), m.Content))
+		for _, tc := range m.ToolCalls {
+			args, _ := json.Marshal(tc.Args)
+			summaryText.WriteString(fmt.Sprintf("%s called %s(%s)\n", strings.ToUpper(string(m.Role)), tc.Name, args))
+		}
 	}
 
-	summaryMessages := []shared_domain.Message{
+	summaryMessages := []ports.ChatMessage{
 		{
 			Role:    shared_domain.RoleSystem,
 			Content: "You are a specialized summarization engine. Summarize the following agent conversation history into a concise, few-paragraph narrative that preserves all key findings, tool results, and the current operational state. Focus on 'What has been done' and 'What was found'.",
@@ -547,15 +905,15 @@ func (a *SessionActor) compressHistory(ctx context.Context, llm shared_domain.LL
 	}
 
 	// 4. Call LLM for summary
-	result, err := llm.Generate(ctx, summaryMessages, nil)
+	result, err := a.generate(ctx, llm, summaryMessages, nil)
 	if err != nil {
 		return nil, err
 	}
 
 	// 5. Reconstruct messages: [head..., summary, tail...]
-	newMessages := make([]shared_domain.Message, 0, 3+1+len(tail))
+	newMessages := make([]ports.ChatMessage, 0, 3+1+len(tail))
 	newMessages = append(newMessages, head...)
-	newMessages = append(newMessages, shared_domain.Message{
+	newMessages = append(newMessages, ports.ChatMessage{
 		Role:    shared_domain.RoleSystem,
 		Content: "=== PREVIOUS HISTORY SUMMARY ===\n" + result.Content + "\n================================",
 	})
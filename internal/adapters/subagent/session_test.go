@@ -0,0 +1,428 @@
+package subagent
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/SecDuckOps/agent/internal/adapters/sessionstore"
+	"github.com/SecDuckOps/agent/internal/domain"
+	sa "github.com/SecDuckOps/agent/internal/domain/subagent"
+	"github.com/SecDuckOps/agent/internal/ports"
+	shared_domain "github.com/SecDuckOps/shared/llm/domain"
+)
+
+// scriptedLLM replies with one scripted generation per request.
+type scriptedLLM struct {
+	mu       sync.Mutex
+	replies  []ports.ToolGeneration
+	requests [][]ports.ChatMessage
+}
+
+func (l *scriptedLLM) Name() string  { return "fake" }
+func (l *scriptedLLM) Model() string { return "fake-model" }
+
+func (l *scriptedLLM) next(messages []ports.ChatMessage) ports.ToolGeneration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.requests = append(l.requests, append([]ports.ChatMessage(nil), messages...))
+	if len(l.replies) == 0 {
+		return ports.ToolGeneration{Content: "out of script"}
+	}
+	reply := l.replies[0]
+	l.replies = l.replies[1:]
+	return reply
+}
+
+func (l *scriptedLLM) Generate(ctx context.Context, messages []shared_domain.Message, tools []shared_domain.ToolDefinition) (shared_domain.GenerationResult, error) {
+	chat := make([]ports.ChatMessage, len(messages))
+	for i, m := range messages {
+		chat[i] = ports.ChatMessage{Role: m.Role, Content: m.Content}
+	}
+	return shared_domain.GenerationResult{Content: l.next(chat).Content}, nil
+}
+
+// toolCallingLLM is a scriptedLLM with native tool calling.
+type toolCallingLLM struct {
+	*scriptedLLM
+	tools []shared_domain.ToolDefinition
+}
+
+func (l *toolCallingLLM) GenerateWithTools(ctx context.Context, messages []ports.ChatMessage, tools []shared_domain.ToolDefinition) (ports.ToolGeneration, error) {
+	l.tools = tools
+	return l.next(messages), nil
+}
+
+// fakeKernel echoes the arguments of every task back as its result.
+type fakeKernel struct {
+	llm     shared_domain.LLM
+	mu      sync.Mutex
+	tasks   []domain.Task
+	batches [][]string // task IDs of each ExecuteBatch call
+}
+
+func (k *fakeKernel) Execute(ctx context.Context, task domain.Task) (domain.Result, error) {
+	k.mu.Lock()
+	k.tasks = append(k.tasks, task)
+	k.mu.Unlock()
+	return domain.Result{TaskID: task.ID, Success: true, Data: map[string]interface{}{"echo": task.Args["text"]}}, nil
+}
+
+func (k *fakeKernel) ExecuteBatch(ctx context.Context, tasks []domain.Task) ([]domain.Result, error) {
+	ids := make([]string, len(tasks))
+	results := make([]domain.Result, len(tasks))
+	for i, task := range tasks {
+		ids[i] = task.ID
+		results[i], _ = k.Execute(ctx, task)
+	}
+	k.mu.Lock()
+	k.batches = append(k.batches, ids)
+	k.mu.Unlock()
+	return results, nil
+}
+
+func (k *fakeKernel) GetToolSchemas(allowedTools []string) []domain.ToolSchema {
+	return []domain.ToolSchema{{
+		Name:        "echo",
+		Description: "Echoes text",
+		Parameters: &domain.JSONSchema{
+			Type:       "object",
+			Properties: map[string]*domain.JSONSchema{"text": {Type: "string"}},
+			Required:   []string{"text"},
+		},
+	}}
+}
+
+func (k *fakeKernel) Get(name string) shared_domain.LLM { return k.llm }
+func (k *fakeKernel) List() []string                    { return []string{"fake"} }
+
+// runSession spawns a sandboxed session and waits for it to finish.
+func runSession(t *testing.T, kernel *fakeKernel) sa.Subagent {
+	t.Helper()
+	tracker, id := spawnSession(t, kernel, false)
+	return waitForStatus(t, tracker, id, sa.StatusCompleted, sa.StatusFailed)
+}
+
+func spawnSession(t *testing.T, kernel *fakeKernel, pauseOnApproval bool) (*Tracker, string) {
+	t.Helper()
+	return spawnStoredSession(t, kernel, nil, pauseOnApproval)
+}
+
+// spawnStoredSession spawns a session that is saved in store, if not nil.
+func spawnStoredSession(t *testing.T, kernel *fakeKernel, store ports.SessionStore, pauseOnApproval bool) (*Tracker, string) {
+	t.Helper()
+	tracker := NewTracker(kernel, kernel, nil, nil)
+	tracker.SetStore(store)
+	id, err := tracker.SpawnSubagent("", sa.SessionConfig{
+		Instructions:    "echo twice",
+		Sandbox:         !pauseOnApproval,
+		PauseOnApproval: pauseOnApproval,
+		Retry:           sa.RetryPolicy{MaxRetries: -1},
+	})
+	if err != nil {
+		t.Fatalf("SpawnSubagent: %v", err)
+	}
+	return tracker, id
+}
+
+// waitForStatus waits until the session reaches one of statuses.
+func waitForStatus(t *testing.T, tracker *Tracker, id string, statuses ...sa.SubagentStatus) sa.Subagent {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		view, err := tracker.GetSession(id)
+		if err != nil {
+			t.Fatalf("GetSession: %v", err)
+		}
+		for _, s := range statuses {
+			if view.Subagent.Status == s {
+				return view.Subagent
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("session did not reach %v", statuses)
+	return sa.Subagent{}
+}
+
+func TestSessionActor_NativeToolCalls(t *testing.T) {
+	llm := &toolCallingLLM{scriptedLLM: &scriptedLLM{replies: []ports.ToolGeneration{
+		{ToolCalls: []ports.ToolCall{
+			{ID: "call_a", Name: "echo", Args: map[string]interface{}{"text": "one"}},
+			{ID: "call_b", Name: "echo", Args: map[string]interface{}{"text": "two"}},
+		}},
+		{Content: "echoed one and two"},
+	}}}
+	kernel := &fakeKernel{llm: llm}
+
+	result := runSession(t, kernel)
+	if result.Status != sa.StatusCompleted || result.Result != "echoed one and two" {
+		t.Fatalf("got status %s, result %q, error %q", result.Status, result.Result, result.Error)
+	}
+	if len(kernel.batches) != 1 || len(kernel.batches[0]) != 2 {
+		t.Fatalf("expected both calls to run in one batch, got %v", kernel.batches)
+	}
+	if len(llm.tools) != 1 || llm.tools[0].Name != "echo" || llm.tools[0].Parameters["type"] != "object" {
+		t.Fatalf("expected the echo schema to be offered, got %+v", llm.tools)
+	}
+
+	// The second request carries the calls and one result per call
+	second := llm.requests[1]
+	tail := second[len(second)-3:]
+	if len(tail[0].ToolCalls) != 2 {
+		t.Fatalf("expected the assistant turn with both calls, got %+v", tail[0])
+	}
+	for i, id := range []string{"call_a", "call_b"} {
+		if tail[i+1].Role != shared_domain.RoleTool || tail[i+1].ToolCallID != id {
+			t.Fatalf("expected a tool result for %s, got %+v", id, tail[i+1])
+		}
+	}
+	if !strings.Contains(tail[2].Content, "two") {
+		t.Fatalf("expected the second result to echo 'two', got %q", tail[2].Content)
+	}
+	if strings.Contains(second[0].Content, `"type": "tool_call"`) {
+		t.Fatal("the native system prompt should not describe the JSON protocol")
+	}
+}
+
+func TestSessionActor_JSONFallbackRetriesMalformedCall(t *testing.T) {
+	llm := &scriptedLLM{replies: []ports.ToolGeneration{
+		{Content: `{"type": "tool_call", "tool_call": {"name": "echo", "args": {"text": "one"}`},
+		{Content: "```json\n{\"type\": \"tool_call\", \"tool_call\": {\"name\": \"echo\", \"args\": {\"text\": \"one\"}}}\n```"},
+		{Content: `{"type": "final_answer", "answer": "done"}`},
+	}}
+	kernel := &fakeKernel{llm: llm}
+
+	result := runSession(t, kernel)
+	if result.Status != sa.StatusCompleted || result.Result != "done" {
+		t.Fatalf("got status %s, result %q, error %q", result.Status, result.Result, result.Error)
+	}
+	if len(kernel.tasks) != 1 || kernel.tasks[0].Args["text"] != "one" {
+		t.Fatalf("expected the corrected call to run once, got %+v", kernel.tasks)
+	}
+	correction := llm.requests[1][len(llm.requests[1])-1]
+	if !strings.Contains(correction.Content, "could not be parsed") {
+		t.Fatalf("expected a correction request, got %q", correction.Content)
+	}
+}
+
+func TestSessionActor_JSONFallbackGivesUpOnRepeatedMalformedCalls(t *testing.T) {
+	broken := ports.ToolGeneration{Content: `{"type": "tool_call"}`}
+	llm := &scriptedLLM{replies: []ports.ToolGeneration{broken, broken, broken, broken}}
+	kernel := &fakeKernel{llm: llm}
+
+	result := runSession(t, kernel)
+	if result.Status != sa.StatusFailed || !strings.Contains(result.Error, "malformed tool calls") {
+		t.Fatalf("got status %s, error %q", result.Status, result.Error)
+	}
+	if len(kernel.tasks) != 0 {
+		t.Fatalf("expected no tool to run, got %+v", kernel.tasks)
+	}
+}
+
+func TestSessionActor_ApprovesParallelCallsIndividually(t *testing.T) {
+	llm := &toolCallingLLM{scriptedLLM: &scriptedLLM{replies: []ports.ToolGeneration{
+		{ToolCalls: []ports.ToolCall{
+			{ID: "call_a", Name: "echo", Args: map[string]interface{}{"text": "one"}},
+			{ID: "call_b", Name: "echo", Args: map[string]interface{}{"text": "two"}},
+			{ID: "call_c", Name: "echo", Args: map[string]interface{}{"text": "three"}},
+		}},
+		{Content: "done"},
+	}}}
+	kernel := &fakeKernel{llm: llm}
+	tracker, id := spawnSession(t, kernel, true)
+
+	// One pause lists every call of the step
+	paused := waitForStatus(t, tracker, id, sa.StatusPaused)
+	pending := paused.PauseInfo.PendingToolCalls
+	if len(pending) != 3 {
+		t.Fatalf("expected 3 pending calls, got %+v", pending)
+	}
+	if err := tracker.ResumeSession(id, sa.ResumeDecision{
+		Approve: []string{pending[0].ID, pending[2].ID},
+		Reject:  []string{pending[1].ID},
+	}); err != nil {
+		t.Fatalf("ResumeSession: %v", err)
+	}
+
+	result := waitForStatus(t, tracker, id, sa.StatusCompleted, sa.StatusFailed)
+	if result.Status != sa.StatusCompleted {
+		t.Fatalf("got status %s, error %q", result.Status, result.Error)
+	}
+	if len(kernel.batches) != 1 || len(kernel.batches[0]) != 2 || kernel.batches[0][0] != pending[0].ID || kernel.batches[0][1] != pending[2].ID {
+		t.Fatalf("expected the approved calls to run together, got %v", kernel.batches)
+	}
+
+	second := llm.requests[1]
+	results := second[len(second)-3:]
+	if !strings.Contains(results[0].Content, "one") || !strings.Contains(results[1].Content, "REJECTED") || !strings.Contains(results[2].Content, "three") {
+		t.Fatalf("expected results in call order, got %+v", results)
+	}
+}
+
+func TestSessionActor_JSONProtocolParallelCalls(t *testing.T) {
+	llm := &scriptedLLM{replies: []ports.ToolGeneration{
+		{Content: `{"type": "tool_call", "tool_calls": [{"name": "echo", "args": {"text": "one"}}, {"name": "echo", "args": {"text": "two"}}]}`},
+		{Content: `{"type": "final_answer", "answer": "done"}`},
+	}}
+	kernel := &fakeKernel{llm: llm}
+
+	result := runSession(t, kernel)
+	if result.Status != sa.StatusCompleted || result.Result != "done" {
+		t.Fatalf("got status %s, result %q, error %q", result.Status, result.Result, result.Error)
+	}
+	if len(kernel.batches) != 1 || len(kernel.batches[0]) != 2 {
+		t.Fatalf("expected both calls to run in one batch, got %v", kernel.batches)
+	}
+
+	// Both results come back in one message
+	last := llm.requests[1][len(llm.requests[1])-1]
+	if last.Role != shared_domain.RoleUser || !strings.Contains(last.Content, "one") || !strings.Contains(last.Content, "two") {
+		t.Fatalf("expected one message with both results, got %+v", last)
+	}
+}
+
+// restoreTracker starts a tracker on the sessions saved in dir, as the agent
+// does after a restart.
+func restoreTracker(t *testing.T, kernel *fakeKernel, dir string) *Tracker {
+	t.Helper()
+	store, err := sessionstore.NewFileStore(dir)
+	if err != nil {
+		t.Fatalf("NewFileStore: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+	tracker := NewTracker(kernel, kernel, nil, nil)
+	tracker.SetStore(store)
+	if err := tracker.Restore(context.Background()); err != nil {
+		t.Fatalf("Restore: %v", err)
+	}
+	return tracker
+}
+
+func TestTracker_RestoredPausedSessionCanBeResumed(t *testing.T) {
+	dir := t.TempDir()
+	store, err := sessionstore.NewFileStore(dir)
+	if err != nil {
+		t.Fatalf("NewFileStore: %v", err)
+	}
+	before := &fakeKernel{llm: &toolCallingLLM{scriptedLLM: &scriptedLLM{replies: []ports.ToolGeneration{
+		{ToolCalls: []ports.ToolCall{
+			{ID: "call_a", Name: "echo", Args: map[string]interface{}{"text": "one"}},
+			{ID: "call_b", Name: "echo", Args: map[string]interface{}{"text": "two"}},
+		}},
+	}}}}
+	previous, id := spawnStoredSession(t, before, store, true)
+	waitForStatus(t, previous, id, sa.StatusPaused)
+	store.Close()
+
+	// The agent restarts
+	llm := &toolCallingLLM{scriptedLLM: &scriptedLLM{replies: []ports.ToolGeneration{{Content: "done"}}}}
+	kernel := &fakeKernel{llm: llm}
+	tracker := restoreTracker(t, kernel, dir)
+
+	paused := waitForStatus(t, tracker, id, sa.StatusPaused)
+	pending := paused.PauseInfo.PendingToolCalls
+	if len(pending) != 2 {
+		t.Fatalf("expected the pending calls to be restored, got %+v", paused.PauseInfo)
+	}
+	events, _ := tracker.ReplayEvents(id, 0)
+	if len(events) == 0 || events[0].Event.Message != "status changed to running" {
+		t.Fatalf("expected the saved events to be restored, got %+v", events)
+	}
+
+	if err := tracker.ResumeSession(id, sa.ResumeDecision{Approve: []string{pending[1].ID}}); err != nil {
+		t.Fatalf("ResumeSession: %v", err)
+	}
+	result := waitForStatus(t, tracker, id, sa.StatusCompleted, sa.StatusFailed)
+	if result.Status != sa.StatusCompleted || result.Result != "done" {
+		t.Fatalf("got status %s, result %q, error %q", result.Status, result.Result, result.Error)
+	}
+	if len(kernel.tasks) != 1 || kernel.tasks[0].Args["text"] != "two" {
+		t.Fatalf("expected only the approved call to run, got %+v", kernel.tasks)
+	}
+
+	// The model continues the saved conversation
+	request := llm.requests[0]
+	tail := request[len(request)-3:]
+	if len(tail[0].ToolCalls) != 2 || !strings.Contains(tail[1].Content, "REJECTED") || !strings.Contains(tail[2].Content, "two") {
+		t.Fatalf("expected the saved calls with their results, got %+v", tail)
+	}
+}
+
+func TestTracker_InterruptedSessionRestarts(t *testing.T) {
+	dir := t.TempDir()
+	store, err := sessionstore.NewFileStore(dir)
+	if err != nil {
+		t.Fatalf("NewFileStore: %v", err)
+	}
+	ctx := context.Background()
+	id := "0123456789-interrupted"
+	store.SaveSession(ctx, sa.Subagent{
+		ID: "sub", SessionID: id, Status: sa.StatusRunning, Step: 1,
+		Config: sa.SessionConfig{Instructions: "echo", Sandbox: true, MaxSteps: 5, Retry: sa.RetryPolicy{MaxRetries: -1}},
+	})
+	store.SaveMessages(ctx, id, []ports.ChatMessage{
+		{Role: shared_domain.RoleSystem, Content: "system"},
+		{Role: shared_domain.RoleUser, Content: "echo"},
+		{Role: shared_domain.RoleAssistant, ToolCalls: []ports.ToolCall{{ID: "call_a", Name: "echo", Args: map[string]interface{}{"text": "one"}}}},
+	})
+	store.Close()
+
+	llm := &toolCallingLLM{scriptedLLM: &scriptedLLM{replies: []ports.ToolGeneration{{Content: "done"}}}}
+	kernel := &fakeKernel{llm: llm}
+	tracker := restoreTracker(t, kernel, dir)
+
+	view, err := tracker.GetSession(id)
+	if err != nil || view.Subagent.Status != sa.StatusInterrupted {
+		t.Fatalf("expected the session to be interrupted, got %+v (%v)", view.Subagent, err)
+	}
+	if err := tracker.ResumeSession(id, sa.ResumeDecision{}); err != nil {
+		t.Fatalf("ResumeSession: %v", err)
+	}
+	result := waitForStatus(t, tracker, id, sa.StatusCompleted, sa.StatusFailed)
+	if result.Status != sa.StatusCompleted || result.Result != "done" {
+		t.Fatalf("got status %s, result %q, error %q", result.Status, result.Result, result.Error)
+	}
+	if len(kernel.tasks) != 0 {
+		t.Fatalf("expected the interrupted call not to run again, got %+v", kernel.tasks)
+	}
+	last := llm.requests[0][len(llm.requests[0])-1]
+	if last.ToolCallID != "call_a" || !strings.Contains(last.Content, "interrupted by an agent restart") {
+		t.Fatalf("expected the call to be reported as interrupted, got %+v", last)
+	}
+}
+
+func TestTracker_ApplyRetentionCompactsThenDeletes(t *testing.T) {
+	store, err := sessionstore.NewFileStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewFileStore: %v", err)
+	}
+	defer store.Close()
+	kernel := &fakeKernel{llm: &scriptedLLM{replies: []ports.ToolGeneration{{Content: "done"}}}}
+	tracker, id := spawnStoredSession(t, kernel, store, false)
+	waitForStatus(t, tracker, id, sa.StatusCompleted)
+	tracker.SetRetentionPolicy(time.Hour, 48*time.Hour)
+	ctx := context.Background()
+
+	if err := tracker.ApplyRetention(ctx, time.Now().Add(2*time.Hour)); err != nil {
+		t.Fatalf("ApplyRetention: %v", err)
+	}
+	if messages, _ := store.LoadMessages(ctx, id); messages != nil {
+		t.Fatalf("expected the conversation to be compacted away, got %d messages", len(messages))
+	}
+	if events, _ := store.LoadEvents(ctx, id, 0); len(events) == 0 {
+		t.Fatal("expected the events to stay readable")
+	}
+
+	if err := tracker.ApplyRetention(ctx, time.Now().Add(72*time.Hour)); err != nil {
+		t.Fatalf("ApplyRetention: %v", err)
+	}
+	if _, err := tracker.GetSession(id); err == nil {
+		t.Fatal("expected the expired session to be gone")
+	}
+	if sessions, _ := store.LoadSessions(ctx); len(sessions) != 0 {
+		t.Fatalf("expected the expired session to be deleted, got %+v", sessions)
+	}
+}
@@ -6,6 +6,8 @@ import (
 	"sync"
 	"time"
 
+	"github.com/SecDuckOps/agent/internal/application/scrub"
+	"github.com/SecDuckOps/agent/internal/domain/security"
 	sa "github.com/SecDuckOps/agent/internal/domain/subagent"
 	"github.com/SecDuckOps/agent/internal/ports"
 	shared_ports "github.com/SecDuckOps/shared/ports"
@@ -20,7 +22,19 @@ type SubagentSession struct {
 	ResumeChan chan sa.ResumeDecision // Channel for receiving resume decisions
 	Ctx        context.Context
 	Cancel     context.CancelFunc
+	redactor   ports.RedactorPort // masks secrets in emitted events, optional
+	store      ports.SessionStore // saves state, conversation and events, optional
+	logger     shared_ports.Logger
 	mu         sync.RWMutex
+
+	// detached is set on sessions restored from the store until their loop
+	// is started again. restored then holds the conversation to continue,
+	// and restoredPaused whether its last tool calls await approval.
+	detached       bool
+	restored       []ports.ChatMessage
+	restoredPaused bool
+
+	compacted bool // the store has compacted this finished session
 }
 
 // Emit sends an event to the session's EventLog.
@@ -33,6 +47,10 @@ func (s *SubagentSession) Emit(evt sa.SubagentEvent) {
 	if evt.Timestamp.IsZero() {
 		evt.Timestamp = time.Now()
 	}
+	if s.redactor != nil {
+		evt.Message = s.redactor.Redact(evt.Message)
+		evt.Data = scrub.RedactValue(s.redactor, evt.Data)
+	}
 
 	s.Log.Append(evt)
 
@@ -60,6 +78,7 @@ func (s *SubagentSession) SetStatus(status sa.SubagentStatus) {
 		s.Subagent.RunState = sa.RunStateFailed
 	}
 	s.mu.Unlock()
+	s.save()
 
 	s.Emit(sa.SubagentEvent{
 		Type:    sa.EventStatus,
@@ -73,6 +92,7 @@ func (s *SubagentSession) SetPauseInfo(info *sa.PauseInfo) {
 	s.Subagent.PauseInfo = info
 	s.Subagent.Status = sa.StatusPaused
 	s.mu.Unlock()
+	s.save()
 
 	s.Emit(sa.SubagentEvent{
 		Type:    sa.EventPaused,
@@ -81,6 +101,55 @@ func (s *SubagentSession) SetPauseInfo(info *sa.PauseInfo) {
 	})
 }
 
+// save writes the session's state to the store, if one is set.
+func (s *SubagentSession) save() {
+	if s.store == nil {
+		return
+	}
+	s.mu.RLock()
+	snapshot := s.Subagent
+	s.mu.RUnlock()
+	s.storeFailed(s.store.SaveSession(context.Background(), snapshot), "Failed to save subagent session")
+}
+
+// saveMessages writes the session's conversation to the store, if one is set.
+func (s *SubagentSession) saveMessages(messages []ports.ChatMessage) {
+	if s.store == nil {
+		return
+	}
+	s.storeFailed(s.store.SaveMessages(context.Background(), s.Subagent.SessionID, messages), "Failed to save subagent conversation")
+}
+
+// storedEvents backs a session's EventLog with the tracker's store.
+type storedEvents struct {
+	session *SubagentSession
+}
+
+func (b storedEvents) Append(evt IndexedEvent) {
+	err := b.session.store.AppendEvent(context.Background(), b.session.Subagent.SessionID, ports.IndexedEvent{SeqID: evt.SeqID, Event: evt.Event})
+	b.session.storeFailed(err, "Failed to save subagent event")
+}
+
+func (b storedEvents) Load(sinceSeqID uint64) ([]IndexedEvent, error) {
+	stored, err := b.session.store.LoadEvents(context.Background(), b.session.Subagent.SessionID, sinceSeqID)
+	if err != nil {
+		return nil, err
+	}
+	events := make([]IndexedEvent, len(stored))
+	for i, evt := range stored {
+		events[i] = IndexedEvent{SeqID: evt.SeqID, Event: evt.Event}
+	}
+	return events, nil
+}
+
+// storeFailed logs a store error. The session keeps running: only its
+// chance of surviving a restart is lost.
+func (s *SubagentSession) storeFailed(err error, msg string) {
+	if err != nil && s.logger != nil {
+		s.logger.ErrorErr(s.Ctx, err, msg, shared_ports.Field{Key: "session_id", Value: s.Subagent.SessionID})
+	}
+}
+
 // Tracker manages all active subagent sessions.
 // Completely decoupled from the Kernel — the Kernel only executes tools.
 type Tracker struct {
@@ -88,6 +157,11 @@ type Tracker struct {
 	executor       ports.ToolExecutor
 	schemaProvider ports.ToolSchemaProvider
 	secretScanner  ports.SecretScannerPort
+	redactor       ports.RedactorPort
+	auditLog       ports.AuditLogPort
+	store          ports.SessionStore
+	compactAfter   time.Duration
+	maxAge         time.Duration
 	logger         shared_ports.Logger
 	mu             sync.RWMutex
 }
@@ -103,6 +177,90 @@ func NewTracker(executor ports.ToolExecutor, schemaProvider ports.ToolSchemaProv
 	}
 }
 
+// SetRedactor makes every session redact secrets from the events it emits.
+// It must be called before the first session is spawned.
+func (t *Tracker) SetRedactor(r ports.RedactorPort) {
+	t.redactor = r
+}
+
+// SetAuditLog records session starts, resumes and ends in the given log.
+// It must be called before the first session is spawned.
+func (t *Tracker) SetAuditLog(a ports.AuditLogPort) {
+	t.auditLog = a
+}
+
+// SetStore saves every session in the given store so it can be restored
+// after a restart. It must be called before Restore and the first spawn.
+func (t *Tracker) SetStore(store ports.SessionStore) {
+	t.store = store
+}
+
+// SetRetentionPolicy makes ApplyRetention compact stored sessions that
+// finished more than compactAfter ago and delete those that finished more
+// than maxAge ago. Zero disables either.
+func (t *Tracker) SetRetentionPolicy(compactAfter, maxAge time.Duration) {
+	t.mu.Lock()
+	t.compactAfter = compactAfter
+	t.maxAge = maxAge
+	t.mu.Unlock()
+}
+
+// ApplyRetention compacts and deletes finished sessions per the retention
+// policy. Deleted sessions are no longer tracked. It keeps going past a
+// session that fails and returns the first error.
+func (t *Tracker) ApplyRetention(ctx context.Context, now time.Time) error {
+	if t.store == nil {
+		return nil
+	}
+	t.mu.RLock()
+	compactAfter, maxAge := t.compactAfter, t.maxAge
+	sessions := make([]*SubagentSession, 0, len(t.sessions))
+	for _, session := range t.sessions {
+		sessions = append(sessions, session)
+	}
+	t.mu.RUnlock()
+
+	var firstErr error
+	for _, session := range sessions {
+		session.mu.RLock()
+		sessionID := session.Subagent.SessionID
+		finished := session.Subagent.Status.IsTerminal() && session.Subagent.CompletedAt != nil
+		var age time.Duration
+		if finished {
+			age = now.Sub(*session.Subagent.CompletedAt)
+		}
+		compacted := session.compacted
+		session.mu.RUnlock()
+		if !finished {
+			continue
+		}
+
+		switch {
+		case maxAge > 0 && age > maxAge:
+			if err := t.store.DeleteSession(ctx, sessionID); err != nil {
+				if firstErr == nil {
+					firstErr = err
+				}
+				continue
+			}
+			t.mu.Lock()
+			delete(t.sessions, sessionID)
+			t.mu.Unlock()
+		case compactAfter > 0 && age > compactAfter && !compacted:
+			if err := t.store.CompactSession(ctx, sessionID); err != nil {
+				if firstErr == nil {
+					firstErr = err
+				}
+				continue
+			}
+			session.mu.Lock()
+			session.compacted = true
+			session.mu.Unlock()
+		}
+	}
+	return firstErr
+}
+
 // SpawnSubagent creates a new session and starts the agent loop in a goroutine.
 func (t *Tracker) SpawnSubagent(parentID string, config sa.SessionConfig) (string, error) {
 	depth := 0
@@ -128,14 +286,7 @@ func (t *Tracker) spawnWithRetry(parentID string, originalID string, config sa.S
 	// Apply defaults (single source of truth — domain/subagent)
 	config.ApplyDefaults()
 
-	// Background context — sessions outlive the spawning request
-	var sessionCtx context.Context
-	var cancel context.CancelFunc
-	if config.TimeoutSeconds > 0 {
-		sessionCtx, cancel = context.WithTimeout(context.Background(), time.Duration(config.TimeoutSeconds)*time.Second)
-	} else {
-		sessionCtx, cancel = context.WithCancel(context.Background())
-	}
+	sessionCtx, cancel := newSessionContext(config, sessionID)
 
 	if originalID == "" {
 		originalID = subagentID
@@ -155,21 +306,129 @@ func (t *Tracker) spawnWithRetry(parentID string, originalID string, config sa.S
 			Depth:      depth,
 			CreatedAt:  now,
 		},
-		Log:        NewEventLog(sessionID),
 		ResumeChan: make(chan sa.ResumeDecision, 1),
 		Ctx:        sessionCtx,
 		Cancel:     cancel,
+		redactor:   t.redactor,
+		store:      t.store,
+		logger:     t.logger,
 	}
+	session.Log = t.newEventLog(session)
+	session.save()
 
 	t.mu.Lock()
 	t.sessions[sessionID] = session
 	t.mu.Unlock()
 
+	t.record(session, security.AuditSessionStart, map[string]interface{}{
+		"parent_id":     parentID,
+		"original_id":   originalID,
+		"description":   config.Description,
+		"allowed_tools": config.AllowedTools,
+		"provider":      config.Provider,
+		"model":         config.Model,
+		"depth":         depth,
+		"retry_count":   retryCount,
+	})
+
 	go t.runSessionLoop(session)
 
 	return sessionID, nil
 }
 
+// newEventLog returns the EventLog of a new session, kept in the store when
+// one is set.
+func (t *Tracker) newEventLog(session *SubagentSession) *EventLog {
+	sessionID := session.Subagent.SessionID
+	if t.store == nil {
+		return NewEventLog(sessionID)
+	}
+	log, err := NewBackedEventLog(sessionID, storedEvents{session})
+	if err != nil {
+		session.storeFailed(err, "Failed to open stored subagent events; keeping them in memory")
+		return NewEventLog(sessionID)
+	}
+	return log
+}
+
+// newSessionContext returns the context a session runs in. Sessions outlive
+// the spawning request, so it derives from the background context.
+func newSessionContext(config sa.SessionConfig, sessionID string) (context.Context, context.CancelFunc) {
+	var ctx context.Context
+	var cancel context.CancelFunc
+	if config.TimeoutSeconds > 0 {
+		ctx, cancel = context.WithTimeout(context.Background(), time.Duration(config.TimeoutSeconds)*time.Second)
+	} else {
+		ctx, cancel = context.WithCancel(context.Background())
+	}
+	return ports.WithSessionID(ctx, sessionID), cancel
+}
+
+// Restore loads the sessions saved in the store. Sessions paused for tool
+// approval can still be resumed. Sessions that were running, or waiting on
+// a policy approval raised by the previous process, are marked interrupted;
+// resuming them restarts the loop from their last saved step. It must be
+// called once, before the first spawn.
+func (t *Tracker) Restore(ctx context.Context) error {
+	if t.store == nil {
+		return nil
+	}
+	saved, err := t.store.LoadSessions(ctx)
+	if err != nil {
+		return types.Wrap(err, types.ErrCodeInternal, "failed to load subagent sessions")
+	}
+
+	for _, s := range saved {
+		sessionCtx, cancel := newSessionContext(s.Config, s.SessionID)
+		session := &SubagentSession{
+			Subagent:   s,
+			ResumeChan: make(chan sa.ResumeDecision, 1),
+			Ctx:        sessionCtx,
+			Cancel:     cancel,
+			redactor:   t.redactor,
+			store:      t.store,
+			logger:     t.logger,
+		}
+		log, err := NewBackedEventLog(s.SessionID, storedEvents{session})
+		if err != nil {
+			cancel()
+			return types.Wrapf(err, types.ErrCodeInternal, "failed to load events of subagent session %s", s.SessionID)
+		}
+		session.Log = log
+
+		t.mu.Lock()
+		t.sessions[s.SessionID] = session
+		t.mu.Unlock()
+
+		switch {
+		case s.Status.IsTerminal():
+			session.Log.Close()
+		case s.Status == sa.StatusRetrying:
+			// The retry either started as its own session or never will
+			session.SetStatus(sa.StatusFailed)
+			session.Log.Close()
+		case s.Status == sa.StatusPaused && s.PauseInfo != nil && s.PauseInfo.Reason == sa.PauseToolApproval:
+			session.detached = true
+			session.Emit(sa.SubagentEvent{
+				Type:    sa.EventLog,
+				Message: "Restored after an agent restart — still waiting for approval",
+			})
+		default:
+			session.detached = true
+			session.mu.Lock()
+			session.Subagent.PauseInfo = nil
+			session.Subagent.RunState = sa.RunStateIdle
+			session.mu.Unlock()
+			session.SetStatus(sa.StatusInterrupted)
+		}
+	}
+
+	if t.logger != nil && len(saved) > 0 {
+		t.logger.Info(ctx, "Restored subagent sessions", shared_ports.Field{Key: "count", Value: len(saved)})
+	}
+	return nil
+}
+
 // GetSession retrieves a session by ID.
 func (t *Tracker) GetSession(sessionID string) (ports.SessionView, error) {
 	t.mu.RLock()
@@ -213,10 +472,19 @@ func (t *Tracker) CancelSession(sessionID string) error {
 
 	session.Cancel()
 	session.SetStatus(sa.StatusCancelled)
+
+	// A restored session without a loop has nothing else to close its log
+	session.mu.RLock()
+	detached := session.detached
+	session.mu.RUnlock()
+	if detached {
+		session.Log.Close()
+	}
 	return nil
 }
 
-// ResumeSession sends a resume decision to a paused session.
+// ResumeSession sends a resume decision to a paused session. An interrupted
+// session is restarted instead; the decision does not apply to it.
 func (t *Tracker) ResumeSession(sessionID string, decision sa.ResumeDecision) error {
 	t.mu.RLock()
 	session, exists := t.sessions[sessionID]
@@ -230,18 +498,76 @@ func (t *Tracker) ResumeSession(sessionID string, decision sa.ResumeDecision) er
 	status := session.Subagent.Status
 	session.mu.RUnlock()
 
+	if status == sa.StatusInterrupted {
+		return t.restart(session, false)
+	}
 	if status != sa.StatusPaused {
 		return types.Newf(types.ErrCodeInvalidInput, "session %s is not paused (status: %s)", sessionID, status)
 	}
 
 	select {
 	case session.ResumeChan <- decision:
-		return nil
+		t.record(session, security.AuditSessionResume, map[string]interface{}{
+			"approve":     decision.Approve,
+			"reject":      decision.Reject,
+			"approve_all": decision.ApproveAll,
+			"reject_all":  decision.RejectAll,
+			"has_input":   decision.Input != "",
+		})
+		// A paused session restored from the store picks the decision up
+		// once its loop runs again
+		return t.restart(session, true)
 	default:
 		return types.Newf(types.ErrCodeInternal, "resume channel is full for session %s", sessionID)
 	}
 }
 
+// restart runs the loop of a restored session again from its saved
+// conversation. It does nothing for sessions whose loop is running.
+func (t *Tracker) restart(session *SubagentSession, paused bool) error {
+	session.mu.Lock()
+	if !session.detached {
+		session.mu.Unlock()
+		if !paused {
+			return types.Newf(types.ErrCodeInvalidInput, "session %s is already running", session.Subagent.SessionID)
+		}
+		return nil
+	}
+	sessionID := session.Subagent.SessionID
+	session.mu.Unlock()
+
+	messages, err := t.store.LoadMessages(session.Ctx, sessionID)
+	if err != nil {
+		return types.Wrapf(err, types.ErrCodeInternal, "failed to load conversation of session %s", sessionID)
+	}
+
+	session.mu.Lock()
+	if !session.detached {
+		// Another resume got here first
+		session.mu.Unlock()
+		return nil
+	}
+	session.detached = false
+	session.restored = messages
+	session.restoredPaused = paused
+	session.Subagent.RunID = uuid.New().String()
+	step := session.Subagent.Step
+	session.mu.Unlock()
+
+	if !paused {
+		t.record(session, security.AuditSessionResume, map[string]interface{}{
+			"restarted": true,
+		})
+	}
+	session.Emit(sa.SubagentEvent{
+		Type:    sa.EventLog,
+		Message: fmt.Sprintf("Restarting from step %d after an agent restart", step+1),
+	})
+
+	go t.runSessionLoop(session)
+	return nil
+}
+
 // StreamEvents returns a subscription to the session's EventLog.
 // Returns the subscription ID (for unsubscribe) and a read-only channel of port-level indexed events.
 func (t *Tracker) StreamEvents(sessionID string) (uint64, <-chan ports.IndexedEvent, error) {
@@ -298,9 +624,45 @@ func (t *Tracker) ReplayEvents(sessionID string, sinceSeqID uint64) ([]ports.Ind
 	return result, nil
 }
 
+// record writes a session lifecycle entry to the audit log, if one is set.
+func (t *Tracker) record(session *SubagentSession, action security.AuditAction, details map[string]interface{}) {
+	if t.auditLog == nil {
+		return
+	}
+	session.mu.RLock()
+	sessionID := session.Subagent.SessionID
+	details["subagent_id"] = session.Subagent.ID
+	details["run_id"] = session.Subagent.RunID
+	session.mu.RUnlock()
+
+	err := t.auditLog.Record(session.Ctx, security.AuditEntry{
+		SessionID: sessionID,
+		Action:    action,
+		Actor:     "subagent",
+		Target:    sessionID,
+		Details:   details,
+		Timestamp: time.Now(),
+	})
+	if err != nil && t.logger != nil {
+		t.logger.ErrorErr(session.Ctx, err, "Failed to record subagent session in audit log")
+	}
+}
+
 // runSessionLoop is the core agent loop for a subagent.
 func (t *Tracker) runSessionLoop(session *SubagentSession) {
 	defer session.Log.Close()
+	if t.secretScanner != nil {
+		defer t.secretScanner.Forget(session.Subagent.SessionID)
+	}
+	defer func() {
+		session.mu.RLock()
+		details := map[string]interface{}{
+			"status": session.Subagent.Status,
+			"error":  session.Subagent.Error,
+		}
+		session.mu.RUnlock()
+		t.record(session, security.AuditSessionEnd, details)
+	}()
 
 	session.SetStatus(sa.StatusRunning)
 
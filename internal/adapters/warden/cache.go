@@ -1,17 +1,39 @@
 package warden
 
 import (
+	"container/list"
 	"crypto/sha256"
 	"encoding/hex"
+	"encoding/json"
 	"fmt"
+	"hash"
 	"sort"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/SecDuckOps/agent/internal/domain/security"
 )
 
+const (
+	// DefaultPolicyCacheSize is the number of decisions kept when the
+	// configuration does not set warden.cache_size.
+	DefaultPolicyCacheSize = 4096
+
+	// DefaultPolicyCacheTTL is how long a decision is reused when the
+	// configuration does not set warden.cache_ttl_seconds.
+	DefaultPolicyCacheTTL = time.Minute
+)
+
+// CacheStats reports the effectiveness of a PolicyCache.
+type CacheStats struct {
+	Hits      uint64 `json:"hits"`
+	Misses    uint64 `json:"misses"`
+	Evictions uint64 `json:"evictions"` // entries dropped to make room
+	Size      int    `json:"size"`
+}
+
 // CacheEntry stores a cached policy decision with expiration.
 type CacheEntry struct {
 	Decision  security.PolicyDecision
@@ -19,86 +41,105 @@ type CacheEntry struct {
 }
 
 // PolicyCache is a thread-safe LRU cache for Cedar policy decisions.
+// Lookups, updates and evictions are O(1).
 type PolicyCache struct {
-	mu       sync.RWMutex
-	entries  map[string]CacheEntry
-	order    []string // LRU order tracking
-	maxSize  int
-	ttl      time.Duration
+	mu      sync.RWMutex
+	entries map[string]*list.Element // values are *cacheItem
+	order   *list.List               // front is the most recently used
+	maxSize int
+	ttl     time.Duration
+
+	hits      atomic.Uint64
+	misses    atomic.Uint64
+	evictions atomic.Uint64
 }
 
-// NewPolicyCache creates a new LRU policy cache.
+// NewPolicyCache creates a new LRU policy cache. Non-positive arguments fall
+// back to DefaultPolicyCacheSize and DefaultPolicyCacheTTL.
 func NewPolicyCache(maxSize int, ttl time.Duration) *PolicyCache {
+	if maxSize <= 0 {
+		maxSize = DefaultPolicyCacheSize
+	}
+	if ttl <= 0 {
+		ttl = DefaultPolicyCacheTTL
+	}
 	return &PolicyCache{
-		entries: make(map[string]CacheEntry, maxSize),
-		order:   make([]string, 0, maxSize),
+		entries: make(map[string]*list.Element, maxSize),
+		order:   list.New(),
 		maxSize: maxSize,
 		ttl:     ttl,
 	}
 }
 
+// cacheItem is the value of an LRU list element.
+type cacheItem struct {
+	key   string
+	entry CacheEntry
+}
+
 // Get retrieves a cached decision if it exists and hasn't expired.
 func (c *PolicyCache) Get(key string) (security.PolicyDecision, bool) {
-	c.mu.RLock()
-	entry, exists := c.entries[key]
-	c.mu.RUnlock()
-
-	if !exists || time.Now().After(entry.ExpiresAt) {
-		if exists {
-			c.mu.Lock()
-			delete(c.entries, key)
-			c.removeFromOrder(key)
-			c.mu.Unlock()
-		}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, exists := c.entries[key]
+	if !exists {
+		c.misses.Add(1)
+		return security.PolicyDecision{}, false
+	}
+	item := elem.Value.(*cacheItem)
+	if time.Now().After(item.entry.ExpiresAt) {
+		c.remove(elem)
+		c.misses.Add(1)
 		return security.PolicyDecision{}, false
 	}
 
 	// Move to front (most recently used)
-	c.mu.Lock()
-	c.moveToFront(key)
-	c.mu.Unlock()
+	c.order.MoveToFront(elem)
 
-	return entry.Decision, true
+	c.hits.Add(1)
+	return copyDecision(item.entry.Decision), true
 }
 
 // Set stores a policy decision in the cache.
 func (c *PolicyCache) Set(key string, decision security.PolicyDecision) {
+	entry := CacheEntry{
+		Decision:  copyDecision(decision),
+		ExpiresAt: time.Now().Add(c.ttl),
+	}
+
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
 	// If key already exists, update it
-	if _, exists := c.entries[key]; exists {
-		c.entries[key] = CacheEntry{
-			Decision:  decision,
-			ExpiresAt: time.Now().Add(c.ttl),
-		}
-		c.moveToFront(key)
+	if elem, exists := c.entries[key]; exists {
+		elem.Value.(*cacheItem).entry = entry
+		c.order.MoveToFront(elem)
 		return
 	}
 
 	// Evict LRU if at capacity
-	if len(c.entries) >= c.maxSize && len(c.order) > 0 {
-		lruKey := c.order[len(c.order)-1]
-		delete(c.entries, lruKey)
-		c.order = c.order[:len(c.order)-1]
+	if len(c.entries) >= c.maxSize {
+		if lru := c.order.Back(); lru != nil {
+			c.remove(lru)
+			c.evictions.Add(1)
+		}
 	}
 
-	c.entries[key] = CacheEntry{
-		Decision:  decision,
-		ExpiresAt: time.Now().Add(c.ttl),
-	}
-	c.order = append([]string{key}, c.order...)
+	c.entries[key] = c.order.PushFront(&cacheItem{key: key, entry: entry})
 }
 
 // GenerateCacheKey creates a deterministic key from principal, facts, and AST.
+// Every component is length-prefixed so that distinct requests (for example
+// args ["a,b"] and ["a", "b"]) can never share a key.
 func GenerateCacheKey(principalID string, facts map[string]interface{}, command string, args []string) string {
 	h := sha256.New()
-	h.Write([]byte(principalID))
-	h.Write([]byte("|"))
-	h.Write([]byte(command))
-	h.Write([]byte("|"))
-	h.Write([]byte(strings.Join(args, ",")))
-	h.Write([]byte("|"))
+	writeKeyPart(h, principalID)
+	writeKeyPart(h, command)
+	fmt.Fprintf(h, "%d|", len(args))
+	for _, arg := range args {
+		writeKeyPart(h, arg)
+	}
 
 	// Sort fact keys for determinism
 	factKeys := make([]string, 0, len(facts))
@@ -107,26 +148,81 @@ func GenerateCacheKey(principalID string, facts map[string]interface{}, command
 	}
 	sort.Strings(factKeys)
 	for _, k := range factKeys {
-		h.Write([]byte(fmt.Sprintf("%s=%v", k, facts[k])))
+		writeKeyPart(h, k)
+		writeKeyPart(h, factValue(facts[k]))
 	}
 
 	return hex.EncodeToString(h.Sum(nil))
 }
 
+// networkCacheKey covers every field networkCedarRequest exposes to policies.
+func networkCacheKey(req security.NetworkRequest) string {
+	facts := make(map[string]interface{}, len(req.Headers)+3)
+	for k, v := range req.Headers {
+		facts["header."+strings.ToLower(k)] = v
+	}
+	facts["method"] = strings.ToUpper(req.Method)
+	facts["url"] = req.URL
+	facts["session_id"] = req.SessionID
+	return GenerateCacheKey(req.SourceTool, facts, cedarActionNetwork, nil)
+}
+
+// executionCacheKey covers every field executionCedarRequest exposes to
+// policies. The principal is the session, the command and args are the
+// normalized forms and the raw forms are kept as facts.
+func executionCacheKey(req security.ExecutionRequest) string {
+	command := req.Command
+	if req.NormalizedCommand != "" {
+		command = req.NormalizedCommand
+	}
+	args := req.Args
+	if len(req.NormalizedArgs) > 0 {
+		args = req.NormalizedArgs
+	}
+
+	facts := make(map[string]interface{}, len(req.Context)+2)
+	for k, v := range req.Context {
+		facts["context."+k] = v
+	}
+	facts["raw_command"] = req.Command
+	facts["raw_args"] = req.Args
+	return GenerateCacheKey(req.SessionID, facts, cedarActionExecute+"|"+command, args)
+}
+
+func writeKeyPart(h hash.Hash, s string) {
+	fmt.Fprintf(h, "%d:%s|", len(s), s)
+}
+
+// factValue renders a fact deterministically; JSON sorts map keys.
+func factValue(v interface{}) string {
+	if b, err := json.Marshal(v); err == nil {
+		return string(b)
+	}
+	return fmt.Sprintf("%#v", v)
+}
+
+// copyDecision detaches the Reasons slice so callers cannot modify a
+// cached decision.
+func copyDecision(d security.PolicyDecision) security.PolicyDecision {
+	d.Reasons = append([]string(nil), d.Reasons...)
+	return d
+}
+
 // Invalidate removes a specific key from the cache.
 func (c *PolicyCache) Invalidate(key string) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
-	delete(c.entries, key)
-	c.removeFromOrder(key)
+	if elem, exists := c.entries[key]; exists {
+		c.remove(elem)
+	}
 }
 
 // Clear removes all entries from the cache.
 func (c *PolicyCache) Clear() {
 	c.mu.Lock()
 	defer c.mu.Unlock()
-	c.entries = make(map[string]CacheEntry, c.maxSize)
-	c.order = c.order[:0]
+	c.entries = make(map[string]*list.Element, c.maxSize)
+	c.order.Init()
 }
 
 // Size returns the current number of entries in the cache.
@@ -136,16 +232,19 @@ func (c *PolicyCache) Size() int {
 	return len(c.entries)
 }
 
-func (c *PolicyCache) moveToFront(key string) {
-	c.removeFromOrder(key)
-	c.order = append([]string{key}, c.order...)
+// Stats returns the hit, miss and eviction counters and the current size.
+func (c *PolicyCache) Stats() CacheStats {
+	return CacheStats{
+		Hits:      c.hits.Load(),
+		Misses:    c.misses.Load(),
+		Evictions: c.evictions.Load(),
+		Size:      c.Size(),
+	}
 }
 
-func (c *PolicyCache) removeFromOrder(key string) {
-	for i, k := range c.order {
-		if k == key {
-			c.order = append(c.order[:i], c.order[i+1:]...)
-			return
-		}
-	}
+// remove drops an element from both the map and the LRU list. Callers must
+// hold c.mu for writing.
+func (c *PolicyCache) remove(elem *list.Element) {
+	delete(c.entries, elem.Value.(*cacheItem).key)
+	c.order.Remove(elem)
 }
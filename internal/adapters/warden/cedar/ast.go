@@ -0,0 +1,122 @@
+package cedar
+
+// Effect is the outcome a policy contributes when it is satisfied.
+type Effect string
+
+const (
+	Permit Effect = "permit"
+	Forbid Effect = "forbid"
+)
+
+// scopeOp enumerates the constraint forms allowed in a policy scope.
+type scopeOp int
+
+const (
+	scopeAny   scopeOp = iota // principal
+	scopeEq                   // principal == User::"alice"
+	scopeIn                   // principal in Group::"admins"
+	scopeInSet                // action in [Action::"a", Action::"b"]
+	scopeIs                   // principal is User
+	scopeIsIn                 // principal is User in Group::"admins"
+)
+
+// scope is a single principal/action/resource constraint of a policy head.
+type scope struct {
+	op         scopeOp
+	entity     EntityUID
+	entities   []EntityUID
+	entityType string
+}
+
+// condition is a `when { ... }` or `unless { ... }` clause.
+type condition struct {
+	unless bool
+	body   expr
+}
+
+// Policy is a single parsed Cedar `permit` or `forbid` statement.
+type Policy struct {
+	ID          string            `json:"id"`
+	Effect      Effect            `json:"effect"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+	Pos         Position          `json:"position"`
+
+	principal  scope
+	action     scope
+	resource   scope
+	conditions []condition
+}
+
+// PolicySet is an ordered collection of policies parsed from one source.
+type PolicySet []*Policy
+
+// ──────────────── Expressions ────────────────
+
+// expr is a node of a condition expression tree.
+type expr interface {
+	eval(e *env) (Value, error)
+}
+
+type literalExpr struct{ v Value }
+
+type varExpr struct{ name string }
+
+type setExpr struct{ elems []expr }
+
+type recordExpr struct {
+	keys []string
+	vals []expr
+}
+
+type ifExpr struct{ cond, then, els expr }
+
+type andExpr struct{ l, r expr }
+
+type orExpr struct{ l, r expr }
+
+type notExpr struct{ x expr }
+
+type negExpr struct{ x expr }
+
+type binaryExpr struct {
+	op   string // ==, !=, <, <=, >, >=, in, +, -, *
+	l, r expr
+}
+
+type hasExpr struct {
+	x    expr
+	attr string
+}
+
+type likeExpr struct {
+	x       expr
+	pattern []patElem
+}
+
+type isExpr struct {
+	x          expr
+	entityType string
+	in         expr // optional
+}
+
+type attrExpr struct {
+	x    expr
+	attr string
+}
+
+type methodExpr struct {
+	recv expr
+	name string
+	args []expr
+}
+
+type callExpr struct {
+	name string
+	args []expr
+}
+
+// patElem is one segment of a compiled `like` pattern.
+type patElem struct {
+	wildcard bool
+	lit      string
+}
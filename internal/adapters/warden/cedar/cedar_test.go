@@ -0,0 +1,183 @@
+package cedar_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/SecDuckOps/agent/internal/adapters/warden/cedar"
+)
+
+func execRequest(command string, args ...string) cedar.Request {
+	argSet := make(cedar.Set, len(args))
+	for i, a := range args {
+		argSet[i] = a
+	}
+	return cedar.Request{
+		Principal: cedar.NewEntityUID("Session", "s-1"),
+		Action:    cedar.NewEntityUID("Action", "execute"),
+		Resource:  cedar.NewEntityUID("Command", command),
+		Context: cedar.Record{
+			"command": command,
+			"args":    argSet,
+			"cwd":     "/home/duck/project",
+			"env":     cedar.Record{"stage": "prod"},
+		},
+	}
+}
+
+func mustParse(t *testing.T, src string) cedar.PolicySet {
+	t.Helper()
+	set, err := cedar.Parse(src)
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	return set
+}
+
+func TestParse_ScopesConditionsAndAnnotations(t *testing.T) {
+	set := mustParse(t, `
+// Allow read-only tooling everywhere.
+@id("allow-readonly")
+permit (
+    principal,
+    action in [Action::"execute", Action::"read"],
+    resource == Command::"ls"
+);
+
+forbid (principal is Session, action, resource == Command::"terraform")
+when { context.args.contains("apply") }
+unless { context.env.stage == "dev" };
+`)
+	if len(set) != 2 {
+		t.Fatalf("expected 2 policies, got %d", len(set))
+	}
+	if set[0].ID != "allow-readonly" || set[0].Effect != cedar.Permit {
+		t.Errorf("unexpected first policy: %+v", set[0])
+	}
+	if set[1].ID != "policy1" || set[1].Effect != cedar.Forbid {
+		t.Errorf("unexpected second policy: %+v", set[1])
+	}
+	if set[1].Pos.Line != 10 {
+		t.Errorf("expected second policy on line 10, got %d", set[1].Pos.Line)
+	}
+}
+
+func TestParse_ReportsLineAndColumn(t *testing.T) {
+	_, err := cedar.Parse("permit(principal, action, resource)\nwhen { context.x == };")
+	var perr *cedar.ParseError
+	if !errors.As(err, &perr) {
+		t.Fatalf("expected *ParseError, got %v", err)
+	}
+	if perr.Pos.Line != 2 || perr.Pos.Column != 21 {
+		t.Errorf("expected error at 2:21, got %s (%v)", perr.Pos, err)
+	}
+}
+
+func TestIsAuthorized_ForbidOverridesPermit(t *testing.T) {
+	set := mustParse(t, `
+permit(principal, action == Action::"execute", resource);
+forbid(principal, action, resource == Command::"rm") when { context.args.contains("-rf") };
+`)
+
+	d := set.IsAuthorized(nil, execRequest("rm", "-rf", "/"))
+	if d.Allowed {
+		t.Fatal("expected forbid to override permit")
+	}
+	if len(d.Determining) != 1 || d.Determining[0].ID != "policy1" {
+		t.Errorf("expected policy1 to be determining, got %+v", d.Determining)
+	}
+
+	d = set.IsAuthorized(nil, execRequest("rm", "notes.txt"))
+	if !d.Allowed || d.Determining[0].ID != "policy0" {
+		t.Errorf("expected permit via policy0, got %+v", d)
+	}
+}
+
+func TestIsAuthorized_NoMatchIsImplicitDeny(t *testing.T) {
+	set := mustParse(t, `permit(principal, action, resource == Command::"ls");`)
+	d := set.IsAuthorized(nil, execRequest("curl"))
+	if d.Allowed || d.Matched() {
+		t.Errorf("expected implicit deny without a determining policy, got %+v", d)
+	}
+}
+
+func TestIsAuthorized_ErrorSkipsPolicy(t *testing.T) {
+	set := mustParse(t, `
+forbid(principal, action, resource) when { context.missing == "x" };
+permit(principal, action, resource);
+`)
+	d := set.IsAuthorized(nil, execRequest("ls"))
+	if !d.Allowed {
+		t.Error("a forbid that fails to evaluate must not apply")
+	}
+	if len(d.Errors) != 1 || d.Errors[0].PolicyID != "policy0" {
+		t.Errorf("expected one evaluation error for policy0, got %+v", d.Errors)
+	}
+}
+
+func TestIsAuthorized_Expressions(t *testing.T) {
+	tests := []struct {
+		name string
+		cond string
+		want bool
+	}{
+		{"like wildcard", `context.cwd like "/home/*/project"`, true},
+		{"like escaped star", `"a*b" like "a\*b" && !("axb" like "a\*b")`, true},
+		{"like suffix after wildcards", `"abcbc" like "*bc" && "abc" like "a**c" && !("abcb" like "*bc")`, true},
+		{"like without backtracking blowup", `!("` + strings.Repeat("a", 200) + `" like "*a*a*a*a*a*a*a*a*a*a*b")`, true},
+		{"has", `context has cwd && !(context has nope)`, true},
+		{"is in", `resource is Command && principal is Session`, true},
+		{"arithmetic", `context.args.isEmpty() || 2 * 3 - 1 >= 5`, true},
+		{"if then else", `if context.env.stage == "prod" then false else true`, false},
+		{"set ops", `[1, 2, 3].containsAll([3, 1]) && [1].containsAny([4, 1])`, true},
+		{"record equality", `{a: 1, "b": [2]} == {"b": [2], a: 1}`, true},
+		{"ip range", `ip("10.1.2.3").isInRange(ip("10.0.0.0/8")) && ip("127.0.0.1").isLoopback()`, true},
+		{"in set", `Command::"ls" in [Command::"cat", Command::"ls"]`, true},
+		{"type mismatch equality", `1 == "1"`, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			set := mustParse(t, `permit(principal, action, resource) when { `+tt.cond+` };`)
+			d := set.IsAuthorized(nil, execRequest("ls", "-la"))
+			if len(d.Errors) > 0 {
+				t.Fatalf("unexpected evaluation error: %v", d.Errors[0])
+			}
+			if d.Allowed != tt.want {
+				t.Errorf("condition %q: got %v, want %v", tt.cond, d.Allowed, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsAuthorized_EntityHierarchy(t *testing.T) {
+	set := mustParse(t, `permit(principal in Team::"secops", action in Action::"readonly", resource);`)
+	entities := cedar.Entities{
+		cedar.NewEntityUID("Session", "s-1"): {
+			UID:     cedar.NewEntityUID("Session", "s-1"),
+			Parents: []cedar.EntityUID{cedar.NewEntityUID("Team", "secops")},
+		},
+		cedar.NewEntityUID("Action", "execute"): {
+			UID:     cedar.NewEntityUID("Action", "execute"),
+			Parents: []cedar.EntityUID{cedar.NewEntityUID("Action", "readonly")},
+		},
+	}
+	if d := set.IsAuthorized(entities, execRequest("ls")); !d.Allowed {
+		t.Error("expected hierarchy membership to satisfy the scope")
+	}
+	if d := set.IsAuthorized(nil, execRequest("ls")); d.Allowed {
+		t.Error("expected no match without the entity hierarchy")
+	}
+}
+
+func TestIsCedar(t *testing.T) {
+	if !cedar.IsCedar("// comment\npermit(principal, action, resource);") {
+		t.Error("expected permit statement to be detected as Cedar")
+	}
+	if !cedar.IsCedar(`@id("x") forbid(principal, action, resource);`) {
+		t.Error("expected annotated statement to be detected as Cedar")
+	}
+	if cedar.IsCedar(`ALLOW url_contains "github.com"`) {
+		t.Error("legacy DSL must not be detected as Cedar")
+	}
+}
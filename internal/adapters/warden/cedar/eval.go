@@ -0,0 +1,505 @@
+package cedar
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// Request is the authorization request a policy set is evaluated against.
+type Request struct {
+	Principal EntityUID
+	Action    EntityUID
+	Resource  EntityUID
+	Context   Record
+}
+
+// EvalError records a policy that could not be evaluated. Following Cedar
+// semantics, such a policy is treated as not satisfied.
+type EvalError struct {
+	PolicyID string
+	Err      error
+}
+
+func (e EvalError) Error() string {
+	return fmt.Sprintf("%s: %v", e.PolicyID, e.Err)
+}
+
+// Decision is the outcome of evaluating a PolicySet.
+//
+// Allowed is true only if at least one permit is satisfied and no forbid is.
+// Determining lists the policies that produced the outcome: the satisfied
+// forbids on deny, the satisfied permits on allow, and nothing when no policy
+// applied at all.
+type Decision struct {
+	Allowed     bool
+	Determining []*Policy
+	Errors      []EvalError
+}
+
+// Matched reports whether any policy of the set applied to the request.
+func (d Decision) Matched() bool {
+	return len(d.Determining) > 0
+}
+
+// IsAuthorized evaluates every policy against the request. Forbid overrides permit.
+func (ps PolicySet) IsAuthorized(entities Entities, req Request) Decision {
+	var permits, forbids []*Policy
+	var errs []EvalError
+
+	for _, policy := range ps {
+		ok, err := policy.satisfied(&env{req: req, entities: entities})
+		if err != nil {
+			errs = append(errs, EvalError{PolicyID: policy.ID, Err: err})
+			continue
+		}
+		if !ok {
+			continue
+		}
+		if policy.Effect == Forbid {
+			forbids = append(forbids, policy)
+		} else {
+			permits = append(permits, policy)
+		}
+	}
+
+	if len(forbids) > 0 {
+		return Decision{Allowed: false, Determining: forbids, Errors: errs}
+	}
+	if len(permits) > 0 {
+		return Decision{Allowed: true, Determining: permits, Errors: errs}
+	}
+	return Decision{Allowed: false, Errors: errs}
+}
+
+// satisfied reports whether the policy scope matches and all conditions hold.
+func (p *Policy) satisfied(e *env) (bool, error) {
+	if !p.principal.matches(e.entities, e.req.Principal) ||
+		!p.action.matches(e.entities, e.req.Action) ||
+		!p.resource.matches(e.entities, e.req.Resource) {
+		return false, nil
+	}
+	for _, c := range p.conditions {
+		v, err := c.body.eval(e)
+		if err != nil {
+			return false, err
+		}
+		b, ok := v.(bool)
+		if !ok {
+			return false, fmt.Errorf("condition evaluated to %s, expected bool", typeName(v))
+		}
+		if b == c.unless {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+func (s scope) matches(entities Entities, uid EntityUID) bool {
+	switch s.op {
+	case scopeEq:
+		return uid == s.entity
+	case scopeIn:
+		return entities.isDescendant(uid, s.entity)
+	case scopeInSet:
+		for _, candidate := range s.entities {
+			if entities.isDescendant(uid, candidate) {
+				return true
+			}
+		}
+		return false
+	case scopeIs:
+		return uid.Type == s.entityType
+	case scopeIsIn:
+		return uid.Type == s.entityType && entities.isDescendant(uid, s.entity)
+	}
+	return true
+}
+
+// env carries the request and entity store through expression evaluation.
+type env struct {
+	req      Request
+	entities Entities
+}
+
+func (x *literalExpr) eval(_ *env) (Value, error) { return x.v, nil }
+
+func (x *varExpr) eval(e *env) (Value, error) {
+	switch x.name {
+	case "principal":
+		return e.req.Principal, nil
+	case "action":
+		return e.req.Action, nil
+	case "resource":
+		return e.req.Resource, nil
+	case "context":
+		if e.req.Context == nil {
+			return Record{}, nil
+		}
+		return e.req.Context, nil
+	}
+	return nil, fmt.Errorf("unknown variable %q", x.name)
+}
+
+func (x *setExpr) eval(e *env) (Value, error) {
+	s := make(Set, 0, len(x.elems))
+	for _, el := range x.elems {
+		v, err := el.eval(e)
+		if err != nil {
+			return nil, err
+		}
+		s = append(s, v)
+	}
+	return s, nil
+}
+
+func (x *recordExpr) eval(e *env) (Value, error) {
+	r := make(Record, len(x.keys))
+	for i, k := range x.keys {
+		v, err := x.vals[i].eval(e)
+		if err != nil {
+			return nil, err
+		}
+		r[k] = v
+	}
+	return r, nil
+}
+
+func (x *ifExpr) eval(e *env) (Value, error) {
+	cond, err := evalBool(e, x.cond)
+	if err != nil {
+		return nil, err
+	}
+	if cond {
+		return x.then.eval(e)
+	}
+	return x.els.eval(e)
+}
+
+func (x *andExpr) eval(e *env) (Value, error) {
+	l, err := evalBool(e, x.l)
+	if err != nil || !l {
+		return false, err
+	}
+	return evalBool(e, x.r)
+}
+
+func (x *orExpr) eval(e *env) (Value, error) {
+	l, err := evalBool(e, x.l)
+	if err != nil || l {
+		return true, err
+	}
+	return evalBool(e, x.r)
+}
+
+func (x *notExpr) eval(e *env) (Value, error) {
+	b, err := evalBool(e, x.x)
+	if err != nil {
+		return nil, err
+	}
+	return !b, nil
+}
+
+func (x *negExpr) eval(e *env) (Value, error) {
+	n, err := evalLong(e, x.x)
+	if err != nil {
+		return nil, err
+	}
+	if n == math.MinInt64 {
+		return nil, fmt.Errorf("integer overflow in negation")
+	}
+	return -n, nil
+}
+
+func (x *binaryExpr) eval(e *env) (Value, error) {
+	l, err := x.l.eval(e)
+	if err != nil {
+		return nil, err
+	}
+	r, err := x.r.eval(e)
+	if err != nil {
+		return nil, err
+	}
+
+	switch x.op {
+	case "==":
+		return valuesEqual(l, r), nil
+	case "!=":
+		return !valuesEqual(l, r), nil
+	case "in":
+		return evalIn(e, l, r)
+	}
+
+	a, ok := l.(int64)
+	if !ok {
+		return nil, fmt.Errorf("operator %s expects long operands, got %s", x.op, typeName(l))
+	}
+	b, ok := r.(int64)
+	if !ok {
+		return nil, fmt.Errorf("operator %s expects long operands, got %s", x.op, typeName(r))
+	}
+
+	switch x.op {
+	case "<":
+		return a < b, nil
+	case "<=":
+		return a <= b, nil
+	case ">":
+		return a > b, nil
+	case ">=":
+		return a >= b, nil
+	case "+":
+		sum := a + b
+		if (sum > a) != (b > 0) {
+			return nil, fmt.Errorf("integer overflow in %d + %d", a, b)
+		}
+		return sum, nil
+	case "-":
+		diff := a - b
+		if (diff < a) != (b > 0) {
+			return nil, fmt.Errorf("integer overflow in %d - %d", a, b)
+		}
+		return diff, nil
+	case "*":
+		if a != 0 && b != 0 {
+			prod := a * b
+			if prod/b != a || (a == -1 && b == math.MinInt64) || (b == -1 && a == math.MinInt64) {
+				return nil, fmt.Errorf("integer overflow in %d * %d", a, b)
+			}
+			return prod, nil
+		}
+		return int64(0), nil
+	}
+	return nil, fmt.Errorf("unknown operator %q", x.op)
+}
+
+func evalIn(e *env, l, r Value) (Value, error) {
+	child, ok := l.(EntityUID)
+	if !ok {
+		return nil, fmt.Errorf("operator in expects an entity on the left, got %s", typeName(l))
+	}
+	switch target := r.(type) {
+	case EntityUID:
+		return e.entities.isDescendant(child, target), nil
+	case Set:
+		for _, el := range target {
+			uid, ok := el.(EntityUID)
+			if !ok {
+				return nil, fmt.Errorf("operator in expects a set of entities, found %s", typeName(el))
+			}
+			if e.entities.isDescendant(child, uid) {
+				return true, nil
+			}
+		}
+		return false, nil
+	}
+	return nil, fmt.Errorf("operator in expects an entity or set on the right, got %s", typeName(r))
+}
+
+func (x *hasExpr) eval(e *env) (Value, error) {
+	v, err := x.x.eval(e)
+	if err != nil {
+		return nil, err
+	}
+	switch t := v.(type) {
+	case Record:
+		_, ok := t[x.attr]
+		return ok, nil
+	case EntityUID:
+		_, ok := e.entities[t].Attrs[x.attr]
+		return ok, nil
+	}
+	return nil, fmt.Errorf("operator has expects a record or entity, got %s", typeName(v))
+}
+
+func (x *likeExpr) eval(e *env) (Value, error) {
+	v, err := x.x.eval(e)
+	if err != nil {
+		return nil, err
+	}
+	s, ok := v.(string)
+	if !ok {
+		return nil, fmt.Errorf("operator like expects a string, got %s", typeName(v))
+	}
+	return matchPattern(x.pattern, s), nil
+}
+
+func (x *isExpr) eval(e *env) (Value, error) {
+	v, err := x.x.eval(e)
+	if err != nil {
+		return nil, err
+	}
+	uid, ok := v.(EntityUID)
+	if !ok {
+		return nil, fmt.Errorf("operator is expects an entity, got %s", typeName(v))
+	}
+	if uid.Type != x.entityType {
+		return false, nil
+	}
+	if x.in == nil {
+		return true, nil
+	}
+	target, err := x.in.eval(e)
+	if err != nil {
+		return nil, err
+	}
+	return evalIn(e, uid, target)
+}
+
+func (x *attrExpr) eval(e *env) (Value, error) {
+	v, err := x.x.eval(e)
+	if err != nil {
+		return nil, err
+	}
+	switch t := v.(type) {
+	case Record:
+		if attr, ok := t[x.attr]; ok {
+			return attr, nil
+		}
+		return nil, fmt.Errorf("record does not have the attribute %q", x.attr)
+	case EntityUID:
+		if attr, ok := e.entities[t].Attrs[x.attr]; ok {
+			return attr, nil
+		}
+		return nil, fmt.Errorf("entity %s does not have the attribute %q", t, x.attr)
+	}
+	return nil, fmt.Errorf("cannot access attribute %q of %s", x.attr, typeName(v))
+}
+
+func (x *methodExpr) eval(e *env) (Value, error) {
+	recv, err := x.recv.eval(e)
+	if err != nil {
+		return nil, err
+	}
+	args := make([]Value, len(x.args))
+	for i, a := range x.args {
+		if args[i], err = a.eval(e); err != nil {
+			return nil, err
+		}
+	}
+
+	switch r := recv.(type) {
+	case Set:
+		switch x.name {
+		case "contains":
+			if len(args) != 1 {
+				return nil, fmt.Errorf("contains expects 1 argument, got %d", len(args))
+			}
+			return setContains(r, args[0]), nil
+		case "containsAll", "containsAny":
+			if len(args) != 1 {
+				return nil, fmt.Errorf("%s expects 1 argument, got %d", x.name, len(args))
+			}
+			other, ok := args[0].(Set)
+			if !ok {
+				return nil, fmt.Errorf("%s expects a set argument, got %s", x.name, typeName(args[0]))
+			}
+			if x.name == "containsAll" {
+				return setContainsAll(r, other), nil
+			}
+			for _, el := range other {
+				if setContains(r, el) {
+					return true, nil
+				}
+			}
+			return false, nil
+		case "isEmpty":
+			if len(args) != 0 {
+				return nil, fmt.Errorf("isEmpty expects no arguments, got %d", len(args))
+			}
+			return len(r) == 0, nil
+		}
+	case IPAddr:
+		switch x.name {
+		case "isIpv4":
+			return r.Prefix.Addr().Is4(), nil
+		case "isIpv6":
+			return r.Prefix.Addr().Is6(), nil
+		case "isLoopback":
+			return r.Prefix.Addr().IsLoopback(), nil
+		case "isMulticast":
+			return r.Prefix.Addr().IsMulticast(), nil
+		case "isInRange":
+			if len(args) != 1 {
+				return nil, fmt.Errorf("isInRange expects 1 argument, got %d", len(args))
+			}
+			rng, ok := args[0].(IPAddr)
+			if !ok {
+				return nil, fmt.Errorf("isInRange expects an ipaddr argument, got %s", typeName(args[0]))
+			}
+			return rng.Prefix.Bits() <= r.Prefix.Bits() && rng.Prefix.Contains(r.Prefix.Addr()), nil
+		}
+	}
+	return nil, fmt.Errorf("%s has no method %q", typeName(recv), x.name)
+}
+
+func (x *callExpr) eval(e *env) (Value, error) {
+	switch x.name {
+	case "ip":
+		if len(x.args) != 1 {
+			return nil, fmt.Errorf("ip expects 1 argument, got %d", len(x.args))
+		}
+		v, err := x.args[0].eval(e)
+		if err != nil {
+			return nil, err
+		}
+		s, ok := v.(string)
+		if !ok {
+			return nil, fmt.Errorf("ip expects a string argument, got %s", typeName(v))
+		}
+		return parseIP(s)
+	}
+	return nil, fmt.Errorf("unknown function %q", x.name)
+}
+
+func evalBool(e *env, x expr) (bool, error) {
+	v, err := x.eval(e)
+	if err != nil {
+		return false, err
+	}
+	b, ok := v.(bool)
+	if !ok {
+		return false, fmt.Errorf("expected bool, got %s", typeName(v))
+	}
+	return b, nil
+}
+
+func evalLong(e *env, x expr) (int64, error) {
+	v, err := x.eval(e)
+	if err != nil {
+		return 0, err
+	}
+	n, ok := v.(int64)
+	if !ok {
+		return 0, fmt.Errorf("expected long, got %s", typeName(v))
+	}
+	return n, nil
+}
+
+// matchPattern matches s against a compiled `like` pattern. It is the
+// iterative two-pointer glob match: on a mismatch it retries from one byte
+// past where the last wildcard started, so it never backtracks further than
+// that wildcard and runs in O(len(pat) * len(s)).
+func matchPattern(pat []patElem, s string) bool {
+	pi, si := 0, 0
+	star, mark := -1, 0
+	for si < len(s) {
+		switch {
+		case pi < len(pat) && pat[pi].wildcard:
+			star, mark = pi, si
+			pi++
+		case pi < len(pat) && strings.HasPrefix(s[si:], pat[pi].lit):
+			si += len(pat[pi].lit)
+			pi++
+		case star >= 0:
+			mark++
+			pi, si = star+1, mark
+		default:
+			return false
+		}
+	}
+	for pi < len(pat) && pat[pi].wildcard {
+		pi++
+	}
+	return pi == len(pat)
+}
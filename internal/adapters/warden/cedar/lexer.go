@@ -0,0 +1,249 @@
+package cedar
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// tokenKind classifies lexical tokens of the Cedar policy language.
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokIdent
+	tokString
+	tokInt
+	tokPunct
+)
+
+// token is a single lexical unit with its source position.
+type token struct {
+	kind tokenKind
+	text string // identifier name, punctuation, or decoded string literal
+	raw  string // undecoded string literal body (used by `like` patterns)
+	pos  Position
+}
+
+// Position is a 1-based line/column location inside a policy source.
+type Position struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
+
+func (p Position) String() string {
+	return fmt.Sprintf("%d:%d", p.Line, p.Column)
+}
+
+// ParseError reports a syntax error at a specific position in a policy source.
+type ParseError struct {
+	Pos Position
+	Msg string
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("line %d, column %d: %s", e.Pos.Line, e.Pos.Column, e.Msg)
+}
+
+// multi-character punctuation, longest first
+var punctuations = []string{
+	"::", "==", "!=", "<=", ">=", "&&", "||",
+	"(", ")", "{", "}", "[", "]", ",", ";", ".", ":", "<", ">", "!", "+", "-", "*", "@", "?",
+}
+
+// lexer turns policy source text into tokens.
+type lexer struct {
+	src  string
+	off  int
+	line int
+	col  int
+}
+
+func newLexer(src string) *lexer {
+	return &lexer{src: src, line: 1, col: 1}
+}
+
+// tokenize lexes the whole input, returning the token stream terminated by tokEOF.
+func tokenize(src string) ([]token, error) {
+	lx := newLexer(src)
+	var toks []token
+	for {
+		tok, err := lx.next()
+		if err != nil {
+			return nil, err
+		}
+		toks = append(toks, tok)
+		if tok.kind == tokEOF {
+			return toks, nil
+		}
+	}
+}
+
+func (lx *lexer) pos() Position {
+	return Position{Line: lx.line, Column: lx.col}
+}
+
+func (lx *lexer) peekRune() (rune, int) {
+	if lx.off >= len(lx.src) {
+		return 0, 0
+	}
+	return utf8.DecodeRuneInString(lx.src[lx.off:])
+}
+
+func (lx *lexer) advance(n int) {
+	for i := 0; i < n && lx.off < len(lx.src); {
+		r, size := utf8.DecodeRuneInString(lx.src[lx.off:])
+		lx.off += size
+		i += size
+		if r == '\n' {
+			lx.line++
+			lx.col = 1
+		} else {
+			lx.col++
+		}
+	}
+}
+
+func (lx *lexer) skipSpaceAndComments() {
+	for lx.off < len(lx.src) {
+		r, size := lx.peekRune()
+		switch {
+		case unicode.IsSpace(r):
+			lx.advance(size)
+		case strings.HasPrefix(lx.src[lx.off:], "//"):
+			end := strings.IndexByte(lx.src[lx.off:], '\n')
+			if end < 0 {
+				end = len(lx.src) - lx.off
+			}
+			lx.advance(end)
+		default:
+			return
+		}
+	}
+}
+
+func (lx *lexer) next() (token, error) {
+	lx.skipSpaceAndComments()
+	start := lx.pos()
+	if lx.off >= len(lx.src) {
+		return token{kind: tokEOF, pos: start}, nil
+	}
+
+	r, _ := lx.peekRune()
+	switch {
+	case r == '_' || unicode.IsLetter(r):
+		begin := lx.off
+		for lx.off < len(lx.src) {
+			c, size := lx.peekRune()
+			if c != '_' && !unicode.IsLetter(c) && !unicode.IsDigit(c) {
+				break
+			}
+			lx.advance(size)
+		}
+		return token{kind: tokIdent, text: lx.src[begin:lx.off], pos: start}, nil
+
+	case r >= '0' && r <= '9':
+		begin := lx.off
+		for lx.off < len(lx.src) && lx.src[lx.off] >= '0' && lx.src[lx.off] <= '9' {
+			lx.advance(1)
+		}
+		return token{kind: tokInt, text: lx.src[begin:lx.off], pos: start}, nil
+
+	case r == '"':
+		return lx.lexString(start)
+	}
+
+	for _, p := range punctuations {
+		if strings.HasPrefix(lx.src[lx.off:], p) {
+			lx.advance(len(p))
+			return token{kind: tokPunct, text: p, pos: start}, nil
+		}
+	}
+
+	return token{}, &ParseError{Pos: start, Msg: fmt.Sprintf("unexpected character %q", r)}
+}
+
+func (lx *lexer) lexString(start Position) (token, error) {
+	lx.advance(1) // opening quote
+	begin := lx.off
+	for {
+		if lx.off >= len(lx.src) {
+			return token{}, &ParseError{Pos: start, Msg: "unterminated string literal"}
+		}
+		c := lx.src[lx.off]
+		if c == '\\' {
+			lx.advance(2)
+			continue
+		}
+		if c == '"' {
+			break
+		}
+		if c == '\n' {
+			return token{}, &ParseError{Pos: start, Msg: "unterminated string literal"}
+		}
+		lx.advance(1)
+	}
+	raw := lx.src[begin:lx.off]
+	lx.advance(1) // closing quote
+
+	decoded, err := unescape(raw)
+	if err != nil {
+		return token{}, &ParseError{Pos: start, Msg: err.Error()}
+	}
+	return token{kind: tokString, text: decoded, raw: raw, pos: start}, nil
+}
+
+// unescape decodes Cedar string escapes. The `\*` escape is only meaningful in
+// `like` patterns, which are decoded separately by compilePattern; here it
+// decodes to a plain star.
+func unescape(raw string) (string, error) {
+	if !strings.Contains(raw, `\`) {
+		return raw, nil
+	}
+	var b strings.Builder
+	for i := 0; i < len(raw); i++ {
+		c := raw[i]
+		if c != '\\' {
+			b.WriteByte(c)
+			continue
+		}
+		i++
+		if i >= len(raw) {
+			return "", fmt.Errorf("dangling escape in string literal")
+		}
+		switch raw[i] {
+		case 'n':
+			b.WriteByte('\n')
+		case 'r':
+			b.WriteByte('\r')
+		case 't':
+			b.WriteByte('\t')
+		case '0':
+			b.WriteByte(0)
+		case '\\':
+			b.WriteByte('\\')
+		case '"':
+			b.WriteByte('"')
+		case '\'':
+			b.WriteByte('\'')
+		case '*':
+			b.WriteByte('*')
+		case 'u':
+			end := strings.IndexByte(raw[i:], '}')
+			if i+1 >= len(raw) || raw[i+1] != '{' || end < 0 {
+				return "", fmt.Errorf(`invalid unicode escape, expected \u{XXXX}`)
+			}
+			code, err := strconv.ParseUint(raw[i+2:i+end], 16, 32)
+			if err != nil || !utf8.ValidRune(rune(code)) {
+				return "", fmt.Errorf("invalid unicode escape %q", raw[i-1:i+end+1])
+			}
+			b.WriteRune(rune(code))
+			i += end
+		default:
+			return "", fmt.Errorf(`invalid escape \%c`, raw[i])
+		}
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,704 @@
+package cedar
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// variables that may appear in conditions
+var variables = map[string]bool{
+	"principal": true,
+	"action":    true,
+	"resource":  true,
+	"context":   true,
+}
+
+// extension functions callable as `name(args)`
+var extensionFuncs = map[string]bool{
+	"ip": true,
+}
+
+// Parse parses Cedar policy source text into a PolicySet.
+//
+// Supported: annotations, permit/forbid with ==/in/is scope constraints,
+// when/unless conditions, the full boolean/comparison/arithmetic operator set,
+// has/like/is/in, sets and records, attribute access, the set methods
+// contains/containsAll/containsAny/isEmpty and the ip() extension.
+// Policy templates (?principal, ?resource) are not supported.
+func Parse(src string) (PolicySet, error) {
+	toks, err := tokenize(src)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{toks: toks}
+
+	var set PolicySet
+	for !p.at(tokEOF, "") {
+		policy, err := p.parsePolicy()
+		if err != nil {
+			return nil, err
+		}
+		if policy.ID == "" {
+			policy.ID = fmt.Sprintf("policy%d", len(set))
+		}
+		set = append(set, policy)
+	}
+	return set, nil
+}
+
+// IsCedar reports whether src looks like Cedar syntax (as opposed to the
+// legacy line-based `ALLOW|DENY predicate "value"` DSL). It only inspects the
+// first meaningful token, so it does not validate the source.
+func IsCedar(src string) bool {
+	tok, err := newLexer(src).next()
+	if err != nil {
+		return false
+	}
+	if tok.kind == tokPunct && tok.text == "@" {
+		return true
+	}
+	return tok.kind == tokIdent && (tok.text == string(Permit) || tok.text == string(Forbid))
+}
+
+// parser is a recursive-descent parser over a token stream.
+type parser struct {
+	toks []token
+	pos  int
+}
+
+func (p *parser) peek() token { return p.toks[p.pos] }
+
+func (p *parser) peekAt(n int) token {
+	if p.pos+n >= len(p.toks) {
+		return p.toks[len(p.toks)-1]
+	}
+	return p.toks[p.pos+n]
+}
+
+func (p *parser) advance() token {
+	tok := p.toks[p.pos]
+	if tok.kind != tokEOF {
+		p.pos++
+	}
+	return tok
+}
+
+// at reports whether the current token has the given kind and (if non-empty) text.
+func (p *parser) at(kind tokenKind, text string) bool {
+	tok := p.peek()
+	return tok.kind == kind && (text == "" || tok.text == text)
+}
+
+func (p *parser) accept(kind tokenKind, text string) bool {
+	if p.at(kind, text) {
+		p.advance()
+		return true
+	}
+	return false
+}
+
+func (p *parser) errorf(tok token, format string, args ...interface{}) error {
+	return &ParseError{Pos: tok.pos, Msg: fmt.Sprintf(format, args...)}
+}
+
+func describe(tok token) string {
+	switch tok.kind {
+	case tokEOF:
+		return "end of input"
+	case tokString:
+		return strconv.Quote(tok.text)
+	}
+	return fmt.Sprintf("%q", tok.text)
+}
+
+func (p *parser) expect(kind tokenKind, text string) (token, error) {
+	tok := p.peek()
+	if tok.kind != kind || (text != "" && tok.text != text) {
+		want := text
+		if want == "" {
+			want = map[tokenKind]string{tokIdent: "identifier", tokString: "string literal", tokInt: "integer"}[kind]
+		} else {
+			want = fmt.Sprintf("%q", want)
+		}
+		return tok, p.errorf(tok, "expected %s, found %s", want, describe(tok))
+	}
+	return p.advance(), nil
+}
+
+// ──────────────── Policies ────────────────
+
+func (p *parser) parsePolicy() (*Policy, error) {
+	policy := &Policy{Pos: p.peek().pos}
+
+	for p.accept(tokPunct, "@") {
+		name, err := p.expect(tokIdent, "")
+		if err != nil {
+			return nil, err
+		}
+		if _, err := p.expect(tokPunct, "("); err != nil {
+			return nil, err
+		}
+		val, err := p.expect(tokString, "")
+		if err != nil {
+			return nil, err
+		}
+		if _, err := p.expect(tokPunct, ")"); err != nil {
+			return nil, err
+		}
+		if policy.Annotations == nil {
+			policy.Annotations = make(map[string]string)
+		}
+		if _, dup := policy.Annotations[name.text]; dup {
+			return nil, p.errorf(name, "duplicate annotation @%s", name.text)
+		}
+		policy.Annotations[name.text] = val.text
+	}
+	policy.ID = policy.Annotations["id"]
+
+	effTok, err := p.expect(tokIdent, "")
+	if err != nil {
+		return nil, err
+	}
+	switch effTok.text {
+	case string(Permit), string(Forbid):
+		policy.Effect = Effect(effTok.text)
+	default:
+		return nil, p.errorf(effTok, "expected \"permit\" or \"forbid\", found %s", describe(effTok))
+	}
+	if len(policy.Annotations) == 0 {
+		policy.Pos = effTok.pos
+	}
+
+	if _, err := p.expect(tokPunct, "("); err != nil {
+		return nil, err
+	}
+	if policy.principal, err = p.parseScope("principal"); err != nil {
+		return nil, err
+	}
+	if _, err := p.expect(tokPunct, ","); err != nil {
+		return nil, err
+	}
+	if policy.action, err = p.parseScope("action"); err != nil {
+		return nil, err
+	}
+	if _, err := p.expect(tokPunct, ","); err != nil {
+		return nil, err
+	}
+	if policy.resource, err = p.parseScope("resource"); err != nil {
+		return nil, err
+	}
+	p.accept(tokPunct, ",") // trailing comma is tolerated
+	if _, err := p.expect(tokPunct, ")"); err != nil {
+		return nil, err
+	}
+
+	for p.at(tokIdent, "when") || p.at(tokIdent, "unless") {
+		kw := p.advance()
+		if _, err := p.expect(tokPunct, "{"); err != nil {
+			return nil, err
+		}
+		body, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		if _, err := p.expect(tokPunct, "}"); err != nil {
+			return nil, err
+		}
+		policy.conditions = append(policy.conditions, condition{unless: kw.text == "unless", body: body})
+	}
+
+	if _, err := p.expect(tokPunct, ";"); err != nil {
+		return nil, err
+	}
+	return policy, nil
+}
+
+func (p *parser) parseScope(variable string) (scope, error) {
+	if _, err := p.expect(tokIdent, variable); err != nil {
+		return scope{}, err
+	}
+
+	switch {
+	case p.accept(tokPunct, "=="):
+		if p.at(tokPunct, "?") {
+			return scope{}, p.errorf(p.peek(), "policy templates are not supported")
+		}
+		uid, err := p.parseEntityRef()
+		if err != nil {
+			return scope{}, err
+		}
+		return scope{op: scopeEq, entity: uid}, nil
+
+	case p.accept(tokIdent, "in"):
+		if p.at(tokPunct, "?") {
+			return scope{}, p.errorf(p.peek(), "policy templates are not supported")
+		}
+		if variable == "action" && p.accept(tokPunct, "[") {
+			var uids []EntityUID
+			for !p.at(tokPunct, "]") {
+				uid, err := p.parseEntityRef()
+				if err != nil {
+					return scope{}, err
+				}
+				uids = append(uids, uid)
+				if !p.accept(tokPunct, ",") {
+					break
+				}
+			}
+			if _, err := p.expect(tokPunct, "]"); err != nil {
+				return scope{}, err
+			}
+			return scope{op: scopeInSet, entities: uids}, nil
+		}
+		uid, err := p.parseEntityRef()
+		if err != nil {
+			return scope{}, err
+		}
+		return scope{op: scopeIn, entity: uid}, nil
+
+	case variable != "action" && p.at(tokIdent, "is"):
+		isTok := p.advance()
+		typ, err := p.parsePath()
+		if err != nil {
+			return scope{}, err
+		}
+		if typ == "" {
+			return scope{}, p.errorf(isTok, "expected entity type after \"is\"")
+		}
+		if p.accept(tokIdent, "in") {
+			uid, err := p.parseEntityRef()
+			if err != nil {
+				return scope{}, err
+			}
+			return scope{op: scopeIsIn, entityType: typ, entity: uid}, nil
+		}
+		return scope{op: scopeIs, entityType: typ}, nil
+	}
+
+	return scope{op: scopeAny}, nil
+}
+
+// parsePath parses `Ident {:: Ident}` stopping before a `::"id"` suffix.
+func (p *parser) parsePath() (string, error) {
+	first, err := p.expect(tokIdent, "")
+	if err != nil {
+		return "", err
+	}
+	parts := []string{first.text}
+	for p.at(tokPunct, "::") && p.peekAt(1).kind == tokIdent {
+		p.advance()
+		parts = append(parts, p.advance().text)
+	}
+	return strings.Join(parts, "::"), nil
+}
+
+// parseEntityRef parses `Type::"id"` (Type may be namespaced).
+func (p *parser) parseEntityRef() (EntityUID, error) {
+	typ, err := p.parsePath()
+	if err != nil {
+		return EntityUID{}, err
+	}
+	if _, err := p.expect(tokPunct, "::"); err != nil {
+		return EntityUID{}, err
+	}
+	id, err := p.expect(tokString, "")
+	if err != nil {
+		return EntityUID{}, err
+	}
+	return EntityUID{Type: typ, ID: id.text}, nil
+}
+
+// ──────────────── Expressions ────────────────
+
+func (p *parser) parseExpr() (expr, error) {
+	if p.accept(tokIdent, "if") {
+		cond, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		if _, err := p.expect(tokIdent, "then"); err != nil {
+			return nil, err
+		}
+		then, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		if _, err := p.expect(tokIdent, "else"); err != nil {
+			return nil, err
+		}
+		els, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		return &ifExpr{cond: cond, then: then, els: els}, nil
+	}
+	return p.parseOr()
+}
+
+func (p *parser) parseOr() (expr, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.accept(tokPunct, "||") {
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &orExpr{l: left, r: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseAnd() (expr, error) {
+	left, err := p.parseRelation()
+	if err != nil {
+		return nil, err
+	}
+	for p.accept(tokPunct, "&&") {
+		right, err := p.parseRelation()
+		if err != nil {
+			return nil, err
+		}
+		left = &andExpr{l: left, r: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseRelation() (expr, error) {
+	left, err := p.parseAdd()
+	if err != nil {
+		return nil, err
+	}
+
+	tok := p.peek()
+	switch {
+	case tok.kind == tokPunct && (tok.text == "==" || tok.text == "!=" || tok.text == "<" ||
+		tok.text == "<=" || tok.text == ">" || tok.text == ">="):
+		p.advance()
+		right, err := p.parseAdd()
+		if err != nil {
+			return nil, err
+		}
+		return &binaryExpr{op: tok.text, l: left, r: right}, nil
+
+	case tok.kind == tokIdent && tok.text == "in":
+		p.advance()
+		right, err := p.parseAdd()
+		if err != nil {
+			return nil, err
+		}
+		return &binaryExpr{op: "in", l: left, r: right}, nil
+
+	case tok.kind == tokIdent && tok.text == "has":
+		p.advance()
+		attr := p.peek()
+		if attr.kind != tokIdent && attr.kind != tokString {
+			return nil, p.errorf(attr, "expected attribute name after \"has\", found %s", describe(attr))
+		}
+		p.advance()
+		return &hasExpr{x: left, attr: attr.text}, nil
+
+	case tok.kind == tokIdent && tok.text == "like":
+		p.advance()
+		pat, err := p.expect(tokString, "")
+		if err != nil {
+			return nil, err
+		}
+		elems, err := compilePattern(pat.raw)
+		if err != nil {
+			return nil, p.errorf(pat, "%v", err)
+		}
+		return &likeExpr{x: left, pattern: elems}, nil
+
+	case tok.kind == tokIdent && tok.text == "is":
+		p.advance()
+		typ, err := p.parsePath()
+		if err != nil {
+			return nil, err
+		}
+		node := &isExpr{x: left, entityType: typ}
+		if p.accept(tokIdent, "in") {
+			if node.in, err = p.parseAdd(); err != nil {
+				return nil, err
+			}
+		}
+		return node, nil
+	}
+	return left, nil
+}
+
+func (p *parser) parseAdd() (expr, error) {
+	left, err := p.parseMult()
+	if err != nil {
+		return nil, err
+	}
+	for p.at(tokPunct, "+") || p.at(tokPunct, "-") {
+		op := p.advance().text
+		right, err := p.parseMult()
+		if err != nil {
+			return nil, err
+		}
+		left = &binaryExpr{op: op, l: left, r: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseMult() (expr, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.accept(tokPunct, "*") {
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = &binaryExpr{op: "*", l: left, r: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseUnary() (expr, error) {
+	if p.accept(tokPunct, "!") {
+		x, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &notExpr{x: x}, nil
+	}
+	if p.at(tokPunct, "-") {
+		minus := p.advance()
+		// Fold negative integer literals so that the minimum long is representable.
+		if p.at(tokInt, "") {
+			lit := p.advance()
+			n, err := strconv.ParseInt("-"+lit.text, 10, 64)
+			if err != nil {
+				return nil, p.errorf(minus, "integer literal out of range: -%s", lit.text)
+			}
+			return p.parseAccess(&literalExpr{v: n})
+		}
+		x, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &negExpr{x: x}, nil
+	}
+	return p.parseMember()
+}
+
+func (p *parser) parseMember() (expr, error) {
+	prim, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+	return p.parseAccess(prim)
+}
+
+func (p *parser) parseAccess(x expr) (expr, error) {
+	for {
+		switch {
+		case p.accept(tokPunct, "."):
+			name, err := p.expect(tokIdent, "")
+			if err != nil {
+				return nil, err
+			}
+			if p.at(tokPunct, "(") {
+				args, err := p.parseArgs()
+				if err != nil {
+					return nil, err
+				}
+				x = &methodExpr{recv: x, name: name.text, args: args}
+			} else {
+				x = &attrExpr{x: x, attr: name.text}
+			}
+		case p.accept(tokPunct, "["):
+			key, err := p.expect(tokString, "")
+			if err != nil {
+				return nil, err
+			}
+			if _, err := p.expect(tokPunct, "]"); err != nil {
+				return nil, err
+			}
+			x = &attrExpr{x: x, attr: key.text}
+		default:
+			return x, nil
+		}
+	}
+}
+
+// parseArgs parses a parenthesized, comma separated argument list.
+func (p *parser) parseArgs() ([]expr, error) {
+	if _, err := p.expect(tokPunct, "("); err != nil {
+		return nil, err
+	}
+	var args []expr
+	for !p.at(tokPunct, ")") {
+		arg, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, arg)
+		if !p.accept(tokPunct, ",") {
+			break
+		}
+	}
+	if _, err := p.expect(tokPunct, ")"); err != nil {
+		return nil, err
+	}
+	return args, nil
+}
+
+func (p *parser) parsePrimary() (expr, error) {
+	tok := p.peek()
+	switch tok.kind {
+	case tokInt:
+		p.advance()
+		n, err := strconv.ParseInt(tok.text, 10, 64)
+		if err != nil {
+			return nil, p.errorf(tok, "integer literal out of range: %s", tok.text)
+		}
+		return &literalExpr{v: n}, nil
+
+	case tokString:
+		p.advance()
+		return &literalExpr{v: tok.text}, nil
+
+	case tokIdent:
+		switch {
+		case tok.text == "true" || tok.text == "false":
+			p.advance()
+			return &literalExpr{v: tok.text == "true"}, nil
+		case p.peekAt(1).kind == tokPunct && p.peekAt(1).text == "::":
+			uid, err := p.parseEntityRef()
+			if err != nil {
+				return nil, err
+			}
+			return &literalExpr{v: uid}, nil
+		case variables[tok.text]:
+			p.advance()
+			return &varExpr{name: tok.text}, nil
+		case p.peekAt(1).kind == tokPunct && p.peekAt(1).text == "(":
+			p.advance()
+			if !extensionFuncs[tok.text] {
+				return nil, p.errorf(tok, "unknown function %q", tok.text)
+			}
+			args, err := p.parseArgs()
+			if err != nil {
+				return nil, err
+			}
+			return &callExpr{name: tok.text, args: args}, nil
+		}
+		return nil, p.errorf(tok, "unexpected identifier %q", tok.text)
+
+	case tokPunct:
+		switch tok.text {
+		case "(":
+			p.advance()
+			x, err := p.parseExpr()
+			if err != nil {
+				return nil, err
+			}
+			if _, err := p.expect(tokPunct, ")"); err != nil {
+				return nil, err
+			}
+			return x, nil
+
+		case "[":
+			p.advance()
+			var elems []expr
+			for !p.at(tokPunct, "]") {
+				el, err := p.parseExpr()
+				if err != nil {
+					return nil, err
+				}
+				elems = append(elems, el)
+				if !p.accept(tokPunct, ",") {
+					break
+				}
+			}
+			if _, err := p.expect(tokPunct, "]"); err != nil {
+				return nil, err
+			}
+			return &setExpr{elems: elems}, nil
+
+		case "{":
+			p.advance()
+			rec := &recordExpr{}
+			seen := map[string]bool{}
+			for !p.at(tokPunct, "}") {
+				key := p.peek()
+				if key.kind != tokIdent && key.kind != tokString {
+					return nil, p.errorf(key, "expected record key, found %s", describe(key))
+				}
+				p.advance()
+				if seen[key.text] {
+					return nil, p.errorf(key, "duplicate record key %q", key.text)
+				}
+				seen[key.text] = true
+				if _, err := p.expect(tokPunct, ":"); err != nil {
+					return nil, err
+				}
+				val, err := p.parseExpr()
+				if err != nil {
+					return nil, err
+				}
+				rec.keys = append(rec.keys, key.text)
+				rec.vals = append(rec.vals, val)
+				if !p.accept(tokPunct, ",") {
+					break
+				}
+			}
+			if _, err := p.expect(tokPunct, "}"); err != nil {
+				return nil, err
+			}
+			return rec, nil
+		}
+	}
+	return nil, p.errorf(tok, "unexpected %s", describe(tok))
+}
+
+// compilePattern turns the raw (undecoded) body of a `like` string literal into
+// pattern segments: an unescaped `*` is a wildcard, `\*` is a literal star.
+func compilePattern(raw string) ([]patElem, error) {
+	var elems []patElem
+	var lit strings.Builder
+	flush := func() {
+		if lit.Len() > 0 {
+			elems = append(elems, patElem{lit: lit.String()})
+			lit.Reset()
+		}
+	}
+	for i := 0; i < len(raw); i++ {
+		switch c := raw[i]; {
+		case c == '*':
+			flush()
+			elems = append(elems, patElem{wildcard: true})
+		case c == '\\' && i+1 < len(raw) && raw[i+1] == '*':
+			lit.WriteByte('*')
+			i++
+		case c == '\\':
+			// Find the extent of this escape and decode it on its own.
+			end := i + 2
+			if i+1 < len(raw) && raw[i+1] == 'u' {
+				if close := strings.IndexByte(raw[i:], '}'); close > 0 {
+					end = i + close + 1
+				}
+			}
+			if end > len(raw) {
+				end = len(raw)
+			}
+			decoded, err := unescape(raw[i:end])
+			if err != nil {
+				return nil, err
+			}
+			lit.WriteString(decoded)
+			i = end - 1
+		default:
+			lit.WriteByte(c)
+		}
+	}
+	flush()
+	return elems, nil
+}
@@ -0,0 +1,248 @@
+package cedar
+
+import (
+	"fmt"
+	"net/netip"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Value is a Cedar runtime value. The concrete types are:
+// bool, int64, string, EntityUID, Set, Record and IPAddr.
+type Value interface{}
+
+// EntityUID identifies an entity, e.g. Action::"execute".
+type EntityUID struct {
+	Type string `json:"type"`
+	ID   string `json:"id"`
+}
+
+// NewEntityUID builds an entity reference.
+func NewEntityUID(typ, id string) EntityUID {
+	return EntityUID{Type: typ, ID: id}
+}
+
+func (e EntityUID) String() string {
+	return e.Type + "::" + strconv.Quote(e.ID)
+}
+
+// Set is an unordered collection of Cedar values.
+type Set []Value
+
+// Record is a map of attribute names to Cedar values.
+type Record map[string]Value
+
+// IPAddr is the value produced by the ip() extension function.
+type IPAddr struct {
+	Prefix netip.Prefix
+}
+
+func (ip IPAddr) String() string {
+	if ip.Prefix.Bits() == ip.Prefix.Addr().BitLen() {
+		return ip.Prefix.Addr().String()
+	}
+	return ip.Prefix.String()
+}
+
+// Entity is an entry of the entity store: its parents define the hierarchy
+// used by the `in` operator and its attributes are reachable via `.attr`.
+type Entity struct {
+	UID     EntityUID
+	Parents []EntityUID
+	Attrs   Record
+}
+
+// Entities is the entity store consulted during evaluation. A nil store is
+// valid; unknown entities simply have no parents and no attributes.
+type Entities map[EntityUID]Entity
+
+// isDescendant reports whether child == ancestor or ancestor is reachable
+// through the parent hierarchy.
+func (es Entities) isDescendant(child, ancestor EntityUID) bool {
+	if child == ancestor {
+		return true
+	}
+	seen := map[EntityUID]bool{child: true}
+	queue := []EntityUID{child}
+	for len(queue) > 0 {
+		cur := queue[0]
+		queue = queue[1:]
+		for _, p := range es[cur].Parents {
+			if p == ancestor {
+				return true
+			}
+			if !seen[p] {
+				seen[p] = true
+				queue = append(queue, p)
+			}
+		}
+	}
+	return false
+}
+
+// FromGo converts plain Go values (as found in JSON-decoded maps) into Cedar values.
+// Unsupported types are rendered as strings.
+func FromGo(v interface{}) Value {
+	switch t := v.(type) {
+	case nil:
+		return ""
+	case bool, int64, string, EntityUID, Set, Record, IPAddr:
+		return t
+	case int:
+		return int64(t)
+	case int32:
+		return int64(t)
+	case uint32:
+		return int64(t)
+	case float64:
+		if t == float64(int64(t)) {
+			return int64(t)
+		}
+		return strconv.FormatFloat(t, 'f', -1, 64)
+	case []string:
+		s := make(Set, len(t))
+		for i, e := range t {
+			s[i] = e
+		}
+		return s
+	case []interface{}:
+		s := make(Set, len(t))
+		for i, e := range t {
+			s[i] = FromGo(e)
+		}
+		return s
+	case map[string]string:
+		r := make(Record, len(t))
+		for k, e := range t {
+			r[k] = e
+		}
+		return r
+	case map[string]interface{}:
+		r := make(Record, len(t))
+		for k, e := range t {
+			r[k] = FromGo(e)
+		}
+		return r
+	}
+	return fmt.Sprint(v)
+}
+
+// typeName returns the Cedar type name of a value for error messages.
+func typeName(v Value) string {
+	switch v.(type) {
+	case bool:
+		return "bool"
+	case int64:
+		return "long"
+	case string:
+		return "string"
+	case EntityUID:
+		return "entity"
+	case Set:
+		return "set"
+	case Record:
+		return "record"
+	case IPAddr:
+		return "ipaddr"
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+// valuesEqual implements Cedar `==`: values of different types are never equal.
+func valuesEqual(a, b Value) bool {
+	switch x := a.(type) {
+	case bool, int64, string, EntityUID:
+		return a == b
+	case IPAddr:
+		y, ok := b.(IPAddr)
+		return ok && x.Prefix == y.Prefix
+	case Set:
+		y, ok := b.(Set)
+		if !ok {
+			return false
+		}
+		return setContainsAll(x, y) && setContainsAll(y, x)
+	case Record:
+		y, ok := b.(Record)
+		if !ok || len(x) != len(y) {
+			return false
+		}
+		for k, xv := range x {
+			yv, ok := y[k]
+			if !ok || !valuesEqual(xv, yv) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+func setContains(s Set, v Value) bool {
+	for _, e := range s {
+		if valuesEqual(e, v) {
+			return true
+		}
+	}
+	return false
+}
+
+func setContainsAll(s, other Set) bool {
+	for _, e := range other {
+		if !setContains(s, e) {
+			return false
+		}
+	}
+	return true
+}
+
+// formatValue renders a value in Cedar syntax, mostly for diagnostics.
+func formatValue(v Value) string {
+	switch t := v.(type) {
+	case string:
+		return strconv.Quote(t)
+	case int64:
+		return strconv.FormatInt(t, 10)
+	case bool:
+		return strconv.FormatBool(t)
+	case EntityUID:
+		return t.String()
+	case IPAddr:
+		return fmt.Sprintf("ip(%q)", t.String())
+	case Set:
+		parts := make([]string, len(t))
+		for i, e := range t {
+			parts[i] = formatValue(e)
+		}
+		return "[" + strings.Join(parts, ", ") + "]"
+	case Record:
+		keys := make([]string, 0, len(t))
+		for k := range t {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		parts := make([]string, len(keys))
+		for i, k := range keys {
+			parts[i] = strconv.Quote(k) + ": " + formatValue(t[k])
+		}
+		return "{" + strings.Join(parts, ", ") + "}"
+	}
+	return fmt.Sprint(v)
+}
+
+// parseIP implements the ip() extension constructor.
+func parseIP(s string) (IPAddr, error) {
+	if strings.Contains(s, "/") {
+		p, err := netip.ParsePrefix(s)
+		if err != nil {
+			return IPAddr{}, fmt.Errorf("invalid ip range %q", s)
+		}
+		return IPAddr{Prefix: p.Masked()}, nil
+	}
+	a, err := netip.ParseAddr(s)
+	if err != nil {
+		return IPAddr{}, fmt.Errorf("invalid ip address %q", s)
+	}
+	return IPAddr{Prefix: netip.PrefixFrom(a, a.BitLen())}, nil
+}
@@ -0,0 +1,166 @@
+package warden
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/SecDuckOps/agent/internal/domain/security"
+	"github.com/SecDuckOps/agent/internal/ports"
+	"github.com/SecDuckOps/shared/types"
+	"github.com/pelletier/go-toml/v2"
+)
+
+// FixtureSuffix is appended to a policy file's stem to locate its fixtures:
+// policies/network.cedar → policies/network.tests.toml
+const FixtureSuffix = ".tests.toml"
+
+// PolicyFixture is a TOML file of expected decisions for a policy file.
+//
+//	[[case]]
+//	name      = "force push is blocked"
+//	expect    = "deny"
+//	policy_id = "no_force_push"
+//	[case.execution]
+//	command = "git"
+//	args    = ["push", "--force"]
+//
+//	[[case]]
+//	name   = "GitHub reads are allowed"
+//	expect = "allow"
+//	[case.network]
+//	method      = "GET"
+//	url         = "https://api.github.com/repos/our-org/agent"
+//	source_tool = "scan"
+type PolicyFixture struct {
+	Path  string        `toml:"-"`
+	Cases []FixtureCase `toml:"case"`
+}
+
+// FixtureCase is a single request with its expected outcome.
+type FixtureCase struct {
+	Name      string            `toml:"name"`
+	Expect    string            `toml:"expect"`              // "allow", "deny" or "require_approval"
+	PolicyID  string            `toml:"policy_id,omitempty"` // optional; "" skips the check
+	Execution *FixtureExecution `toml:"execution,omitempty"`
+	Network   *FixtureNetwork   `toml:"network,omitempty"`
+}
+
+// FixtureExecution describes a synthetic security.ExecutionRequest.
+type FixtureExecution struct {
+	Command           string                 `toml:"command"`
+	Args              []string               `toml:"args,omitempty"`
+	NormalizedCommand string                 `toml:"normalized_command,omitempty"`
+	NormalizedArgs    []string               `toml:"normalized_args,omitempty"`
+	Cwd               string                 `toml:"cwd,omitempty"`
+	SessionID         string                 `toml:"session_id,omitempty"`
+	Context           map[string]interface{} `toml:"context,omitempty"`
+}
+
+// FixtureNetwork describes a synthetic security.NetworkRequest.
+type FixtureNetwork struct {
+	Method     string            `toml:"method"`
+	URL        string            `toml:"url"`
+	Headers    map[string]string `toml:"headers,omitempty"`
+	SourceTool string            `toml:"source_tool,omitempty"`
+	SessionID  string            `toml:"session_id,omitempty"`
+}
+
+// FixtureResult is the outcome of running one FixtureCase.
+type FixtureResult struct {
+	Fixture  string
+	Case     FixtureCase
+	Decision security.PolicyDecision
+	Passed   bool
+	Problem  string // why the case failed, empty when passed
+}
+
+// FixturePathFor returns the fixture file path belonging to a policy file.
+func FixturePathFor(policyPath string) string {
+	return strings.TrimSuffix(policyPath, filepath.Ext(policyPath)) + FixtureSuffix
+}
+
+// LoadPolicyFixture reads and validates a fixture file.
+func LoadPolicyFixture(path string) (*PolicyFixture, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, types.Wrapf(err, types.ErrCodeInternal, "failed to read fixture %s", path)
+	}
+
+	var fx PolicyFixture
+	if err := toml.Unmarshal(data, &fx); err != nil {
+		return nil, types.Wrapf(err, types.ErrCodeInvalidInput, "failed to parse fixture %s", path)
+	}
+	fx.Path = path
+
+	for i, c := range fx.Cases {
+		label := c.Name
+		if label == "" {
+			label = fmt.Sprintf("case #%d", i+1)
+		}
+		if c.Expect != "allow" && c.Expect != "deny" && c.Expect != RuleActionRequireApproval {
+			return nil, types.Newf(types.ErrCodeInvalidInput, "%s: %s: expect must be \"allow\", \"deny\" or \"require_approval\", got %q", path, label, c.Expect)
+		}
+		if (c.Execution == nil) == (c.Network == nil) {
+			return nil, types.Newf(types.ErrCodeInvalidInput, "%s: %s: exactly one of [case.execution] or [case.network] is required", path, label)
+		}
+		fx.Cases[i].Name = label
+	}
+	return &fx, nil
+}
+
+// RunFixture evaluates every case of a fixture against the Warden.
+func RunFixture(ctx context.Context, w ports.WardenPort, fx *PolicyFixture) ([]FixtureResult, error) {
+	results := make([]FixtureResult, 0, len(fx.Cases))
+	for _, c := range fx.Cases {
+		var decision security.PolicyDecision
+		var err error
+
+		if c.Execution != nil {
+			reqCtx := make(map[string]interface{}, len(c.Execution.Context)+1)
+			for k, v := range c.Execution.Context {
+				reqCtx[k] = v
+			}
+			reqCtx["cwd"] = c.Execution.Cwd
+			decision, err = w.EvaluateExecution(ctx, security.ExecutionRequest{
+				Command:           c.Execution.Command,
+				Args:              c.Execution.Args,
+				NormalizedCommand: c.Execution.NormalizedCommand,
+				NormalizedArgs:    c.Execution.NormalizedArgs,
+				Context:           reqCtx,
+				SessionID:         c.Execution.SessionID,
+			})
+		} else {
+			decision, err = w.Evaluate(ctx, security.NetworkRequest{
+				Method:     strings.ToUpper(c.Network.Method),
+				URL:        c.Network.URL,
+				Headers:    c.Network.Headers,
+				SourceTool: c.Network.SourceTool,
+				SessionID:  c.Network.SessionID,
+			})
+		}
+		if err != nil {
+			return results, types.Wrapf(err, types.ErrCodeExecutionFailed, "%s: %s: evaluation failed", fx.Path, c.Name)
+		}
+
+		res := FixtureResult{Fixture: fx.Path, Case: c, Decision: decision, Passed: true}
+		got := "deny"
+		if decision.Allowed {
+			got = "allow"
+		} else if decision.RequiresApproval {
+			got = RuleActionRequireApproval
+		}
+		switch {
+		case got != c.Expect:
+			res.Passed = false
+			res.Problem = fmt.Sprintf("expected %s, got %s (policy %q)", c.Expect, got, decision.PolicyID)
+		case c.PolicyID != "" && c.PolicyID != decision.PolicyID:
+			res.Passed = false
+			res.Problem = fmt.Sprintf("expected policy %q, got %q", c.PolicyID, decision.PolicyID)
+		}
+		results = append(results, res)
+	}
+	return results, nil
+}
@@ -0,0 +1,356 @@
+package warden
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"io"
+	"log"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/SecDuckOps/shared/types"
+)
+
+const (
+	// CACertFile and CAKeyFile are the names of the interception CA inside its directory.
+	CACertFile = "ca.pem"
+	CAKeyFile  = "ca-key.pem"
+
+	caValidity   = 10 * 365 * 24 * time.Hour
+	leafValidity = 7 * 24 * time.Hour
+	leafRenewal  = time.Hour // mint a new leaf when the cached one expires sooner
+)
+
+// CertAuthority is the local CA used to intercept HTTPS in the Warden proxy.
+// Tools must trust its certificate (CertPath) for interception to succeed.
+type CertAuthority struct {
+	dir  string
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+
+	mu     sync.Mutex
+	leaves map[string]*tls.Certificate
+}
+
+// LoadOrCreateCA loads the CA from dir, generating and storing a new one on
+// first use. The private key is written with 0600 permissions.
+func LoadOrCreateCA(dir string) (*CertAuthority, error) {
+	certPath := filepath.Join(dir, CACertFile)
+	keyPath := filepath.Join(dir, CAKeyFile)
+
+	certPEM, certErr := os.ReadFile(certPath)
+	keyPEM, keyErr := os.ReadFile(keyPath)
+	if certErr == nil && keyErr == nil {
+		return parseCA(dir, certPEM, keyPEM)
+	}
+	if !errors.Is(certErr, os.ErrNotExist) && certErr != nil {
+		return nil, types.Wrapf(certErr, types.ErrCodeInternal, "failed to read %s", certPath)
+	}
+	if !errors.Is(keyErr, os.ErrNotExist) && keyErr != nil {
+		return nil, types.Wrapf(keyErr, types.ErrCodeInternal, "failed to read %s", keyPath)
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, types.Wrap(err, types.ErrCodeInternal, "failed to generate CA key")
+	}
+	now := time.Now()
+	tmpl := &x509.Certificate{
+		SerialNumber:          randomSerial(),
+		Subject:               pkix.Name{CommonName: "DuckOps Warden Local CA", Organization: []string{"DuckOps"}},
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.Add(caValidity),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign | x509.KeyUsageDigitalSignature,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		MaxPathLenZero:        true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		return nil, types.Wrap(err, types.ErrCodeInternal, "failed to create CA certificate")
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return nil, types.Wrap(err, types.ErrCodeInternal, "failed to encode CA key")
+	}
+
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, types.Wrapf(err, types.ErrCodeInternal, "failed to create %s", dir)
+	}
+	if err := os.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600); err != nil {
+		return nil, types.Wrapf(err, types.ErrCodeInternal, "failed to write %s", keyPath)
+	}
+	if err := os.WriteFile(certPath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0644); err != nil {
+		return nil, types.Wrapf(err, types.ErrCodeInternal, "failed to write %s", certPath)
+	}
+
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, types.Wrap(err, types.ErrCodeInternal, "failed to parse generated CA certificate")
+	}
+	return &CertAuthority{dir: dir, cert: cert, key: key, leaves: make(map[string]*tls.Certificate)}, nil
+}
+
+func parseCA(dir string, certPEM, keyPEM []byte) (*CertAuthority, error) {
+	certBlock, _ := pem.Decode(certPEM)
+	if certBlock == nil {
+		return nil, types.Newf(types.ErrCodeInvalidInput, "%s: no PEM certificate found", filepath.Join(dir, CACertFile))
+	}
+	cert, err := x509.ParseCertificate(certBlock.Bytes)
+	if err != nil {
+		return nil, types.Wrapf(err, types.ErrCodeInvalidInput, "failed to parse %s", filepath.Join(dir, CACertFile))
+	}
+	keyBlock, _ := pem.Decode(keyPEM)
+	if keyBlock == nil {
+		return nil, types.Newf(types.ErrCodeInvalidInput, "%s: no PEM key found", filepath.Join(dir, CAKeyFile))
+	}
+	key, err := x509.ParseECPrivateKey(keyBlock.Bytes)
+	if err != nil {
+		return nil, types.Wrapf(err, types.ErrCodeInvalidInput, "failed to parse %s", filepath.Join(dir, CAKeyFile))
+	}
+	if !cert.IsCA {
+		return nil, types.Newf(types.ErrCodeInvalidInput, "%s is not a CA certificate", filepath.Join(dir, CACertFile))
+	}
+	return &CertAuthority{dir: dir, cert: cert, key: key, leaves: make(map[string]*tls.Certificate)}, nil
+}
+
+// CertPath returns the path of the CA certificate that clients must trust.
+func (ca *CertAuthority) CertPath() string {
+	return filepath.Join(ca.dir, CACertFile)
+}
+
+// Certificate returns the CA certificate.
+func (ca *CertAuthority) Certificate() *x509.Certificate {
+	return ca.cert
+}
+
+// LeafFor returns a certificate for host signed by the CA, minting and caching
+// it on first use.
+func (ca *CertAuthority) LeafFor(host string) (*tls.Certificate, error) {
+	host = strings.ToLower(host)
+
+	ca.mu.Lock()
+	defer ca.mu.Unlock()
+
+	if leaf, ok := ca.leaves[host]; ok && time.Until(leaf.Leaf.NotAfter) > leafRenewal {
+		return leaf, nil
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, types.Wrap(err, types.ErrCodeInternal, "failed to generate leaf key")
+	}
+	now := time.Now()
+	tmpl := &x509.Certificate{
+		SerialNumber: randomSerial(),
+		Subject:      pkix.Name{CommonName: host},
+		NotBefore:    now.Add(-time.Hour),
+		NotAfter:     now.Add(leafValidity),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	if ip := net.ParseIP(host); ip != nil {
+		tmpl.IPAddresses = []net.IP{ip}
+	} else {
+		tmpl.DNSNames = []string{host}
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		return nil, types.Wrapf(err, types.ErrCodeInternal, "failed to mint certificate for %s", host)
+	}
+	parsed, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, types.Wrapf(err, types.ErrCodeInternal, "failed to parse certificate for %s", host)
+	}
+
+	leaf := &tls.Certificate{
+		Certificate: [][]byte{der, ca.cert.Raw},
+		PrivateKey:  key,
+		Leaf:        parsed,
+	}
+	ca.leaves[host] = leaf
+	return leaf, nil
+}
+
+func randomSerial() *big.Int {
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 127))
+	if err != nil {
+		return big.NewInt(time.Now().UnixNano())
+	}
+	return serial
+}
+
+// InterceptConfig enables TLS interception in the Warden proxy.
+type InterceptConfig struct {
+	CA *CertAuthority
+
+	// Bypass lists hosts that are never intercepted, for example endpoints
+	// with certificate pinning. "*.example.com" matches every subdomain.
+	// Bypassed hosts are tunnelled and evaluated on the CONNECT target only.
+	Bypass []string
+
+	// UpstreamRoots verifies upstream servers; nil uses the system roots.
+	UpstreamRoots *x509.CertPool
+}
+
+// bypassed reports whether host must be tunnelled without interception.
+func (c *InterceptConfig) bypassed(host string) bool {
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	for _, pattern := range c.Bypass {
+		pattern = strings.ToLower(strings.TrimSpace(pattern))
+		if suffix, ok := strings.CutPrefix(pattern, "*."); ok {
+			if strings.HasSuffix(host, "."+suffix) {
+				return true
+			}
+			continue
+		}
+		if host == pattern {
+			return true
+		}
+	}
+	return false
+}
+
+// serveIntercepted terminates TLS for a CONNECT target with a leaf signed by
+// the local CA and serves the decrypted requests through serveHTTP, so that
+// each one is evaluated with its full URL, method and headers.
+func (p *forwardProxy) serveIntercepted(rw http.ResponseWriter, r *http.Request, target string) {
+	host, port, _ := net.SplitHostPort(target)
+
+	client, buffered, err := http.NewResponseController(rw).Hijack()
+	if err != nil {
+		http.Error(rw, "tunnelling not supported", http.StatusInternalServerError)
+		return
+	}
+	if _, err := io.WriteString(client, "HTTP/1.1 200 Connection Established\r\n\r\n"); err != nil {
+		client.Close()
+		return
+	}
+
+	var conn net.Conn = client
+	if buffered.Reader.Buffered() > 0 {
+		conn = &prefixedConn{Conn: client, r: buffered.Reader}
+	}
+	tlsConn := tls.Server(conn, &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		NextProtos: []string{"http/1.1"},
+		GetCertificate: func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+			// Always mint for the CONNECT host: the policy decision was
+			// scoped to it, whatever SNI the client sends.
+			return p.intercept.CA.LeafFor(host)
+		},
+	})
+
+	p.track(client)
+	defer p.untrack(client)
+
+	// The outer request's attribution applies to every request in the tunnel.
+	tool := r.Header.Get(headerSourceTool)
+	session := r.Header.Get(headerSessionID)
+	if credTool, credSession, ok := proxyCredentials(r); ok {
+		if tool == "" {
+			tool = credTool
+		}
+		if session == "" {
+			session = credSession
+		}
+	}
+
+	urlHost := target
+	if port == "443" {
+		urlHost = host
+	}
+
+	listener := newSingleConnListener(tlsConn)
+	server := &http.Server{
+		Handler: http.HandlerFunc(func(irw http.ResponseWriter, ir *http.Request) {
+			innerHost := ir.Host
+			if h, _, err := net.SplitHostPort(innerHost); err == nil {
+				innerHost = h
+			}
+			if !strings.EqualFold(innerHost, host) {
+				// Refuse domain fronting: the Host must match the CONNECT target.
+				http.Error(irw, "Host does not match the CONNECT target", http.StatusMisdirectedRequest)
+				return
+			}
+
+			ir.URL.Scheme = "https"
+			ir.URL.Host = urlHost
+			if tool != "" && ir.Header.Get(headerSourceTool) == "" {
+				ir.Header.Set(headerSourceTool, tool)
+			}
+			if session != "" && ir.Header.Get(headerSessionID) == "" {
+				ir.Header.Set(headerSessionID, session)
+			}
+			p.serveHTTP(irw, ir)
+		}),
+		ReadHeaderTimeout: p.timeouts.ReadHeader,
+		IdleTimeout:       p.timeouts.Idle,
+		ErrorLog:          log.New(io.Discard, "", 0), // handshake failures from clients that do not trust the CA
+		ConnState: func(_ net.Conn, state http.ConnState) {
+			if state == http.StateClosed || state == http.StateHijacked {
+				listener.Close()
+			}
+		},
+	}
+	_ = server.Serve(listener)
+}
+
+// prefixedConn replays bytes buffered before a connection was hijacked.
+type prefixedConn struct {
+	net.Conn
+	r io.Reader
+}
+
+func (c *prefixedConn) Read(b []byte) (int, error) {
+	return c.r.Read(b)
+}
+
+// singleConnListener hands one connection to http.Server and then blocks
+// Accept until closed.
+type singleConnListener struct {
+	conn   net.Conn
+	once   sync.Once
+	closed chan struct{}
+	mu     sync.Mutex
+	taken  bool
+}
+
+func newSingleConnListener(conn net.Conn) *singleConnListener {
+	return &singleConnListener{conn: conn, closed: make(chan struct{})}
+}
+
+func (l *singleConnListener) Accept() (net.Conn, error) {
+	l.mu.Lock()
+	if !l.taken {
+		l.taken = true
+		l.mu.Unlock()
+		return l.conn, nil
+	}
+	l.mu.Unlock()
+
+	<-l.closed
+	return nil, net.ErrClosed
+}
+
+func (l *singleConnListener) Close() error {
+	l.once.Do(func() { close(l.closed) })
+	return nil
+}
+
+func (l *singleConnListener) Addr() net.Addr {
+	return l.conn.LocalAddr()
+}
@@ -0,0 +1,195 @@
+package warden
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/SecDuckOps/agent/internal/adapters/warden/cedar"
+	"github.com/SecDuckOps/agent/internal/domain/security"
+	"github.com/SecDuckOps/shared/types"
+)
+
+// Entity types and actions exposed to Cedar policies.
+//
+// Network requests are evaluated as
+//
+//	principal = Tool::"<source_tool>", action = Action::"network", resource = Host::"<host>"
+//
+// and local executions as
+//
+//	principal = Session::"<session_id>", action = Action::"execute", resource = Command::"<command>"
+//
+// Request details are available as attributes of `context` (see networkContext
+// and executionContext).
+const (
+	cedarToolType    = "Tool"
+	cedarSessionType = "Session"
+	cedarHostType    = "Host"
+	cedarCommandType = "Command"
+	cedarActionType  = "Action"
+
+	cedarActionNetwork = "network"
+	cedarActionExecute = "execute"
+
+	// approvalAnnotation marks a forbid statement as "denied until a human
+	// approves" rather than a hard deny: @approval("required")
+	approvalAnnotation = "approval"
+)
+
+// compiledPolicy pairs a NetworkPolicy with its parsed Cedar statements.
+type compiledPolicy struct {
+	security.NetworkPolicy
+	cedar cedar.PolicySet // nil when the body uses the legacy line DSL
+}
+
+// policyVerdict is the outcome of evaluating a single NetworkPolicy.
+type policyVerdict struct {
+	matched    bool
+	allowed    bool
+	approval   bool     // denied only by @approval forbids
+	statements []string // determining Cedar statement IDs, if any
+	errors     []string // Cedar evaluation errors (the statement is skipped)
+}
+
+// compilePolicy parses the policy body. Bodies that do not look like Cedar are
+// kept as legacy `ALLOW|DENY predicate "value"` rules.
+func compilePolicy(p security.NetworkPolicy) (compiledPolicy, error) {
+	cp := compiledPolicy{NetworkPolicy: p}
+	if !cedar.IsCedar(p.CedarBody) {
+		return cp, nil
+	}
+	set, err := cedar.Parse(p.CedarBody)
+	if err != nil {
+		return cp, types.Wrapf(err, types.ErrCodeInvalidInput, "invalid Cedar policy %s (%s)", p.Name, p.ID)
+	}
+	cp.cedar = set
+	return cp, nil
+}
+
+func (p compiledPolicy) evaluateNetwork(req security.NetworkRequest) policyVerdict {
+	if p.cedar == nil {
+		matched, allowed := evaluateCedarPolicy(p.NetworkPolicy, req)
+		return policyVerdict{matched: matched, allowed: allowed}
+	}
+	return verdictFrom(p.cedar.IsAuthorized(nil, networkCedarRequest(req)))
+}
+
+func (p compiledPolicy) evaluateExecution(req security.ExecutionRequest) policyVerdict {
+	if p.cedar == nil {
+		matched, allowed := evaluateCedarExecutionPolicy(p.NetworkPolicy, req)
+		return policyVerdict{matched: matched, allowed: allowed}
+	}
+	return verdictFrom(p.cedar.IsAuthorized(nil, executionCedarRequest(req)))
+}
+
+func verdictFrom(d cedar.Decision) policyVerdict {
+	v := policyVerdict{matched: d.Matched(), allowed: d.Allowed}
+	v.approval = v.matched && !v.allowed
+	for _, p := range d.Determining {
+		if _, ok := p.Annotations[approvalAnnotation]; !ok {
+			v.approval = false
+		}
+		v.statements = append(v.statements, fmt.Sprintf("%s %s", p.Effect, p.ID))
+	}
+	for _, e := range d.Errors {
+		v.errors = append(v.errors, e.Error())
+	}
+	return v
+}
+
+// networkCedarRequest maps a NetworkRequest onto the Cedar request model.
+//
+// Context attributes: method, url, scheme, host, port, path, query,
+// headers (record keyed by lower-case header name), source_tool, session_id.
+func networkCedarRequest(req security.NetworkRequest) cedar.Request {
+	scheme, host, port, path, query := splitRequestURL(req.URL)
+
+	headers := make(cedar.Record, len(req.Headers))
+	for k, v := range req.Headers {
+		headers[strings.ToLower(k)] = v
+	}
+
+	return cedar.Request{
+		Principal: cedar.NewEntityUID(cedarToolType, req.SourceTool),
+		Action:    cedar.NewEntityUID(cedarActionType, cedarActionNetwork),
+		Resource:  cedar.NewEntityUID(cedarHostType, host),
+		Context: cedar.Record{
+			"method":      strings.ToUpper(req.Method),
+			"url":         req.URL,
+			"scheme":      scheme,
+			"host":        host,
+			"port":        port,
+			"path":        path,
+			"query":       query,
+			"headers":     headers,
+			"source_tool": req.SourceTool,
+			"session_id":  req.SessionID,
+		},
+	}
+}
+
+// executionCedarRequest maps an ExecutionRequest onto the Cedar request model.
+//
+// Context attributes: command and args (normalized when available),
+// raw_command, raw_args, cwd, session_id, plus every entry of req.Context.
+func executionCedarRequest(req security.ExecutionRequest) cedar.Request {
+	command := req.Command
+	if req.NormalizedCommand != "" {
+		command = req.NormalizedCommand
+	}
+	args := req.Args
+	if len(req.NormalizedArgs) > 0 {
+		args = req.NormalizedArgs
+	}
+
+	ctx := make(cedar.Record, len(req.Context)+6)
+	for k, v := range req.Context {
+		ctx[k] = cedar.FromGo(v)
+	}
+	if _, ok := ctx["cwd"].(string); !ok {
+		ctx["cwd"] = ""
+	}
+	ctx["command"] = command
+	ctx["args"] = cedar.FromGo(append([]string{}, args...))
+	ctx["raw_command"] = req.Command
+	ctx["raw_args"] = cedar.FromGo(append([]string{}, req.Args...))
+	ctx["session_id"] = req.SessionID
+
+	return cedar.Request{
+		Principal: cedar.NewEntityUID(cedarSessionType, req.SessionID),
+		Action:    cedar.NewEntityUID(cedarActionType, cedarActionExecute),
+		Resource:  cedar.NewEntityUID(cedarCommandType, command),
+		Context:   ctx,
+	}
+}
+
+// splitRequestURL extracts the components of an absolute URL or of a bare
+// `host:port` authority (as seen in CONNECT requests).
+func splitRequestURL(raw string) (scheme, host string, port int64, path, query string) {
+	if !strings.Contains(raw, "://") {
+		h, p, err := net.SplitHostPort(raw)
+		if err != nil {
+			return "", raw, 0, "", ""
+		}
+		n, _ := strconv.ParseInt(p, 10, 64)
+		return "", h, n, "", ""
+	}
+
+	u, err := url.Parse(raw)
+	if err != nil {
+		return "", "", 0, "", ""
+	}
+	scheme = strings.ToLower(u.Scheme)
+	host = strings.ToLower(u.Hostname())
+	if p := u.Port(); p != "" {
+		port, _ = strconv.ParseInt(p, 10, 64)
+	} else if scheme == "https" {
+		port = 443
+	} else if scheme == "http" {
+		port = 80
+	}
+	return scheme, host, port, u.EscapedPath(), u.RawQuery
+}
@@ -0,0 +1,153 @@
+package warden
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/SecDuckOps/agent/internal/adapters/warden/cedar"
+	"github.com/SecDuckOps/agent/internal/domain/security"
+	"github.com/SecDuckOps/shared/types"
+)
+
+// DefaultPolicyPriority is assigned to policies loaded from local files.
+const DefaultPolicyPriority = 10
+
+// PolicyIssue is a syntax problem found in a policy body.
+type PolicyIssue struct {
+	File    string `json:"file,omitempty"`
+	Line    int    `json:"line"`
+	Column  int    `json:"column,omitempty"`
+	Message string `json:"message"`
+}
+
+func (i PolicyIssue) String() string {
+	loc := fmt.Sprintf("%d", i.Line)
+	if i.Column > 0 {
+		loc = fmt.Sprintf("%d:%d", i.Line, i.Column)
+	}
+	if i.File != "" {
+		return fmt.Sprintf("%s:%s: %s", i.File, loc, i.Message)
+	}
+	return fmt.Sprintf("%s: %s", loc, i.Message)
+}
+
+// legacy DSL predicates understood by the network and execution evaluators
+var legacyPredicates = map[string]bool{
+	"url_contains": true, "url_prefix": true, "url_suffix": true, "method": true,
+	"source_tool": true, "session_id": true, "header": true,
+	"command": true, "dir_prefix": true, "arg_contains": true,
+	"all": true,
+}
+
+// ValidatePolicy checks a policy body for syntax errors. Cedar bodies are
+// parsed in full (a parse stops at the first error); legacy DSL bodies are
+// checked line by line so that every malformed rule is reported.
+func ValidatePolicy(body string) []PolicyIssue {
+	if cedar.IsCedar(body) {
+		if _, err := cedar.Parse(body); err != nil {
+			var perr *cedar.ParseError
+			if errors.As(err, &perr) {
+				return []PolicyIssue{{Line: perr.Pos.Line, Column: perr.Pos.Column, Message: perr.Msg}}
+			}
+			return []PolicyIssue{{Line: 1, Message: err.Error()}}
+		}
+		return nil
+	}
+
+	var issues []PolicyIssue
+	for i, line := range strings.Split(body, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "//") {
+			continue
+		}
+
+		parts := strings.SplitN(line, " ", 3)
+		if len(parts) < 3 {
+			issues = append(issues, PolicyIssue{Line: i + 1, Message: fmt.Sprintf("expected `ALLOW|DENY predicate \"value\"`, got %q", line)})
+			continue
+		}
+		if action := strings.ToUpper(parts[0]); action != "ALLOW" && action != "DENY" {
+			issues = append(issues, PolicyIssue{Line: i + 1, Message: fmt.Sprintf("unknown action %q, expected ALLOW or DENY", parts[0])})
+		}
+		if predicate := strings.ToLower(parts[1]); !legacyPredicates[predicate] {
+			issues = append(issues, PolicyIssue{Line: i + 1, Message: fmt.Sprintf("unknown predicate %q", parts[1])})
+		}
+	}
+	return issues
+}
+
+// PolicyIDFromPath derives a stable policy ID from a policy file name.
+func PolicyIDFromPath(path string) string {
+	return strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+}
+
+// PolicyFileExt is the extension of policy files picked up from a policy
+// directory.
+const PolicyFileExt = ".cedar"
+
+// ExpandPolicyFiles returns the configured policy files followed by every
+// *.cedar file in the configured policy directories, so that dropping a new
+// file into a policy directory is enough to load it. Files are taken exactly
+// as listed, in their given order; nothing next to them is picked up. The
+// directory files are sorted by path.
+func ExpandPolicyFiles(files, dirs []string) []string {
+	expanded := make([]string, 0, len(files))
+	seen := make(map[string]bool, len(files))
+	for _, path := range files {
+		path = filepath.Clean(path)
+		if !seen[path] {
+			seen[path] = true
+			expanded = append(expanded, path)
+		}
+	}
+
+	var extra []string
+	for _, dir := range dirs {
+		matches, _ := filepath.Glob(filepath.Join(dir, "*"+PolicyFileExt))
+		for _, path := range matches {
+			if !seen[path] {
+				seen[path] = true
+				extra = append(extra, path)
+			}
+		}
+	}
+	sort.Strings(extra)
+	return append(expanded, extra...)
+}
+
+// ReadPolicyFiles reads policy files into NetworkPolicy entries. Each file
+// becomes one policy whose ID is derived from its file name. Files that cannot
+// be read are returned as errors; syntax problems are not checked here.
+func ReadPolicyFiles(paths []string) ([]security.NetworkPolicy, error) {
+	policies := make([]security.NetworkPolicy, 0, len(paths))
+	seen := make(map[string]int)
+	var errs []error
+
+	for _, path := range paths {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			errs = append(errs, types.Wrapf(err, types.ErrCodeInternal, "failed to read policy file %s", path))
+			continue
+		}
+
+		id := PolicyIDFromPath(path)
+		if n := seen[id]; n > 0 {
+			id = fmt.Sprintf("%s-%d", id, n+1)
+		}
+		seen[PolicyIDFromPath(path)]++
+
+		policies = append(policies, security.NetworkPolicy{
+			ID:        id,
+			Name:      filepath.Base(path),
+			CedarBody: string(content),
+			Enabled:   true,
+			Priority:  DefaultPolicyPriority,
+		})
+	}
+
+	return policies, errors.Join(errs...)
+}
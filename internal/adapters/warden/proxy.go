@@ -0,0 +1,408 @@
+package warden
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/SecDuckOps/agent/internal/domain/security"
+	shared_ports "github.com/SecDuckOps/shared/ports"
+	"github.com/google/uuid"
+)
+
+const (
+	// headerSourceTool and headerSessionID attribute a proxied request to a
+	// tool and session. They are stripped before the request is forwarded.
+	headerSourceTool = "X-DuckOps-Source-Tool"
+	headerSessionID  = "X-DuckOps-Session-ID"
+)
+
+// ProxyTimeouts bounds how long the egress proxy waits on clients and upstreams.
+type ProxyTimeouts struct {
+	Dial           time.Duration // TCP connect and TLS handshake to the upstream
+	ResponseHeader time.Duration // wait for the upstream's response headers
+	ReadHeader     time.Duration // wait for a client's request headers
+	Idle           time.Duration // keep-alive connections and silent CONNECT tunnels
+}
+
+// DefaultProxyTimeouts returns the timeouts used unless SetProxyTimeouts is called.
+func DefaultProxyTimeouts() ProxyTimeouts {
+	return ProxyTimeouts{
+		Dial:           10 * time.Second,
+		ResponseHeader: 30 * time.Second,
+		ReadHeader:     10 * time.Second,
+		Idle:           90 * time.Second,
+	}
+}
+
+// forwardProxy is the egress proxy behind Warden.StartProxy. Plain HTTP
+// requests are evaluated and relayed; HTTPS is tunnelled with CONNECT after
+// the policy allows the target host. Bodies are streamed in both directions.
+type forwardProxy struct {
+	warden    *Warden
+	timeouts  ProxyTimeouts
+	intercept *InterceptConfig // nil unless TLS interception is enabled
+	transport *http.Transport
+	relay     *httputil.ReverseProxy
+
+	mu      sync.Mutex
+	tunnels map[net.Conn]struct{}
+	closed  bool
+}
+
+func newForwardProxy(w *Warden, timeouts ProxyTimeouts, intercept *InterceptConfig) *forwardProxy {
+	dialer := &net.Dialer{Timeout: timeouts.Dial, KeepAlive: 30 * time.Second}
+	p := &forwardProxy{
+		warden:    w,
+		timeouts:  timeouts,
+		intercept: intercept,
+		transport: &http.Transport{
+			// Never chain through HTTP(S)_PROXY: it usually points back at us.
+			Proxy:                 nil,
+			DialContext:           dialer.DialContext,
+			TLSHandshakeTimeout:   timeouts.Dial,
+			ResponseHeaderTimeout: timeouts.ResponseHeader,
+			IdleConnTimeout:       timeouts.Idle,
+			ExpectContinueTimeout: time.Second,
+			MaxIdleConnsPerHost:   16,
+			ForceAttemptHTTP2:     true,
+		},
+		tunnels: make(map[net.Conn]struct{}),
+	}
+	if intercept != nil && intercept.UpstreamRoots != nil {
+		p.transport.TLSClientConfig = &tls.Config{RootCAs: intercept.UpstreamRoots}
+	}
+	p.relay = &httputil.ReverseProxy{
+		Rewrite: func(pr *httputil.ProxyRequest) {
+			// The outbound URL is the absolute request URL; only our own
+			// attribution headers are dropped. Hop-by-hop headers such as
+			// Proxy-Authorization are removed by ReverseProxy itself.
+			pr.Out.Header.Del(headerSourceTool)
+			pr.Out.Header.Del(headerSessionID)
+		},
+		Transport:     p.transport,
+		FlushInterval: -1, // flush immediately so streamed responses are not held back
+		ErrorHandler: func(rw http.ResponseWriter, r *http.Request, err error) {
+			rw.WriteHeader(upstreamErrorStatus(err))
+		},
+	}
+	return p
+}
+
+func (p *forwardProxy) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodConnect {
+		p.serveConnect(rw, r)
+		return
+	}
+	if !r.URL.IsAbs() {
+		http.Error(rw, "Warden is a forward proxy; send absolute-form requests", http.StatusBadRequest)
+		return
+	}
+	p.serveHTTP(rw, r)
+}
+
+// serveHTTP evaluates and relays a plain HTTP request.
+func (p *forwardProxy) serveHTTP(rw http.ResponseWriter, r *http.Request) {
+	req := proxyNetworkRequest(r, r.URL.String())
+	decision, err := p.warden.Evaluate(r.Context(), req)
+	if err != nil || !decision.Allowed {
+		p.block(rw, r, req, decision, err)
+		return
+	}
+
+	start := time.Now()
+	rec := &statusRecorder{ResponseWriter: rw, status: http.StatusOK}
+	p.relay.ServeHTTP(rec, r)
+
+	details := map[string]interface{}{
+		"status":      rec.status,
+		"bytes_in":    rec.written,
+		"duration_ms": time.Since(start).Milliseconds(),
+	}
+	if r.TLS != nil {
+		details["intercepted"] = true
+	}
+	p.warden.recordNetwork(r.Context(), security.AuditNetworkReq, req, decision, details)
+}
+
+// serveConnect evaluates the CONNECT target and tunnels raw bytes to it.
+// With interception enabled, non-bypassed hosts are decrypted instead and
+// every inner request is evaluated on its own.
+func (p *forwardProxy) serveConnect(rw http.ResponseWriter, r *http.Request) {
+	target := r.Host
+	if _, _, err := net.SplitHostPort(target); err != nil {
+		target = net.JoinHostPort(target, "443")
+	}
+	if host, _, _ := net.SplitHostPort(target); p.intercept != nil && !p.intercept.bypassed(host) {
+		p.serveIntercepted(rw, r, target)
+		return
+	}
+
+	req := proxyNetworkRequest(r, target)
+	decision, err := p.warden.Evaluate(r.Context(), req)
+	if err != nil || !decision.Allowed {
+		p.block(rw, r, req, decision, err)
+		return
+	}
+
+	upstream, err := net.DialTimeout("tcp", target, p.timeouts.Dial)
+	if err != nil {
+		rw.WriteHeader(upstreamErrorStatus(err))
+		p.warden.recordNetwork(r.Context(), security.AuditNetworkReq, req, decision, map[string]interface{}{
+			"status": upstreamErrorStatus(err),
+			"error":  err.Error(),
+		})
+		return
+	}
+
+	client, buffered, err := http.NewResponseController(rw).Hijack()
+	if err != nil {
+		upstream.Close()
+		http.Error(rw, "tunnelling not supported", http.StatusInternalServerError)
+		return
+	}
+	if _, err := io.WriteString(client, "HTTP/1.1 200 Connection Established\r\n\r\n"); err != nil {
+		client.Close()
+		upstream.Close()
+		return
+	}
+
+	// Bytes the client sent right after the CONNECT headers are already in
+	// the hijacked reader's buffer.
+	var clientReader io.Reader = client
+	if n := buffered.Reader.Buffered(); n > 0 {
+		early, _ := buffered.Reader.Peek(n)
+		clientReader = io.MultiReader(bytes.NewReader(early), client)
+	}
+
+	p.track(client, upstream)
+	start := time.Now()
+	sent, received := p.pipe(client, clientReader, upstream)
+	p.untrack(client, upstream)
+
+	p.warden.recordNetwork(r.Context(), security.AuditNetworkReq, req, decision, map[string]interface{}{
+		"status":      http.StatusOK,
+		"bytes_out":   sent,
+		"bytes_in":    received,
+		"duration_ms": time.Since(start).Milliseconds(),
+	})
+}
+
+// pipe copies in both directions until either side closes or stays silent
+// longer than the idle timeout. It returns the byte counts client→upstream
+// and upstream→client.
+func (p *forwardProxy) pipe(client net.Conn, clientReader io.Reader, upstream net.Conn) (sent, received int64) {
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		sent = p.copyIdle(upstream, clientReader, client)
+		closeWrite(upstream)
+	}()
+	received = p.copyIdle(client, upstream, upstream)
+	closeWrite(client)
+	wg.Wait()
+
+	client.Close()
+	upstream.Close()
+	return sent, received
+}
+
+// copyIdle copies src to dst, extending conn's read deadline before every read.
+func (p *forwardProxy) copyIdle(dst io.Writer, src io.Reader, conn net.Conn) int64 {
+	buf := make([]byte, 32*1024)
+	var total int64
+	for {
+		if p.timeouts.Idle > 0 {
+			_ = conn.SetReadDeadline(time.Now().Add(p.timeouts.Idle))
+		}
+		n, err := src.Read(buf)
+		if n > 0 {
+			written, werr := dst.Write(buf[:n])
+			total += int64(written)
+			if werr != nil {
+				return total
+			}
+		}
+		if err != nil {
+			return total
+		}
+	}
+}
+
+// block rejects a request and records it in the audit log.
+func (p *forwardProxy) block(rw http.ResponseWriter, r *http.Request, req security.NetworkRequest, decision security.PolicyDecision, err error) {
+	details := map[string]interface{}{"reasons": decision.Reasons}
+	if err != nil {
+		details["error"] = err.Error()
+	}
+	p.warden.recordNetwork(r.Context(), security.AuditNetworkBlock, req, decision, details)
+	http.Error(rw, "Blocked by Warden policy", http.StatusForbidden)
+}
+
+// track registers tunnel connections for close. A tunnel hijacked while the
+// proxy was closing is closed right away.
+func (p *forwardProxy) track(conns ...net.Conn) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, c := range conns {
+		if p.closed {
+			c.Close()
+			continue
+		}
+		p.tunnels[c] = struct{}{}
+	}
+}
+
+func (p *forwardProxy) untrack(conns ...net.Conn) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, c := range conns {
+		delete(p.tunnels, c)
+	}
+}
+
+// close tears down open tunnels, which http.Server.Shutdown does not track
+// once a connection has been hijacked, and drops idle upstream connections.
+func (p *forwardProxy) close() {
+	p.mu.Lock()
+	for c := range p.tunnels {
+		c.Close()
+	}
+	p.tunnels = make(map[net.Conn]struct{})
+	p.closed = true
+	p.mu.Unlock()
+
+	p.transport.CloseIdleConnections()
+}
+
+// proxyNetworkRequest builds the policy request for a proxied call. The
+// calling tool and session come from the X-DuckOps-* headers or, for clients
+// that can only be configured through HTTP(S)_PROXY, from the proxy
+// credentials: http://<tool>:<session>@127.0.0.1:9090.
+func proxyNetworkRequest(r *http.Request, target string) security.NetworkRequest {
+	req := security.NetworkRequest{
+		ID:         uuid.New().String(),
+		Method:     r.Method,
+		URL:        target,
+		Headers:    flattenHeaders(r.Header),
+		SourceTool: r.Header.Get(headerSourceTool),
+		SessionID:  r.Header.Get(headerSessionID),
+		Timestamp:  time.Now(),
+	}
+	delete(req.Headers, "Proxy-Authorization")
+
+	if tool, session, ok := proxyCredentials(r); ok {
+		if req.SourceTool == "" {
+			req.SourceTool = tool
+		}
+		if req.SessionID == "" {
+			req.SessionID = session
+		}
+	}
+	return req
+}
+
+func proxyCredentials(r *http.Request) (tool, session string, ok bool) {
+	auth := r.Header.Get("Proxy-Authorization")
+	scheme, encoded, found := strings.Cut(auth, " ")
+	if !found || !strings.EqualFold(scheme, "Basic") {
+		return "", "", false
+	}
+	decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+	if err != nil {
+		return "", "", false
+	}
+	tool, session, _ = strings.Cut(string(decoded), ":")
+	return tool, session, true
+}
+
+// recordNetwork writes a proxy decision to the audit log, if one is configured.
+func (w *Warden) recordNetwork(ctx context.Context, action security.AuditAction, req security.NetworkRequest, decision security.PolicyDecision, details map[string]interface{}) {
+	w.mu.RLock()
+	audit := w.audit
+	w.mu.RUnlock()
+	if audit == nil {
+		return
+	}
+
+	details["method"] = req.Method
+	details["policy_id"] = decision.PolicyID
+	if req.SourceTool != "" {
+		details["source_tool"] = req.SourceTool
+	}
+	actor := req.SourceTool
+	if actor == "" {
+		actor = "warden"
+	}
+	session := req.SessionID
+	if session == "" {
+		session = policyAuditSession
+	}
+
+	err := audit.Record(ctx, security.AuditEntry{
+		ID:        req.ID,
+		SessionID: session,
+		Action:    action,
+		Actor:     actor,
+		Target:    req.URL,
+		Details:   details,
+		Timestamp: time.Now(),
+	})
+	if err != nil && w.logger != nil {
+		w.logger.ErrorErr(ctx, err, "Failed to record proxied request in audit log",
+			shared_ports.Field{Key: "request_id", Value: req.ID},
+		)
+	}
+}
+
+// upstreamErrorStatus maps a dial or round-trip failure to 504 for timeouts
+// and 502 otherwise.
+func upstreamErrorStatus(err error) int {
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() || errors.Is(err, context.DeadlineExceeded) {
+		return http.StatusGatewayTimeout
+	}
+	return http.StatusBadGateway
+}
+
+func closeWrite(c net.Conn) {
+	if cw, ok := c.(interface{ CloseWrite() error }); ok {
+		_ = cw.CloseWrite()
+		return
+	}
+	_ = c.Close()
+}
+
+// statusRecorder captures the status code and body size of a relayed
+// response. Unwrap lets http.ResponseController reach the underlying
+// writer's Flush for streaming.
+type statusRecorder struct {
+	http.ResponseWriter
+	status  int
+	written int64
+}
+
+func (s *statusRecorder) WriteHeader(code int) {
+	s.status = code
+	s.ResponseWriter.WriteHeader(code)
+}
+
+func (s *statusRecorder) Write(b []byte) (int, error) {
+	n, err := s.ResponseWriter.Write(b)
+	s.written += int64(n)
+	return n, err
+}
+
+func (s *statusRecorder) Unwrap() http.ResponseWriter {
+	return s.ResponseWriter
+}
@@ -0,0 +1,315 @@
+package warden
+
+import (
+	"context"
+	"errors"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/SecDuckOps/agent/internal/domain/security"
+	"github.com/SecDuckOps/agent/internal/ports"
+	shared_ports "github.com/SecDuckOps/shared/ports"
+)
+
+const (
+	// PolicyReloadedTopic is the event-bus topic carrying security.PolicyReloadEvent.
+	PolicyReloadedTopic = "warden.policy.reloaded"
+
+	// DefaultPolicyPollInterval is how often the watcher lists and stats the
+	// policy files.
+	DefaultPolicyPollInterval = 2 * time.Second
+
+	// policyAuditSession groups Warden reload entries in the audit log.
+	policyAuditSession = "warden"
+
+	// reloadSourceFileWatch is the reload source of polls that found a change.
+	reloadSourceFileWatch = "file_watch"
+
+	// reloadSourceRemoteSync is the reload source of remote rule updates.
+	reloadSourceRemoteSync = "remote_sync"
+)
+
+// DiffPolicies compares two policy sets by ID. A policy is reported as changed
+// when its body, name, priority or enabled flag differ.
+func DiffPolicies(before, after []security.NetworkPolicy) security.PolicyDiff {
+	old := make(map[string]security.NetworkPolicy, len(before))
+	for _, p := range before {
+		old[p.ID] = p
+	}
+
+	var diff security.PolicyDiff
+	for _, p := range after {
+		prev, ok := old[p.ID]
+		switch {
+		case !ok:
+			diff.Added = append(diff.Added, p.ID)
+		case prev != p:
+			diff.Changed = append(diff.Changed, p.ID)
+		}
+		delete(old, p.ID)
+	}
+	for id := range old {
+		diff.Removed = append(diff.Removed, id)
+	}
+
+	sort.Strings(diff.Added)
+	sort.Strings(diff.Removed)
+	sort.Strings(diff.Changed)
+	return diff
+}
+
+// fileStamp is the part of a file's metadata used to detect edits.
+type fileStamp struct {
+	modTime time.Time
+	size    int64
+	exists  bool
+}
+
+// PolicyWatcher owns the Warden's live policy set: the local policy files
+// merged with the rules pushed by the API Gateway. The local files are the
+// configured ones plus every *.cedar file in the configured policy
+// directories (see ExpandPolicyFiles). It reloads the set when a policy file is added, removed
+// or changed, or new remote rules arrive. It polls the files instead of relying on filesystem notifications so that
+// it behaves the same on every platform and survives editors that replace
+// files by renaming them.
+//
+// A reload is all-or-nothing: if any file cannot be read or parsed the
+// previous policy set stays active and the failure is reported. The files are
+// only recorded as seen once a reload succeeds, so a broken edit is retried on
+// every poll until it is fixed; the same failure is reported once. Remote
+// rules are the exception: a broken local file does not hold them up, see
+// SetRemotePolicies.
+type PolicyWatcher struct {
+	warden   *Warden
+	files    []string
+	dirs     []string
+	interval time.Duration
+	bus      ports.EventBusPort  // optional
+	audit    ports.AuditLogPort  // optional
+	logger   shared_ports.Logger // optional
+
+	mu        sync.Mutex
+	stamps    map[string]fileStamp     // stamps of the last successfully loaded files
+	lastError string                   // last reported reload failure
+	remote    []security.NetworkPolicy // last applied remote rules
+}
+
+// NewPolicyWatcher creates a watcher for the given policy files and policy
+// directories. The files are assumed to be loaded already. The event bus,
+// audit log and logger may be nil.
+func NewPolicyWatcher(w *Warden, files, dirs []string, bus ports.EventBusPort, audit ports.AuditLogPort, logger shared_ports.Logger) *PolicyWatcher {
+	pw := &PolicyWatcher{
+		warden:   w,
+		files:    append([]string{}, files...),
+		dirs:     append([]string{}, dirs...),
+		interval: DefaultPolicyPollInterval,
+		bus:      bus,
+		audit:    audit,
+		logger:   logger,
+	}
+	pw.stamps = pw.snapshot()
+	return pw
+}
+
+// SetInterval changes the polling interval. It must be called before Run.
+func (pw *PolicyWatcher) SetInterval(d time.Duration) {
+	if d > 0 {
+		pw.interval = d
+	}
+}
+
+// Run polls the policy files and directories until ctx is cancelled.
+func (pw *PolicyWatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(pw.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if pw.changed() {
+				pw.Reload(ctx, reloadSourceFileWatch)
+			}
+		}
+	}
+}
+
+// Reload reads and applies the policy files now. When nothing changed no
+// event is emitted and an empty diff is returned.
+func (pw *PolicyWatcher) Reload(ctx context.Context, source string) (security.PolicyDiff, error) {
+	pw.mu.Lock()
+	defer pw.mu.Unlock()
+
+	return pw.apply(ctx, source, pw.remote)
+}
+
+// SetRemotePolicies replaces the remote part of the policy set and reloads.
+// If a remote policy fails to parse, the previous remote policies stay in
+// effect. A local file that cannot be read or parsed does not block the
+// update: as at startup, the remote policies are loaded with the local ones
+// that parse, default deny is forced on until the file is fixed, and the
+// skipped files are reported.
+func (pw *PolicyWatcher) SetRemotePolicies(ctx context.Context, remote []security.NetworkPolicy) (security.PolicyDiff, error) {
+	pw.mu.Lock()
+	defer pw.mu.Unlock()
+
+	diff, err := pw.apply(ctx, reloadSourceRemoteSync, remote)
+	if err == nil {
+		pw.remote = remote
+	}
+	return diff, err
+}
+
+// apply merges the local files with the given remote policies and loads the
+// result. Callers must hold pw.mu.
+func (pw *PolicyWatcher) apply(ctx context.Context, source string, remote []security.NetworkPolicy) (security.PolicyDiff, error) {
+	before := pw.warden.Policies()
+
+	// Stat before reading, so an edit made during the read is seen next poll
+	files := ExpandPolicyFiles(pw.files, pw.dirs)
+	stamps := statFiles(files)
+
+	local, readErr := ReadPolicyFiles(files)
+	policies := append(local, remote...)
+	err := readErr
+	if err == nil {
+		diff := DiffPolicies(before, policies)
+		// A skipped broken file leaves no trace in the diff once it is
+		// removed, so a forced deny always reloads to be lifted
+		if diff.Empty() && !pw.warden.ForceDeny() {
+			pw.stamps, pw.lastError = stamps, ""
+			return diff, nil
+		}
+		if err = pw.warden.LoadPolicies(ctx, policies); err == nil {
+			pw.stamps, pw.lastError = stamps, ""
+			pw.report(ctx, security.PolicyReloadEvent{Source: source, Diff: diff, Timestamp: time.Now()})
+			return diff, nil
+		}
+	}
+
+	if source == reloadSourceRemoteSync && policiesParse(remote) {
+		// The stamps are left as they were so the broken files are retried
+		skipped := errors.Join(readErr, pw.warden.LoadValidPolicies(ctx, policies))
+		pw.lastError = skipped.Error()
+		diff := DiffPolicies(before, pw.warden.Policies())
+		pw.report(ctx, security.PolicyReloadEvent{Source: source, Diff: diff, Skipped: pw.lastError, Timestamp: time.Now()})
+		return diff, nil
+	}
+
+	if msg := err.Error(); msg != pw.lastError || source != reloadSourceFileWatch {
+		pw.lastError = msg
+		pw.report(ctx, security.PolicyReloadEvent{Source: source, Error: msg, Timestamp: time.Now()})
+	}
+	return security.PolicyDiff{}, err
+}
+
+// policiesParse reports whether every policy compiles.
+func policiesParse(policies []security.NetworkPolicy) bool {
+	for _, p := range policies {
+		if _, err := compilePolicy(p); err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// changed lists and stats the policy files and reports whether the set of
+// files or any of their stamps differ from the last successful reload.
+func (pw *PolicyWatcher) changed() bool {
+	current := pw.snapshot()
+
+	pw.mu.Lock()
+	defer pw.mu.Unlock()
+
+	return stampsDiffer(pw.stamps, current)
+}
+
+func (pw *PolicyWatcher) snapshot() map[string]fileStamp {
+	return statFiles(ExpandPolicyFiles(pw.files, pw.dirs))
+}
+
+// statFiles records the stamp of every path; missing files get a zero stamp.
+func statFiles(paths []string) map[string]fileStamp {
+	stamps := make(map[string]fileStamp, len(paths))
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			stamps[path] = fileStamp{}
+			continue
+		}
+		stamps[path] = fileStamp{modTime: info.ModTime(), size: info.Size(), exists: true}
+	}
+	return stamps
+}
+
+func stampsDiffer(before, after map[string]fileStamp) bool {
+	if len(before) != len(after) {
+		return true
+	}
+	for path, stamp := range after {
+		if before[path] != stamp {
+			return true
+		}
+	}
+	return false
+}
+
+// report logs the reload and forwards it to the audit log and event bus.
+func (pw *PolicyWatcher) report(ctx context.Context, ev security.PolicyReloadEvent) {
+	details := map[string]interface{}{
+		"source":  ev.Source,
+		"added":   ev.Diff.Added,
+		"removed": ev.Diff.Removed,
+		"changed": ev.Diff.Changed,
+	}
+	if ev.Error != "" {
+		details["error"] = ev.Error
+	}
+	if ev.Skipped != "" {
+		details["skipped"] = ev.Skipped
+	}
+
+	if pw.logger != nil {
+		switch {
+		case ev.Error != "":
+			pw.logger.Info(ctx, "Warden policy reload failed, keeping previous policies",
+				shared_ports.Field{Key: "error", Value: ev.Error},
+			)
+		case ev.Skipped != "":
+			pw.logger.Info(ctx, "Warden policies reloaded without the local policies that failed to load",
+				shared_ports.Field{Key: "skipped", Value: ev.Skipped},
+				shared_ports.Field{Key: "added", Value: ev.Diff.Added},
+				shared_ports.Field{Key: "removed", Value: ev.Diff.Removed},
+				shared_ports.Field{Key: "changed", Value: ev.Diff.Changed},
+			)
+		default:
+			pw.logger.Info(ctx, "Warden policies reloaded",
+				shared_ports.Field{Key: "added", Value: ev.Diff.Added},
+				shared_ports.Field{Key: "removed", Value: ev.Diff.Removed},
+				shared_ports.Field{Key: "changed", Value: ev.Diff.Changed},
+			)
+		}
+	}
+
+	if pw.audit != nil {
+		err := pw.audit.Record(ctx, security.AuditEntry{
+			SessionID: policyAuditSession,
+			Action:    security.AuditPolicyReload,
+			Actor:     "warden",
+			Details:   details,
+			Timestamp: ev.Timestamp,
+		})
+		if err != nil && pw.logger != nil {
+			pw.logger.ErrorErr(ctx, err, "Failed to record policy reload in audit log")
+		}
+	}
+
+	if pw.bus != nil {
+		if err := pw.bus.Publish(ctx, PolicyReloadedTopic, ev); err != nil && pw.logger != nil {
+			pw.logger.ErrorErr(ctx, err, "Failed to publish policy reload event")
+		}
+	}
+}
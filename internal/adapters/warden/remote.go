@@ -0,0 +1,128 @@
+package warden
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/SecDuckOps/agent/internal/adapters/warden/cedar"
+	"github.com/SecDuckOps/agent/internal/domain/security"
+	"github.com/SecDuckOps/agent/internal/ports"
+	"github.com/SecDuckOps/shared/types"
+)
+
+const (
+	// RemotePolicyPriority is assigned to rules pushed by the API Gateway unless
+	// the rule sets its own priority. It ranks above local policy files, so a
+	// remote rule is reported when it agrees with a local one.
+	RemotePolicyPriority = 100
+
+	// RemotePolicyPrefix namespaces remote rule IDs so they cannot collide
+	// with the file-derived IDs of local policies.
+	RemotePolicyPrefix = "remote."
+)
+
+// Remote rule actions.
+const (
+	RuleActionAllow           = "allow"
+	RuleActionDeny            = "deny"
+	RuleActionRequireApproval = "require_approval"
+)
+
+// RemoteRulePolicies translates rules fetched from the API Gateway into Warden
+// policies. Each rule becomes one Cedar statement:
+//
+//	allow            → permit(...) when { match };
+//	deny             → forbid(...) when { match };
+//	require_approval → @approval("required") forbid(...) when { match };
+//
+// Scope "execute" or "network" restricts the action; an empty scope applies
+// the rule to both. If any rule is invalid, no policies are returned so that
+// a partially applied rule set can never weaken the fleet's policies.
+func RemoteRulePolicies(rules []ports.Rule) ([]security.NetworkPolicy, error) {
+	policies := make([]security.NetworkPolicy, 0, len(rules))
+	seen := make(map[string]bool, len(rules))
+	var errs []error
+
+	for i, rule := range rules {
+		if rule.ID == "" {
+			errs = append(errs, types.Newf(types.ErrCodeInvalidInput, "remote rule #%d has no id", i+1))
+			continue
+		}
+		if seen[rule.ID] {
+			errs = append(errs, types.Newf(types.ErrCodeInvalidInput, "duplicate remote rule id %q", rule.ID))
+			continue
+		}
+		seen[rule.ID] = true
+
+		body, err := remoteRuleBody(rule)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		priority := rule.Priority
+		if priority == 0 {
+			priority = RemotePolicyPriority
+		}
+		name := rule.Description
+		if name == "" {
+			name = rule.ID
+		}
+		policies = append(policies, security.NetworkPolicy{
+			ID:        RemotePolicyPrefix + rule.ID,
+			Name:      name,
+			CedarBody: body,
+			Enabled:   true,
+			Priority:  priority,
+		})
+	}
+
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return policies, nil
+}
+
+// remoteRuleBody renders a rule as a single Cedar statement and checks that it
+// parses to exactly one statement.
+func remoteRuleBody(rule ports.Rule) (string, error) {
+	var b strings.Builder
+	fmt.Fprintf(&b, "@id(%s)\n", strconv.Quote(rule.ID))
+
+	switch strings.ToLower(rule.Action) {
+	case RuleActionAllow:
+		b.WriteString("permit")
+	case RuleActionDeny:
+		b.WriteString("forbid")
+	case RuleActionRequireApproval:
+		fmt.Fprintf(&b, "@%s(\"required\")\nforbid", approvalAnnotation)
+	default:
+		return "", types.Newf(types.ErrCodeInvalidInput, "remote rule %q: unknown action %q", rule.ID, rule.Action)
+	}
+
+	switch strings.ToLower(rule.Scope) {
+	case "":
+		b.WriteString("(principal, action, resource)")
+	case cedarActionExecute, cedarActionNetwork:
+		fmt.Fprintf(&b, "(principal, action == %s::%s, resource)", cedarActionType, strconv.Quote(strings.ToLower(rule.Scope)))
+	default:
+		return "", types.Newf(types.ErrCodeInvalidInput, "remote rule %q: unknown scope %q", rule.ID, rule.Scope)
+	}
+
+	if match := strings.TrimSpace(rule.Match); match != "" {
+		fmt.Fprintf(&b, "\nwhen { %s }", match)
+	}
+	b.WriteString(";\n")
+
+	body := b.String()
+	set, err := cedar.Parse(body)
+	if err != nil {
+		return "", types.Wrapf(err, types.ErrCodeInvalidInput, "remote rule %q: invalid match", rule.ID)
+	}
+	if len(set) != 1 {
+		return "", types.Newf(types.ErrCodeInvalidInput, "remote rule %q: match must be a single condition", rule.ID)
+	}
+	return body, nil
+}
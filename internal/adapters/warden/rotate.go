@@ -0,0 +1,78 @@
+package warden
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	shared_ports "github.com/SecDuckOps/shared/ports"
+)
+
+// DefaultCertPollInterval is how often the cert watcher stats the mTLS files.
+const DefaultCertPollInterval = 10 * time.Second
+
+// CertWatcher rotates the Warden's mTLS certificates when their files change
+// on disk, so short-lived certificates can be renewed without a restart.
+// Rotate can also be triggered directly, e.g. on SIGHUP.
+type CertWatcher struct {
+	warden   *Warden
+	interval time.Duration
+	logger   shared_ports.Logger // optional
+
+	mu     sync.Mutex
+	stamps map[string]fileStamp
+}
+
+// NewCertWatcher watches the files passed to Warden.ConfigureMTLS.
+func NewCertWatcher(w *Warden, logger shared_ports.Logger) *CertWatcher {
+	cw := &CertWatcher{
+		warden:   w,
+		interval: DefaultCertPollInterval,
+		logger:   logger,
+	}
+	cw.stamps = statFiles(w.MTLSFiles())
+	return cw
+}
+
+// SetInterval changes the polling interval. It must be called before Run.
+func (cw *CertWatcher) SetInterval(d time.Duration) {
+	if d > 0 {
+		cw.interval = d
+	}
+}
+
+// Run polls the certificate files until ctx is cancelled.
+func (cw *CertWatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(cw.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			current := statFiles(cw.warden.MTLSFiles())
+			cw.mu.Lock()
+			changed := stampsDiffer(cw.stamps, current)
+			cw.stamps = current
+			cw.mu.Unlock()
+
+			if changed {
+				cw.Rotate(ctx, "file_watch")
+			}
+		}
+	}
+}
+
+// Rotate reloads the certificates now. Failures are logged and the previous
+// certificates stay in use; a half-written key pair is picked up on the next
+// change.
+func (cw *CertWatcher) Rotate(ctx context.Context, source string) error {
+	err := cw.warden.ReloadMTLS(ctx)
+	if err != nil && cw.logger != nil {
+		cw.logger.ErrorErr(ctx, err, "Warden mTLS rotation failed, keeping previous certificates",
+			shared_ports.Field{Key: "source", Value: source},
+		)
+	}
+	return err
+}
@@ -4,15 +4,18 @@ import (
 	"context"
 	"crypto/tls"
 	"crypto/x509"
+	"errors"
 	"fmt"
 	"net"
 	"net/http"
 	"os"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/SecDuckOps/agent/internal/domain/security"
+	"github.com/SecDuckOps/agent/internal/ports"
 	"github.com/SecDuckOps/shared/types"
 	"github.com/google/uuid"
 	shared_ports "github.com/SecDuckOps/shared/ports"
@@ -23,16 +26,29 @@ import (
 // Provides a transparent proxy with Cedar-style policy evaluation and mTLS enforcement.
 // All traffic stays on-premises — nothing leaves the trust boundary.
 type Warden struct {
-	policies    []security.NetworkPolicy
+	policies    []compiledPolicy
 	defaultDeny bool
+	forceDeny   bool // a policy failed to parse; see LoadValidPolicies
 
 	// Proxy
-	listener  net.Listener
-	server    *http.Server
-	proxyAddr string
-
-	// mTLS
+	listener      net.Listener
+	server        *http.Server
+	proxy         *forwardProxy
+	proxyAddr     string
+	proxyTimeouts ProxyTimeouts
+	intercept     *InterceptConfig
+
+	// mTLS. The listener's tlsConfig hands out whatever mtls holds, so
+	// ReloadMTLS rotates certificates without restarting the proxy.
 	tlsConfig *tls.Config
+	mtls      atomic.Pointer[tls.Config]
+	mtlsCfg   *security.MTLSConfig
+
+	// Decision cache (optional); cleared whenever policies are loaded
+	cache *PolicyCache
+
+	// Audit log for proxied requests (optional)
+	audit ports.AuditLogPort
 
 	mu     sync.RWMutex
 	closed bool
@@ -42,9 +58,10 @@ type Warden struct {
 // New creates a new Warden adapter.
 func New(defaultDeny bool, logger shared_ports.Logger) *Warden {
 	return &Warden{
-		policies:    make([]security.NetworkPolicy, 0),
-		defaultDeny: defaultDeny,
-		logger:      logger,
+		policies:      make([]compiledPolicy, 0),
+		defaultDeny:   defaultDeny,
+		proxyTimeouts: DefaultProxyTimeouts(),
+		logger:        logger,
 	}
 }
 
@@ -58,42 +75,24 @@ func (w *Warden) Evaluate(_ context.Context, req security.NetworkRequest) (secur
 		req.ID = uuid.New().String()
 	}
 
-	// Evaluate policies in priority order (highest first)
-	for _, policy := range w.policies {
-		if !policy.Enabled {
-			continue
-		}
-
-		matched, allowed := evaluateCedarPolicy(policy, req)
-		if matched {
-			decision := security.PolicyDecision{
-				Allowed:  allowed,
-				PolicyID: policy.ID,
-				Reasons:  []string{fmt.Sprintf("Matched policy: %s (%s)", policy.Name, policy.ID)},
-			}
-			return decision, nil
-		}
-	}
+	decision, cached := w.cachedDecide(func() string { return networkCacheKey(req) }, func() security.PolicyDecision {
+		return w.decide("policy", func(p compiledPolicy) policyVerdict {
+			return p.evaluateNetwork(req)
+		})
+	})
 
-	// No policy matched — apply default
-	decision := security.PolicyDecision{
-		Allowed: !w.defaultDeny,
-		Reasons: []string{"No matching policy found"},
-	}
-	if w.defaultDeny {
-		decision.Reasons = append(decision.Reasons, "default deny is active")
-	} else {
-		decision.Reasons = append(decision.Reasons, "default allow")
+	if w.logger != nil {
+		w.logger.Info(context.Background(), "Warden evaluation",
+			shared_ports.Field{Key: "request_id", Value: req.ID},
+			shared_ports.Field{Key: "tool", Value: req.SourceTool},
+			shared_ports.Field{Key: "method", Value: req.Method},
+			shared_ports.Field{Key: "url", Value: req.URL},
+			shared_ports.Field{Key: "policy_id", Value: decision.PolicyID},
+			shared_ports.Field{Key: "allowed", Value: decision.Allowed},
+			shared_ports.Field{Key: "cached", Value: cached},
+		)
 	}
 
-	w.logger.Info(context.Background(), "Warden evaluation",
-		shared_ports.Field{Key: "request_id", Value: req.ID},
-		shared_ports.Field{Key: "tool", Value: req.SourceTool},
-		shared_ports.Field{Key: "method", Value: req.Method},
-		shared_ports.Field{Key: "url", Value: req.URL},
-		shared_ports.Field{Key: "allowed", Value: decision.Allowed},
-	)
-
 	return decision, nil
 }
 
@@ -107,106 +106,285 @@ func (w *Warden) EvaluateExecution(_ context.Context, req security.ExecutionRequ
 		req.ID = uuid.New().String()
 	}
 
-	// Evaluate policies in priority order
+	decision, cached := w.cachedDecide(func() string { return executionCacheKey(req) }, func() security.PolicyDecision {
+		return w.decide("execution policy", func(p compiledPolicy) policyVerdict {
+			return p.evaluateExecution(req)
+		})
+	})
+
+	if w.logger != nil {
+		w.logger.Info(context.Background(), "Warden execution evaluation",
+			shared_ports.Field{Key: "request_id", Value: req.ID},
+			shared_ports.Field{Key: "command", Value: req.Command},
+			shared_ports.Field{Key: "policy_id", Value: decision.PolicyID},
+			shared_ports.Field{Key: "allowed", Value: decision.Allowed},
+			shared_ports.Field{Key: "cached", Value: cached},
+		)
+	}
+
+	return decision, nil
+}
+
+// cachedDecide returns the cached decision for the request key, or computes
+// and caches it. The key is only built when a cache is configured.
+// Callers must hold w.mu.
+func (w *Warden) cachedDecide(key func() string, decide func() security.PolicyDecision) (security.PolicyDecision, bool) {
+	if w.cache == nil {
+		return decide(), false
+	}
+
+	k := key()
+	if decision, ok := w.cache.Get(k); ok {
+		return decision, true
+	}
+	decision := decide()
+	w.cache.Set(k, decision)
+	return decision, false
+}
+
+// decide evaluates every enabled policy and combines the verdicts with Cedar
+// semantics: any matching forbid overrides every permit, and a hard forbid
+// overrides one that only requires approval. When several policies agree, the
+// one with the highest priority is reported as the PolicyID.
+// Callers must hold w.mu.
+func (w *Warden) decide(kind string, eval func(compiledPolicy) policyVerdict) security.PolicyDecision {
+	var permit, approval, forbid *security.PolicyDecision
+	var evalErrors []string
+
+	// Evaluate policies in priority order (highest first)
 	for _, policy := range w.policies {
 		if !policy.Enabled {
 			continue
 		}
 
-		matched, allowed := evaluateCedarExecutionPolicy(policy, req)
-		if matched {
-			decision := security.PolicyDecision{
-				Allowed:  allowed,
-				PolicyID: policy.ID,
-				Reasons:  []string{fmt.Sprintf("Matched execution policy: %s (%s)", policy.Name, policy.ID)},
+		verdict := eval(policy)
+		for _, e := range verdict.errors {
+			evalErrors = append(evalErrors, fmt.Sprintf("Evaluation error in %s %s (%s): %s", kind, policy.Name, policy.ID, e))
+		}
+		if !verdict.matched {
+			continue
+		}
+
+		reasons := []string{fmt.Sprintf("Matched %s: %s (%s)", kind, policy.Name, policy.ID)}
+		for _, stmt := range verdict.statements {
+			reasons = append(reasons, "Determining statement: "+stmt)
+		}
+		decision := &security.PolicyDecision{
+			Allowed:          verdict.allowed,
+			RequiresApproval: verdict.approval,
+			PolicyID:         policy.ID,
+			Reasons:          reasons,
+		}
+
+		switch {
+		case verdict.allowed:
+			if permit == nil {
+				permit = decision
+			}
+		case verdict.approval:
+			if approval == nil {
+				approval = decision
 			}
-			return decision, nil
+		default:
+			if forbid == nil {
+				forbid = decision
+			}
+		}
+	}
+
+	for _, d := range []*security.PolicyDecision{forbid, approval, permit} {
+		if d != nil {
+			d.Reasons = append(d.Reasons, evalErrors...)
+			return *d
 		}
 	}
 
-	// No policy matched
+	// No policy matched — apply default
 	decision := security.PolicyDecision{
-		Allowed: !w.defaultDeny,
-		Reasons: []string{"No matching execution policy found"},
+		Allowed: !w.defaultDeny && !w.forceDeny,
+		Reasons: []string{fmt.Sprintf("No matching %s found", kind)},
 	}
-	if w.defaultDeny {
+	if w.forceDeny {
+		decision.Reasons = append(decision.Reasons, "default deny is forced on until every policy parses")
+	} else if w.defaultDeny {
 		decision.Reasons = append(decision.Reasons, "default deny is active")
 	} else {
 		decision.Reasons = append(decision.Reasons, "default allow")
 	}
-
-	w.logger.Info(context.Background(), "Warden execution evaluation",
-		shared_ports.Field{Key: "request_id", Value: req.ID},
-		shared_ports.Field{Key: "command", Value: req.Command},
-		shared_ports.Field{Key: "allowed", Value: decision.Allowed},
-	)
-
-	return decision, nil
+	decision.Reasons = append(decision.Reasons, evalErrors...)
+	return decision
 }
 
 // LoadPolicies loads Cedar policies into the evaluator.
+// Every Cedar body is parsed up front; if any fails to parse, the previously
+// loaded policy set is kept and the syntax error is returned.
 func (w *Warden) LoadPolicies(_ context.Context, policies []security.NetworkPolicy) error {
-	w.mu.Lock()
-	defer w.mu.Unlock()
+	compiled := make([]compiledPolicy, 0, len(policies))
+	for _, p := range policies {
+		cp, err := compilePolicy(p)
+		if err != nil {
+			return err
+		}
+		compiled = append(compiled, cp)
+	}
+
+	w.install(compiled, false)
+	return nil
+}
+
+// LoadValidPolicies loads every policy that parses and returns the syntax
+// errors of the others. While any policy is left out, default deny is forced
+// on, so a broken file cannot open up what it was meant to restrict. The next
+// successful LoadPolicies lifts it.
+func (w *Warden) LoadValidPolicies(_ context.Context, policies []security.NetworkPolicy) error {
+	compiled := make([]compiledPolicy, 0, len(policies))
+	var errs []error
+	for _, p := range policies {
+		cp, err := compilePolicy(p)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		compiled = append(compiled, cp)
+	}
+
+	w.install(compiled, len(errs) > 0)
+	return errors.Join(errs...)
+}
 
-	w.policies = make([]security.NetworkPolicy, len(policies))
-	copy(w.policies, policies)
+// ForceDeny reports whether default deny is forced on because a policy passed
+// to LoadValidPolicies failed to parse.
+func (w *Warden) ForceDeny() bool {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.forceDeny
+}
 
+// install replaces the policy set with compiled, sorted by priority.
+func (w *Warden) install(compiled []compiledPolicy, forceDeny bool) {
 	// Sort by priority (descending)
-	for i := 1; i < len(w.policies); i++ {
-		for j := i; j > 0 && w.policies[j].Priority > w.policies[j-1].Priority; j-- {
-			w.policies[j], w.policies[j-1] = w.policies[j-1], w.policies[j]
+	for i := 1; i < len(compiled); i++ {
+		for j := i; j > 0 && compiled[j].Priority > compiled[j-1].Priority; j-- {
+			compiled[j], compiled[j-1] = compiled[j-1], compiled[j]
 		}
 	}
 
-	w.logger.Info(context.Background(), "Warden policies loaded",
-		shared_ports.Field{Key: "count", Value: len(w.policies)},
-	)
+	w.mu.Lock()
+	defer w.mu.Unlock()
 
-	return nil
+	w.policies = compiled
+	w.forceDeny = forceDeny
+	if w.cache != nil {
+		w.cache.Clear()
+	}
+
+	if w.logger != nil {
+		w.logger.Info(context.Background(), "Warden policies loaded",
+			shared_ports.Field{Key: "count", Value: len(w.policies)},
+			shared_ports.Field{Key: "default_deny_forced", Value: forceDeny},
+		)
+	}
 }
 
-// StartProxy starts the transparent HTTP proxy.
-func (w *Warden) StartProxy(ctx context.Context, listenAddr string) error {
+// SetCache enables decision caching with the given cache, or disables it when
+// c is nil. Any previously cached decisions are discarded.
+func (w *Warden) SetCache(c *PolicyCache) {
 	w.mu.Lock()
 	defer w.mu.Unlock()
 
-	if w.closed {
-		return types.New(types.ErrCodeInvalidInput, "warden is closed")
+	if c != nil {
+		c.Clear()
 	}
+	w.cache = c
+}
 
-	mux := http.NewServeMux()
-	mux.HandleFunc("/", func(rw http.ResponseWriter, r *http.Request) {
-		req := security.NetworkRequest{
-			ID:        uuid.New().String(),
-			Method:    r.Method,
-			URL:       r.URL.String(),
-			Headers:   flattenHeaders(r.Header),
-			Timestamp: time.Now(),
-		}
+// CacheStats returns the decision cache counters. The zero value is returned
+// when caching is disabled.
+func (w *Warden) CacheStats() CacheStats {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
 
-		// Extract source tool from custom header
-		if tool := r.Header.Get("X-DuckOps-Source-Tool"); tool != "" {
-			req.SourceTool = tool
-		}
-		if sid := r.Header.Get("X-DuckOps-Session-ID"); sid != "" {
-			req.SessionID = sid
-		}
+	if w.cache == nil {
+		return CacheStats{}
+	}
+	return w.cache.Stats()
+}
 
-		decision, err := w.Evaluate(ctx, req)
-		if err != nil || !decision.Allowed {
-			http.Error(rw, "Blocked by Warden policy", http.StatusForbidden)
-			return
-		}
+// SetAuditLog records every proxied request (AuditNetworkReq) and every
+// blocked one (AuditNetworkBlock) in the given log. Nil disables recording.
+func (w *Warden) SetAuditLog(audit ports.AuditLogPort) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.audit = audit
+}
 
-		// Forward the request (simple proxy)
-		rw.WriteHeader(http.StatusOK)
-		fmt.Fprintf(rw, `{"allowed": true, "policy_id": %q}`, decision.PolicyID)
-	})
+// SetProxyTimeouts overrides the proxy timeouts; zero fields keep their
+// defaults. It must be called before StartProxy.
+func (w *Warden) SetProxyTimeouts(t ProxyTimeouts) {
+	def := DefaultProxyTimeouts()
+	if t.Dial <= 0 {
+		t.Dial = def.Dial
+	}
+	if t.ResponseHeader <= 0 {
+		t.ResponseHeader = def.ResponseHeader
+	}
+	if t.ReadHeader <= 0 {
+		t.ReadHeader = def.ReadHeader
+	}
+	if t.Idle <= 0 {
+		t.Idle = def.Idle
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.proxyTimeouts = t
+}
+
+// EnableInterception decrypts HTTPS in the proxy so that policies can see full
+// URLs. Clients must trust cfg.CA. It must be called before StartProxy.
+func (w *Warden) EnableInterception(cfg InterceptConfig) error {
+	if cfg.CA == nil {
+		return types.New(types.ErrCodeInvalidInput, "TLS interception requires a CA")
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.intercept = &cfg
+	return nil
+}
+
+// Policies returns a copy of the loaded policies in evaluation (priority) order.
+func (w *Warden) Policies() []security.NetworkPolicy {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	out := make([]security.NetworkPolicy, len(w.policies))
+	for i, p := range w.policies {
+		out[i] = p.NetworkPolicy
+	}
+	return out
+}
 
+// StartProxy starts the HTTP/HTTPS egress proxy. Plain HTTP requests are
+// evaluated and forwarded; HTTPS is tunnelled via CONNECT once the policy
+// allows the target host. Point HTTP_PROXY and HTTPS_PROXY at ProxyAddr().
+func (w *Warden) StartProxy(ctx context.Context, listenAddr string) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.closed {
+		return types.New(types.ErrCodeInvalidInput, "warden is closed")
+	}
+	if w.server != nil {
+		return types.New(types.ErrCodeInvalidInput, "warden proxy is already running")
+	}
+
+	w.proxy = newForwardProxy(w, w.proxyTimeouts, w.intercept)
 	w.server = &http.Server{
-		Addr:    listenAddr,
-		Handler: mux,
+		Addr:              listenAddr,
+		Handler:           w.proxy,
+		ReadHeaderTimeout: w.proxyTimeouts.ReadHeader,
+		IdleTimeout:       w.proxyTimeouts.Idle,
 	}
 
 	var err error
@@ -217,15 +395,18 @@ func (w *Warden) StartProxy(ctx context.Context, listenAddr string) error {
 		w.listener, err = net.Listen("tcp", listenAddr)
 	}
 	if err != nil {
+		w.server, w.proxy = nil, nil
 		return types.Wrap(err, types.ErrCodeInternal, "failed to start proxy listener")
 	}
 
 	w.proxyAddr = w.listener.Addr().String()
-	w.logger.Info(ctx, "Warden proxy started", shared_ports.Field{Key: "addr", Value: w.proxyAddr})
+	if w.logger != nil {
+		w.logger.Info(ctx, "Warden proxy started", shared_ports.Field{Key: "addr", Value: w.proxyAddr})
+	}
 
+	server, listener := w.server, w.listener
 	go func() {
-		if serveErr := w.server.Serve(w.listener); serveErr != nil && serveErr != http.ErrServerClosed {
-			// Log error — in production this would go to the audit log
+		if serveErr := server.Serve(listener); serveErr != nil && serveErr != http.ErrServerClosed && w.logger != nil {
 			w.logger.ErrorErr(ctx, serveErr, "Proxy server stopped unexpectedly")
 		}
 	}()
@@ -233,51 +414,143 @@ func (w *Warden) StartProxy(ctx context.Context, listenAddr string) error {
 	return nil
 }
 
-// StopProxy stops the proxy gracefully.
+// StopProxy stops the proxy gracefully. In-flight HTTP requests are allowed to
+// finish until ctx expires; open CONNECT tunnels are closed.
 func (w *Warden) StopProxy(ctx context.Context) error {
 	w.mu.Lock()
 	defer w.mu.Unlock()
 
 	w.closed = true
-	if w.server != nil {
+	if w.server == nil {
+		return nil
+	}
+	if w.logger != nil {
 		w.logger.Info(ctx, "Warden proxy stopping")
-		return w.server.Shutdown(ctx)
 	}
-	return nil
+
+	// Shutdown does not wait for hijacked tunnels; close tears down those
+	// left and any a handler hijacks from here on
+	err := w.server.Shutdown(ctx)
+	w.proxy.close()
+	w.server, w.proxy = nil, nil
+	return err
 }
 
 // ConfigureMTLS sets up mutual TLS for agent↔server communication.
-func (w *Warden) ConfigureMTLS(_ context.Context, cfg security.MTLSConfig) error {
+// The certificate files are validated up front; errors name the offending file.
+func (w *Warden) ConfigureMTLS(ctx context.Context, cfg security.MTLSConfig) error {
+	tlsConfig, err := loadMTLS(cfg)
+	if err != nil {
+		return err
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.mtls.Store(tlsConfig)
+	w.mtlsCfg = &cfg
+	if w.tlsConfig == nil {
+		w.tlsConfig = &tls.Config{
+			MinVersion: tls.VersionTLS13,
+			GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+				return w.mtls.Load(), nil
+			},
+		}
+	}
+
+	w.logMTLS(ctx, "Warden mTLS configured", tlsConfig)
+	return nil
+}
+
+// ReloadMTLS re-reads the certificate files given to ConfigureMTLS. New
+// handshakes use the new certificates; if they cannot be loaded the previous
+// ones stay in use and the error is returned.
+func (w *Warden) ReloadMTLS(ctx context.Context) error {
+	w.mu.RLock()
+	cfg := w.mtlsCfg
+	w.mu.RUnlock()
+	if cfg == nil {
+		return nil
+	}
+
+	tlsConfig, err := loadMTLS(*cfg)
+	if err != nil {
+		return err
+	}
+	w.mtls.Store(tlsConfig)
+
+	w.logMTLS(ctx, "Warden mTLS certificates rotated", tlsConfig)
+	return nil
+}
+
+// MTLSFiles returns the certificate files in use, or nil without mTLS.
+func (w *Warden) MTLSFiles() []string {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	if w.mtlsCfg == nil {
+		return nil
+	}
+	return []string{w.mtlsCfg.CACert, w.mtlsCfg.ClientCert, w.mtlsCfg.ClientKey}
+}
+
+func (w *Warden) logMTLS(ctx context.Context, msg string, tlsConfig *tls.Config) {
+	if w.logger == nil {
+		return
+	}
+	fields := []shared_ports.Field{}
+	if leaf := tlsConfig.Certificates[0].Leaf; leaf != nil {
+		fields = append(fields,
+			shared_ports.Field{Key: "subject", Value: leaf.Subject.CommonName},
+			shared_ports.Field{Key: "not_after", Value: leaf.NotAfter},
+		)
+	}
+	w.logger.Info(ctx, msg, fields...)
+}
+
+// loadMTLS reads and checks the CA and key pair of an mTLS configuration.
+func loadMTLS(cfg security.MTLSConfig) (*tls.Config, error) {
+	if cfg.CACert == "" || cfg.ClientCert == "" || cfg.ClientKey == "" {
+		return nil, types.New(types.ErrCodeInvalidInput, "mTLS requires ca_cert, client_cert and client_key")
+	}
+
 	// Load CA certificate
 	caCert, err := os.ReadFile(cfg.CACert)
 	if err != nil {
-		return types.Wrap(err, types.ErrCodeInternal, "failed to read CA cert")
+		return nil, types.Wrapf(err, types.ErrCodeInternal, "failed to read CA cert %s", cfg.CACert)
 	}
 
 	caCertPool := x509.NewCertPool()
 	if !caCertPool.AppendCertsFromPEM(caCert) {
-		return types.New(types.ErrCodeInvalidInput, "failed to parse CA cert")
+		return nil, types.Newf(types.ErrCodeInvalidInput, "failed to parse CA cert %s: no PEM certificates found", cfg.CACert)
 	}
 
 	// Load client certificate and key
 	clientCert, err := tls.LoadX509KeyPair(cfg.ClientCert, cfg.ClientKey)
 	if err != nil {
-		return types.Wrap(err, types.ErrCodeInternal, "failed to load client cert/key")
+		return nil, types.Wrapf(err, types.ErrCodeInvalidInput, "failed to load client cert/key %s, %s", cfg.ClientCert, cfg.ClientKey)
 	}
+	leaf, err := x509.ParseCertificate(clientCert.Certificate[0])
+	if err != nil {
+		return nil, types.Wrapf(err, types.ErrCodeInvalidInput, "failed to parse client cert %s", cfg.ClientCert)
+	}
+	now := time.Now()
+	if now.After(leaf.NotAfter) {
+		return nil, types.Newf(types.ErrCodeInvalidInput, "client cert %s expired at %s", cfg.ClientCert, leaf.NotAfter.Format(time.RFC3339))
+	}
+	if now.Before(leaf.NotBefore) {
+		return nil, types.Newf(types.ErrCodeInvalidInput, "client cert %s is not valid before %s", cfg.ClientCert, leaf.NotBefore.Format(time.RFC3339))
+	}
+	clientCert.Leaf = leaf
 
-	w.mu.Lock()
-	defer w.mu.Unlock()
-
-	w.tlsConfig = &tls.Config{
+	return &tls.Config{
 		Certificates: []tls.Certificate{clientCert},
 		RootCAs:      caCertPool,
 		ClientCAs:    caCertPool,
 		ClientAuth:   tls.RequireAndVerifyClientCert,
 		MinVersion:   tls.VersionTLS13,
 		ServerName:   cfg.ServerName,
-	}
-
-	return nil
+	}, nil
 }
 
 // ProxyAddr returns the address the proxy is listening on, if started.
@@ -289,8 +562,8 @@ func (w *Warden) ProxyAddr() string {
 
 // ──────────────── Cedar policy evaluation ────────────────
 
-// evaluateCedarPolicy provides a Go-native evaluation of Cedar-style policies.
-// The CedarBody field supports a simple DSL:
+// evaluateCedarPolicy evaluates policies written in the legacy line-based DSL
+// that predates Cedar support (see policy.go for the Cedar path):
 //
 //	ALLOW url_contains "example.com"
 //	DENY method "DELETE"
@@ -347,7 +620,7 @@ func evaluateCedarPolicy(policy security.NetworkPolicy, req security.NetworkRequ
 	return false, false
 }
 
-// evaluateCedarExecutionPolicy provides a naive Go-native evaluation for execution requests.
+// evaluateCedarExecutionPolicy evaluates legacy-DSL policies for execution requests.
 // Supports: ALLOW command "ls"
 // DENY command "rm"
 // ALLOW dir_prefix "/var/log"
@@ -2,10 +2,23 @@ package warden_test
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/SecDuckOps/agent/internal/adapters/audit"
 	"github.com/SecDuckOps/agent/internal/adapters/warden"
 	"github.com/SecDuckOps/agent/internal/domain/security"
+	"github.com/SecDuckOps/agent/internal/ports"
 )
 
 func TestWarden_EvaluateAllowPolicy(t *testing.T) {
@@ -207,3 +220,742 @@ func TestWarden_SourceToolFilter(t *testing.T) {
 		t.Error("echo tool should be denied (no matching policy)")
 	}
 }
+
+func TestWarden_CedarNetworkPolicy(t *testing.T) {
+	w := warden.New(true, nil)
+
+	policies := []security.NetworkPolicy{
+		{
+			ID:   "pol-cedar-github",
+			Name: "GitHub read-only",
+			CedarBody: `
+permit(principal == Tool::"scan_tool", action == Action::"network", resource == Host::"api.github.com")
+when { context.method == "GET" && context.path like "/repos/our-org/*" };`,
+			Priority: 100,
+			Enabled:  true,
+		},
+	}
+
+	ctx := context.Background()
+	if err := w.LoadPolicies(ctx, policies); err != nil {
+		t.Fatalf("LoadPolicies failed: %v", err)
+	}
+
+	decision, _ := w.Evaluate(ctx, security.NetworkRequest{
+		Method:     "GET",
+		URL:        "https://api.github.com/repos/our-org/agent",
+		SourceTool: "scan_tool",
+	})
+	if !decision.Allowed || decision.PolicyID != "pol-cedar-github" {
+		t.Errorf("expected allow via pol-cedar-github, got %+v", decision)
+	}
+
+	decision, _ = w.Evaluate(ctx, security.NetworkRequest{
+		Method:     "GET",
+		URL:        "https://api.github.com/repos/other-org/agent",
+		SourceTool: "scan_tool",
+	})
+	if decision.Allowed {
+		t.Error("expected other orgs to fall through to default deny")
+	}
+}
+
+func TestWarden_CedarForbidOverridesHigherPriorityPermit(t *testing.T) {
+	w := warden.New(false, nil)
+
+	policies := []security.NetworkPolicy{
+		{
+			ID:        "pol-allow-all",
+			Name:      "Allow everything",
+			CedarBody: `permit(principal, action == Action::"execute", resource);`,
+			Priority:  500,
+			Enabled:   true,
+		},
+		{
+			ID:   "pol-no-force-push",
+			Name: "No force push",
+			CedarBody: `forbid(principal, action == Action::"execute", resource == Command::"git")
+when { context.args.contains("push") && context.args.containsAny(["-f", "--force"]) };`,
+			Priority: 10,
+			Enabled:  true,
+		},
+	}
+
+	ctx := context.Background()
+	if err := w.LoadPolicies(ctx, policies); err != nil {
+		t.Fatalf("LoadPolicies failed: %v", err)
+	}
+
+	decision, _ := w.EvaluateExecution(ctx, security.ExecutionRequest{
+		Command: "git",
+		Args:    []string{"push", "--force", "origin", "main"},
+		Context: map[string]interface{}{"cwd": "/src"},
+	})
+	if decision.Allowed || decision.PolicyID != "pol-no-force-push" {
+		t.Errorf("expected forbid via pol-no-force-push, got %+v", decision)
+	}
+
+	decision, _ = w.EvaluateExecution(ctx, security.ExecutionRequest{
+		Command: "git",
+		Args:    []string{"push", "origin", "main"},
+	})
+	if !decision.Allowed || decision.PolicyID != "pol-allow-all" {
+		t.Errorf("expected allow via pol-allow-all, got %+v", decision)
+	}
+}
+
+func TestWarden_InvalidCedarKeepsPreviousPolicies(t *testing.T) {
+	w := warden.New(true, nil)
+	ctx := context.Background()
+
+	good := []security.NetworkPolicy{
+		{ID: "pol-ls", Name: "ls", CedarBody: `permit(principal, action, resource == Command::"ls");`, Enabled: true},
+	}
+	if err := w.LoadPolicies(ctx, good); err != nil {
+		t.Fatalf("LoadPolicies failed: %v", err)
+	}
+
+	bad := []security.NetworkPolicy{
+		{ID: "pol-broken", Name: "broken", CedarBody: `permit(principal, action resource);`, Enabled: true},
+	}
+	if err := w.LoadPolicies(ctx, bad); err == nil {
+		t.Fatal("expected a parse error for the broken policy")
+	}
+
+	decision, _ := w.EvaluateExecution(ctx, security.ExecutionRequest{Command: "ls"})
+	if !decision.Allowed {
+		t.Error("expected the previously loaded policies to remain active")
+	}
+}
+
+func TestWarden_LoadValidPoliciesForcesDefaultDeny(t *testing.T) {
+	w := warden.New(false, nil)
+	ctx := context.Background()
+
+	policies := []security.NetworkPolicy{
+		{ID: "pol-ls", Name: "ls", CedarBody: `permit(principal, action, resource == Command::"ls");`, Enabled: true},
+		{ID: "pol-broken", Name: "broken", CedarBody: `forbid(principal, action resource);`, Enabled: true},
+	}
+	if err := w.LoadValidPolicies(ctx, policies); err == nil {
+		t.Fatal("expected the parse error of the broken policy")
+	}
+
+	if decision, _ := w.EvaluateExecution(ctx, security.ExecutionRequest{Command: "ls"}); !decision.Allowed {
+		t.Errorf("expected the valid policy to be loaded, got %+v", decision)
+	}
+	if decision, _ := w.EvaluateExecution(ctx, security.ExecutionRequest{Command: "rm"}); decision.Allowed {
+		t.Errorf("expected default deny while a policy is broken, got %+v", decision)
+	}
+
+	// Fixing the file lifts the forced deny
+	if err := w.LoadPolicies(ctx, policies[:1]); err != nil {
+		t.Fatalf("LoadPolicies failed: %v", err)
+	}
+	if decision, _ := w.EvaluateExecution(ctx, security.ExecutionRequest{Command: "rm"}); !decision.Allowed {
+		t.Errorf("expected default allow once every policy parses, got %+v", decision)
+	}
+}
+
+func TestValidatePolicy_ReportsLineNumbers(t *testing.T) {
+	issues := warden.ValidatePolicy("DENY command \"rm\"\nALLOW commnd \"ls\"\nDENYX\n")
+	if len(issues) != 2 {
+		t.Fatalf("expected 2 legacy issues, got %v", issues)
+	}
+	if issues[0].Line != 2 || issues[1].Line != 3 {
+		t.Errorf("expected issues on lines 2 and 3, got %v", issues)
+	}
+
+	issues = warden.ValidatePolicy("permit(principal, action, resource)\nwhen { context.x == };")
+	if len(issues) != 1 || issues[0].Line != 2 || issues[0].Column != 21 {
+		t.Errorf("expected one Cedar issue at 2:21, got %v", issues)
+	}
+
+	if issues := warden.ValidatePolicy(`forbid(principal, action, resource == Command::"rm");`); len(issues) != 0 {
+		t.Errorf("expected valid Cedar policy, got %v", issues)
+	}
+}
+
+func TestRunFixture_ReportsMismatches(t *testing.T) {
+	dir := t.TempDir()
+	policyPath := filepath.Join(dir, "git.cedar")
+	os.WriteFile(policyPath, []byte(`
+permit(principal, action == Action::"execute", resource);
+forbid(principal, action, resource == Command::"git") when { context.args.contains("--force") };
+`), 0644)
+
+	fixturePath := warden.FixturePathFor(policyPath)
+	os.WriteFile(fixturePath, []byte(`
+[[case]]
+name      = "force push is blocked"
+expect    = "deny"
+policy_id = "git"
+[case.execution]
+command = "git"
+args    = ["push", "--force"]
+
+[[case]]
+name   = "wrong expectation"
+expect = "deny"
+[case.execution]
+command = "git"
+args    = ["status"]
+`), 0644)
+
+	policies, err := warden.ReadPolicyFiles([]string{policyPath})
+	if err != nil {
+		t.Fatalf("ReadPolicyFiles failed: %v", err)
+	}
+	w := warden.New(true, nil)
+	ctx := context.Background()
+	if err := w.LoadPolicies(ctx, policies); err != nil {
+		t.Fatalf("LoadPolicies failed: %v", err)
+	}
+
+	fx, err := warden.LoadPolicyFixture(fixturePath)
+	if err != nil {
+		t.Fatalf("LoadPolicyFixture failed: %v", err)
+	}
+	results, err := warden.RunFixture(ctx, w, fx)
+	if err != nil {
+		t.Fatalf("RunFixture failed: %v", err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("expected 2 results, got %d", len(results))
+	}
+	if !results[0].Passed {
+		t.Errorf("expected first case to pass: %s", results[0].Problem)
+	}
+	if results[1].Passed {
+		t.Error("expected second case to fail")
+	}
+}
+
+func TestLoadPolicyFixture_RejectsInvalidExpectation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bad.tests.toml")
+	os.WriteFile(path, []byte(`
+[[case]]
+expect = "maybe"
+[case.network]
+url = "https://example.com"
+`), 0644)
+
+	if _, err := warden.LoadPolicyFixture(path); err == nil {
+		t.Error("expected an error for an unknown expectation")
+	}
+}
+
+func TestPolicyWatcher_ReloadDiffAndKeepOnFailure(t *testing.T) {
+	dir := t.TempDir()
+	a := filepath.Join(dir, "a.cedar")
+	b := filepath.Join(dir, "b.cedar")
+	os.WriteFile(a, []byte(`permit(principal, action, resource == Command::"ls");`), 0644)
+	os.WriteFile(b, []byte(`forbid(principal, action, resource == Command::"rm");`), 0644)
+
+	ctx := context.Background()
+	w := warden.New(true, nil)
+	initial, _ := warden.ReadPolicyFiles([]string{a})
+	w.LoadPolicies(ctx, initial)
+
+	pw := warden.NewPolicyWatcher(w, []string{a, b}, nil, nil, nil, nil)
+	diff, err := pw.Reload(ctx, "test")
+	if err != nil {
+		t.Fatalf("Reload failed: %v", err)
+	}
+	if len(diff.Added) != 1 || diff.Added[0] != "b" || len(diff.Changed) != 0 {
+		t.Errorf("expected b to be added, got %+v", diff)
+	}
+
+	os.WriteFile(a, []byte(`permit(principal, action, resource == Command::"cat");`), 0644)
+	if diff, _ = pw.Reload(ctx, "test"); len(diff.Changed) != 1 || diff.Changed[0] != "a" {
+		t.Errorf("expected a to be changed, got %+v", diff)
+	}
+
+	os.WriteFile(b, []byte(`forbid(principal, action, resource ==`), 0644)
+	if _, err := pw.Reload(ctx, "test"); err == nil {
+		t.Fatal("expected reload to fail on a parse error")
+	}
+	decision, _ := w.EvaluateExecution(ctx, security.ExecutionRequest{Command: "rm"})
+	if decision.Allowed || decision.PolicyID != "b" {
+		t.Errorf("expected previous forbid policy to stay active, got %+v", decision)
+	}
+}
+
+func TestPolicyWatcher_RemoteRulesSkipBrokenLocalFiles(t *testing.T) {
+	dir := t.TempDir()
+	a := filepath.Join(dir, "a.cedar")
+	b := filepath.Join(dir, "b.cedar")
+	os.WriteFile(a, []byte(`permit(principal, action, resource == Command::"ls");`), 0644)
+
+	ctx := context.Background()
+	w := warden.New(false, nil)
+	initial, _ := warden.ReadPolicyFiles([]string{a})
+	w.LoadPolicies(ctx, initial)
+	pw := warden.NewPolicyWatcher(w, []string{a, b}, nil, nil, nil, nil)
+
+	os.WriteFile(b, []byte(`forbid(principal, action resource);`), 0644)
+	remote, err := warden.RemoteRulePolicies([]ports.Rule{
+		{ID: "no-rm", Action: "deny", Scope: "execute", Match: `resource == Command::"rm"`},
+	})
+	if err != nil {
+		t.Fatalf("RemoteRulePolicies failed: %v", err)
+	}
+	if _, err := pw.SetRemotePolicies(ctx, remote); err != nil {
+		t.Fatalf("expected the remote rules to apply despite the broken file, got %v", err)
+	}
+
+	allowed := func(command string) bool {
+		d, _ := w.EvaluateExecution(ctx, security.ExecutionRequest{Command: command})
+		return d.Allowed
+	}
+	if allowed("rm") || !allowed("ls") {
+		t.Error("expected the remote rule and the valid local file to be loaded")
+	}
+	if allowed("cat") {
+		t.Error("expected default deny while a local file is broken")
+	}
+
+	// An invalid remote policy is still rejected as a whole
+	bad := security.NetworkPolicy{ID: "remote.bad", Enabled: true, CedarBody: `permit(principal, action`}
+	if _, err := pw.SetRemotePolicies(ctx, []security.NetworkPolicy{bad}); err == nil {
+		t.Error("expected an invalid remote policy to be rejected")
+	}
+	if allowed("rm") {
+		t.Error("expected the previous remote rules to stay active")
+	}
+}
+
+func TestPolicyWatcher_PicksUpNewFilesAndRetriesFailures(t *testing.T) {
+	dir := t.TempDir()
+	a := filepath.Join(dir, "a.cedar")
+	os.WriteFile(a, []byte(`permit(principal, action, resource == Command::"ls");`), 0644)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	w := warden.New(true, nil)
+	initial, _ := warden.ReadPolicyFiles(warden.ExpandPolicyFiles(nil, []string{dir}))
+	w.LoadPolicies(ctx, initial)
+
+	pw := warden.NewPolicyWatcher(w, nil, []string{dir}, nil, nil, nil)
+	pw.SetInterval(10 * time.Millisecond)
+	go pw.Run(ctx)
+
+	allowed := func(command string) bool {
+		d, _ := w.EvaluateExecution(ctx, security.ExecutionRequest{Command: command})
+		return d.Allowed
+	}
+	waitFor := func(what string, cond func() bool) {
+		t.Helper()
+		deadline := time.Now().Add(2 * time.Second)
+		for !cond() {
+			if time.Now().After(deadline) {
+				t.Fatalf("timed out waiting for %s", what)
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+
+	// A broken new file keeps the previous set until it is fixed
+	b := filepath.Join(dir, "b.cedar")
+	fixed := `permit(principal, action, resource == Command::"cat");`
+	os.WriteFile(b, []byte(strings.Replace(fixed, ");", "  ", 1)), 0644)
+	time.Sleep(50 * time.Millisecond)
+	if allowed("cat") || !allowed("ls") {
+		t.Fatal("expected the previous policies to stay active")
+	}
+
+	// The fix has the same size and mtime, so only a retry can pick it up
+	info, _ := os.Stat(b)
+	os.WriteFile(b, []byte(fixed), 0644)
+	os.Chtimes(b, info.ModTime(), info.ModTime())
+	waitFor("the new file to load", func() bool { return allowed("cat") })
+
+	os.Remove(b)
+	waitFor("the removed file to unload", func() bool { return !allowed("cat") })
+}
+
+func TestExpandPolicyFiles_OnlyListsConfiguredFilesAndDirs(t *testing.T) {
+	dir, policyDir := t.TempDir(), t.TempDir()
+	configured := filepath.Join(dir, "a.cedar")
+	for _, path := range []string{configured, filepath.Join(dir, "a.bak.cedar"),
+		filepath.Join(policyDir, "c.cedar"), filepath.Join(policyDir, "b.cedar"), filepath.Join(policyDir, "notes.txt")} {
+		os.WriteFile(path, nil, 0644)
+	}
+
+	got := warden.ExpandPolicyFiles([]string{configured}, []string{policyDir})
+	want := []string{configured, filepath.Join(policyDir, "b.cedar"), filepath.Join(policyDir, "c.cedar")}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
+
+func TestPolicyWatcher_RemovingBrokenFileLiftsForcedDeny(t *testing.T) {
+	dir := t.TempDir()
+	a := filepath.Join(dir, "a.cedar")
+	b := filepath.Join(dir, "b.cedar")
+	os.WriteFile(a, []byte(`permit(principal, action, resource == Command::"ls");`), 0644)
+	os.WriteFile(b, []byte(`forbid(principal, action resource);`), 0644)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	w := warden.New(false, nil)
+	initial, _ := warden.ReadPolicyFiles(warden.ExpandPolicyFiles(nil, []string{dir}))
+	if err := w.LoadValidPolicies(ctx, initial); err == nil {
+		t.Fatal("expected the parse error of the broken file")
+	}
+
+	pw := warden.NewPolicyWatcher(w, nil, []string{dir}, nil, nil, nil)
+	pw.SetInterval(10 * time.Millisecond)
+	go pw.Run(ctx)
+
+	allowed := func(command string) bool {
+		d, _ := w.EvaluateExecution(ctx, security.ExecutionRequest{Command: command})
+		return d.Allowed
+	}
+	if allowed("rm") || !allowed("ls") {
+		t.Fatal("expected default deny and the valid file while a file is broken")
+	}
+
+	// The policy set is unchanged without the broken file, but the deny is lifted
+	os.Remove(b)
+	deadline := time.Now().Add(2 * time.Second)
+	for !allowed("rm") {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for default allow once the broken file is gone")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if w.ForceDeny() {
+		t.Error("expected the forced deny to be lifted")
+	}
+}
+
+func TestRemoteRulePolicies_PrecedenceAndApproval(t *testing.T) {
+	policies, err := warden.RemoteRulePolicies([]ports.Rule{
+		{ID: "exec", Action: "allow", Scope: "execute"},
+		{ID: "tf-apply", Action: "require_approval", Scope: "execute",
+			Match: `resource == Command::"terraform" && context.args.contains("apply")`},
+		{ID: "no-rm", Action: "deny", Scope: "execute", Match: `resource == Command::"rm"`},
+	})
+	if err != nil {
+		t.Fatalf("RemoteRulePolicies failed: %v", err)
+	}
+
+	ctx := context.Background()
+	w := warden.New(true, nil)
+	local := security.NetworkPolicy{ID: "local", Name: "local.cedar", Enabled: true, Priority: warden.DefaultPolicyPriority,
+		CedarBody: `forbid(principal, action, resource == Command::"terraform") when { context.args.contains("destroy") };`}
+	if err := w.LoadPolicies(ctx, append(policies, local)); err != nil {
+		t.Fatalf("LoadPolicies failed: %v", err)
+	}
+
+	tests := []struct {
+		command  string
+		args     []string
+		allowed  bool
+		approval bool
+		policy   string
+	}{
+		{"ls", nil, true, false, "remote.exec"},
+		{"terraform", []string{"apply"}, false, true, "remote.tf-apply"},
+		{"terraform", []string{"apply", "destroy"}, false, false, "local"},
+		{"rm", nil, false, false, "remote.no-rm"},
+	}
+	for _, tt := range tests {
+		d, _ := w.EvaluateExecution(ctx, security.ExecutionRequest{Command: tt.command, Args: tt.args})
+		if d.Allowed != tt.allowed || d.RequiresApproval != tt.approval || d.PolicyID != tt.policy {
+			t.Errorf("%s %v: got %+v", tt.command, tt.args, d)
+		}
+	}
+}
+
+func TestRemoteRulePolicies_RejectsInvalidRules(t *testing.T) {
+	bad := [][]ports.Rule{
+		{{ID: "x", Action: "maybe"}},
+		{{ID: "x", Action: "deny", Scope: "filesystem"}},
+		{{ID: "x", Action: "deny", Match: `true }; permit(principal, action, resource) when { true`}},
+		{{ID: "x", Action: "deny"}, {ID: "x", Action: "allow"}},
+	}
+	for _, rules := range bad {
+		if policies, err := warden.RemoteRulePolicies(rules); err == nil {
+			t.Errorf("expected %+v to be rejected, got %+v", rules, policies)
+		}
+	}
+}
+
+func TestWarden_DecisionCache(t *testing.T) {
+	ctx := context.Background()
+	w := warden.New(true, nil)
+	w.SetCache(warden.NewPolicyCache(1, time.Minute))
+
+	allowGit := []security.NetworkPolicy{{
+		ID:        "allow-git",
+		Name:      "allow git",
+		CedarBody: `permit(principal, action == Action::"execute", resource == Command::"git");`,
+		Enabled:   true,
+	}}
+	if err := w.LoadPolicies(ctx, allowGit); err != nil {
+		t.Fatalf("LoadPolicies failed: %v", err)
+	}
+
+	git := security.ExecutionRequest{Command: "git", Args: []string{"status"}, SessionID: "s1"}
+	for i := 0; i < 2; i++ {
+		d, _ := w.EvaluateExecution(ctx, git)
+		if !d.Allowed {
+			t.Fatalf("evaluation %d: expected git to be allowed", i)
+		}
+	}
+	if s := w.CacheStats(); s.Hits != 1 || s.Misses != 1 {
+		t.Fatalf("expected 1 hit and 1 miss, got %+v", s)
+	}
+
+	// Args that only differ in how they split must not share an entry.
+	w.EvaluateExecution(ctx, security.ExecutionRequest{Command: "git", Args: []string{"sta", "tus"}, SessionID: "s1"})
+	if s := w.CacheStats(); s.Misses != 2 || s.Evictions != 1 {
+		t.Fatalf("expected a miss and an eviction, got %+v", s)
+	}
+
+	// Reloading policies invalidates cached decisions.
+	if err := w.LoadPolicies(ctx, nil); err != nil {
+		t.Fatalf("LoadPolicies failed: %v", err)
+	}
+	if d, _ := w.EvaluateExecution(ctx, git); d.Allowed {
+		t.Fatal("expected stale allow to be discarded after reload")
+	}
+}
+
+func TestPolicyCache_EvictsLeastRecentlyUsedAndExpires(t *testing.T) {
+	c := warden.NewPolicyCache(2, time.Minute)
+	c.Set("a", security.PolicyDecision{Allowed: true})
+	c.Set("b", security.PolicyDecision{Allowed: true})
+	c.Get("a") // b is now the least recently used
+	c.Set("c", security.PolicyDecision{Allowed: true})
+
+	if _, ok := c.Get("b"); ok {
+		t.Error("expected b to be evicted")
+	}
+	for _, key := range []string{"a", "c"} {
+		if _, ok := c.Get(key); !ok {
+			t.Errorf("expected %s to stay cached", key)
+		}
+	}
+
+	short := warden.NewPolicyCache(2, time.Millisecond)
+	short.Set("a", security.PolicyDecision{Allowed: true})
+	time.Sleep(5 * time.Millisecond)
+	if _, ok := short.Get("a"); ok || short.Size() != 0 {
+		t.Errorf("expected the expired entry to be dropped, size %d", short.Size())
+	}
+}
+
+func TestWarden_ProxyForwardsAndTunnels(t *testing.T) {
+	ctx := context.Background()
+	upstream := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-DuckOps-Source-Tool") != "" {
+			t.Error("attribution header leaked upstream")
+		}
+		io.WriteString(rw, "hello "+r.URL.Path)
+	}))
+	defer upstream.Close()
+	tlsUpstream := httptest.NewTLSServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		io.WriteString(rw, "secure")
+	}))
+	defer tlsUpstream.Close()
+
+	dir := t.TempDir()
+	auditLog, err := audit.New(dir, filepath.Join(dir, "backups"))
+	if err != nil {
+		t.Fatalf("audit.New failed: %v", err)
+	}
+	defer auditLog.Close()
+
+	w := warden.New(true, nil)
+	w.SetAuditLog(auditLog)
+	w.LoadPolicies(ctx, []security.NetworkPolicy{{
+		ID:        "allow-local",
+		Name:      "allow local paths except /admin",
+		CedarBody: `permit(principal, action, resource) when { !(context.path like "/admin*") };`,
+		Enabled:   true,
+	}})
+	if err := w.StartProxy(ctx, "127.0.0.1:0"); err != nil {
+		t.Fatalf("StartProxy failed: %v", err)
+	}
+	defer w.StopProxy(ctx)
+
+	proxyURL, _ := url.Parse("http://scan:sess-1@" + w.ProxyAddr())
+	transport := tlsUpstream.Client().Transport.(*http.Transport).Clone()
+	transport.Proxy = http.ProxyURL(proxyURL)
+	client := &http.Client{Transport: transport}
+
+	get := func(u string) (int, string) {
+		t.Helper()
+		req, _ := http.NewRequest(http.MethodGet, u, nil)
+		req.Header.Set("X-DuckOps-Source-Tool", "scan")
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatalf("GET %s failed: %v", u, err)
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return resp.StatusCode, string(body)
+	}
+
+	if code, body := get(upstream.URL + "/repos"); code != http.StatusOK || body != "hello /repos" {
+		t.Errorf("expected relayed response, got %d %q", code, body)
+	}
+	if code, _ := get(upstream.URL + "/admin"); code != http.StatusForbidden {
+		t.Errorf("expected /admin to be blocked, got %d", code)
+	}
+	if code, body := get(tlsUpstream.URL + "/"); code != http.StatusOK || body != "secure" {
+		t.Errorf("expected CONNECT tunnel, got %d %q", code, body)
+	}
+
+	// A tunnel is recorded once it closes.
+	transport.CloseIdleConnections()
+	counts := map[security.AuditAction]int{}
+	for deadline := time.Now().Add(2 * time.Second); time.Now().Before(deadline); time.Sleep(20 * time.Millisecond) {
+		entries, err := auditLog.Query(ctx, ports.AuditFilter{SessionID: "sess-1"})
+		if err != nil {
+			t.Fatalf("Query failed: %v", err)
+		}
+		counts = map[security.AuditAction]int{}
+		for _, e := range entries {
+			counts[e.Action]++
+		}
+		if counts[security.AuditNetworkReq] == 2 {
+			break
+		}
+	}
+	if counts[security.AuditNetworkReq] != 2 || counts[security.AuditNetworkBlock] != 1 {
+		t.Errorf("expected 2 forwarded and 1 blocked audit entries, got %v", counts)
+	}
+}
+
+func TestWarden_ProxyInterceptsTLSForPathPolicies(t *testing.T) {
+	ctx := context.Background()
+	upstream := httptest.NewTLSServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		io.WriteString(rw, r.URL.Path)
+	}))
+	defer upstream.Close()
+
+	caDir := t.TempDir()
+	ca, err := warden.LoadOrCreateCA(caDir)
+	if err != nil {
+		t.Fatalf("LoadOrCreateCA failed: %v", err)
+	}
+	if again, err := warden.LoadOrCreateCA(caDir); err != nil || !again.Certificate().Equal(ca.Certificate()) {
+		t.Fatalf("expected the stored CA to be reused, err=%v", err)
+	}
+
+	upstreamRoots := x509.NewCertPool()
+	upstreamRoots.AddCert(upstream.Certificate())
+
+	w := warden.New(true, nil)
+	w.LoadPolicies(ctx, []security.NetworkPolicy{{
+		ID:        "our-org-only",
+		Name:      "only our org",
+		CedarBody: `permit(principal, action, resource) when { context.path like "/repos/our-org/*" };`,
+		Enabled:   true,
+	}})
+	if err := w.EnableInterception(warden.InterceptConfig{CA: ca, UpstreamRoots: upstreamRoots}); err != nil {
+		t.Fatalf("EnableInterception failed: %v", err)
+	}
+	if err := w.StartProxy(ctx, "127.0.0.1:0"); err != nil {
+		t.Fatalf("StartProxy failed: %v", err)
+	}
+	defer w.StopProxy(ctx)
+
+	clientRoots := x509.NewCertPool()
+	clientRoots.AddCert(ca.Certificate())
+	proxyURL, _ := url.Parse("http://" + w.ProxyAddr())
+	client := &http.Client{Transport: &http.Transport{
+		Proxy:           http.ProxyURL(proxyURL),
+		TLSClientConfig: &tls.Config{RootCAs: clientRoots},
+	}}
+
+	for path, want := range map[string]int{
+		"/repos/our-org/agent":   http.StatusOK,
+		"/repos/other-org/agent": http.StatusForbidden,
+	} {
+		resp, err := client.Get(upstream.URL + path)
+		if err != nil {
+			t.Fatalf("GET %s failed: %v", path, err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != want {
+			t.Errorf("GET %s: expected %d, got %d", path, want, resp.StatusCode)
+		}
+	}
+}
+
+func TestWarden_MTLSRotation(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	ca, err := warden.LoadOrCreateCA(dir)
+	if err != nil {
+		t.Fatalf("LoadOrCreateCA failed: %v", err)
+	}
+	cfg := security.MTLSConfig{
+		CACert:     ca.CertPath(),
+		ClientCert: filepath.Join(dir, "agent.pem"),
+		ClientKey:  filepath.Join(dir, "agent-key.pem"),
+	}
+	writeLeaf := func(host string) {
+		t.Helper()
+		leaf, err := ca.LeafFor(host)
+		if err != nil {
+			t.Fatalf("LeafFor failed: %v", err)
+		}
+		key, _ := x509.MarshalPKCS8PrivateKey(leaf.PrivateKey)
+		os.WriteFile(cfg.ClientCert, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: leaf.Certificate[0]}), 0600)
+		os.WriteFile(cfg.ClientKey, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: key}), 0600)
+	}
+
+	w := warden.New(true, nil)
+	if err := w.ConfigureMTLS(ctx, cfg); err == nil || !strings.Contains(err.Error(), cfg.ClientCert) {
+		t.Fatalf("expected an error naming the missing cert, got %v", err)
+	}
+
+	writeLeaf("agent-1")
+	if err := w.ConfigureMTLS(ctx, cfg); err != nil {
+		t.Fatalf("ConfigureMTLS failed: %v", err)
+	}
+	if err := w.StartProxy(ctx, "127.0.0.1:0"); err != nil {
+		t.Fatalf("StartProxy failed: %v", err)
+	}
+	defer w.StopProxy(ctx)
+
+	servedCN := func() string {
+		t.Helper()
+		var cn string
+		conn, err := tls.Dial("tcp", w.ProxyAddr(), &tls.Config{
+			InsecureSkipVerify: true,
+			VerifyConnection: func(cs tls.ConnectionState) error {
+				cn = cs.PeerCertificates[0].Subject.CommonName
+				return nil
+			},
+		})
+		if err == nil {
+			conn.Close()
+		}
+		return cn
+	}
+	if cn := servedCN(); cn != "agent-1" {
+		t.Fatalf("expected agent-1, got %q", cn)
+	}
+
+	writeLeaf("agent-2")
+	if err := w.ReloadMTLS(ctx); err != nil {
+		t.Fatalf("ReloadMTLS failed: %v", err)
+	}
+	if cn := servedCN(); cn != "agent-2" {
+		t.Fatalf("expected rotated cert agent-2, got %q", cn)
+	}
+
+	os.WriteFile(cfg.ClientCert, []byte("garbage"), 0600)
+	if err := w.ReloadMTLS(ctx); err == nil {
+		t.Fatal("expected reload of a corrupt cert to fail")
+	}
+	if cn := servedCN(); cn != "agent-2" {
+		t.Fatalf("expected previous cert to stay in use, got %q", cn)
+	}
+}
@@ -64,7 +64,8 @@ func NewToolRegistryService(l shared_ports.Logger) *ToolRegistryService {
 	}
 }
 
-// RegisterTool adds a tool to the registry.
+// RegisterTool adds a tool to the registry. A tool whose schema cannot be
+// built, such as one with a malformed jsonschema tag, is rejected.
 func (s *ToolRegistryService) RegisterTool(ctx context.Context, tool domain.Tool) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
@@ -72,12 +73,27 @@ func (s *ToolRegistryService) RegisterTool(ctx context.Context, tool domain.Tool
 	if _, exists := s.tools[tool.Name()]; exists {
 		return types.Newf(types.ErrCodeAlreadyExists, "tool already registered: %s", tool.Name())
 	}
+	if err := checkSchema(tool); err != nil {
+		return err
+	}
 
 	s.tools[tool.Name()] = tool
 	s.logger.Debug(ctx, "Tool registered", shared_ports.Field{Key: "tool", Value: tool.Name()})
 	return nil
 }
 
+// checkSchema builds the tool's schema once, turning the panic base.SchemaOf
+// raises on a malformed tag into an error.
+func checkSchema(tool domain.Tool) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = types.Newf(types.ErrCodeInvalidInput, "invalid schema for tool %s: %v", tool.Name(), r)
+		}
+	}()
+	tool.Schema()
+	return nil
+}
+
 // GetTool retrieves a tool by name.
 func (s *ToolRegistryService) GetTool(ctx context.Context, name string) (domain.Tool, error) {
 	s.mu.RLock()
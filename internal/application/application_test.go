@@ -0,0 +1,37 @@
+package application_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/SecDuckOps/agent/internal/application"
+	"github.com/SecDuckOps/agent/internal/domain"
+	"github.com/SecDuckOps/agent/internal/tools/base"
+)
+
+type badParams struct {
+	N int `json:"n" jsonschema:"minimum=many"`
+}
+
+type badTool struct{}
+
+func (badTool) Name() string { return "bad" }
+
+func (badTool) Schema() domain.ToolSchema {
+	return domain.ToolSchema{Name: "bad", Parameters: base.SchemaOf[badParams]()}
+}
+
+func (badTool) ExecuteRaw(context.Context, map[string]interface{}) (domain.Result, error) {
+	return domain.Result{}, nil
+}
+
+func TestToolRegistryService_RejectsInvalidSchema(t *testing.T) {
+	registry := application.NewToolRegistryService(nil)
+
+	if err := registry.RegisterTool(context.Background(), badTool{}); err == nil {
+		t.Fatal("expected a malformed jsonschema tag to fail registration")
+	}
+	if _, err := registry.GetTool(context.Background(), "bad"); err == nil {
+		t.Error("expected the tool to be left out")
+	}
+}
@@ -0,0 +1,150 @@
+// Package scrub applies the secret scanner and redactor ports to the data
+// that crosses the agent's boundaries: conversations sent to an LLM, tool
+// arguments about to run, and values written to output sinks.
+package scrub
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"strings"
+
+	"github.com/SecDuckOps/agent/internal/domain/security"
+	"github.com/SecDuckOps/agent/internal/ports"
+	"github.com/SecDuckOps/shared/llm/domain"
+)
+
+// Messages scrubs every message in place and merges the placeholders it
+// used into pm, which should live as long as the conversation does. Messages
+// already scrubbed on an earlier turn pass through unchanged. A nil scanner
+// is a no-op.
+func Messages(scanner ports.SecretScannerPort, pm *security.PlaceholderMap, messages []domain.Message) {
+	if scanner == nil {
+		return
+	}
+	for i := range messages {
+		scrubbed, used := scanner.Scrub(pm.SessionID, messages[i].Content)
+		messages[i].Content = scrubbed
+		pm.Merge(used)
+	}
+}
+
+// ChatMessages is Messages for conversations with tool calls; the
+// arguments of each call are scrubbed as well as the content.
+func ChatMessages(scanner ports.SecretScannerPort, pm *security.PlaceholderMap, messages []ports.ChatMessage) {
+	if scanner == nil {
+		return
+	}
+	scrub := func(text string) string {
+		scrubbed, used := scanner.Scrub(pm.SessionID, text)
+		pm.Merge(used)
+		return scrubbed
+	}
+	for i := range messages {
+		messages[i].Content = scrub(messages[i].Content)
+		for j, tc := range messages[i].ToolCalls {
+			if tc.Args != nil {
+				messages[i].ToolCalls[j].Args = mapStrings(tc.Args, scrub).(map[string]interface{})
+			}
+		}
+	}
+}
+
+// RestoreArgs returns a copy of tool arguments with placeholders replaced by
+// real values, descending into nested maps and slices. Call it only on
+// arguments about to be executed — never on text shown to the LLM again.
+func RestoreArgs(scanner ports.SecretScannerPort, pm security.PlaceholderMap, args map[string]interface{}) map[string]interface{} {
+	if scanner == nil || len(pm.Mappings) == 0 || args == nil {
+		return args
+	}
+	return restoreValue(scanner, pm, args).(map[string]interface{})
+}
+
+func restoreValue(scanner ports.SecretScannerPort, pm security.PlaceholderMap, v interface{}) interface{} {
+	return mapStrings(v, func(s string) string { return scanner.Restore(s, pm) })
+}
+
+// RedactValue returns a copy of v with every string redacted, descending
+// into nested maps and slices. Other values, such as structs, are redacted
+// through their JSON form at any depth and returned as generic JSON values
+// only when something was found. A nil redactor is a no-op.
+func RedactValue(r ports.RedactorPort, v interface{}) interface{} {
+	if r == nil || v == nil {
+		return v
+	}
+	return mapStrings(v, r.Redact)
+}
+
+// mapStrings returns a copy of v with fn applied to every string in it.
+// Values of other types, such as structs or typed slices, go through their
+// JSON form; they are kept as they are when fn changes nothing in it.
+func mapStrings(v interface{}, fn func(string) string) interface{} {
+	switch val := v.(type) {
+	case nil, bool, int, int64, uint64, float64, json.Number:
+		return v
+	case string:
+		return fn(val)
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(val))
+		for k, item := range val {
+			out[k] = mapStrings(item, fn)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(val))
+		for i, item := range val {
+			out[i] = mapStrings(item, fn)
+		}
+		return out
+	case map[string]string:
+		out := make(map[string]string, len(val))
+		for k, item := range val {
+			out[k] = fn(item)
+		}
+		return out
+	case []string:
+		out := make([]string, len(val))
+		for i, item := range val {
+			out[i] = fn(item)
+		}
+		return out
+	default:
+		return mapJSON(v, fn)
+	}
+}
+
+// mapJSON applies fn to the strings of v's JSON form. v is returned unchanged
+// when it cannot be encoded or fn finds nothing to change.
+func mapJSON(v interface{}, fn func(string) string) interface{} {
+	data, err := json.Marshal(v)
+	if err != nil || fn(string(data)) == string(data) {
+		return v
+	}
+	var generic interface{}
+	if err := json.Unmarshal(data, &generic); err != nil {
+		return v
+	}
+	return mapStrings(generic, fn)
+}
+
+// MaskResolvedSecrets replaces secret values resolved for the current tool
+// call with the references they came from. Anything that sends execution
+// details to an LLM calls it first, so the model only sees references.
+func MaskResolvedSecrets(ctx context.Context, text string) string {
+	resolved := ports.ResolvedSecretsFromContext(ctx)
+	if len(resolved) == 0 || text == "" {
+		return text
+	}
+	// Longer values first, so a value containing another is masked whole.
+	values := make([]string, 0, len(resolved))
+	for value := range resolved {
+		values = append(values, value)
+	}
+	sort.Slice(values, func(i, j int) bool { return len(values[i]) > len(values[j]) })
+
+	pairs := make([]string, 0, 2*len(values))
+	for _, value := range values {
+		pairs = append(pairs, value, resolved[value])
+	}
+	return strings.NewReplacer(pairs...).Replace(text)
+}
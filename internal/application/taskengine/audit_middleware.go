@@ -0,0 +1,47 @@
+package taskengine
+
+import (
+	"context"
+	"time"
+
+	"github.com/SecDuckOps/agent/internal/domain"
+	"github.com/SecDuckOps/agent/internal/domain/security"
+	"github.com/SecDuckOps/agent/internal/ports"
+)
+
+// AuditMiddleware records every command that passed the security gate as an
+// AuditCommand entry, with its exit code and outcome. Output is not recorded.
+func AuditMiddleware(auditLog ports.AuditLogPort) TaskMiddleware {
+	return func(next TaskHandler) TaskHandler {
+		return func(ctx context.Context, task *domain.OSTask) domain.OSTaskResult {
+			start := time.Now()
+			res := next(ctx, task)
+			if auditLog != nil {
+				details := map[string]interface{}{
+					"args":        task.Args,
+					"cwd":         task.Cwd,
+					"status":      res.Status,
+					"exit_code":   res.ExitCode,
+					"duration_ms": time.Since(start).Milliseconds(),
+				}
+				if res.Error != nil {
+					details["error"] = res.Error.Error()
+				}
+				recordCommand(ctx, auditLog, task, details)
+			}
+			return res
+		}
+	}
+}
+
+// recordCommand writes an AuditCommand entry for task.
+func recordCommand(ctx context.Context, auditLog ports.AuditLogPort, task *domain.OSTask, details map[string]interface{}) {
+	_ = auditLog.Record(ctx, security.AuditEntry{
+		SessionID: ports.SessionIDFromContext(ctx),
+		Action:    security.AuditCommand,
+		Actor:     "terminal",
+		Target:    task.OriginalCmd,
+		Details:   details,
+		Timestamp: time.Now(),
+	})
+}
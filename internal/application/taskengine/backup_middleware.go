@@ -0,0 +1,264 @@
+package taskengine
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/SecDuckOps/agent/internal/domain"
+	"github.com/SecDuckOps/agent/internal/domain/security"
+	"github.com/SecDuckOps/agent/internal/ports"
+	"github.com/SecDuckOps/shared/types"
+	"github.com/google/shlex"
+)
+
+// BackupMiddleware backs up the files a command is about to write before it
+// runs and records what it left in them afterwards, so `duckops session
+// rollback` can undo it. Only writes visible on the command line are
+// caught: redirections, tee, cp, mv, install, touch, truncate, dd of= and
+// sed -i, directly or inside sh -c. A command whose targets cannot be
+// backed up is not run.
+func BackupMiddleware(auditLog ports.AuditLogPort) TaskMiddleware {
+	return func(next TaskHandler) TaskHandler {
+		return func(ctx context.Context, task *domain.OSTask) domain.OSTaskResult {
+			if auditLog == nil {
+				return next(ctx, task)
+			}
+			paths := writeTargets(*task)
+			if len(paths) == 0 {
+				return next(ctx, task)
+			}
+			if err := BackupFiles(ctx, auditLog, paths...); err != nil {
+				return domain.OSTaskResult{Status: domain.StatusFailed, Error: err}
+			}
+			res := next(ctx, task)
+			_ = RecordWritten(ctx, auditLog, paths...)
+			return res
+		}
+	}
+}
+
+// BackupFiles backs up paths into log before the session in ctx writes
+// them: the content of those that exist and the absence of those that do
+// not. Directories are skipped. Only the first backup of a path in a session
+// is kept, so rolling back restores the state before the session began.
+func BackupFiles(ctx context.Context, log ports.AuditLogPort, paths ...string) error {
+	snapshot := security.SessionSnapshot{
+		SessionID: ports.SessionIDFromContext(ctx),
+		Files:     make(map[string][]byte),
+		CreatedAt: time.Now(),
+	}
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if errors.Is(err, os.ErrNotExist) {
+			snapshot.Missing = append(snapshot.Missing, path)
+			continue
+		}
+		if err != nil {
+			return types.Wrapf(err, types.ErrCodeInternal, "cannot back up %s", path)
+		}
+		if info.IsDir() {
+			continue
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return types.Wrapf(err, types.ErrCodeInternal, "cannot back up %s", path)
+		}
+		snapshot.Files[path] = data
+		snapshot.FileList = append(snapshot.FileList, path)
+	}
+	if len(snapshot.Files) == 0 && len(snapshot.Missing) == 0 {
+		return nil
+	}
+	return log.BackupSession(ctx, snapshot)
+}
+
+// RecordWritten stores in log what the session in ctx left in paths after
+// writing them, completing the backups made by BackupFiles.
+func RecordWritten(ctx context.Context, log ports.AuditLogPort, paths ...string) error {
+	snapshot := security.SessionSnapshot{
+		SessionID: ports.SessionIDFromContext(ctx),
+		Written:   make(map[string]string),
+		CreatedAt: time.Now(),
+	}
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue // not written, or not a regular file
+		}
+		sum := sha256.Sum256(data)
+		snapshot.Written[path] = hex.EncodeToString(sum[:])
+	}
+	if len(snapshot.Written) == 0 {
+		return nil
+	}
+	return log.BackupSession(ctx, snapshot)
+}
+
+var (
+	// redirectPattern matches output redirections and their target, e.g.
+	// "> out", "2>>err.log" and "&>all"; fd duplications like 2>&1 are not
+	// matched because the target cannot start with &.
+	redirectPattern = regexp.MustCompile(`(?:\d|&)?>>?\|?[ \t]*('[^']*'|"[^"]*"|[^\s;&|<>()'"]+)`)
+	// separatorPattern splits a shell script into simple commands.
+	separatorPattern = regexp.MustCompile(`&&|\|\||[;|&\n]`)
+)
+
+// shells run their -c argument as a script.
+var shells = map[string]bool{"sh": true, "bash": true, "zsh": true, "dash": true, "ksh": true}
+
+// writeTargets returns the absolute paths task writes, as far as the command
+// line tells.
+func writeTargets(task domain.OSTask) []string {
+	cwd := task.Cwd
+	if cwd == "" {
+		cwd, _ = os.Getwd()
+	}
+
+	var targets []string
+	if shells[filepath.Base(task.OriginalCmd)] {
+		for i, arg := range task.Args {
+			if arg == "-c" && i+1 < len(task.Args) {
+				targets = scriptTargets(task.Args[i+1], cwd)
+				break
+			}
+		}
+	} else {
+		targets = commandTargets(task.OriginalCmd, task.Args, cwd)
+	}
+
+	seen := make(map[string]bool)
+	paths := make([]string, 0, len(targets))
+	for _, target := range targets {
+		if target == "" || strings.HasPrefix(target, "/dev/") || strings.ContainsAny(target, "$`*?") {
+			continue
+		}
+		if !filepath.IsAbs(target) {
+			target = filepath.Join(cwd, target)
+		}
+		target = filepath.Clean(target)
+		if !seen[target] {
+			seen[target] = true
+			paths = append(paths, target)
+		}
+	}
+	return paths
+}
+
+// scriptTargets returns the files a shell script run in cwd writes.
+func scriptTargets(script, cwd string) []string {
+	var targets []string
+	for _, m := range redirectPattern.FindAllStringSubmatch(script, -1) {
+		targets = append(targets, strings.Trim(m[1], `'"`))
+	}
+	script = redirectPattern.ReplaceAllString(script, " ")
+	for _, part := range separatorPattern.Split(script, -1) {
+		words, err := shlex.Split(part)
+		if err != nil || len(words) == 0 {
+			continue
+		}
+		// cd is not followed, so relative paths after it resolve against the
+		// task's directory; better a wrong backup than none.
+		targets = append(targets, commandTargets(words[0], words[1:], cwd)...)
+	}
+	return targets
+}
+
+// commandTargets returns the files one command writes. cwd resolves
+// directory destinations of cp and mv.
+func commandTargets(cmd string, args []string, cwd string) []string {
+	operands, flags := splitFlags(args)
+	switch filepath.Base(cmd) {
+	case "tee", "touch":
+		return operands
+	case "truncate":
+		return withoutFlagValues(args, "-s", "--size", "-r", "--reference")
+	case "cp", "mv", "install":
+		if len(operands) < 2 {
+			return nil
+		}
+		dest := operands[len(operands)-1]
+		resolved := dest
+		if !filepath.IsAbs(resolved) && cwd != "" {
+			resolved = filepath.Join(cwd, resolved)
+		}
+		if info, err := os.Stat(resolved); err == nil && info.IsDir() {
+			var targets []string
+			for _, src := range operands[:len(operands)-1] {
+				targets = append(targets, filepath.Join(dest, filepath.Base(src)))
+			}
+			return targets
+		}
+		return []string{dest}
+	case "sed":
+		inPlace := false
+		for _, f := range flags {
+			if strings.HasPrefix(f, "-i") || strings.HasPrefix(f, "--in-place") {
+				inPlace = true
+			}
+		}
+		if !inPlace || len(operands) == 0 {
+			return nil
+		}
+		if hasAny(flags, "-e", "--expression", "-f", "--file") {
+			return withoutFlagValues(args, "-e", "--expression", "-f", "--file")
+		}
+		return operands[1:] // the first operand is the script
+	case "dd":
+		for _, arg := range args {
+			if strings.HasPrefix(arg, "of=") {
+				return []string{strings.TrimPrefix(arg, "of=")}
+			}
+		}
+	}
+	return nil
+}
+
+// splitFlags separates operands from flags; everything after "--" is an
+// operand.
+func splitFlags(args []string) (operands, flags []string) {
+	for i, arg := range args {
+		if arg == "--" {
+			return append(operands, args[i+1:]...), flags
+		}
+		if strings.HasPrefix(arg, "-") && arg != "-" {
+			flags = append(flags, arg)
+		} else {
+			operands = append(operands, arg)
+		}
+	}
+	return operands, flags
+}
+
+// withoutFlagValues returns the operands of args, skipping the value after
+// each of the given flags.
+func withoutFlagValues(args []string, valued ...string) []string {
+	var operands []string
+	for i := 0; i < len(args); i++ {
+		if hasAny(valued, args[i]) {
+			i++
+			continue
+		}
+		if !strings.HasPrefix(args[i], "-") {
+			operands = append(operands, args[i])
+		}
+	}
+	return operands
+}
+
+func hasAny(list []string, values ...string) bool {
+	for _, item := range list {
+		for _, v := range values {
+			if item == v {
+				return true
+			}
+		}
+	}
+	return false
+}
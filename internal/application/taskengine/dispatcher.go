@@ -16,6 +16,7 @@ type Dispatcher struct {
 	logger       shared_ports.Logger
 	executor     ports.CommandExecutorPort
 	securityGate ports.SecurityGatePort
+	auditLog     ports.AuditLogPort
 }
 
 // NewDispatcher creates a new task dispatcher with a middleware-based pipeline.
@@ -24,6 +25,8 @@ func NewDispatcher(
 	s ports.SecurityGatePort,
 	e ports.CommandExecutorPort,
 	th ports.ThinkingPort,
+	r ports.RedactorPort,
+	a ports.AuditLogPort,
 	l shared_ports.Logger,
 ) *Dispatcher {
 	// 1. Base handler: The final step that actually executes the OS command.
@@ -99,13 +102,14 @@ func NewDispatcher(
 	}
 
 	// Define Pipeline (Outer to Inner)
-	// Registration order: Reflection -> Thinking -> Observability -> Translation -> Security
-	// Execution order: Translation -> Security -> (Execution) -> Observability -> Thinking -> Reflection
+	// Registration order: Reflection -> Thinking -> Observability -> Translation -> Security -> Backup -> Audit -> Redaction
+	// Execution order: Translation -> Security -> Backup -> (Execution) -> Redaction -> Audit -> Backup -> Observability -> Thinking -> Reflection
 	return &Dispatcher{
-		pipeline:     ChainMiddleware(base, ReflectionMiddleware(th), ThinkingMiddleware(th), observabilityMW, translateMW, securityMW),
+		pipeline:     ChainMiddleware(base, ReflectionMiddleware(th), ThinkingMiddleware(th), observabilityMW, translateMW, securityMW, BackupMiddleware(a), AuditMiddleware(a), RedactionMiddleware(r)),
 		logger:       l,
 		executor:     e,
 		securityGate: s,
+		auditLog:     a,
 	}
 }
 
@@ -137,8 +141,31 @@ func (d *Dispatcher) Start(ctx context.Context, task domain.OSTask) (string, err
 	if d.executor == nil {
 		return "", fmt.Errorf("no command executor configured")
 	}
-	
-	return d.executor.Start(ctx, task)
+
+	// Files are backed up, but a streaming command's result is never seen,
+	// so rolling them back needs --force.
+	if d.auditLog != nil {
+		if paths := writeTargets(task); len(paths) > 0 {
+			if err := BackupFiles(ctx, d.auditLog, paths...); err != nil {
+				return "", err
+			}
+		}
+	}
+
+	sessionID, err := d.executor.Start(ctx, task)
+	if d.auditLog != nil {
+		details := map[string]interface{}{
+			"args":          task.Args,
+			"cwd":           task.Cwd,
+			"streaming":     true,
+			"shell_session": sessionID,
+		}
+		if err != nil {
+			details["error"] = err.Error()
+		}
+		recordCommand(ctx, d.auditLog, &task, details)
+	}
+	return sessionID, err
 }
 
 func (d *Dispatcher) Kill(ctx context.Context, sessionID string) error {
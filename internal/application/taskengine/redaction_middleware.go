@@ -0,0 +1,23 @@
+package taskengine
+
+import (
+	"context"
+
+	"github.com/SecDuckOps/agent/internal/domain"
+	"github.com/SecDuckOps/agent/internal/ports"
+)
+
+// RedactionMiddleware masks secrets in the command output as soon as it is
+// produced, so no later middleware, log or caller sees them.
+func RedactionMiddleware(redactor ports.RedactorPort) TaskMiddleware {
+	return func(next TaskHandler) TaskHandler {
+		return func(ctx context.Context, task *domain.OSTask) domain.OSTaskResult {
+			res := next(ctx, task)
+			if redactor != nil {
+				res.Stdout = redactor.Redact(res.Stdout)
+				res.Stderr = redactor.Redact(res.Stderr)
+			}
+			return res
+		}
+	}
+}
@@ -28,6 +28,7 @@ type Profile struct {
 	Warden       *WardenConfig       `toml:"warden,omitempty"`
 	Secrets      *SecretsConfig      `toml:"secrets,omitempty"`
 	Audit        *AuditConfig        `toml:"audit,omitempty"`
+	Sessions     *SessionsConfig     `toml:"sessions,omitempty"`
 }
 
 // Provider configures an LLM provider within a profile.
@@ -37,6 +38,16 @@ type Provider struct {
 	Model   string        `toml:"model,omitempty"`
 	BaseURL string        `toml:"base_url,omitempty"`
 	Auth    *ProviderAuth `toml:"auth,omitempty"`
+
+	// FunctionCalling set to false makes agents describe tools in the prompt
+	// instead of using the provider's native tool calling. Default: true.
+	FunctionCalling *bool `toml:"function_calling,omitempty"`
+}
+
+// UsesFunctionCalling reports whether agents may use the provider's native
+// tool calling.
+func (p Provider) UsesFunctionCalling() bool {
+	return p.FunctionCalling == nil || *p.FunctionCalling
 }
 
 // ProviderAuth configures authentication for a provider.
@@ -51,25 +62,172 @@ type WardenConfig struct {
 	Volumes     []string `toml:"volumes,omitempty"`
 	ProxyAddr   string   `toml:"proxy_addr,omitempty"`   // e.g., "127.0.0.1:9090"
 	PolicyFiles []string `toml:"policy_files,omitempty"` // paths to .cedar files
+	PolicyDirs  []string `toml:"policy_dirs,omitempty"`  // directories whose .cedar files are all loaded
 	DefaultDeny bool     `toml:"default_deny,omitempty"` // deny unmatched requests
 	CACert      string   `toml:"ca_cert,omitempty"`      // mTLS CA certificate path
 	ClientCert  string   `toml:"client_cert,omitempty"`  // mTLS client certificate
 	ClientKey   string   `toml:"client_key,omitempty"`   // mTLS client key
+
+	// TLS interception (MITM) so HTTPS policies can match full URLs. The CA is
+	// generated on first use; hosts in InterceptBypass are only tunnelled.
+	Intercept       bool     `toml:"intercept,omitempty"`
+	InterceptBypass []string `toml:"intercept_bypass,omitempty"` // e.g. "*.pinned.example.com"
+	InterceptCADir  string   `toml:"intercept_ca_dir,omitempty"` // default: ~/.duckops/warden
+
+	// Policy decision cache. CacheSize 0 uses the default size and a negative
+	// value disables caching; CacheTTLSeconds 0 uses the default TTL.
+	CacheSize       int `toml:"cache_size,omitempty"`
+	CacheTTLSeconds int `toml:"cache_ttl_seconds,omitempty"`
 }
 
 // SecretsConfig holds secret substitution settings.
 type SecretsConfig struct {
 	Enabled        bool   `toml:"enabled"`
 	CustomPatterns string `toml:"custom_patterns,omitempty"` // path to extra patterns.json
+
+	// Detection "entropy" adds context- and entropy-based rules to the regex
+	// patterns. Matches below Threshold (0–1, default 0.7) are ignored, as are
+	// values whose SHA-256 fingerprint is listed in the Allowlist file.
+	Detection string  `toml:"detection,omitempty"` // "regex" (default) or "entropy"
+	Threshold float64 `toml:"threshold,omitempty"`
+	Allowlist string  `toml:"allowlist,omitempty"`
+
+	// Resolution of secret:// references in tool arguments. env references
+	// always work; file references are limited to FileRoots and cmd references
+	// to commands starting with one of AllowedCommands.
+	FileRoots       []string     `toml:"file_roots,omitempty"`       // default: ~/.duckops/secrets
+	AllowedCommands []string     `toml:"allowed_commands,omitempty"` // e.g. "pass show", "op read"
+	Vault           *VaultConfig `toml:"vault,omitempty"`
+
+	Redaction *RedactionConfig `toml:"redaction,omitempty"`
+}
+
+// RedactionConfig masks secrets in data leaving the agent with a stable
+// [REDACTED:<hash>] marker. Detection follows the settings above.
+type RedactionConfig struct {
+	Enabled bool     `toml:"enabled"`
+	Sinks   []string `toml:"sinks,omitempty"`   // "audit", "events", "task_output", "app_log"; default: all
+	KeyEnv  string   `toml:"key_env,omitempty"` // env var with an HMAC key for markers, optional
+}
+
+// VaultConfig configures secret://vault/ references against a Vault KV v2 engine.
+type VaultConfig struct {
+	Address   string `toml:"address"`
+	Mount     string `toml:"mount,omitempty"`     // default: "secret"
+	TokenEnv  string `toml:"token_env,omitempty"` // default: VAULT_TOKEN
+	Namespace string `toml:"namespace,omitempty"`
+	CACert    string `toml:"ca_cert,omitempty"`
 }
 
 // AuditConfig holds session audit logging settings.
 type AuditConfig struct {
-	Enabled       bool   `toml:"enabled"`
-	LogDir        string `toml:"log_dir,omitempty"`         // default: ~/.duckops/audit
-	BackupDir     string `toml:"backup_dir,omitempty"`      // default: ~/.duckops/audit/backups
-	SSHBackupHost string `toml:"ssh_backup_host,omitempty"` // optional remote backup
-	SSHBackupPath string `toml:"ssh_backup_path,omitempty"`
+	Enabled   bool   `toml:"enabled"`
+	LogDir    string `toml:"log_dir,omitempty"`    // default: ~/.duckops/audit
+	BackupDir string `toml:"backup_dir,omitempty"` // default: ~/.duckops/audit/backups
+
+	// Closed session files are copied over SCP to SSHBackupPath, an existing
+	// directory on SSHBackupHost ([user@]host[:port]), every
+	// SSHBackupIntervalMinutes. The host key must be in SSHKnownHosts.
+	SSHBackupHost            string `toml:"ssh_backup_host,omitempty"` // optional remote backup
+	SSHBackupPath            string `toml:"ssh_backup_path,omitempty"`
+	SSHKeyFile               string `toml:"ssh_key_file,omitempty"`                // default: ~/.ssh/id_ed25519
+	SSHKnownHosts            string `toml:"ssh_known_hosts,omitempty"`             // default: ~/.ssh/known_hosts
+	SSHBackupIntervalMinutes int    `toml:"ssh_backup_interval_minutes,omitempty"` // default: 15
+
+	// Entries are always hash-chained. Signing "hmac" or "ed25519" also signs
+	// each entry and checkpoint with the key in KeyFile, created if missing.
+	Signing                   string `toml:"signing,omitempty"`
+	KeyFile                   string `toml:"key_file,omitempty"`                    // default: ~/.duckops/audit.key
+	CheckpointEvery           int    `toml:"checkpoint_every,omitempty"`            // entries; default: 100
+	CheckpointIntervalSeconds int    `toml:"checkpoint_interval_seconds,omitempty"` // default: 300
+
+	// Session logs are rotated into gzip-compressed segments once they reach
+	// MaxFileSizeMB or their first entry is RotateAfterHours old. Segments
+	// and backups older than RetentionDays are deleted; 0 keeps them forever.
+	MaxFileSizeMB    int `toml:"max_file_size_mb,omitempty"`   // default: 10
+	RotateAfterHours int `toml:"rotate_after_hours,omitempty"` // default: 24
+	RetentionDays    int `toml:"retention_days,omitempty"`
+
+	// Entries are also forwarded to each configured sink. A sink that is down
+	// is retried in the background; once its SinkBufferSize entries are
+	// queued, further entries are not forwarded to it.
+	Syslog         *AuditSyslogConfig        `toml:"syslog,omitempty"`
+	HTTP           *AuditHTTPConfig          `toml:"http,omitempty"`
+	Elasticsearch  *AuditElasticsearchConfig `toml:"elasticsearch,omitempty"`
+	SinkBufferSize int                       `toml:"sink_buffer_size,omitempty"` // default: 10000
+}
+
+// AuditSyslogConfig forwards audit entries as RFC 5424 syslog messages.
+type AuditSyslogConfig struct {
+	Address  string `toml:"address"` // host:port
+	TLS      bool   `toml:"tls,omitempty"`
+	CACert   string `toml:"ca_cert,omitempty"`  // default: system roots
+	Facility int    `toml:"facility,omitempty"` // default: 13 (log audit)
+	AppName  string `toml:"app_name,omitempty"` // default: duckops
+}
+
+// AuditHTTPConfig posts batches of audit entries as JSON arrays.
+type AuditHTTPConfig struct {
+	URL      string            `toml:"url"`
+	Headers  map[string]string `toml:"headers,omitempty"`
+	TokenEnv string            `toml:"token_env,omitempty"` // env var with a bearer token, optional
+	CACert   string            `toml:"ca_cert,omitempty"`
+}
+
+// AuditElasticsearchConfig indexes audit entries in Elasticsearch.
+type AuditElasticsearchConfig struct {
+	Addresses   []string `toml:"addresses"`
+	Username    string   `toml:"username,omitempty"`
+	PasswordEnv string   `toml:"password_env,omitempty"` // env var with the password
+	Index       string   `toml:"index,omitempty"`        // default: duckops-audit
+}
+
+// KeyPath returns KeyFile, or ~/.duckops/audit.key when it is not set.
+func (c *AuditConfig) KeyPath() (string, error) {
+	if c.KeyFile != "" {
+		return c.KeyFile, nil
+	}
+	dir, err := DuckOpsDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "audit.key"), nil
+}
+
+// SessionsConfig selects where subagent sessions are saved so they survive
+// a restart. Without it, sessions are saved as files under ~/.duckops/sessions.
+type SessionsConfig struct {
+	Store    string                  `toml:"store,omitempty"` // "file" (default), "postgres" or "memory" (not saved)
+	Dir      string                  `toml:"dir,omitempty"`   // file store; default: ~/.duckops/sessions
+	Postgres *SessionsPostgresConfig `toml:"postgres,omitempty"`
+
+	// Finished sessions are compacted CompactAfterHours after they end: their
+	// conversation is dropped and their event log compressed. They are
+	// deleted after RetentionDays; 0 keeps them forever.
+	CompactAfterHours int `toml:"compact_after_hours,omitempty"` // default: 24
+	RetentionDays     int `toml:"retention_days,omitempty"`
+}
+
+// SessionsPostgresConfig connects the session store to PostgreSQL.
+type SessionsPostgresConfig struct {
+	Host        string `toml:"host"`
+	Port        int    `toml:"port,omitempty"` // default: 5432
+	User        string `toml:"user"`
+	PasswordEnv string `toml:"password_env,omitempty"` // env var with the password
+	DBName      string `toml:"dbname"`
+	SSLMode     string `toml:"sslmode,omitempty"` // default: disable
+}
+
+// DirPath returns Dir, or ~/.duckops/sessions when it is not set.
+func (c *SessionsConfig) DirPath() (string, error) {
+	if c != nil && c.Dir != "" {
+		return c.Dir, nil
+	}
+	dir, err := DuckOpsDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "sessions"), nil
 }
 
 type Settings struct {
@@ -126,7 +284,7 @@ func EnsureDuckOpsDir() (string, error) {
 	policyDir := filepath.Join(dir, "policies")
 	safePolicyPath := filepath.Join(policyDir, "safe_execution.cedar")
 	if _, err := os.Stat(safePolicyPath); os.IsNotExist(err) {
-		defaultSafe := "// Default Safe Execution Policy\nALLOW all command\n"
+		defaultSafe := "// Default Safe Execution Policy\npermit(principal, action == Action::\"execute\", resource);\n"
 		if err := os.WriteFile(safePolicyPath, []byte(defaultSafe), 0600); err != nil {
 			return "", types.Wrap(err, types.ErrCodeInternal, "failed to write default safe policy")
 		}
@@ -134,7 +292,7 @@ func EnsureDuckOpsDir() (string, error) {
 
 	denyPolicyPath := filepath.Join(policyDir, "deny_execution.cedar")
 	if _, err := os.Stat(denyPolicyPath); os.IsNotExist(err) {
-		defaultDeny := "// Default Deny Execution Policy\n// DENY command \"rm\"\n"
+		defaultDeny := "// Default Deny Execution Policy\n// forbid(principal, action == Action::\"execute\", resource == Command::\"rm\");\n"
 		if err := os.WriteFile(denyPolicyPath, []byte(defaultDeny), 0600); err != nil {
 			return "", types.Wrap(err, types.ErrCodeInternal, "failed to write default deny policy")
 		}
@@ -0,0 +1,251 @@
+package domain
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// JSONSchema is the subset of JSON Schema used to describe tool parameters.
+// It is what LLM function calling APIs accept, and what the kernel checks
+// task arguments against before a tool runs.
+type JSONSchema struct {
+	Type                 string                 `json:"type,omitempty"` // "object", "string", "integer", "number", "boolean", "array"; empty allows anything
+	Description          string                 `json:"description,omitempty"`
+	Properties           map[string]*JSONSchema `json:"properties,omitempty"`
+	Required             []string               `json:"required,omitempty"`
+	AdditionalProperties *JSONSchema            `json:"additionalProperties,omitempty"` // values of a map
+	Items                *JSONSchema            `json:"items,omitempty"`
+	Enum                 []interface{}          `json:"enum,omitempty"`
+	Default              interface{}            `json:"default,omitempty"`
+	Minimum              *float64               `json:"minimum,omitempty"`
+	Maximum              *float64               `json:"maximum,omitempty"`
+	MinLength            *int                   `json:"minLength,omitempty"`
+	MaxLength            *int                   `json:"maxLength,omitempty"`
+	MinItems             *int                   `json:"minItems,omitempty"`
+	MaxItems             *int                   `json:"maxItems,omitempty"`
+}
+
+// FieldError reports one argument that does not match its schema. Field is
+// a path such as "env.HOME" or "args[2]"; it is empty for the arguments as
+// a whole.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+func (e FieldError) Error() string {
+	if e.Field == "" {
+		return e.Message
+	}
+	return e.Field + ": " + e.Message
+}
+
+// Map returns the schema as generic JSON, the form LLM SDKs take function
+// parameters in.
+func (s *JSONSchema) Map() map[string]interface{} {
+	if s == nil {
+		return map[string]interface{}{"type": "object", "properties": map[string]interface{}{}}
+	}
+	data, _ := json.Marshal(s)
+	var m map[string]interface{}
+	_ = json.Unmarshal(data, &m)
+	return m
+}
+
+// Validate checks value against the schema and returns every mismatch, in
+// field order. A nil schema accepts anything. Values may be decoded JSON or
+// Go values of the matching kind, so []string passes as an array.
+func (s *JSONSchema) Validate(value interface{}) []FieldError {
+	if s == nil {
+		return nil
+	}
+	var errs []FieldError
+	s.validate("", value, &errs)
+	return errs
+}
+
+func (s *JSONSchema) validate(path string, value interface{}, errs *[]FieldError) {
+	fail := func(format string, args ...interface{}) {
+		*errs = append(*errs, FieldError{Field: path, Message: fmt.Sprintf(format, args...)})
+	}
+
+	v := reflect.ValueOf(value)
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return // null is treated as absent
+		}
+		v = v.Elem()
+	}
+	if !v.IsValid() {
+		return
+	}
+
+	switch s.Type {
+	case "string":
+		if v.Kind() != reflect.String {
+			fail("must be a string, got %s", jsonKind(v))
+			return
+		}
+		n := len([]rune(v.String()))
+		if s.MinLength != nil && n < *s.MinLength {
+			fail("must be at least %d characters long", *s.MinLength)
+		}
+		if s.MaxLength != nil && n > *s.MaxLength {
+			fail("must be at most %d characters long", *s.MaxLength)
+		}
+	case "boolean":
+		if v.Kind() != reflect.Bool {
+			fail("must be a boolean, got %s", jsonKind(v))
+			return
+		}
+	case "integer", "number":
+		n, ok := number(v)
+		if !ok {
+			fail("must be %s, got %s", article(s.Type), jsonKind(v))
+			return
+		}
+		if s.Type == "integer" && n != math.Trunc(n) {
+			fail("must be an integer, got %v", n)
+			return
+		}
+		if s.Minimum != nil && n < *s.Minimum {
+			fail("must be at least %v", *s.Minimum)
+		}
+		if s.Maximum != nil && n > *s.Maximum {
+			fail("must be at most %v", *s.Maximum)
+		}
+	case "array":
+		if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+			fail("must be an array, got %s", jsonKind(v))
+			return
+		}
+		if s.MinItems != nil && v.Len() < *s.MinItems {
+			fail("must have at least %d items", *s.MinItems)
+		}
+		if s.MaxItems != nil && v.Len() > *s.MaxItems {
+			fail("must have at most %d items", *s.MaxItems)
+		}
+		if s.Items != nil {
+			for i := 0; i < v.Len(); i++ {
+				s.Items.validate(fmt.Sprintf("%s[%d]", path, i), v.Index(i).Interface(), errs)
+			}
+		}
+	case "object":
+		if v.Kind() != reflect.Map || v.Type().Key().Kind() != reflect.String {
+			fail("must be an object, got %s", jsonKind(v))
+			return
+		}
+		s.validateObject(path, v, errs)
+	}
+
+	if len(s.Enum) > 0 && !s.inEnum(v) {
+		options := make([]string, len(s.Enum))
+		for i, option := range s.Enum {
+			data, _ := json.Marshal(option)
+			options[i] = string(data)
+		}
+		fail("must be one of %s", strings.Join(options, ", "))
+	}
+}
+
+func (s *JSONSchema) validateObject(path string, v reflect.Value, errs *[]FieldError) {
+	child := func(key string) string {
+		if path == "" {
+			return key
+		}
+		return path + "." + key
+	}
+
+	for _, name := range s.Required {
+		if value := v.MapIndex(reflect.ValueOf(name).Convert(v.Type().Key())); !value.IsValid() || isNil(value) {
+			*errs = append(*errs, FieldError{Field: child(name), Message: "is required"})
+		}
+	}
+
+	keys := make([]string, 0, v.Len())
+	for _, key := range v.MapKeys() {
+		keys = append(keys, key.String())
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		value := v.MapIndex(reflect.ValueOf(key).Convert(v.Type().Key())).Interface()
+		if prop, ok := s.Properties[key]; ok {
+			prop.validate(child(key), value, errs)
+		} else if s.AdditionalProperties != nil {
+			s.AdditionalProperties.validate(child(key), value, errs)
+		}
+	}
+}
+
+func (s *JSONSchema) inEnum(v reflect.Value) bool {
+	n, isNumber := number(v)
+	for _, option := range s.Enum {
+		o := reflect.ValueOf(option)
+		if on, ok := number(o); ok && isNumber {
+			if on == n {
+				return true
+			}
+			continue
+		}
+		if o.Kind() == v.Kind() && o.Kind() != reflect.Map && o.Kind() != reflect.Slice && o.Interface() == v.Interface() {
+			return true
+		}
+	}
+	return false
+}
+
+// number returns v as a float64 if it holds a number.
+func number(v reflect.Value) (float64, bool) {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), true
+	}
+	if n, ok := v.Interface().(json.Number); ok {
+		f, err := n.Float64()
+		return f, err == nil
+	}
+	return 0, false
+}
+
+// jsonKind names the JSON type v would encode as, for error messages.
+func jsonKind(v reflect.Value) string {
+	if _, ok := number(v); ok {
+		return "number"
+	}
+	switch v.Kind() {
+	case reflect.String:
+		return "string"
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Slice, reflect.Array:
+		return "array"
+	case reflect.Map, reflect.Struct:
+		return "object"
+	}
+	return v.Kind().String()
+}
+
+func article(jsonType string) string {
+	if jsonType == "integer" {
+		return "an integer"
+	}
+	return "a " + jsonType
+}
+
+func isNil(v reflect.Value) bool {
+	for v.Kind() == reflect.Interface || v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return true
+		}
+		v = v.Elem()
+	}
+	return false
+}
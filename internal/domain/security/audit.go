@@ -10,20 +10,24 @@ import "time"
 type AuditAction string
 
 const (
-	AuditToolExecute  AuditAction = "tool.execute"
-	AuditToolResult   AuditAction = "tool.result"
-	AuditFileEdit     AuditAction = "file.edit"
-	AuditFileBackup   AuditAction = "file.backup"
-	AuditCommand      AuditAction = "command.run"
-	AuditLLMRequest   AuditAction = "llm.request"
-	AuditLLMResponse  AuditAction = "llm.response"
-	AuditNetworkReq   AuditAction = "network.request"
-	AuditNetworkBlock AuditAction = "network.blocked"
-	AuditSecretScrub  AuditAction = "secret.scrubbed"
-	AuditSessionStart AuditAction = "session.start"
-	AuditSessionEnd   AuditAction = "session.end"
-	AuditPolicyDeny   AuditAction = "policy.deny"
-	AuditPolicyAllow  AuditAction = "policy.allow"
+	AuditToolExecute   AuditAction = "tool.execute"
+	AuditToolResult    AuditAction = "tool.result"
+	AuditFileEdit      AuditAction = "file.edit"
+	AuditFileBackup    AuditAction = "file.backup"
+	AuditFileRestore   AuditAction = "file.restore"
+	AuditCommand       AuditAction = "command.run"
+	AuditLLMRequest    AuditAction = "llm.request"
+	AuditLLMResponse   AuditAction = "llm.response"
+	AuditNetworkReq    AuditAction = "network.request"
+	AuditNetworkBlock  AuditAction = "network.blocked"
+	AuditSecretScrub   AuditAction = "secret.scrubbed"
+	AuditSecretResolve AuditAction = "secret.resolved"
+	AuditSessionStart  AuditAction = "session.start"
+	AuditSessionResume AuditAction = "session.resume"
+	AuditSessionEnd    AuditAction = "session.end"
+	AuditPolicyDeny    AuditAction = "policy.deny"
+	AuditPolicyAllow   AuditAction = "policy.allow"
+	AuditPolicyReload  AuditAction = "policy.reload"
 )
 
 // AuditEntry is a single immutable log record.
@@ -37,6 +41,13 @@ type AuditEntry struct {
 	Details   map[string]interface{} `json:"details,omitempty"`
 	Timestamp time.Time              `json:"timestamp"`
 	ParentID  string                 `json:"parent_id,omitempty"` // links request → response
+
+	// Tamper evidence, filled in by the audit log: PrevHash is the Hash of the
+	// previous entry in the same log, Hash covers every other field and the
+	// optional Signature signs Hash.
+	PrevHash  string `json:"prev_hash,omitempty"`
+	Hash      string `json:"hash,omitempty"`
+	Signature string `json:"sig,omitempty"`
 }
 
 // SessionSnapshot captures a point-in-time backup of all modified files.
@@ -46,4 +57,10 @@ type SessionSnapshot struct {
 	Files     map[string][]byte `json:"-"` // path → original content (not serialized inline)
 	FileList  []string          `json:"files"`
 	CreatedAt time.Time         `json:"created_at"`
+
+	// Missing lists paths that did not exist yet; rolling back deletes them.
+	Missing []string `json:"missing,omitempty"`
+	// Written maps paths to the SHA-256 of the content the session left in
+	// them. Rollback will not overwrite a file that no longer matches.
+	Written map[string]string `json:"-"`
 }
@@ -1,6 +1,10 @@
 package security
 
-import "context"
+import (
+	"context"
+	"regexp"
+	"strings"
+)
 
 // ────────────────────────────────────
 // Secret Substitution Domain Types
@@ -13,13 +17,22 @@ type SecretPattern struct {
 	Prefix  string `json:"prefix"`  // placeholder prefix, e.g., "AWS_KEY"
 }
 
+// Detection rules recorded in SecretMatch.Rule.
+const (
+	RulePattern = "pattern" // a built-in or custom regular expression
+	RuleContext = "context" // a value assigned to a secret-looking key
+	RuleEntropy = "entropy" // a random-looking token with no other context
+)
+
 // SecretMatch represents a detected secret in text.
 type SecretMatch struct {
-	PatternName string `json:"pattern_name"`
-	Value       string `json:"value"`       // original secret value
-	Placeholder string `json:"placeholder"` // replacement placeholder
-	StartIndex  int    `json:"start_index"`
-	EndIndex    int    `json:"end_index"`
+	PatternName string  `json:"pattern_name"`
+	Rule        string  `json:"rule"`        // RulePattern, RuleContext or RuleEntropy
+	Confidence  float64 `json:"confidence"`  // 0–1
+	Value       string  `json:"value"`       // original secret value
+	Placeholder string  `json:"placeholder"` // replacement placeholder
+	StartIndex  int     `json:"start_index"`
+	EndIndex    int     `json:"end_index"`
 }
 
 // PlaceholderMap tracks placeholder → real value mappings for one scrub pass.
@@ -29,8 +42,64 @@ type PlaceholderMap struct {
 	Mappings  map[string]string `json:"-"` // placeholder → real value (never serialized)
 }
 
+// NewPlaceholderMap returns an empty map for a session.
+func NewPlaceholderMap(sessionID string) PlaceholderMap {
+	return PlaceholderMap{SessionID: sessionID, Mappings: make(map[string]string)}
+}
+
+// Merge adds the mappings from other, so one map can cover a whole
+// conversation rather than a single scrub pass.
+func (pm *PlaceholderMap) Merge(other PlaceholderMap) {
+	if len(other.Mappings) == 0 {
+		return
+	}
+	if pm.Mappings == nil {
+		pm.Mappings = make(map[string]string, len(other.Mappings))
+	}
+	for placeholder, value := range other.Mappings {
+		pm.Mappings[placeholder] = value
+	}
+}
+
 // SecretProvider defines an interface to securely resolve vault references dynamically.
 // This prevents sensitive credentials from leaking into the LLM context.
 type SecretProvider interface {
 	Resolve(ctx context.Context, ref string) (string, error)
 }
+
+// SecretRefScheme prefixes secret references such as secret://env/GITHUB_TOKEN.
+// The LLM only ever sees the reference; the Kernel runtime resolves it right
+// before a tool executes.
+const SecretRefScheme = "secret://"
+
+// SecretRef is a parsed secret://<backend>/<path> reference.
+type SecretRef struct {
+	Backend string // e.g. "env", "file", "cmd", "vault"
+	Path    string // backend-specific, e.g. "GITHUB_TOKEN" or "pass show ci/token"
+}
+
+// String returns the reference in secret:// form.
+func (r SecretRef) String() string {
+	return SecretRefScheme + r.Backend + "/" + r.Path
+}
+
+var (
+	// A value that is only a reference may contain spaces (cmd references).
+	wholeSecretRefRe = regexp.MustCompile(`^secret://([a-z][a-z0-9_-]*)/(\S.*)$`)
+	// A reference embedded in a longer string ends at whitespace or a quote.
+	embeddedSecretRefRe = regexp.MustCompile("secret://[a-z][a-z0-9_-]*/[^\\s\"'`<>]+")
+)
+
+// ParseSecretRef parses a complete secret:// reference.
+func ParseSecretRef(s string) (SecretRef, bool) {
+	m := wholeSecretRefRe.FindStringSubmatch(strings.TrimSpace(s))
+	if m == nil {
+		return SecretRef{}, false
+	}
+	return SecretRef{Backend: m[1], Path: m[2]}, true
+}
+
+// FindSecretRefs returns the [start, end) offsets of references embedded in s.
+func FindSecretRefs(s string) [][]int {
+	return embeddedSecretRefRe.FindAllStringIndex(s, -1)
+}
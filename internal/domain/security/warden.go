@@ -31,9 +31,21 @@ type ExecutionRequest struct {
 
 // PolicyDecision is the result of evaluating a Cedar policy against a request.
 type PolicyDecision struct {
-	Allowed  bool     `json:"allowed"`
-	PolicyID string   `json:"policy_id,omitempty"` // which policy matched
-	Reasons  []string `json:"reasons,omitempty"`   // explanation chain
+	Allowed          bool     `json:"allowed"`
+	RequiresApproval bool     `json:"requires_approval,omitempty"` // denied until a human confirms
+	PolicyID         string   `json:"policy_id,omitempty"`         // which policy matched
+	Reasons          []string `json:"reasons,omitempty"`           // explanation chain
+}
+
+// ApprovalRequest describes a request that a policy allows only after a human
+// confirms it (PolicyDecision.RequiresApproval).
+type ApprovalRequest struct {
+	ID        string   `json:"id"`
+	Kind      string   `json:"kind"`    // "execution", "network" or "filesystem"
+	Summary   string   `json:"summary"` // e.g. "terraform apply -auto-approve"
+	PolicyID  string   `json:"policy_id,omitempty"`
+	Reasons   []string `json:"reasons,omitempty"`
+	SessionID string   `json:"session_id,omitempty"`
 }
 
 // NetworkPolicy is a single Cedar-style policy with an ID.
@@ -45,6 +57,30 @@ type NetworkPolicy struct {
 	Enabled   bool   `json:"enabled"`
 }
 
+// PolicyDiff lists the policy IDs that differ between two policy sets.
+type PolicyDiff struct {
+	Added   []string `json:"added,omitempty"`
+	Removed []string `json:"removed,omitempty"`
+	Changed []string `json:"changed,omitempty"`
+}
+
+// Empty reports whether the two policy sets were identical.
+func (d PolicyDiff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0
+}
+
+// PolicyReloadEvent is published whenever the Warden's policy set is reloaded
+// at runtime. On failure Error is set and the previous policies stay active.
+// Skipped is set instead when the reload went through without the local
+// policies that could not be read or parsed.
+type PolicyReloadEvent struct {
+	Source    string     `json:"source"` // what triggered the reload, e.g. "file_watch"
+	Diff      PolicyDiff `json:"diff"`
+	Error     string     `json:"error,omitempty"`
+	Skipped   string     `json:"skipped,omitempty"`
+	Timestamp time.Time  `json:"timestamp"`
+}
+
 // MTLSConfig holds certificate paths for mutual TLS.
 // All paths are local — no external PKI dependencies.
 type MTLSConfig struct {
@@ -1,7 +1,10 @@
 package subagent
 
 import (
+	"slices"
 	"time"
+
+	"github.com/SecDuckOps/agent/internal/domain/security"
 )
 
 // SubagentStatus represents the lifecycle state of a subagent session.
@@ -15,8 +18,17 @@ const (
 	StatusFailed    SubagentStatus = "failed"
 	StatusCancelled SubagentStatus = "cancelled"
 	StatusRetrying  SubagentStatus = "retrying"
+
+	// StatusInterrupted marks a session that was running when the agent
+	// stopped. Resuming it restarts the loop from its last saved step.
+	StatusInterrupted SubagentStatus = "interrupted"
 )
 
+// IsTerminal reports whether a session in this status has finished for good.
+func (s SubagentStatus) IsTerminal() bool {
+	return s == StatusCompleted || s == StatusFailed || s == StatusCancelled
+}
+
 // EventType classifies what kind of event occurred during a subagent session.
 type EventType string
 
@@ -30,14 +42,19 @@ const (
 	EventPaused   EventType = "paused"
 	EventResumed  EventType = "resumed"
 	EventThought  EventType = "thought" // Used for AI "Thinking" and educational logs
+
+	// EventGap stands in for events a replay can no longer return. Its data
+	// holds the first and last missing sequence IDs (from_seq_id, to_seq_id).
+	EventGap EventType = "gap"
 )
 
 // PauseReason describes why a subagent paused.
 type PauseReason string
 
 const (
-	PauseToolApproval PauseReason = "tool_approval_required"
-	PauseInputNeeded  PauseReason = "input_required"
+	PauseToolApproval   PauseReason = "tool_approval_required"
+	PauseInputNeeded    PauseReason = "input_required"
+	PausePolicyApproval PauseReason = "policy_approval_required" // a Warden policy requires human sign-off
 )
 
 // RetryPolicy defines how failed subagents should be retried.
@@ -67,6 +84,10 @@ type PauseInfo struct {
 	Message          string            `json:"message,omitempty"`
 	PendingToolCalls []PendingToolCall `json:"pending_tool_calls,omitempty"`
 	RawOutput        string            `json:"raw_output,omitempty"`
+
+	// Approval is set for PausePolicyApproval. Resume with its ID in
+	// ResumeDecision.Approve or Reject to grant or deny that request.
+	Approval *security.ApprovalRequest `json:"approval,omitempty"`
 }
 
 // ResumeDecision carries the master agent's decision for a paused subagent.
@@ -78,6 +99,16 @@ type ResumeDecision struct {
 	Input      string   `json:"input,omitempty"` // Follow-up text input
 }
 
+// Approves reports whether the decision approves the pending tool call or
+// approval request with the given ID. Anything not approved is rejected,
+// and an explicit rejection wins over an approval.
+func (d ResumeDecision) Approves(id string) bool {
+	if d.RejectAll || slices.Contains(d.Reject, id) {
+		return false
+	}
+	return d.ApproveAll || slices.Contains(d.Approve, id)
+}
+
 // SessionConfig defines the parameters for creating a new subagent session.
 // Based on AOrchestra 4-tuple: (Instruction, Context, Tools, Model)
 type SessionConfig struct {
@@ -139,6 +170,7 @@ type Subagent struct {
 	Error       string         `json:"error,omitempty"`
 	RetryCount  int            `json:"retry_count"`
 	PauseInfo   *PauseInfo     `json:"pause_info,omitempty"`
+	Step        int            `json:"step"` // Agent loop iteration the session is at
 	CreatedAt   time.Time      `json:"created_at"`
 	Depth       int            `json:"depth"` // Recursion depth (0 = root)
 	StartedAt   *time.Time     `json:"started_at,omitempty"`
@@ -2,13 +2,36 @@ package domain
 
 import (
 	"context"
+
+	llm_domain "github.com/SecDuckOps/shared/llm/domain"
 )
 
 // ToolSchema defines the metadata for a tool, used by LLMs for function calling.
+// Parameters describes the arguments object; tools built on base.BaseTypedTool
+// derive it from their parameter struct with base.SchemaOf.
 type ToolSchema struct {
-	Name        string            `json:"name"`
-	Description string            `json:"description"`
-	Parameters  map[string]string `json:"parameters"`
+	Name        string      `json:"name"`
+	Description string      `json:"description"`
+	Parameters  *JSONSchema `json:"parameters,omitempty"`
+}
+
+// Definition returns the schema as a function declaration for providers with
+// native function calling.
+func (s ToolSchema) Definition() llm_domain.ToolDefinition {
+	return llm_domain.ToolDefinition{
+		Name:        s.Name,
+		Description: s.Description,
+		Parameters:  s.Parameters.Map(),
+	}
+}
+
+// ToolDefinitions converts schemas with Definition.
+func ToolDefinitions(schemas []ToolSchema) []llm_domain.ToolDefinition {
+	defs := make([]llm_domain.ToolDefinition, len(schemas))
+	for i, s := range schemas {
+		defs[i] = s.Definition()
+	}
+	return defs
 }
 
 // Tool is the basic, LLM-safe interface for all tools.
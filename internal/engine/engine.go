@@ -11,6 +11,7 @@ import (
 	"github.com/SecDuckOps/agent/internal/domain"
 	"github.com/SecDuckOps/agent/internal/domain/security"
 	"github.com/SecDuckOps/agent/internal/kernel"
+	"github.com/SecDuckOps/agent/internal/ports"
 	shared_domain "github.com/SecDuckOps/shared/llm/domain"
 	"path/filepath"
 )
@@ -60,6 +61,21 @@ type ReflectionEvent struct {
 	Model      string
 }
 
+// ApprovalEvent asks the user to approve a request that a Warden policy allows
+// only after human confirmation. The pipeline blocks until Respond is called.
+type ApprovalEvent struct {
+	Request security.ApprovalRequest
+	reply   chan bool
+}
+
+// Respond delivers the user's decision to the waiting pipeline.
+func (e ApprovalEvent) Respond(approved bool) {
+	select {
+	case e.reply <- approved:
+	default: // already answered
+	}
+}
+
 // StreamChat processes a user prompt and returns a channel of events (Thinking, ToolCalls, FinalResult).
 func (e *Engine) StreamChat(ctx context.Context, input string) (<-chan any, error) {
 	if e.kernel == nil {
@@ -74,8 +90,22 @@ func (e *Engine) StreamChat(ctx context.Context, input string) (<-chan any, erro
 		// 1. Prepare Task (same logic as Chat, but simplified for brevity)
 		task := e.prepareTask(input)
 		
-		// 2. Wrap context with event callback
-		execCtx := kernel.NewExecutionContext(ctx, "session:tui", "user:tui", []security.Capability{
+		// 2. Route approval-required decisions to the user, then wrap context with event callback
+		approvalCtx := ports.WithApprover(ctx, ports.ApprovalFunc(func(ctx context.Context, req security.ApprovalRequest) (bool, error) {
+			reply := make(chan bool, 1)
+			select {
+			case eventCh <- ApprovalEvent{Request: req, reply: reply}:
+			case <-ctx.Done():
+				return false, ctx.Err()
+			}
+			select {
+			case approved := <-reply:
+				return approved, nil
+			case <-ctx.Done():
+				return false, ctx.Err()
+			}
+		}))
+		execCtx := kernel.NewExecutionContext(approvalCtx, "session:tui", "user:tui", []security.Capability{
 			security.CapReadFS,
 			security.CapExecuteShell,
 		}).WithEventCallback(func(evt any) {
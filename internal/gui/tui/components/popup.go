@@ -102,3 +102,54 @@ func RenderShortcutsPopup(termW, termH int, isLegacy bool) string {
 	// Place the content in the center of the modal box
 	return modalStyle.Render(content)
 }
+
+// RenderApprovalPopup renders the prompt for a request that a Warden policy
+// allows only after the user confirms it.
+func RenderApprovalPopup(kind, summary, policyID string, reasons []string, termW int, isLegacy bool) string {
+	bgColor := lipgloss.AdaptiveColor{Light: "#FFFFFF", Dark: "#1A1A1A"}
+	baseStyle := lipgloss.NewStyle().Background(bgColor)
+
+	titleStyle := baseStyle.Copy().Foreground(popupTitle).Bold(true)
+	textStyle := baseStyle.Copy().Foreground(popupText)
+	mutedStyle := baseStyle.Copy().Foreground(popupMuted).Italic(true)
+	sepStyle := baseStyle.Copy().Foreground(lipgloss.AdaptiveColor{Light: "#DDDDDD", Dark: "#333333"})
+
+	popupW := 70
+	if popupW > termW-4 {
+		popupW = termW - 4
+	}
+	textW := popupW - 6
+
+	sep := sepStyle.Render(strings.Repeat("─", popupW-4))
+	rows := []string{
+		textStyle.Copy().Bold(true).Width(textW).Render(fmt.Sprintf("%s: %s", kind, summary)),
+		"",
+		mutedStyle.Render("Policy: " + policyID),
+	}
+	for _, r := range reasons {
+		rows = append(rows, mutedStyle.Copy().Width(textW).Render("• "+r))
+	}
+
+	content := lipgloss.JoinVertical(lipgloss.Center,
+		titleStyle.Render("APPROVAL REQUIRED"),
+		sep,
+		"",
+		lipgloss.JoinVertical(lipgloss.Left, rows...),
+		"",
+		sep,
+		mutedStyle.Render("Press y to approve, n or Esc to reject"),
+	)
+
+	border := lipgloss.RoundedBorder()
+	if isLegacy {
+		border = lipgloss.NormalBorder()
+	}
+
+	return lipgloss.NewStyle().
+		BorderStyle(border).
+		BorderForeground(popupBorder).
+		Background(bgColor).
+		Padding(1, 2).
+		Width(popupW).
+		Render(content)
+}
@@ -61,6 +61,7 @@ const (
 	PopupNone PopupType = iota
 	PopupShortcuts
 	PopupConfirm
+	PopupApproval
 )
 
 // ── Session Mode ───────────────────────────────────────────────────
@@ -166,6 +167,9 @@ type model struct {
 
 	// Stream tracking
 	lastStreamCh <-chan any
+
+	// Policy approval awaiting a y/n answer (PopupApproval)
+	pendingApproval *engine.ApprovalEvent
 }
 
 // NewModel creates an initialised model with the given terminal capabilities.
@@ -2,6 +2,7 @@ package tui
 
 import (
 	"context"
+	"fmt"
 	"regexp"
 	"strings"
 	"time"
@@ -115,6 +116,11 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.stayAtBottom = true
 		return m, waitForAgentEvent(m.lastStreamCh)
 
+	case engine.ApprovalEvent:
+		m.pendingApproval = &msg
+		m.activePopup = PopupApproval
+		return m, nil
+
 	case engine.ThoughtEvent:
 		m.messages = append(m.messages, Message{
 			Type:      ThoughtMsg,
@@ -344,6 +350,10 @@ func (m model) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		}
 	}
 
+	if m.activePopup == PopupApproval {
+		return m.handleApprovalKey(msg)
+	}
+
 	if m.activePopup != PopupNone {
 		if msg.Type == tea.KeyEsc {
 			m.activePopup = PopupNone
@@ -503,6 +513,45 @@ func (m model) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 
 // ── Menu key handling ───────────────────────────────────────────────
 
+// handleApprovalKey answers a pending policy approval: y approves, n or Esc rejects.
+func (m model) handleApprovalKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if m.pendingApproval == nil {
+		m.activePopup = PopupNone
+		return m, nil
+	}
+
+	var approved bool
+	switch {
+	case msg.Type == tea.KeyRunes && (msg.String() == "y" || msg.String() == "Y"):
+		approved = true
+	case msg.Type == tea.KeyEsc || (msg.Type == tea.KeyRunes && (msg.String() == "n" || msg.String() == "N")):
+		approved = false
+	default:
+		return m, nil
+	}
+
+	req := m.pendingApproval.Request
+	m.pendingApproval.Respond(approved)
+	m.pendingApproval = nil
+	m.activePopup = PopupNone
+
+	verdict, level := "Rejected", ToastWarning
+	if approved {
+		verdict, level = "Approved", ToastSuccess
+	}
+	m.messages = append(m.messages, Message{
+		Type:      SystemMsg,
+		Content:   fmt.Sprintf("%s %s: %s (policy %s)", verdict, req.Kind, req.Summary, req.PolicyID),
+		Sender:    "Warden",
+		Timestamp: time.Now(),
+	})
+	m.toast = &Toast{Message: verdict + " by you", Level: level}
+	return m, tea.Batch(
+		waitForAgentEvent(m.lastStreamCh),
+		tea.Tick(2*time.Second, func(t time.Time) tea.Msg { return toastDismissMsg{} }),
+	)
+}
+
 func (m model) handleMenuKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	items := components.GetFilteredMenuItems(m.textarea.Value())
 
@@ -109,8 +109,12 @@ func (m model) View() string {
 	if m.activePopup != PopupNone {
 		// When a popup is active, we render an empty dimmed area for messages
 		// to create a "Z-index / Blur" focus on the popup.
-		msgs = lipgloss.Place(mainW, msgsH, lipgloss.Center, lipgloss.Center,
-			components.RenderShortcutsPopup(mainW, msgsH, m.caps.IsLegacy))
+		popup := components.RenderShortcutsPopup(mainW, msgsH, m.caps.IsLegacy)
+		if m.activePopup == PopupApproval && m.pendingApproval != nil {
+			req := m.pendingApproval.Request
+			popup = components.RenderApprovalPopup(req.Kind, req.Summary, req.PolicyID, req.Reasons, mainW, m.caps.IsLegacy)
+		}
+		msgs = lipgloss.Place(mainW, msgsH, lipgloss.Center, lipgloss.Center, popup)
 		input = components.RenderInput(m.textarea, mainW, m.caps.IsLegacy, isShell)
 		// Dim the input while popup is active
 		input = dim(input)
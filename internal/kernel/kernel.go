@@ -4,6 +4,7 @@ import (
 	"context"
 
 	"github.com/SecDuckOps/agent/internal/domain"
+	"github.com/SecDuckOps/agent/internal/domain/security"
 	"github.com/SecDuckOps/agent/internal/ports"
 	shared_domain "github.com/SecDuckOps/shared/llm/domain"
 	shared_ports "github.com/SecDuckOps/shared/ports"
@@ -21,6 +22,7 @@ type Dependencies struct {
 	Warden         ports.WardenPort
 	ShellExecution ports.ShellExecutionPort
 	ShellLifecycle ports.ShellLifecyclePort
+	Secrets        security.SecretProvider // resolves secret:// references in tool arguments
 }
 
 // Kernel is the execution authority — it coordinates registry, runtime and dispatching.
@@ -40,6 +42,7 @@ func New(deps Dependencies) *Kernel {
 	}
 	
 	run := NewRuntime(deps.ToolRegistry, deps.AuditLog)
+	run.SetSecretProvider(deps.Secrets)
 	disp := NewDispatcher(run, deps.MessageBus, deps.Logger)
 
 	if run == nil || disp == nil {
@@ -103,6 +106,17 @@ func (k *Kernel) ExecuteCompat(ctx context.Context, task domain.Task) (domain.Re
 	return k.Execute(execCtx, task)
 }
 
+// ExecuteBatchCompat is ExecuteCompat for ExecuteBatch; it satisfies
+// ports.BatchToolExecutor.
+func (k *Kernel) ExecuteBatchCompat(ctx context.Context, tasks []domain.Task) ([]domain.Result, error) {
+	sessionID := ""
+	if len(tasks) > 0 {
+		sessionID = tasks[0].SessionID
+	}
+	execCtx := NewExecutionContext(ctx, sessionID, "system:compat", nil) // nil caps = no restrictions
+	return k.ExecuteBatch(execCtx, tasks)
+}
+
 // GetToolSchemas returns the schemas of all registered tools.
 // If allowedTools is non-empty, only schemas for those tools are returned.
 func (k *Kernel) GetToolSchemas(allowedTools []string) []domain.ToolSchema {
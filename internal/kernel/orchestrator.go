@@ -3,6 +3,7 @@ package kernel
 import (
 	"fmt"
 	"sync"
+	"time"
 
 	"github.com/SecDuckOps/agent/internal/domain"
 	"github.com/SecDuckOps/agent/internal/domain/orchestration"
@@ -38,13 +39,15 @@ func (o *Orchestrator) ExecutePlan(ctx *ExecutionContext, plan *orchestration.Ex
 			taskIDs[i] = t.ID
 		}
 		_ = o.auditLog.Record(ctx, security.AuditEntry{
-			Action: security.AuditToolExecute,
-			Actor:  ctx.PrincipalID,
-			Target: plan.ID,
+			SessionID: ctx.SessionID,
+			Action:    security.AuditToolExecute,
+			Actor:     ctx.PrincipalID,
+			Target:    plan.ID,
 			Details: map[string]interface{}{
 				"plan_name": plan.Name,
 				"task_ids":  taskIDs,
 			},
+			Timestamp: time.Now(),
 		})
 	}
 
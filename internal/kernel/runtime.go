@@ -1,19 +1,24 @@
 package kernel
 
 import (
+	"errors"
+	"strings"
 	"sync"
 	"time"
 
+	"github.com/SecDuckOps/agent/internal/application/scrub"
 	"github.com/SecDuckOps/agent/internal/domain"
 	"github.com/SecDuckOps/agent/internal/domain/security"
 	"github.com/SecDuckOps/agent/internal/ports"
 	types "github.com/SecDuckOps/shared/types"
+	"github.com/google/uuid"
 )
 
 // Runtime handles the execution of tools.
 type Runtime struct {
 	registry ports.ToolRegistry
 	auditLog ports.AuditLogPort
+	secrets  security.SecretProvider // resolves secret:// references; nil leaves them as is
 }
 
 // NewRuntime creates a new runtime.
@@ -24,6 +29,12 @@ func NewRuntime(registry ports.ToolRegistry, auditLog ports.AuditLogPort) *Runti
 	}
 }
 
+// SetSecretProvider sets the resolver for secret:// references in task
+// arguments.
+func (r *Runtime) SetSecretProvider(p security.SecretProvider) {
+	r.secrets = p
+}
+
 // Execute runs a tool based on the provided task.
 func (r *Runtime) Execute(ctx *ExecutionContext, task domain.Task) (domain.Result, error) {
 	if r.registry == nil {
@@ -35,14 +46,18 @@ func (r *Runtime) Execute(ctx *ExecutionContext, task domain.Task) (domain.Resul
 		}, err
 	}
 
+	sessionID := auditSession(ctx, task)
+
 	tool, err := r.registry.GetTool(ctx, task.Tool)
 	if err != nil {
 		appErr := types.Newf(types.ErrCodeToolNotFound, "tool not found: %s", task.Tool)
-		return domain.Result{
+		result := domain.Result{
 			TaskID:  task.ID,
 			Success: false,
 			Error:   appErr.Error(),
-		}, appErr
+		}
+		r.recordResult(ctx, sessionID, "", task, result)
+		return result, appErr
 	}
 
 	// Security Policy Enforcement: Verify task capability requirements
@@ -52,7 +67,7 @@ func (r *Runtime) Execute(ctx *ExecutionContext, task domain.Task) (domain.Resul
 		// Log the denial
 		if r.auditLog != nil {
 			_ = r.auditLog.Record(ctx, security.AuditEntry{
-				SessionID: task.SessionID,
+				SessionID: sessionID,
 				Action:    security.AuditPolicyDeny,
 				Actor:     ctx.PrincipalID,
 				Target:    task.Tool,
@@ -73,23 +88,73 @@ func (r *Runtime) Execute(ctx *ExecutionContext, task domain.Task) (domain.Resul
 	}
 
 	// 1. Audit Log: Tool Execution Started
+	executeID := uuid.New().String()
 	if r.auditLog != nil {
 		_ = r.auditLog.Record(ctx, security.AuditEntry{
-			SessionID: task.SessionID, // Requires SessionID on Task
+			ID:        executeID,
+			SessionID: sessionID,
 			Action:    security.AuditToolExecute,
 			Actor:     ctx.PrincipalID,
 			Target:    task.Tool,
 			Details: map[string]interface{}{
-				"args": task.Args,
+				"task_id": task.ID,
+				"args":    task.Args,
 			},
 			Timestamp: time.Now(),
 		})
 	}
 
+	// Arguments are checked against the tool's parameter schema, so the
+	// caller gets every problem at once instead of a decoding error
+	if fieldErrs := tool.Schema().Parameters.Validate(task.Args); len(fieldErrs) > 0 {
+		err := invalidArgs(task.Tool, fieldErrs)
+		result := domain.Result{
+			TaskID:  task.ID,
+			Success: false,
+			Error:   err.Error(),
+			Data:    map[string]interface{}{"field_errors": fieldErrs},
+		}
+		r.recordResult(ctx, sessionID, executeID, task, result)
+		return result, err
+	}
+
+	// Secret references are resolved only here, after the audit entry above
+	// recorded the arguments with their references intact.
+	args, masks, err := r.resolveSecrets(ctx, task)
+	if err != nil {
+		result := domain.Result{
+			TaskID:  task.ID,
+			Success: false,
+			Error:   err.Error(),
+		}
+		r.recordResult(ctx, sessionID, executeID, task, result)
+		return result, err
+	}
+
+	// Tools, and the LLM calls and commands they make, audit into the same session
+	scoped := *ctx
+	scoped.Context = ports.WithSessionID(ctx.Context, sessionID)
+	if len(masks) > 0 {
+		scoped.Context = ports.WithResolvedSecrets(scoped.Context, masks)
+	}
+	execCtx := &scoped
+
 	// Runtime executes the tool (only the runtime should execute this)
-	result, err := tool.ExecuteRaw(ctx, task.Args)
+	result, err := tool.ExecuteRaw(execCtx, args)
+	if len(masks) > 0 {
+		result = maskSecrets(execCtx, result)
+		if err != nil {
+			err = errors.New(scrub.MaskResolvedSecrets(execCtx, err.Error()))
+		}
+	}
 	if err != nil {
 		appErr := types.Wrapf(err, types.ErrCodeToolExecution, "failed to execute tool %s", task.Tool)
+		failed := result
+		failed.Success = false
+		if failed.Error == "" {
+			failed.Error = appErr.Error()
+		}
+		r.recordResult(ctx, sessionID, executeID, task, failed)
 		return result, appErr
 	}
 
@@ -97,24 +162,53 @@ func (r *Runtime) Execute(ctx *ExecutionContext, task domain.Task) (domain.Resul
 	result.TaskID = task.ID
 
 	// 2. Audit Log: Tool Execution Completed
-	if r.auditLog != nil {
-		_ = r.auditLog.Record(ctx, security.AuditEntry{
-			SessionID: task.SessionID,
-			Action:    security.AuditToolResult,
-			Actor:     "kernel",
-			Target:    task.Tool,
-			Details: map[string]interface{}{
-				"success": result.Success,
-				"error":   result.Error,
-			},
-			Timestamp: time.Now(),
-		})
-	}
+	r.recordResult(ctx, sessionID, executeID, task, result)
 
 	return result, nil
 }
 
-// ExecuteBatch runs multiple tools in parallel.
+// invalidArgs reports the field errors of a tool call in one error the LLM
+// can act on.
+func invalidArgs(toolName string, fieldErrs []domain.FieldError) error {
+	msgs := make([]string, len(fieldErrs))
+	for i, fe := range fieldErrs {
+		msgs[i] = fe.Error()
+	}
+	return types.Newf(types.ErrCodeInvalidInput, "invalid arguments for tool %s: %s", toolName, strings.Join(msgs, "; "))
+}
+
+// auditSession returns the session a task is audited under: its own, or
+// that of the context executing it.
+func auditSession(ctx *ExecutionContext, task domain.Task) string {
+	if task.SessionID != "" {
+		return task.SessionID
+	}
+	return ctx.SessionID
+}
+
+// recordResult audits the outcome of a tool call, linked to the entry that
+// recorded its start when there is one.
+func (r *Runtime) recordResult(ctx *ExecutionContext, sessionID, parentID string, task domain.Task, result domain.Result) {
+	if r.auditLog == nil {
+		return
+	}
+	_ = r.auditLog.Record(ctx, security.AuditEntry{
+		SessionID: sessionID,
+		Action:    security.AuditToolResult,
+		Actor:     "kernel",
+		Target:    task.Tool,
+		Details: map[string]interface{}{
+			"task_id": task.ID,
+			"success": result.Success,
+			"error":   result.Error,
+		},
+		Timestamp: time.Now(),
+		ParentID:  parentID,
+	})
+}
+
+// ExecuteBatch runs multiple tools in parallel. Results are in task order;
+// a failed task's result carries its error, and the first error is returned.
 func (r *Runtime) ExecuteBatch(ctx *ExecutionContext, tasks []domain.Task) ([]domain.Result, error) {
 	if r.registry == nil {
 		return nil, types.New(types.ErrCodeInternal, "runtime registry is not initialized")
@@ -129,6 +223,13 @@ func (r *Runtime) ExecuteBatch(ctx *ExecutionContext, tasks []domain.Task) ([]do
 		go func(idx int, t domain.Task) {
 			defer wg.Done()
 			res, err := r.Execute(ctx, t)
+			if err != nil {
+				res.TaskID = t.ID
+				res.Success = false
+				if res.Error == "" {
+					res.Error = err.Error()
+				}
+			}
 			results[idx] = res
 			errors[idx] = err
 		}(i, task)
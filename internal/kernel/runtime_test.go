@@ -0,0 +1,127 @@
+package kernel_test
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/SecDuckOps/agent/internal/adapters/audit"
+	"github.com/SecDuckOps/agent/internal/domain"
+	"github.com/SecDuckOps/agent/internal/domain/security"
+	"github.com/SecDuckOps/agent/internal/kernel"
+	"github.com/SecDuckOps/agent/internal/tools/base"
+)
+
+func TestRuntime_AuditsEveryExecution(t *testing.T) {
+	auditLog, err := audit.New(filepath.Join(t.TempDir(), "audit"), filepath.Join(t.TempDir(), "backups"))
+	if err != nil {
+		t.Fatalf("audit.New failed: %v", err)
+	}
+	defer auditLog.Close()
+
+	tool := &echoTool{}
+	run := kernel.NewRuntime(oneToolRegistry{tool}, auditLog)
+	ctx := kernel.NewExecutionContext(context.Background(), "s2", "tester", nil)
+
+	// Tasks without a session are audited under the executing context's.
+	if _, err := run.Execute(ctx, domain.Task{ID: "t1", Tool: "echo", Args: map[string]interface{}{"command": "ls"}}); err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	if tool.session != "s2" {
+		t.Errorf("tool saw session %q, want s2", tool.session)
+	}
+	if _, err := run.Execute(ctx, domain.Task{ID: "t2", Tool: "missing"}); err == nil {
+		t.Fatal("expected an unknown tool to fail")
+	}
+
+	entries, err := auditLog.ReplaySession(context.Background(), "s2")
+	if err != nil {
+		t.Fatalf("ReplaySession failed: %v", err)
+	}
+	if len(entries) != 3 {
+		t.Fatalf("expected 3 entries, got %+v", entries)
+	}
+	start, done, missing := entries[0], entries[1], entries[2]
+	if start.Action != security.AuditToolExecute || start.Actor != "tester" {
+		t.Errorf("unexpected start entry %+v", start)
+	}
+	if done.Action != security.AuditToolResult || done.ParentID != start.ID || done.Details["success"] != true {
+		t.Errorf("result entry not linked to its start: %+v", done)
+	}
+	if missing.Action != security.AuditToolResult || missing.Target != "missing" || missing.Details["success"] != false {
+		t.Errorf("unknown tool not audited: %+v", missing)
+	}
+}
+
+type fileParams struct {
+	Action string `json:"action" jsonschema:"required,enum=view|create"`
+	Path   string `json:"path" jsonschema:"required"`
+	Lines  int    `json:"lines,omitempty" jsonschema:"minimum=1"`
+}
+
+// fileTool declares its parameters, unlike echoTool.
+type fileTool struct{ echoTool }
+
+func (f *fileTool) Schema() domain.ToolSchema {
+	return domain.ToolSchema{Name: "echo", Parameters: base.SchemaOf[fileParams]()}
+}
+
+func TestRuntime_ValidatesArguments(t *testing.T) {
+	tool := &fileTool{}
+	run := kernel.NewRuntime(oneToolRegistry{tool}, nil)
+	ctx := kernel.NewExecutionContext(context.Background(), "s1", "tester", nil)
+
+	result, err := run.Execute(ctx, domain.Task{ID: "t1", Tool: "echo", Args: map[string]interface{}{
+		"action": "delete",
+		"lines":  "10",
+	}})
+	if err == nil || result.Success {
+		t.Fatal("expected invalid arguments to be rejected")
+	}
+	if !strings.Contains(err.Error(), "invalid arguments for tool echo") || !strings.Contains(err.Error(), "path: is required") {
+		t.Errorf("expected field errors in %q", err)
+	}
+	if tool.received != nil {
+		t.Error("tool ran despite invalid arguments")
+	}
+	fieldErrs, _ := result.Data["field_errors"].([]domain.FieldError)
+	fields := make(map[string]bool)
+	for _, fe := range fieldErrs {
+		fields[fe.Field] = true
+	}
+	if len(fieldErrs) != 3 || !fields["action"] || !fields["path"] || !fields["lines"] {
+		t.Errorf("expected errors for action, path and lines, got %+v", fieldErrs)
+	}
+
+	if _, err := run.Execute(ctx, domain.Task{ID: "t2", Tool: "echo", Args: map[string]interface{}{
+		"action":  "view",
+		"path":    "main.go",
+		"lines":   float64(20),
+		"command": "cat",
+	}}); err != nil {
+		t.Fatalf("valid arguments rejected: %v", err)
+	}
+}
+
+func TestRuntime_ExecuteBatchKeepsEachError(t *testing.T) {
+	run := kernel.NewRuntime(oneToolRegistry{&echoTool{}}, nil)
+	ctx := kernel.NewExecutionContext(context.Background(), "s1", "tester", nil)
+
+	results, err := run.ExecuteBatch(ctx, []domain.Task{
+		{ID: "t1", Tool: "missing"},
+		{ID: "t2", Tool: "echo", Args: map[string]interface{}{"command": "ls"}},
+	})
+	if err == nil || !strings.Contains(err.Error(), "missing") {
+		t.Fatalf("expected the unknown tool's error, got %v", err)
+	}
+	if len(results) != 2 || results[0].TaskID != "t1" || results[1].TaskID != "t2" {
+		t.Fatalf("expected results in task order, got %+v", results)
+	}
+	if results[0].Success || !strings.Contains(results[0].Error, "tool not found") {
+		t.Errorf("expected the failed result to carry its error, got %+v", results[0])
+	}
+	if !results[1].Success || results[1].Error != "" {
+		t.Errorf("expected the second task to succeed, got %+v", results[1])
+	}
+}
@@ -0,0 +1,184 @@
+package kernel
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/SecDuckOps/agent/internal/application/scrub"
+	"github.com/SecDuckOps/agent/internal/domain"
+	"github.com/SecDuckOps/agent/internal/domain/security"
+	types "github.com/SecDuckOps/shared/types"
+)
+
+// minMaskLength keeps very short secret values from being masked everywhere
+// they happen to appear in tool output.
+const minMaskLength = 4
+
+// refResolver replaces secret:// references in one task's arguments.
+type refResolver struct {
+	runtime *Runtime
+	ctx     *ExecutionContext
+	task    domain.Task
+	values  map[string]string // reference → value, so each is resolved once
+	err     error
+}
+
+// resolveSecrets returns a copy of task.Args with every secret:// reference
+// replaced by its value, and the resolved values mapped to their references
+// for masking. Each reference is audited without its value.
+func (r *Runtime) resolveSecrets(ctx *ExecutionContext, task domain.Task) (map[string]interface{}, map[string]string, error) {
+	if r.secrets == nil || task.Args == nil {
+		return task.Args, nil, nil
+	}
+
+	rr := &refResolver{runtime: r, ctx: ctx, task: task, values: make(map[string]string)}
+	args := rr.walk(task.Args).(map[string]interface{})
+	if rr.err != nil {
+		return nil, nil, rr.err
+	}
+
+	masks := make(map[string]string, len(rr.values))
+	for ref, value := range rr.values {
+		if len(value) >= minMaskLength {
+			masks[value] = ref
+		}
+	}
+	return args, masks, nil
+}
+
+func (rr *refResolver) walk(v interface{}) interface{} {
+	switch val := v.(type) {
+	case string:
+		return rr.str(val)
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(val))
+		for k, item := range val {
+			out[k] = rr.walk(item)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(val))
+		for i, item := range val {
+			out[i] = rr.walk(item)
+		}
+		return out
+	case map[string]string:
+		out := make(map[string]string, len(val))
+		for k, item := range val {
+			out[k] = rr.str(item)
+		}
+		return out
+	case []string:
+		out := make([]string, len(val))
+		for i, item := range val {
+			out[i] = rr.str(item)
+		}
+		return out
+	default:
+		return v
+	}
+}
+
+// str resolves a value that is a single reference (which may contain
+// spaces, as cmd references do) or a string with references embedded in it.
+func (rr *refResolver) str(s string) string {
+	if rr.err != nil || !strings.Contains(s, security.SecretRefScheme) {
+		return s
+	}
+	if ref, ok := security.ParseSecretRef(s); ok && !strings.Contains(ref.Path, security.SecretRefScheme) {
+		return rr.resolve(ref.String())
+	}
+
+	var b strings.Builder
+	last := 0
+	for _, loc := range security.FindSecretRefs(s) {
+		b.WriteString(s[last:loc[0]])
+		b.WriteString(rr.resolve(s[loc[0]:loc[1]]))
+		last = loc[1]
+	}
+	b.WriteString(s[last:])
+	return b.String()
+}
+
+func (rr *refResolver) resolve(ref string) string {
+	if rr.err != nil {
+		return ""
+	}
+	if value, ok := rr.values[ref]; ok {
+		return value
+	}
+
+	value, err := rr.runtime.secrets.Resolve(rr.ctx, ref)
+	rr.runtime.auditSecret(rr.ctx, rr.task, ref, err)
+	if err != nil {
+		rr.err = types.Wrapf(err, types.ErrCodePermissionDenied, "failed to resolve secret reference %s", ref)
+		return ""
+	}
+	rr.values[ref] = value
+	return value
+}
+
+// auditSecret records a resolution attempt. The value is never recorded.
+func (r *Runtime) auditSecret(ctx *ExecutionContext, task domain.Task, ref string, err error) {
+	if r.auditLog == nil {
+		return
+	}
+	details := map[string]interface{}{
+		"tool":     task.Tool,
+		"task_id":  task.ID,
+		"resolved": err == nil,
+	}
+	if parsed, ok := security.ParseSecretRef(ref); ok {
+		details["backend"] = parsed.Backend
+	}
+	if err != nil {
+		details["error"] = err.Error()
+	}
+	_ = r.auditLog.Record(ctx, security.AuditEntry{
+		SessionID: auditSession(ctx, task),
+		Action:    security.AuditSecretResolve,
+		Actor:     ctx.PrincipalID,
+		Target:    ref,
+		Details:   details,
+		Timestamp: time.Now(),
+	})
+}
+
+// maskSecrets replaces resolved values in a tool result with their
+// references, so output that echoes a secret never reaches the LLM.
+func maskSecrets(ctx context.Context, result domain.Result) domain.Result {
+	mask := func(s string) string { return scrub.MaskResolvedSecrets(ctx, s) }
+	result.Error = mask(result.Error)
+	if result.Data != nil {
+		result.Data = maskValue(result.Data, mask).(map[string]interface{})
+	}
+	return result
+}
+
+func maskValue(v interface{}, mask func(string) string) interface{} {
+	switch val := v.(type) {
+	case string:
+		return mask(val)
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(val))
+		for k, item := range val {
+			out[k] = maskValue(item, mask)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(val))
+		for i, item := range val {
+			out[i] = maskValue(item, mask)
+		}
+		return out
+	case []string:
+		out := make([]string, len(val))
+		for i, item := range val {
+			out[i] = mask(item)
+		}
+		return out
+	default:
+		return v
+	}
+}
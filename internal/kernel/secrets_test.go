@@ -0,0 +1,108 @@
+package kernel_test
+
+import (
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/SecDuckOps/agent/internal/adapters/audit"
+	"github.com/SecDuckOps/agent/internal/adapters/secrets"
+	"github.com/SecDuckOps/agent/internal/application/scrub"
+	"github.com/SecDuckOps/agent/internal/domain"
+	"github.com/SecDuckOps/agent/internal/domain/security"
+	"github.com/SecDuckOps/agent/internal/kernel"
+	"github.com/SecDuckOps/agent/internal/ports"
+	"github.com/SecDuckOps/shared/types"
+)
+
+// echoTool returns its arguments, as a command that prints a secret would.
+type echoTool struct {
+	received map[string]interface{}
+	masked   string
+	session  string
+}
+
+func (e *echoTool) Name() string              { return "echo" }
+func (e *echoTool) Schema() domain.ToolSchema { return domain.ToolSchema{Name: "echo"} }
+func (e *echoTool) ExecuteRaw(ctx context.Context, input map[string]interface{}) (domain.Result, error) {
+	e.received = input
+	e.session = ports.SessionIDFromContext(ctx)
+	e.masked = scrub.MaskResolvedSecrets(ctx, input["command"].(string))
+	return domain.Result{Success: true, Data: map[string]interface{}{"stdout": input["command"]}}, nil
+}
+
+type oneToolRegistry struct{ tool domain.Tool }
+
+func (r oneToolRegistry) RegisterTool(context.Context, domain.Tool) error { return nil }
+func (r oneToolRegistry) ListTools(context.Context) ([]domain.ToolSchema, error) {
+	return []domain.ToolSchema{r.tool.Schema()}, nil
+}
+func (r oneToolRegistry) GetTool(_ context.Context, name string) (domain.Tool, error) {
+	if name != r.tool.Name() {
+		return nil, types.Newf(types.ErrCodeToolNotFound, "tool not found: %s", name)
+	}
+	return r.tool, nil
+}
+
+func TestRuntime_ResolvesSecretReferences(t *testing.T) {
+	t.Setenv("DUCKOPS_TEST_TOKEN", "tok-1234567890")
+	auditLog, err := audit.New(filepath.Join(t.TempDir(), "audit"), filepath.Join(t.TempDir(), "backups"))
+	if err != nil {
+		t.Fatalf("audit.New failed: %v", err)
+	}
+	defer auditLog.Close()
+
+	resolver := secrets.NewResolver()
+	resolver.Register("env", secrets.EnvProvider{})
+	tool := &echoTool{}
+	run := kernel.NewRuntime(oneToolRegistry{tool}, auditLog)
+	run.SetSecretProvider(resolver)
+
+	ctx := kernel.NewExecutionContext(context.Background(), "s1", "tester", nil)
+	task := domain.Task{
+		ID:        "t1",
+		SessionID: "s1",
+		Tool:      "echo",
+		Args: map[string]interface{}{
+			"command": "curl -H 'Authorization: Bearer secret://env/DUCKOPS_TEST_TOKEN' https://api",
+			"env":     map[string]interface{}{"TOKEN": "secret://env/DUCKOPS_TEST_TOKEN"},
+		},
+	}
+
+	result, err := run.Execute(ctx, task)
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	if got := tool.received["env"].(map[string]interface{})["TOKEN"]; got != "tok-1234567890" {
+		t.Errorf("tool received %v, want the resolved value", got)
+	}
+	if !strings.Contains(tool.received["command"].(string), "Bearer tok-1234567890'") {
+		t.Errorf("embedded reference not resolved: %v", tool.received["command"])
+	}
+	if strings.Contains(tool.masked, "tok-1234567890") {
+		t.Errorf("MaskResolvedSecrets left the value in %q", tool.masked)
+	}
+	if stdout := result.Data["stdout"].(string); !strings.Contains(stdout, "secret://env/DUCKOPS_TEST_TOKEN") || strings.Contains(stdout, "tok-1234567890") {
+		t.Errorf("result not masked: %q", stdout)
+	}
+	if task.Args["env"].(map[string]interface{})["TOKEN"] != "secret://env/DUCKOPS_TEST_TOKEN" {
+		t.Error("the caller's arguments must keep the reference")
+	}
+
+	entries, _ := auditLog.Query(context.Background(), ports.AuditFilter{SessionID: "s1", Action: security.AuditSecretResolve})
+	if len(entries) != 1 || entries[0].Target != "secret://env/DUCKOPS_TEST_TOKEN" {
+		t.Fatalf("expected one secret.resolved entry, got %+v", entries)
+	}
+	all, _ := auditLog.ReplaySession(context.Background(), "s1")
+	raw, _ := json.Marshal(all)
+	if strings.Contains(string(raw), "tok-1234567890") {
+		t.Error("the audit log must never contain the secret value")
+	}
+
+	task.Args = map[string]interface{}{"command": "secret://env/DUCKOPS_TEST_UNSET"}
+	if _, err := run.Execute(ctx, task); err == nil {
+		t.Error("expected an unresolvable reference to fail the task")
+	}
+}
@@ -0,0 +1,38 @@
+package ports
+
+import (
+	"context"
+
+	"github.com/SecDuckOps/agent/internal/domain/security"
+)
+
+// ApprovalPort asks a human to confirm a request that a policy allows only
+// after approval. RequestApproval blocks until the request is approved (true),
+// rejected (false) or ctx is cancelled.
+type ApprovalPort interface {
+	RequestApproval(ctx context.Context, req security.ApprovalRequest) (bool, error)
+}
+
+// ApprovalFunc adapts a function to ApprovalPort.
+type ApprovalFunc func(ctx context.Context, req security.ApprovalRequest) (bool, error)
+
+// RequestApproval calls f(ctx, req).
+func (f ApprovalFunc) RequestApproval(ctx context.Context, req security.ApprovalRequest) (bool, error) {
+	return f(ctx, req)
+}
+
+type approverKey struct{}
+
+// WithApprover returns a context carrying the approver that security gates use
+// for approval-required decisions. Whoever drives an execution (a subagent
+// session, the TUI) attaches itself before calling into the kernel.
+func WithApprover(ctx context.Context, a ApprovalPort) context.Context {
+	return context.WithValue(ctx, approverKey{}, a)
+}
+
+// ApproverFromContext returns the approver attached to ctx, or nil. Without an
+// approver, approval-required requests must be denied.
+func ApproverFromContext(ctx context.Context) ApprovalPort {
+	a, _ := ctx.Value(approverKey{}).(ApprovalPort)
+	return a
+}
@@ -36,3 +36,29 @@ type AuditFilter struct {
 	To        *time.Time           `json:"to,omitempty"`
 	Limit     int                  `json:"limit,omitempty"`
 }
+
+type sessionIDKey struct{}
+
+// WithSessionID returns a context carrying the session that audit entries
+// recorded further down the call chain belong to.
+func WithSessionID(ctx context.Context, sessionID string) context.Context {
+	return context.WithValue(ctx, sessionIDKey{}, sessionID)
+}
+
+// SessionIDFromContext returns the session attached by WithSessionID, or "".
+func SessionIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(sessionIDKey{}).(string)
+	return id
+}
+
+// AuditSinkPort receives audit entries after they are written to the local
+// log, e.g. to forward them to a SIEM. Send is called from a background
+// goroutine with batches in recording order; on error the same batch is
+// sent again later, so sinks should tolerate duplicates.
+type AuditSinkPort interface {
+	// Name identifies the sink in logs.
+	Name() string
+
+	// Send delivers a batch of entries.
+	Send(ctx context.Context, entries []security.AuditEntry) error
+}
@@ -13,7 +13,10 @@ type RemoteConfig struct {
 type Rule struct {
 	ID          string `json:"id"`
 	Description string `json:"description"`
-	Action      string `json:"action"` // e.g., "allow", "deny", "require_approval"
+	Action      string `json:"action"`             // e.g., "allow", "deny", "require_approval"
+	Scope       string `json:"scope,omitempty"`    // "execute", "network", or empty for both
+	Match       string `json:"match,omitempty"`    // Cedar condition; empty matches every request in scope
+	Priority    int    `json:"priority,omitempty"` // overrides the default remote priority
 }
 
 type Model struct {
@@ -12,6 +12,13 @@ type ToolExecutor interface {
 	Execute(ctx context.Context, task domain.Task) (domain.Result, error)
 }
 
+// BatchToolExecutor runs several tasks concurrently. The Kernel satisfies
+// this too. Results are in task order, and each failed task's result carries
+// its error; the returned error is the first failure.
+type BatchToolExecutor interface {
+	ExecuteBatch(ctx context.Context, tasks []domain.Task) ([]domain.Result, error)
+}
+
 // ToolSchemaProvider provides tool schemas for the LLM system prompt.
 type ToolSchemaProvider interface {
 	GetToolSchemas(allowedTools []string) []domain.ToolSchema
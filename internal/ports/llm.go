@@ -0,0 +1,42 @@
+package ports
+
+import (
+	"context"
+
+	"github.com/SecDuckOps/shared/llm/domain"
+)
+
+// ToolCall is a tool invocation a model requested through native function
+// calling. ID links the call to its result in the next request. Invalid is
+// set, and the call must not run, when the arguments could not be decoded.
+type ToolCall struct {
+	ID      string                 `json:"id"`
+	Name    string                 `json:"name"`
+	Args    map[string]interface{} `json:"args"`
+	Invalid string                 `json:"invalid,omitempty"`
+}
+
+// ChatMessage is a conversation message that can carry tool calls and their
+// results, which domain.Message cannot.
+type ChatMessage struct {
+	Role       domain.MessageRole `json:"role"`
+	Content    string             `json:"content"`
+	ToolCalls  []ToolCall         `json:"tool_calls,omitempty"`   // assistant messages
+	ToolCallID string             `json:"tool_call_id,omitempty"` // domain.RoleTool messages: the call answered
+	ToolName   string             `json:"tool_name,omitempty"`    // domain.RoleTool messages
+}
+
+// ToolGeneration is a model reply: text, tool calls, or both.
+type ToolGeneration struct {
+	Content   string
+	ToolCalls []ToolCall
+	Usage     domain.TokenUsage
+}
+
+// ToolCallingLLM is implemented by providers with native tool calling.
+// Callers check for it with a type assertion and otherwise fall back to
+// Generate and a tool protocol described in the prompt.
+type ToolCallingLLM interface {
+	domain.LLM
+	GenerateWithTools(ctx context.Context, messages []ChatMessage, tools []domain.ToolDefinition) (ToolGeneration, error)
+}
@@ -1,6 +1,10 @@
 package ports
 
-import "github.com/SecDuckOps/agent/internal/domain/security"
+import (
+	"context"
+
+	"github.com/SecDuckOps/agent/internal/domain/security"
+)
 
 // SecretScannerPort defines the interface for secret detection and substitution.
 // Secrets are detected and replaced with placeholders before reaching any LLM.
@@ -15,4 +19,32 @@ type SecretScannerPort interface {
 
 	// Restore replaces placeholders back with real values.
 	Restore(text string, pm security.PlaceholderMap) string
+
+	// Forget drops the session's placeholder table, and with it the secret
+	// values it holds. Call it when the session ends.
+	Forget(sessionID string)
+}
+
+// RedactorPort masks secrets in data leaving the agent through a sink such
+// as the audit log, the event stream or the application log. Unlike Scrub,
+// redaction is one-way: the same value always yields the same marker, so
+// entries can be correlated without revealing it. Thread-safe.
+type RedactorPort interface {
+	// Redact returns text with every detected secret replaced by its marker.
+	Redact(text string) string
+}
+
+type resolvedSecretsKey struct{}
+
+// WithResolvedSecrets returns a context recording the secret values the
+// Kernel runtime resolved for a tool call, mapped to their references.
+func WithResolvedSecrets(ctx context.Context, resolved map[string]string) context.Context {
+	return context.WithValue(ctx, resolvedSecretsKey{}, resolved)
+}
+
+// ResolvedSecretsFromContext returns the secret values recorded by
+// WithResolvedSecrets, mapped to their references, or nil.
+func ResolvedSecretsFromContext(ctx context.Context) map[string]string {
+	resolved, _ := ctx.Value(resolvedSecretsKey{}).(map[string]string)
+	return resolved
 }
@@ -0,0 +1,34 @@
+package ports
+
+import (
+	"context"
+
+	"github.com/SecDuckOps/agent/internal/domain/subagent"
+)
+
+// SessionStore persists subagent sessions — their state, conversation and
+// events — so they survive an agent restart. Implementations must be safe
+// for concurrent use.
+type SessionStore interface {
+	// SaveSession creates or replaces the stored state of s.
+	SaveSession(ctx context.Context, s subagent.Subagent) error
+	// SaveMessages replaces the stored conversation of a session.
+	SaveMessages(ctx context.Context, sessionID string, messages []ChatMessage) error
+	// AppendEvent adds an event to a session's stored log.
+	AppendEvent(ctx context.Context, sessionID string, evt IndexedEvent) error
+
+	// LoadSessions returns every stored session.
+	LoadSessions(ctx context.Context) ([]subagent.Subagent, error)
+	// LoadMessages returns a session's conversation, or nil if none was saved.
+	LoadMessages(ctx context.Context, sessionID string) ([]ChatMessage, error)
+	// LoadEvents returns a session's events with SeqID > sinceSeqID, in order.
+	LoadEvents(ctx context.Context, sessionID string, sinceSeqID uint64) ([]IndexedEvent, error)
+
+	// CompactSession shrinks what is stored for a finished session. Its
+	// state and events stay readable; its conversation may be dropped.
+	CompactSession(ctx context.Context, sessionID string) error
+	// DeleteSession removes everything stored for a session.
+	DeleteSession(ctx context.Context, sessionID string) error
+
+	Close() error
+}
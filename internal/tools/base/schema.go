@@ -0,0 +1,168 @@
+package base
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/SecDuckOps/agent/internal/domain"
+)
+
+// schemaCache holds the schema of each parameter type, built once.
+var schemaCache sync.Map // reflect.Type -> *domain.JSONSchema
+
+// SchemaOf returns the JSON Schema of the parameter struct P, built from its
+// fields and tags:
+//
+//	type Params struct {
+//	    Action  string `json:"action" jsonschema:"required,enum=view|replace" description:"What to do"`
+//	    Timeout int    `json:"timeout,omitempty" jsonschema:"default=30,minimum=1,maximum=120"`
+//	}
+//
+// Property names come from the json tag; fields tagged json:"-" and
+// unexported fields are left out, and embedded structs are flattened. The
+// jsonschema tag takes comma-separated rules: required, enum=a|b|c,
+// default=v, minimum=n, maximum=n, minLength=n, maxLength=n, minItems=n and
+// maxItems=n. Values are read as JSON when they parse as such, so
+// enum=1|2 on an int field gives numbers. The description tag documents the
+// field.
+//
+// SchemaOf panics on a malformed tag, so mistakes surface the first time a
+// tool's schema is requested rather than as a wrong schema. The tool registry
+// builds every schema at registration and rejects the tool instead.
+func SchemaOf[P any]() *domain.JSONSchema {
+	t := reflect.TypeOf((*P)(nil)).Elem()
+	if cached, ok := schemaCache.Load(t); ok {
+		return cached.(*domain.JSONSchema)
+	}
+	schema := typeSchema(t)
+	schemaCache.Store(t, schema)
+	return schema
+}
+
+func typeSchema(t reflect.Type) *domain.JSONSchema {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return &domain.JSONSchema{Type: "string"}
+	case reflect.Bool:
+		return &domain.JSONSchema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &domain.JSONSchema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &domain.JSONSchema{Type: "number"}
+	case reflect.Slice, reflect.Array:
+		return &domain.JSONSchema{Type: "array", Items: typeSchema(t.Elem())}
+	case reflect.Map:
+		return &domain.JSONSchema{Type: "object", AdditionalProperties: typeSchema(t.Elem())}
+	case reflect.Struct:
+		schema := &domain.JSONSchema{Type: "object", Properties: make(map[string]*domain.JSONSchema)}
+		addFields(schema, t)
+		return schema
+	}
+	return &domain.JSONSchema{} // interface{} and the like: anything goes
+}
+
+// addFields adds the properties of struct t, and of the structs it embeds,
+// to schema.
+func addFields(schema *domain.JSONSchema, t reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" || (!field.IsExported() && !field.Anonymous) {
+			continue
+		}
+		if field.Anonymous && name == "" {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Pointer {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				addFields(schema, embedded)
+				continue
+			}
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		prop := typeSchema(field.Type)
+		prop.Description = field.Tag.Get("description")
+		if applyRules(prop, field) {
+			schema.Required = append(schema.Required, name)
+		}
+		schema.Properties[name] = prop
+	}
+}
+
+// applyRules applies the field's jsonschema tag to prop and reports whether
+// the field is required.
+func applyRules(prop *domain.JSONSchema, field reflect.StructField) (required bool) {
+	tag := field.Tag.Get("jsonschema")
+	if tag == "" {
+		return false
+	}
+	bad := func(rule string) {
+		panic(fmt.Sprintf("base.SchemaOf: invalid jsonschema rule %q on field %s", rule, field.Name))
+	}
+	for _, rule := range strings.Split(tag, ",") {
+		key, value, _ := strings.Cut(rule, "=")
+		switch key {
+		case "required":
+			required = true
+		case "enum":
+			for _, option := range strings.Split(value, "|") {
+				prop.Enum = append(prop.Enum, tagValue(prop, option))
+			}
+		case "default":
+			prop.Default = tagValue(prop, value)
+		case "minimum", "maximum":
+			n, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				bad(rule)
+			}
+			if key == "minimum" {
+				prop.Minimum = &n
+			} else {
+				prop.Maximum = &n
+			}
+		case "minLength", "maxLength", "minItems", "maxItems":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 0 {
+				bad(rule)
+			}
+			switch key {
+			case "minLength":
+				prop.MinLength = &n
+			case "maxLength":
+				prop.MaxLength = &n
+			case "minItems":
+				prop.MinItems = &n
+			default:
+				prop.MaxItems = &n
+			}
+		default:
+			bad(rule)
+		}
+	}
+	return required
+}
+
+// tagValue reads a tag value for prop: as text for strings, otherwise as
+// JSON when it parses.
+func tagValue(prop *domain.JSONSchema, value string) interface{} {
+	if prop.Type == "string" {
+		return value
+	}
+	var v interface{}
+	if err := json.Unmarshal([]byte(value), &v); err != nil {
+		return value
+	}
+	return v
+}
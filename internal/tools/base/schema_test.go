@@ -0,0 +1,96 @@
+package base_test
+
+import (
+	"testing"
+
+	"github.com/SecDuckOps/agent/internal/tools/base"
+)
+
+type nested struct {
+	Name string `json:"name" jsonschema:"required"`
+}
+
+type shared struct {
+	Verbose bool `json:"verbose,omitempty" description:"Print more."`
+}
+
+type params struct {
+	shared
+	Mode    string            `json:"mode" jsonschema:"required,enum=fast|slow" description:"How to run."`
+	Retries int               `json:"retries,omitempty" jsonschema:"default=3,minimum=0,maximum=10"`
+	Level   int               `json:"level,omitempty" jsonschema:"enum=1|2"`
+	Tags    []string          `json:"tags,omitempty" jsonschema:"minItems=1"`
+	Env     map[string]string `json:"env,omitempty"`
+	Items   []nested          `json:"items,omitempty"`
+	Ignored string            `json:"-"`
+	hidden  string
+}
+
+func TestSchemaOf(t *testing.T) {
+	schema := base.SchemaOf[params]()
+	if schema.Type != "object" || len(schema.Required) != 1 || schema.Required[0] != "mode" {
+		t.Fatalf("unexpected object schema %+v", schema)
+	}
+	if len(schema.Properties) != 7 {
+		t.Errorf("expected 7 properties, got %d", len(schema.Properties))
+	}
+
+	mode := schema.Properties["mode"]
+	if mode.Type != "string" || mode.Description != "How to run." || len(mode.Enum) != 2 || mode.Enum[1] != "slow" {
+		t.Errorf("unexpected mode schema %+v", mode)
+	}
+	retries := schema.Properties["retries"]
+	if retries.Type != "integer" || retries.Default != float64(3) || *retries.Minimum != 0 || *retries.Maximum != 10 {
+		t.Errorf("unexpected retries schema %+v", retries)
+	}
+	if level := schema.Properties["level"]; level.Enum[0] != float64(1) {
+		t.Errorf("integer enum not numeric: %#v", level.Enum)
+	}
+	if env := schema.Properties["env"]; env.Type != "object" || env.AdditionalProperties.Type != "string" {
+		t.Errorf("unexpected env schema %+v", env)
+	}
+	if items := schema.Properties["items"]; items.Items.Properties["name"].Type != "string" || items.Items.Required[0] != "name" {
+		t.Errorf("unexpected items schema %+v", items.Items)
+	}
+	if _, ok := schema.Properties["verbose"]; !ok {
+		t.Error("embedded struct fields not flattened")
+	}
+
+	errs := schema.Validate(map[string]interface{}{
+		"mode":    "fast",
+		"retries": float64(11),
+		"tags":    []interface{}{},
+		"env":     map[string]interface{}{"HOME": 1},
+		"items":   []interface{}{map[string]interface{}{}},
+	})
+	want := []string{
+		"env.HOME: must be a string, got number",
+		"items[0].name: is required",
+		"retries: must be at most 10",
+		"tags: must have at least 1 items",
+	}
+	if len(errs) != len(want) {
+		t.Fatalf("got errors %v, want %v", errs, want)
+	}
+	for i, err := range errs {
+		if err.Error() != want[i] {
+			t.Errorf("error %d = %q, want %q", i, err.Error(), want[i])
+		}
+	}
+
+	if errs := schema.Validate(map[string]interface{}{"mode": "slow", "tags": []string{"a"}, "level": 2}); len(errs) != 0 {
+		t.Errorf("Go-typed arguments rejected: %v", errs)
+	}
+}
+
+func TestSchemaOf_InvalidTagPanics(t *testing.T) {
+	type bad struct {
+		N int `json:"n" jsonschema:"minimum=many"`
+	}
+	defer func() {
+		if recover() == nil {
+			t.Error("expected a malformed rule to panic")
+		}
+	}()
+	base.SchemaOf[bad]()
+}
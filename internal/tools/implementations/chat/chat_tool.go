@@ -6,21 +6,28 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/SecDuckOps/agent/internal/application/scrub"
 	agent_domain "github.com/SecDuckOps/agent/internal/domain"
+	"github.com/SecDuckOps/agent/internal/domain/security"
 	"github.com/SecDuckOps/agent/internal/domain/subagent"
 	"github.com/SecDuckOps/agent/internal/ports"
 	"github.com/SecDuckOps/agent/internal/tools/base"
 	"github.com/SecDuckOps/shared/llm/domain"
 	"github.com/SecDuckOps/shared/types"
+	"github.com/google/uuid"
 	"strings"
 )
 
+// chatSessionID prefixes the scope of each chat's secret placeholders, so the
+// same secret gets the same placeholder across the turns of one chat.
+const chatSessionID = "chat"
+
 // ChatParams defines the parameters for the chat tool.
 type ChatParams struct {
 	SystemPrompt    string `json:"system_prompt,omitempty"`
 	AssistantPrefix string `json:"assistant_prefix,omitempty"`
-	Prompt          string `json:"prompt"`
-	AIProvider      string `json:"ai_provider"`
+	Prompt          string `json:"prompt" jsonschema:"required,minLength=1"`
+	AIProvider      string `json:"ai_provider" jsonschema:"default=gemini"`
 }
 
 // ChatTool implements the base.Tool interface for chatting with an LLM.
@@ -29,14 +36,17 @@ type ChatTool struct {
 	llmRegistry    domain.LLMRegistry
 	executor       ports.ToolExecutor
 	schemaProvider ports.ToolSchemaProvider
+	secretScanner  ports.SecretScannerPort
 }
 
-// NewChatTool creates a new instance of ChatTool.
-func NewChatTool(llmRegistry domain.LLMRegistry, executor ports.ToolExecutor, schemaProvider ports.ToolSchemaProvider) *ChatTool {
+// NewChatTool creates a new instance of ChatTool. secretScanner may be nil,
+// in which case messages are sent to the provider unscrubbed.
+func NewChatTool(llmRegistry domain.LLMRegistry, executor ports.ToolExecutor, schemaProvider ports.ToolSchemaProvider, secretScanner ports.SecretScannerPort) *ChatTool {
 	t := &ChatTool{
 		llmRegistry:    llmRegistry,
 		executor:       executor,
 		schemaProvider: schemaProvider,
+		secretScanner:  secretScanner,
 	}
 	t.Impl = t
 	return t
@@ -52,12 +62,7 @@ func (t *ChatTool) Schema() agent_domain.ToolSchema {
 	return agent_domain.ToolSchema{
 		Name:        "chat",
 		Description: "A tool for chatting with various LLM providers.",
-		Parameters: map[string]string{
-			"system_prompt":    "string",
-			"assistant_prefix": "string",
-			"prompt":           "string",
-			"ai_provider":      "string",
-		},
+		Parameters:  base.SchemaOf[ChatParams](),
 	}
 }
 
@@ -127,6 +132,13 @@ Always respond with valid JSON. Do not include any text outside the JSON object.
 	}
 	messages = append(messages, domain.Message{Role: domain.RoleUser, Content: params.Prompt})
 
+	// Every message is scrubbed before each call; placeholders are restored
+	// only in tool arguments handed to the executor.
+	placeholders := security.NewPlaceholderMap(chatSessionID + ":" + uuid.NewString())
+	if t.secretScanner != nil {
+		defer t.secretScanner.Forget(placeholders.SessionID)
+	}
+
 	const maxSteps = 15
 	var lastUsage domain.TokenUsage
 	var finalResponse string
@@ -135,7 +147,7 @@ Always respond with valid JSON. Do not include any text outside the JSON object.
 	emit := func(msg string, evtType subagent.EventType) {
 		if e, ok := ctx.(interface{ Emit(any) }); ok {
 			e.Emit(subagent.SubagentEvent{
-				SessionID: "chat",
+				SessionID: chatSessionID,
 				Type:      evtType,
 				Message:   msg,
 				Timestamp: time.Now(),
@@ -148,6 +160,7 @@ Always respond with valid JSON. Do not include any text outside the JSON object.
 	for i := 0; i < maxSteps; i++ {
 		var result domain.GenerationResult
 		var err error
+		scrub.Messages(t.secretScanner, &placeholders, messages)
 		
 		// Attempt generation with current provider, with fallback rotation and retry logic
 		maxRetries := 3
@@ -248,7 +261,7 @@ Always respond with valid JSON. Do not include any text outside the JSON object.
 			task := agent_domain.Task{
 				ID:   fmt.Sprintf("chat_loop_%d", i),
 				Tool: tc.Name,
-				Args: tc.Args,
+				Args: scrub.RestoreArgs(t.secretScanner, placeholders, tc.Args),
 			}
 			
 			execRes, execErr := t.executor.Execute(ctx, task)
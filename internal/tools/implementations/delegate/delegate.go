@@ -12,8 +12,8 @@ import (
 )
 
 type DelegateParams struct {
-	CapabilityName string `json:"capability_name"`
-	Objective      string `json:"objective"`
+	CapabilityName string `json:"capability_name" jsonschema:"required,minLength=1" description:"The exact name of the specialized capability (e.g. 'scanner.filesystem')."`
+	Objective      string `json:"objective" jsonschema:"required,minLength=1" description:"The specific task instructions for the subagent to perform."`
 }
 
 type TrackerClient interface {
@@ -40,10 +40,7 @@ func (t *DelegateTool) Schema() domain.ToolSchema {
 	return domain.ToolSchema{
 		Name:        "delegate",
 		Description: "Delegates a specific task to a specialized subagent based on a capability profile. You must provide the capability name and the task objective.",
-		Parameters: map[string]string{
-			"capability_name": "string (required) - The exact name of the specialized capability (e.g., 'scanner.filesystem')",
-			"objective":       "string (required) - The specific task instructions for the subagent to perform",
-		},
+		Parameters:  base.SchemaOf[DelegateParams](),
 	}
 }
 
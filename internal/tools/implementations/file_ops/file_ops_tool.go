@@ -2,34 +2,41 @@ package file_ops
 
 import (
 	"context"
+	"crypto/sha256"
 	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
+	"github.com/SecDuckOps/agent/internal/application/taskengine"
 	"github.com/SecDuckOps/agent/internal/domain"
 	agent_domain "github.com/SecDuckOps/agent/internal/domain"
+	"github.com/SecDuckOps/agent/internal/domain/security"
+	"github.com/SecDuckOps/agent/internal/ports"
 	"github.com/SecDuckOps/agent/internal/tools/base"
 	"github.com/SecDuckOps/agent/internal/tools/implementations/filesystem"
 	"github.com/SecDuckOps/shared/types"
 )
 
 type FileOpsParams struct {
-	Action          string `json:"action"` // "view", "replace", "create"
-	FilePath        string `json:"file_path"`
-	TargetText      string `json:"target_text,omitempty"`
-	ReplacementText string `json:"replacement_text,omitempty"`
-	Content         string `json:"content,omitempty"`
+	Action          string `json:"action" jsonschema:"required,enum=view|replace|create"`
+	FilePath        string `json:"file_path" jsonschema:"required,minLength=1" description:"Absolute or relative path to the file."`
+	TargetText      string `json:"target_text,omitempty" description:"Required for 'replace': the exact text to be replaced."`
+	ReplacementText string `json:"replacement_text,omitempty" description:"Required for 'replace': the new text to insert."`
+	Content         string `json:"content,omitempty" description:"Required for 'create': the full content of the new file."`
 }
 
 type FileOpsTool struct {
 	base.BaseTypedTool[FileOpsParams]
-	gate *filesystem.WardenGate
+	gate     *filesystem.WardenGate
+	auditLog ports.AuditLogPort // records every write, optional
 }
 
-func NewFileOpsTool(gate *filesystem.WardenGate) *FileOpsTool {
+func NewFileOpsTool(gate *filesystem.WardenGate, auditLog ports.AuditLogPort) *FileOpsTool {
 	t := &FileOpsTool{
-		gate: gate,
+		gate:     gate,
+		auditLog: auditLog,
 	}
 	t.Impl = t
 	return t
@@ -43,13 +50,7 @@ func (t *FileOpsTool) Schema() agent_domain.ToolSchema {
 	return agent_domain.ToolSchema{
 		Name:        "file_edit",
 		Description: "A native tool to safely view, edit (search-and-replace), or create files. Use this instead of running raw bash 'sed' or 'cat' commands to avoid escaping syntax errors.",
-		Parameters: map[string]string{
-			"action":           "string (required: 'view', 'replace', 'create')",
-			"file_path":        "string (required: absolute or relative path to the file)",
-			"target_text":      "string (required for 'replace': the exact text to be replaced)",
-			"replacement_text": "string (required for 'replace': the new text to insert)",
-			"content":          "string (required for 'create': the full content of the new file)",
-		},
+		Parameters:  base.SchemaOf[FileOpsParams](),
 	}
 }
 
@@ -119,10 +120,15 @@ func (t *FileOpsTool) Execute(ctx context.Context, params FileOpsParams) (agent_
 			return domain.Result{Success: false, Error: fmt.Sprintf("failed to create directories: %v", err)}, nil
 		}
 
+		if err := t.backup(ctx, absPath); err != nil {
+			return domain.Result{Success: false, Error: err.Error()}, nil
+		}
+		previous, readErr := os.ReadFile(absPath)
 		err := os.WriteFile(absPath, []byte(params.Content), 0644)
 		if err != nil {
 			return domain.Result{Success: false, Error: fmt.Sprintf("failed to create file: %v", err)}, nil
 		}
+		t.recordEdit(ctx, params.Action, absPath, previous, readErr == nil, []byte(params.Content))
 		return domain.Result{
 			Success: true,
 			Data:    map[string]interface{}{"message": fmt.Sprintf("File created successfully at %s", absPath)},
@@ -146,10 +152,14 @@ func (t *FileOpsTool) Execute(ctx context.Context, params FileOpsParams) (agent_
 
 		newContent := strings.Replace(content, params.TargetText, params.ReplacementText, 1)
 
+		if err := t.backup(ctx, absPath); err != nil {
+			return domain.Result{Success: false, Error: err.Error()}, nil
+		}
 		err = os.WriteFile(absPath, []byte(newContent), 0644)
 		if err != nil {
 			return domain.Result{Success: false, Error: fmt.Sprintf("failed to write updated file: %v", err)}, nil
 		}
+		t.recordEdit(ctx, params.Action, absPath, data, true, []byte(newContent))
 
 		return domain.Result{
 			Success: true,
@@ -159,3 +169,42 @@ func (t *FileOpsTool) Execute(ctx context.Context, params FileOpsParams) (agent_
 
 	return domain.Result{Success: false, Error: "Unknown action"}, nil
 }
+
+// backup saves the file's original state for `duckops session rollback`
+// before it is written. Nothing is written when that fails.
+func (t *FileOpsTool) backup(ctx context.Context, path string) error {
+	if t.auditLog == nil {
+		return nil
+	}
+	if err := taskengine.BackupFiles(ctx, t.auditLog, path); err != nil {
+		return fmt.Errorf("refusing to write %s: backup failed: %v", path, err)
+	}
+	return nil
+}
+
+// recordEdit audits a write with the size and SHA-256 of the file before and
+// after it; the content itself stays out of the log.
+func (t *FileOpsTool) recordEdit(ctx context.Context, action, path string, before []byte, existed bool, after []byte) {
+	if t.auditLog == nil {
+		return
+	}
+	_ = taskengine.RecordWritten(ctx, t.auditLog, path)
+	details := map[string]interface{}{
+		"action":       action,
+		"existed":      existed,
+		"size_after":   len(after),
+		"sha256_after": fmt.Sprintf("%x", sha256.Sum256(after)),
+	}
+	if existed {
+		details["size_before"] = len(before)
+		details["sha256_before"] = fmt.Sprintf("%x", sha256.Sum256(before))
+	}
+	_ = t.auditLog.Record(ctx, security.AuditEntry{
+		SessionID: ports.SessionIDFromContext(ctx),
+		Action:    security.AuditFileEdit,
+		Actor:     t.Name(),
+		Target:    path,
+		Details:   details,
+		Timestamp: time.Now(),
+	})
+}
@@ -3,26 +3,30 @@ package filesystem
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/SecDuckOps/agent/internal/domain/security"
 	"github.com/SecDuckOps/agent/internal/ports"
 	shared_ports "github.com/SecDuckOps/shared/ports"
 	"github.com/SecDuckOps/shared/types"
+	"github.com/google/uuid"
 )
 
 // WardenGate wraps filesystem operations with Warden Cedar policy checks.
 // Every FS operation passes through the Warden before execution.
 type WardenGate struct {
-	warden ports.WardenPort
-	logger shared_ports.Logger
+	warden   ports.WardenPort
+	auditLog ports.AuditLogPort // records every decision, optional
+	logger   shared_ports.Logger
 }
 
 // NewWardenGate creates a new WardenGate.
 // If warden is nil, all operations are allowed (Stand Duck without explicit policies).
-func NewWardenGate(warden ports.WardenPort, logger shared_ports.Logger) *WardenGate {
+func NewWardenGate(warden ports.WardenPort, auditLog ports.AuditLogPort, logger shared_ports.Logger) *WardenGate {
 	return &WardenGate{
-		warden: warden,
-		logger: logger,
+		warden:   warden,
+		auditLog: auditLog,
+		logger:   logger,
 	}
 }
 
@@ -67,6 +71,12 @@ func (g *WardenGate) evaluate(ctx context.Context, method, path, tool, operation
 		return types.Wrap(err, types.ErrCodeInternal, "warden policy evaluation failed")
 	}
 
+	if !decision.Allowed && decision.RequiresApproval {
+		err := g.requestApproval(ctx, operation, path, decision)
+		g.record(ctx, operation, path, decision, err)
+		return err
+	}
+
 	if !decision.Allowed {
 		reason := "policy denied"
 		if len(decision.Reasons) > 0 {
@@ -80,10 +90,68 @@ func (g *WardenGate) evaluate(ctx context.Context, method, path, tool, operation
 			shared_ports.Field{Key: "policy_id", Value: decision.PolicyID},
 		)
 
-		return types.Newf(types.ErrCodePermissionDenied,
+		err := types.Newf(types.ErrCodePermissionDenied,
 			"filesystem %s on %q blocked by Warden: %s (policy: %s)",
 			operation, path, reason, decision.PolicyID)
+		g.record(ctx, operation, path, decision, err)
+		return err
 	}
 
+	g.record(ctx, operation, path, decision, nil)
+	return nil
+}
+
+// record audits a decision on a filesystem operation.
+func (g *WardenGate) record(ctx context.Context, operation, path string, decision security.PolicyDecision, denial error) {
+	if g.auditLog == nil {
+		return
+	}
+	action := security.AuditPolicyAllow
+	details := map[string]interface{}{
+		"operation":         operation,
+		"policy_id":         decision.PolicyID,
+		"requires_approval": decision.RequiresApproval,
+	}
+	if denial != nil {
+		action = security.AuditPolicyDeny
+		details["reason"] = denial.Error()
+	}
+	err := g.auditLog.Record(ctx, security.AuditEntry{
+		SessionID: ports.SessionIDFromContext(ctx),
+		Action:    action,
+		Actor:     "warden",
+		Target:    path,
+		Details:   details,
+		Timestamp: time.Now(),
+	})
+	if err != nil && g.logger != nil {
+		g.logger.ErrorErr(ctx, err, "Failed to record Warden decision in audit log")
+	}
+}
+
+// requestApproval asks the approver attached to ctx to confirm an operation
+// that a policy allows only after human review.
+func (g *WardenGate) requestApproval(ctx context.Context, operation, path string, decision security.PolicyDecision) error {
+	approver := ports.ApproverFromContext(ctx)
+	if approver == nil {
+		return types.Newf(types.ErrCodePermissionDenied,
+			"filesystem %s on %q requires human approval, but no approver is available (policy: %s)",
+			operation, path, decision.PolicyID)
+	}
+
+	approved, err := approver.RequestApproval(ctx, security.ApprovalRequest{
+		ID:       uuid.New().String(),
+		Kind:     "filesystem",
+		Summary:  fmt.Sprintf("%s %s", operation, path),
+		PolicyID: decision.PolicyID,
+		Reasons:  decision.Reasons,
+	})
+	if err != nil {
+		return types.Wrapf(err, types.ErrCodeInternal, "approval for filesystem %s on %q failed", operation, path)
+	}
+	if !approved {
+		return types.Newf(types.ErrCodePermissionDenied,
+			"filesystem %s on %q rejected by reviewer (policy: %s)", operation, path, decision.PolicyID)
+	}
 	return nil
 }
@@ -24,10 +24,10 @@ type Note struct {
 }
 
 type NotesParams struct {
-	Action  string   `json:"action"` // "add", "update", "view", "list", "delete"
-	Key     string   `json:"key,omitempty"`
-	Content string   `json:"content,omitempty"`
-	Tags    []string `json:"tags,omitempty"`
+	Action  string   `json:"action" jsonschema:"required,enum=add|update|view|list|delete"`
+	Key     string   `json:"key,omitempty" description:"Required for all actions except 'list'."`
+	Content string   `json:"content,omitempty" description:"The note content, required for 'add' and 'update'."`
+	Tags    []string `json:"tags,omitempty" description:"Optional tags for categorization."`
 }
 
 type NotesTool struct {
@@ -48,12 +48,7 @@ func (t *NotesTool) Schema() agent_domain.ToolSchema {
 	return agent_domain.ToolSchema{
 		Name:        "notes",
 		Description: "A persistent key-value store for the agent to remember important findings, credentials, IP addresses, or state across different steps or subagents.",
-		Parameters: map[string]string{
-			"action":  "string (required: 'add', 'update', 'view', 'list', 'delete')",
-			"key":     "string (required for all actions except 'list')",
-			"content": "string (the note content, required for 'add' and 'update')",
-			"tags":    "array of strings (optional, for categorization)",
-		},
+		Parameters:  base.SchemaOf[NotesParams](),
 	}
 }
 
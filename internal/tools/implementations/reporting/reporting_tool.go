@@ -4,24 +4,33 @@ import (
 	"context"
 	"fmt"
 
+	"github.com/SecDuckOps/agent/internal/application/scrub"
 	"github.com/SecDuckOps/agent/internal/domain"
+	"github.com/SecDuckOps/agent/internal/domain/security"
+	"github.com/SecDuckOps/agent/internal/ports"
 	shared_domain "github.com/SecDuckOps/shared/llm/domain"
 	"github.com/SecDuckOps/agent/internal/tools/base"
+	"github.com/google/uuid"
 )
 
 type ReportingTool struct {
 	base.BaseTypedTool[ReportingParams]
-	llmRegistry shared_domain.LLMRegistry
+	llmRegistry   shared_domain.LLMRegistry
+	secretScanner ports.SecretScannerPort
 }
 
+// reportSessionID prefixes the scope of the placeholders used in a report prompt.
+const reportSessionID = "generate_report"
+
 type ReportingParams struct {
-	Data   string `json:"data"`
-	Format string `json:"format"` // e.g., "ExecutiveSummary", "DetailedFindings", "CleanupGuide"
+	Data   string `json:"data" jsonschema:"required" description:"The raw logs, findings, or data to process."`
+	Format string `json:"format" jsonschema:"required,enum=ExecutiveSummary|DetailedFindings|CleanupGuide" description:"The specialized reporting format."`
 }
 
-func NewReportingTool(llmRegistry shared_domain.LLMRegistry) *ReportingTool {
+func NewReportingTool(llmRegistry shared_domain.LLMRegistry, secretScanner ports.SecretScannerPort) *ReportingTool {
 	t := &ReportingTool{
-		llmRegistry: llmRegistry,
+		llmRegistry:   llmRegistry,
+		secretScanner: secretScanner,
 	}
 	t.Impl = t
 	return t
@@ -35,10 +44,7 @@ func (t *ReportingTool) Schema() domain.ToolSchema {
 	return domain.ToolSchema{
 		Name:        "generate_report",
 		Description: "Transforms raw scanning data or investigation findings into a structured Markdown report using AI. Use this to summarize complex results for the user.",
-		Parameters: map[string]string{
-			"data":   "The raw logs, findings, or data to process.",
-			"format": "The specialized reporting format: 'ExecutiveSummary', 'DetailedFindings', or 'CleanupGuide'.",
-		},
+		Parameters:  base.SchemaOf[ReportingParams](),
 	}
 }
 
@@ -76,6 +82,13 @@ Rules:
 		{Role: shared_domain.RoleUser, Content: fmt.Sprintf("RAW FINDINGS:\n%s", params.Data)},
 	}
 
+	// Raw findings often quote credentials; the report keeps the placeholders.
+	placeholders := security.NewPlaceholderMap(reportSessionID + ":" + uuid.NewString())
+	if t.secretScanner != nil {
+		defer t.secretScanner.Forget(placeholders.SessionID)
+	}
+	scrub.Messages(t.secretScanner, &placeholders, messages)
+
 	result, err := llm.Generate(ctx, messages, nil)
 	if err != nil {
 		return domain.Result{Success: false, Error: err.Error()}, err
@@ -12,8 +12,8 @@ import (
 
 // ScanParams defines the typed parameters for the scan tool.
 type ScanParams struct {
-	Target  string `json:"target"`
-	Scanner string `json:"scanner"`
+	Target  string `json:"target" jsonschema:"required,minLength=1" description:"The directory or file to scan. IMPORTANT: Use '.' to scan the current project workspace. DO NOT use absolute Linux paths like '/vuln' or '/app' as they will fail on Windows hosts."`
+	Scanner string `json:"scanner" jsonschema:"required,minLength=1" description:"The scanner engine to use (e.g. 'trivy', 'semgrep', 'gitleaks', 'zap', 'tfsec', 'gosec')."`
 }
 
 // ScanTool performs security scans via DockerWarden and specific parsers.
@@ -37,10 +37,7 @@ func (t *ScanTool) Schema() agent_domain.ToolSchema {
 	return agent_domain.ToolSchema{
 		Name:        "scan",
 		Description: "Perform a security scan on a target using a specific scanner engine.",
-		Parameters: map[string]string{
-			"target":  "string - The directory or file to scan. IMPORTANT: Use '.' to scan the current project workspace. DO NOT use absolute Linux paths like '/vuln' or '/app' as they will fail on Windows hosts.",
-			"scanner": "string - The scanner engine to use (e.g. 'trivy', 'semgrep', 'gitleaks', 'zap', 'tfsec', 'gosec', etc.)",
-		},
+		Parameters:  base.SchemaOf[ScanParams](),
 	}
 }
 
@@ -24,11 +24,11 @@ const (
 
 // ShellParams defines the typed parameters for the shell tool.
 type ShellParams struct {
-	Command   string   `json:"command"`             // Canonical command name (ls, cat, grep, git, etc.)
-	Args      []string `json:"args,omitempty"`       // Arguments to pass to the command
-	Workspace string   `json:"workspace,omitempty"`  // Working directory (defaults to current)
-	Timeout   int      `json:"timeout,omitempty"`    // Timeout in seconds (default: 30)
-	UsePTY    bool     `json:"use_pty,omitempty"`    // Whether to use a PTY
+	Command   string   `json:"command" jsonschema:"required,minLength=1" description:"The command to execute (e.g. ls, cat, grep, git, find, tree, wc, head, tail, du, which, stat)."`
+	Args      []string `json:"args,omitempty" description:"Arguments and flags to pass to the command."`
+	Workspace string   `json:"workspace,omitempty" description:"Working directory for the command (must be within project boundaries)."`
+	Timeout   int      `json:"timeout,omitempty" jsonschema:"default=30,minimum=0,maximum=120" description:"Timeout in seconds."`
+	UsePTY    bool     `json:"use_pty,omitempty" description:"Whether to use a PTY for interactive commands."`
 }
 
 // ShellTool executes safe OS commands via ShellExecutionPort with Warden policy gating.
@@ -54,13 +54,7 @@ func (t *ShellTool) Schema() domain.ToolSchema {
 	return domain.ToolSchema{
 		Name:        "shell",
 		Description: "Execute safe OS commands (ls, cat, grep, git, find, etc.) with security policy enforcement. Commands are restricted to a safe allowlist and arguments are sanitized.",
-		Parameters: map[string]string{
-			"command":   "string - The command to execute (e.g., ls, cat, grep, git, find, tree, wc, head, tail, du, which, stat)",
-			"args":      "[]string - Arguments and flags to pass to the command",
-			"workspace": "string - Optional. Working directory for the command (must be within project boundaries)",
-			"timeout":   "int - Optional. Timeout in seconds (default: 30, max: 120)",
-			"use_pty":   "bool - Optional. Whether to use a PTY for interactive commands",
-		},
+		Parameters:  base.SchemaOf[ShellParams](),
 	}
 }
 
@@ -11,7 +11,7 @@ import (
 )
 
 type LoadSkillParams struct {
-	SkillName string `json:"skill_name"`
+	SkillName string `json:"skill_name" jsonschema:"required" description:"The name of the skill to load, e.g. 'taskboard', 'autopilot', 'subagents'."`
 }
 
 type LoadSkillTool struct {
@@ -33,9 +33,7 @@ func (t *LoadSkillTool) Schema() agent_domain.ToolSchema {
 	return agent_domain.ToolSchema{
 		Name:        "load_skill",
 		Description: "Dynamically loads a specialized skill (markdown documentation) into your context. Use this when you are asked about tools like `duckops autopilot`, `duckops board`, or when you need detailed guidance on subagents. You MUST load a skill before proposing implementations related to these topics.",
-		Parameters: map[string]string{
-			"skill_name": "string (the name of the skill to load, e.g., 'taskboard', 'autopilot', 'subagents')",
-		},
+		Parameters:  base.SchemaOf[LoadSkillParams](),
 	}
 }
 
@@ -12,12 +12,12 @@ import (
 
 // ResumeParams defines the input for resuming a paused subagent.
 type ResumeParams struct {
-	TaskID     string   `json:"task_id"`
-	Approve    []string `json:"approve,omitempty"`
-	Reject     []string `json:"reject,omitempty"`
-	ApproveAll bool     `json:"approve_all,omitempty"`
-	RejectAll  bool     `json:"reject_all,omitempty"`
-	Input      string   `json:"input,omitempty"`
+	TaskID     string   `json:"task_id" jsonschema:"required,minLength=1" description:"Session ID of the paused subagent."`
+	Approve    []string `json:"approve,omitempty" description:"Tool call IDs to approve."`
+	Reject     []string `json:"reject,omitempty" description:"Tool call IDs to reject."`
+	ApproveAll bool     `json:"approve_all,omitempty" description:"Approve all pending tool calls."`
+	RejectAll  bool     `json:"reject_all,omitempty" description:"Reject all pending tool calls."`
+	Input      string   `json:"input,omitempty" description:"Text input for input_required pauses."`
 }
 
 // ResumeTool resumes a paused subagent with approval decisions.
@@ -55,17 +55,15 @@ PARAMETERS:
 - input: Text input for follow-up (for input_required pauses)
 
 NOTES:
-- Only works on sessions with status 'paused'
+- A step may pause with several pending_tool_calls; approve or reject each by ID. Calls not approved are rejected
+- Only works on sessions with status 'paused' or 'interrupted'
+- 'interrupted' sessions were running when the agent restarted; resuming restarts them from their
+  last saved step, and approve/reject are ignored
 - Unspecified tool calls are rejected by default
-- Sandbox subagents never pause (they run autonomously)`,
-		Parameters: map[string]string{
-			"task_id":     "string (required) - Session ID of the paused subagent",
-			"approve":     "[]string (optional) - Tool call IDs to approve",
-			"reject":      "[]string (optional) - Tool call IDs to reject",
-			"approve_all": "bool (optional) - Approve all pending tool calls",
-			"reject_all":  "bool (optional) - Reject all pending tool calls",
-			"input":       "string (optional) - Text input for input_required pauses",
-		},
+- Sandbox subagents never pause for tool calls (they run autonomously)
+- Any subagent pauses with reason 'policy_approval_required' when a Warden policy requires human
+  sign-off (pause_info.approval). You may reject it, but only the user can approve it`,
+		Parameters: base.SchemaOf[ResumeParams](),
 	}
 }
 
@@ -78,6 +76,22 @@ func (t *ResumeTool) Execute(ctx context.Context, params ResumeParams) (domain.R
 		return domain.Result{Success: false, Error: "task_id is required"}, nil
 	}
 
+	// Policy approvals need a human decision; the agent may only reject them.
+	if view, err := t.tracker.GetSession(params.TaskID); err == nil {
+		if info := view.Subagent.PauseInfo; info != nil && info.Reason == sa.PausePolicyApproval && info.Approval != nil {
+			approving := params.ApproveAll
+			for _, id := range params.Approve {
+				approving = approving || id == info.Approval.ID
+			}
+			if approving {
+				return domain.Result{
+					Success: false,
+					Error:   fmt.Sprintf("policy '%s' requires human approval for %q; ask the user to approve it in the TUI or via POST /v1/sessions/%s/resume", info.Approval.PolicyID, info.Approval.Summary, params.TaskID),
+				}, nil
+			}
+		}
+	}
+
 	decision := sa.ResumeDecision{
 		Approve:    params.Approve,
 		Reject:     params.Reject,
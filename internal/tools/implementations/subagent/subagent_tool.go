@@ -13,15 +13,15 @@ import (
 
 // SubagentParams — AOrchestra 4-tuple: (Instruction, Context, Tools, Model)
 type SubagentParams struct {
-	Description  string   `json:"description"`
-	Instructions string   `json:"instructions"`
-	Context      string   `json:"context,omitempty"`
-	Tools        []string `json:"tools"`
-	Model        string   `json:"model,omitempty"`
-	MaxSteps     int      `json:"max_steps,omitempty"`
-	Sandbox      bool     `json:"enable_sandbox,omitempty"`
-	MaxRetries   int      `json:"max_retries,omitempty"`
-	Provider     string   `json:"provider,omitempty"`
+	Description  string   `json:"description" jsonschema:"required" description:"Short 3-5 word task description."`
+	Instructions string   `json:"instructions" jsonschema:"required,minLength=1" description:"What the subagent should do, with success criteria."`
+	Context      string   `json:"context,omitempty" description:"Curated context from previous work."`
+	Tools        []string `json:"tools" jsonschema:"required,minItems=1" description:"Tool names to grant (least-privilege)."`
+	Model        string   `json:"model,omitempty" description:"Model override (auto-downgraded for cost)."`
+	MaxSteps     int      `json:"max_steps,omitempty" jsonschema:"default=30,minimum=0" description:"Maximum number of steps."`
+	Sandbox      bool     `json:"enable_sandbox,omitempty" description:"Run in an isolated sandbox."`
+	MaxRetries   int      `json:"max_retries,omitempty" jsonschema:"default=3,minimum=0" description:"Maximum retry attempts on failure."`
+	Provider     string   `json:"provider,omitempty" description:"LLM provider override."`
 }
 
 // SubagentTool is the MCP tool that the LLM calls to spawn a subagent.
@@ -59,17 +59,7 @@ SANDBOX MODE (enable_sandbox=true):
 - Non-sandbox subagents pause on each tool call for master agent approval
 
 Use resume_subagent_task to approve/reject paused tool calls.`,
-		Parameters: map[string]string{
-			"description":    "string (required) - Short 3-5 word task description",
-			"instructions":   "string (required) - What the subagent should do, with success criteria",
-			"context":        "string (optional) - Curated context from previous work",
-			"tools":          "[]string (required) - Tool names to grant (least-privilege)",
-			"model":          "string (optional) - Model override (auto-downgraded for cost)",
-			"max_steps":      "int (optional) - Max steps, default 30",
-			"enable_sandbox": "bool (optional) - Run in isolated sandbox",
-			"max_retries":    "int (optional) - Max retry attempts on failure (default: 3)",
-			"provider":       "string (optional) - LLM provider override",
-		},
+		Parameters: base.SchemaOf[SubagentParams](),
 	}
 }
 
@@ -12,14 +12,14 @@ import (
 
 // TerminalParams defines the inputs for the TerminalTool.
 type TerminalParams struct {
-	Command   string            `json:"command"`
-	Args      []string          `json:"args,omitempty"`
-	Cwd       string            `json:"cwd,omitempty"`
-	Env       map[string]string `json:"env,omitempty"`
-	UsePTY    bool              `json:"use_pty,omitempty"`
-	Cols      int               `json:"cols,omitempty"`
-	Rows      int               `json:"rows,omitempty"`
-	Streaming bool              `json:"streaming,omitempty"`
+	Command   string            `json:"command" jsonschema:"required,minLength=1" description:"The command name (e.g. ls, pwd, cat, git, go)."`
+	Args      []string          `json:"args,omitempty" description:"Arguments to pass to the command."`
+	Cwd       string            `json:"cwd,omitempty" description:"Working directory; defaults to the current directory."`
+	Env       map[string]string `json:"env,omitempty" description:"Environment variables. AVOID including standard system variables unless required for the specific command. Pass credentials as secret:// references (e.g. secret://env/GITHUB_TOKEN), never as literal values."`
+	UsePTY    bool              `json:"use_pty,omitempty" description:"Whether to use a PTY for interactive commands."`
+	Cols      int               `json:"cols,omitempty" jsonschema:"minimum=0" description:"Terminal columns for the PTY."`
+	Rows      int               `json:"rows,omitempty" jsonschema:"minimum=0" description:"Terminal rows for the PTY."`
+	Streaming bool              `json:"streaming,omitempty" description:"Whether to start as a streaming session."`
 }
 
 // TerminalTool acts as the bridge between the LLM Kernel Tool interface
@@ -44,16 +44,7 @@ func (t *TerminalTool) Schema() domain.ToolSchema {
 	return domain.ToolSchema{
 		Name:        "terminal",
 		Description: "Execute a command securely on the host operating system. Replaces both shell and filesystem tools.",
-		Parameters: map[string]string{
-			"command":   "string - The command name (e.g., ls, pwd, cat, git, go, etc.)",
-			"args":      "[]string - Arguments to pass to the command",
-			"cwd":       "string - Optional. Working directory (defaults to current dir)",
-			"env":       "map[string]string - Optional. Environment variables. AVOID including standard system variables unless required for the specific command.",
-			"use_pty":   "bool - Optional. Whether to use a PTY for interactive commands",
-			"cols":      "int - Optional. Terminal columns for PTY",
-			"rows":      "int - Optional. Terminal rows for PTY",
-			"streaming": "bool - Optional. Whether to start as a streaming session",
-		},
+		Parameters:  base.SchemaOf[TerminalParams](),
 	}
 }
 
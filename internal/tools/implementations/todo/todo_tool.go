@@ -26,9 +26,9 @@ type TodoItem struct {
 }
 
 type TodoParams struct {
-	Action      string `json:"action"` // "add", "complete", "list"
-	Description string `json:"description,omitempty"`
-	ID          int    `json:"id,omitempty"`
+	Action      string `json:"action" jsonschema:"required,enum=add|complete|list"`
+	Description string `json:"description,omitempty" description:"The task description, required for 'add'."`
+	ID          int    `json:"id,omitempty" jsonschema:"minimum=0" description:"The task ID, required for 'complete'."`
 }
 
 type TodoTool struct {
@@ -49,11 +49,7 @@ func (t *TodoTool) Schema() agent_domain.ToolSchema {
 	return agent_domain.ToolSchema{
 		Name:        "todo",
 		Description: "A tool for the agent to manage its execution plan and keep track of pending tasks. Highly recommended for complex, multi-step operations to avoid hallucination.",
-		Parameters: map[string]string{
-			"action":      "string (required: 'add', 'complete', 'list')",
-			"description": "string (the task description, required for 'add')",
-			"id":          "integer (the task ID, required for 'complete')",
-		},
+		Parameters:  base.SchemaOf[TodoParams](),
 	}
 }
 